@@ -0,0 +1,182 @@
+// explain 是调试搜索排序的命令行工具：对给定的关键词和帖子 ID 执行 Elasticsearch 的 _explain，
+// 打印该帖子是否匹配以及得分的组成。查询 DSL 由 repositories.SearchQueryBuilder 按配置文件中的
+// searchConfig (字段权重、新帖加权等) 生成，与服务执行搜索时使用的查询完全一致。
+//
+// 与 healthcheck 不同，这里直接创建 ES 客户端而不复用 NewESClient，不会创建索引或注册模板，只发起只读请求。
+//
+// 示例:
+//
+//	go run ./cmd/explain -q "kafka 教程" -id 1024
+//	go run ./cmd/explain -q "title:kafka" -query-mode advanced -id 1024 -recency off
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// explanation 是 _explain 响应中的得分说明节点。
+type explanation struct {
+	Value       float64       `json:"value"`
+	Description string        `json:"description"`
+	Details     []explanation `json:"details"`
+}
+
+func main() {
+	var (
+		configFile string
+		query      string
+		postID     uint64
+		queryMode  string
+		authorID   string
+		recency    string
+		routing    string
+		indexName  string
+		timeout    time.Duration
+		printRaw   bool
+	)
+	defaultConfigPath := filepath.Join("config", "config.development.yaml")
+	flag.StringVar(&configFile, "config", defaultConfigPath, "指定配置文件的路径 (相对于当前工作目录或绝对路径)")
+	flag.StringVar(&query, "q", "", "搜索关键词 (必填)，与 GET /search 的 q 参数相同")
+	flag.Uint64Var(&postID, "id", 0, "需要解释得分的帖子 ID (必填)")
+	flag.StringVar(&queryMode, "query-mode", models.QueryModeDefault, "关键词语法: default 或 advanced")
+	flag.StringVar(&authorID, "author", "", "按作者 ID 筛选 (可选)")
+	flag.StringVar(&recency, "recency", "config", "新帖加权: config (使用配置文件)、on 或 off")
+	flag.StringVar(&routing, "routing", "", "文档的 routing 值；帖子索引开启 routeByAuthor 时必须传入帖子的作者 ID")
+	flag.StringVar(&indexName, "index", "", "目标索引，为空时使用配置中的主帖子索引")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "_explain 请求的超时时间")
+	flag.BoolVar(&printRaw, "raw", false, "额外输出 _explain 的原始 JSON 响应")
+	flag.Parse()
+
+	if strings.TrimSpace(query) == "" || postID == 0 {
+		fmt.Fprintln(os.Stderr, "参数 -q 和 -id 为必填项")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if queryMode != models.QueryModeDefault && queryMode != models.QueryModeAdvanced {
+		log.Fatalf("参数 -query-mode 无效: %s (可选 default、advanced)", queryMode)
+	}
+
+	// --- 1. 加载配置并初始化 Logger ---
+	var cfg config.PostSearchConfig
+	if err := core.LoadConfig(configFile, &cfg); err != nil {
+		log.Fatalf("加载配置文件 '%s' 失败: %v", configFile, err)
+	}
+	logger, err := core.NewZapLogger(cfg.ZapConfig)
+	if err != nil {
+		log.Fatalf("初始化 ZapLogger 失败: %v", err)
+	}
+	defer func() { _ = logger.Logger().Sync() }()
+
+	esCfg := cfg.ElasticsearchConfig
+	if indexName == "" {
+		indexName = esCfg.PrimaryIndex.Name
+	}
+	if esCfg.PrimaryIndex.RouteByAuthor && routing == "" {
+		log.Fatalf("帖子索引开启了 routeByAuthor，请通过 -routing 传入帖子的作者 ID")
+	}
+
+	// --- 2. 按服务的查询构建逻辑生成 query ---
+	req := models.SearchRequest{
+		Query:     query,
+		QueryMode: queryMode,
+		AuthorID:  authorID,
+	}
+	switch recency {
+	case "config":
+	case "on", "off":
+		enabled := recency == "on"
+		req.BoostRecency = &enabled
+	default:
+		log.Fatalf("参数 -recency 无效: %s (可选 config、on、off)", recency)
+	}
+	builder := repositories.NewSearchQueryBuilder(cfg.SearchConfig, esCfg.PrimaryIndex, logger)
+	body, err := json.MarshalIndent(map[string]interface{}{"query": builder.Query(req)}, "", "  ")
+	if err != nil {
+		log.Fatalf("序列化查询 DSL 失败: %v", err)
+	}
+	fmt.Printf("查询 DSL:\n%s\n\n", body)
+
+	// --- 3. 执行 _explain ---
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses: esCfg.Addresses,
+		Username:  esCfg.Username,
+		Password:  esCfg.Password,
+	})
+	if err != nil {
+		log.Fatalf("创建 Elasticsearch 客户端失败: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	res, err := esapi.ExplainRequest{
+		Index:      indexName,
+		DocumentID: strconv.FormatUint(postID, 10),
+		Routing:    routing,
+		Body:       bytes.NewReader(body),
+	}.Do(ctx, client)
+	if err != nil {
+		log.Fatalf("执行 _explain 请求失败: %v", err)
+	}
+	defer res.Body.Close()
+	raw, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Fatalf("读取 _explain 响应失败: %v", err)
+	}
+	if res.StatusCode == 404 {
+		log.Fatalf("索引 '%s' 中不存在帖子 %d (开启 routeByAuthor 时请确认 -routing 为帖子的作者 ID)", indexName, postID)
+	}
+	if res.IsError() {
+		log.Fatalf("_explain 请求失败，状态码: %s，响应: %s", res.Status(), raw)
+	}
+
+	var result struct {
+		Matched     bool         `json:"matched"`
+		Explanation *explanation `json:"explanation"`
+	}
+	if err := json.Unmarshal(raw, &result); err != nil {
+		log.Fatalf("解析 _explain 响应失败: %v", err)
+	}
+
+	// --- 4. 输出得分说明 ---
+	if result.Matched {
+		fmt.Printf("帖子 %d (索引 %s) 匹配查询，得分 %.4f\n\n", postID, indexName, result.Explanation.Value)
+	} else {
+		fmt.Printf("帖子 %d (索引 %s) 不匹配查询\n\n", postID, indexName)
+	}
+	if result.Explanation != nil {
+		printExplanation(os.Stdout, *result.Explanation, 0)
+	}
+	if printRaw {
+		var pretty bytes.Buffer
+		if err := json.Indent(&pretty, raw, "", "  "); err == nil {
+			raw = pretty.Bytes()
+		}
+		fmt.Printf("\n原始响应:\n%s\n", raw)
+	}
+}
+
+// printExplanation 以缩进树的形式输出得分说明，每层缩进两个空格。
+func printExplanation(w io.Writer, e explanation, depth int) {
+	fmt.Fprintf(w, "%s%.4f  %s\n", strings.Repeat("  ", depth), e.Value, e.Description)
+	for _, d := range e.Details {
+		printExplanation(w, d, depth+1)
+	}
+}
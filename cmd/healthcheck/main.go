@@ -0,0 +1,153 @@
+// healthcheck 是部署前的自检命令：校验配置、连接 Elasticsearch 并检查两个索引的映射、
+// 连接 Kafka 并确认订阅的主题和 DLQ 主题存在。任何一项失败时以非零状态码退出，
+// 可用作 Kubernetes init container 或 CI 流水线的检查步骤。
+//
+// 注意：Elasticsearch 客户端复用服务启动时的 NewESClient，索引不存在时会像服务启动一样被创建。
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	coreES "github.com/Xushengqwer/post_search/internal/core/es"
+	internalKafka "github.com/Xushengqwer/post_search/internal/core/kafka"
+	"go.uber.org/zap"
+)
+
+func main() {
+	var configFile string
+	var timeout time.Duration
+	defaultConfigPath := filepath.Join("config", "config.development.yaml")
+	flag.StringVar(&configFile, "config", defaultConfigPath, "指定配置文件的路径 (相对于当前工作目录或绝对路径)")
+	flag.DurationVar(&timeout, "timeout", 30*time.Second, "映射检查和 Kafka 元数据查询的总超时时间")
+	flag.Parse()
+
+	// --- 1. 加载配置 ---
+	var cfg config.PostSearchConfig
+	if err := core.LoadConfig(configFile, &cfg); err != nil {
+		log.Fatalf("自检失败: 加载配置文件 '%s' 失败: %v", configFile, err)
+	}
+
+	// --- 2. 初始化 Logger ---
+	logger, err := core.NewZapLogger(cfg.ZapConfig)
+	if err != nil {
+		log.Fatalf("自检失败: 初始化 ZapLogger 失败: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	failures := runChecks(ctx, cfg, logger)
+	cancel()
+	_ = logger.Logger().Sync()
+
+	if len(failures) > 0 {
+		fmt.Fprintf(os.Stderr, "自检失败 (%d 项):\n", len(failures))
+		for _, f := range failures {
+			fmt.Fprintf(os.Stderr, "  - %s\n", f)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("自检通过: 配置、Elasticsearch 索引映射与 Kafka 主题均正常。")
+}
+
+// runChecks 依次执行各项检查并返回所有失败项的描述。ES 或 Kafka 连接失败时跳过依赖该连接的后续检查。
+func runChecks(ctx context.Context, cfg config.PostSearchConfig, logger *core.ZapLogger) []string {
+	var failures []string
+	failures = append(failures, checkElasticsearch(ctx, cfg.ElasticsearchConfig, logger)...)
+	failures = append(failures, checkKafka(cfg.KafkaConfig, logger)...)
+	return failures
+}
+
+// checkElasticsearch 连接 ES (复用服务启动流程：Ping、分析器检查、索引模板与索引创建)，并比较两个索引的映射。
+func checkElasticsearch(ctx context.Context, esCfg config.ESConfig, logger *core.ZapLogger) []string {
+	// 映射由下面的检查统一比较并计入失败项，关闭 NewESClient 内置的漂移检查以免重复输出。
+	esCfg.MappingDriftCheck = "off"
+	esClient, err := coreES.NewESClient(esCfg, logger, nil)
+	if err != nil {
+		return []string{fmt.Sprintf("连接 Elasticsearch 失败: %v", err)}
+	}
+	defer esClient.Close()
+	logger.Info("自检: Elasticsearch 连接成功", zap.Strings("addresses", esCfg.Addresses))
+
+	var failures []string
+	checks := []struct {
+		name  string
+		check func() ([]coreES.MappingDrift, error)
+	}{
+		{"帖子索引 " + esCfg.PrimaryIndex.Name, func() ([]coreES.MappingDrift, error) {
+			return coreES.CheckPostsIndexMapping(ctx, esClient.Client, esCfg.PrimaryIndex)
+		}},
+		{"热门搜索词索引 " + esCfg.HotTermsIndex.Name, func() ([]coreES.MappingDrift, error) {
+			return coreES.CheckHotTermsIndexMapping(ctx, esClient.Client, esCfg.HotTermsIndex)
+		}},
+	}
+	for _, c := range checks {
+		drifts, err := c.check()
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c.name, err))
+			continue
+		}
+		for _, d := range drifts {
+			failures = append(failures, fmt.Sprintf("%s: 映射不一致: %s", c.name, d))
+		}
+		if len(drifts) == 0 {
+			logger.Info("自检: 索引映射与期望一致", zap.String("index", c.name))
+		}
+	}
+	return failures
+}
+
+// checkKafka 解析订阅主题，连接 Kafka 获取集群中的主题列表，确认订阅的主题和 DLQ 主题都已存在。
+func checkKafka(kafkaCfg config.KafkaConfig, logger *core.ZapLogger) []string {
+	if _, err := internalKafka.ResolveTopics(&kafkaCfg, logger); err != nil {
+		return []string{fmt.Sprintf("Kafka 主题配置无效: %v", err)}
+	}
+	saramaCfg, err := internalKafka.ConfigureSarama(kafkaCfg, logger)
+	if err != nil {
+		return []string{fmt.Sprintf("Sarama 配置无效: %v", err)}
+	}
+
+	client, err := sarama.NewClient(kafkaCfg.Brokers, saramaCfg)
+	if err != nil {
+		return []string{fmt.Sprintf("连接 Kafka 失败 (brokers: %v): %v", kafkaCfg.Brokers, err)}
+	}
+	defer client.Close()
+
+	clusterTopics, err := client.Topics()
+	if err != nil {
+		return []string{fmt.Sprintf("获取 Kafka 主题列表失败: %v", err)}
+	}
+	existing := make(map[string]bool, len(clusterTopics))
+	for _, t := range clusterTopics {
+		existing[t] = true
+	}
+	logger.Info("自检: Kafka 连接成功", zap.Strings("brokers", kafkaCfg.Brokers), zap.Int("cluster_topic_count", len(clusterTopics)))
+
+	var failures []string
+	required := append([]string(nil), kafkaCfg.SubscribedTopics...)
+	if kafkaCfg.DLQTopic != "" {
+		required = append(required, kafkaCfg.DLQTopic)
+	} else {
+		failures = append(failures, "未配置死信队列主题 (kafkaConfig.dlqTopic)")
+	}
+	for _, topic := range kafkaCfg.SubscribedTopics {
+		if topic == kafkaCfg.DLQTopic {
+			failures = append(failures, fmt.Sprintf("死信队列主题 %s 同时出现在订阅的主题中，会导致失败消息被循环消费", topic))
+		}
+	}
+	for _, topic := range required {
+		if !existing[topic] {
+			failures = append(failures, fmt.Sprintf("Kafka 主题 %s 不存在", topic))
+			continue
+		}
+		logger.Info("自检: Kafka 主题存在", zap.String("topic", topic))
+	}
+	return failures
+}
@@ -3,6 +3,7 @@ package main
 import (
 	"encoding/json"
 	"flag"
+	"fmt"
 	"log" // 标准日志库，用于早期错误输出
 	"os"
 	"path/filepath"
@@ -12,9 +13,10 @@ import (
 	"github.com/IBM/sarama"
 	"github.com/Xushengqwer/go-common/core"
 	"github.com/Xushengqwer/go-common/models/enums" // 导入您的枚举类型
+	"github.com/Xushengqwer/go-common/models/kafkaevents"
 	"github.com/Xushengqwer/post_search/config"
 	internalKafka "github.com/Xushengqwer/post_search/internal/core/kafka" // 为内部 kafka 包使用别名
-	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 )
 
@@ -23,8 +25,13 @@ func main() {
 	var configFile string
 	defaultConfigPath := filepath.Join("..", "..", "config", "config.development.yaml")
 
+	var syntheticCount int
 	flag.StringVar(&configFile, "config", defaultConfigPath, "指定配置文件的路径 (相对于当前工作目录或绝对路径)")
+	flag.IntVar(&syntheticCount, "synthetic", 0, "在固定测试数据之外额外生成的合成帖子审核通过事件数量")
 	flag.Parse()
+	if syntheticCount < 0 {
+		log.Fatalf("参数 -synthetic 不能为负数: %d", syntheticCount)
+	}
 
 	if !filepath.IsAbs(configFile) {
 		absPath, err := filepath.Abs(configFile)
@@ -57,19 +64,16 @@ func main() {
 
 	// --- 3. 准备 Kafka 生产者 ---
 	kafkaCfg := cfg.KafkaConfig
-	if len(kafkaCfg.SubscribedTopics) == 0 {
-		logger.Fatal("Kafka 配置错误：未在 subscribedTopics 中找到用于 Seeder 的目标主题。")
-	}
-	if len(kafkaCfg.SubscribedTopics) < 2 {
-		logger.Fatal("Kafka 配置错误：subscribedTopics 至少需要包含两个主题 (一个用于审计，一个用于删除)。")
+	topics, err := internalKafka.ResolveTopics(&kafkaCfg, logger)
+	if err != nil {
+		logger.Fatal("Kafka 主题配置无效", zap.Error(err))
 	}
-
-	auditTopic := kafkaCfg.SubscribedTopics[0]  // 第一个主题用于 PostAudit 事件
-	deleteTopic := kafkaCfg.SubscribedTopics[1] // 第二个主题用于 PostDelete 事件
+	auditTopic := topics.Audit   // PostApproved 事件主题
+	deleteTopic := topics.Delete // PostDeleted 事件主题
 
 	logger.Info("Kafka Seeder 将使用以下主题",
-		zap.String("审计事件主题 (PostAudit)", auditTopic),
-		zap.String("删除事件主题 (PostDelete)", deleteTopic),
+		zap.String("审核通过事件主题 (PostApproved)", auditTopic),
+		zap.String("删除事件主题 (PostDeleted)", deleteTopic),
 	)
 
 	saramaConfig, err := internalKafka.ConfigureSarama(kafkaCfg, logger)
@@ -91,170 +95,191 @@ func main() {
 	}()
 	logger.Info("Kafka 同步生产者 (SyncProducer) 初始化成功并已连接。", zap.Strings("Brokers地址", kafkaCfg.Brokers))
 
-	// --- 4. 定义帖子创建/更新的测试数据 (PostAuditEvents) ---
-	testPostAuditEvents := []models.KafkaPostAuditEvent{
-		{
+	// --- 4. 定义帖子审核通过的测试数据 (PostApprovedEvent) ---
+	// 事件结构与 EventService 消费的 go-common kafkaevents 模型一致，本地测试可以走通真实的消费路径。
+	now := time.Now()
+	testPostApprovedEvents := []kafkaevents.PostApprovedEvent{
+		newApprovedEvent(now, kafkaevents.PostData{
 			ID:             401, // 保留原有的
 			Title:          "Seeder新增: 学习 Go 语言微服务",
 			Content:        "这是通过 Seeder 添加的关于 Go 语言微服务开发的测试帖子。",
 			AuthorID:       "go_micro_dev_01",
 			AuthorAvatar:   "http://example.com/avatars/go_micro.png",
 			AuthorUsername: "Go微服务大师",
-			Status:         enums.Status(1),
+			Status:         enums.Approved,
 			ViewCount:      200,
-			OfficialTag:    enums.OfficialTag(1),
+			OfficialTag:    enums.OfficialTagCertified,
 			PricePerUnit:   0.0,
-			ContactQRCode:  "http://example.com/qr/go_micro_contact.png",
-		},
-		{
+			ContactInfo:    "http://example.com/qr/go_micro_contact.png",
+		}),
+		newApprovedEvent(now, kafkaevents.PostData{
 			ID:             402, // 保留原有的
 			Title:          "Seeder新增: Kafka 消息队列实践",
 			Content:        "通过 Seeder 添加的 Kafka 实践帖子，讨论消息传递模式。",
 			AuthorID:       "kafka_guru_02",
 			AuthorAvatar:   "http://example.com/avatars/kafka_guru.png",
 			AuthorUsername: "Kafka专家",
-			Status:         enums.Status(1),
+			Status:         enums.Approved,
 			ViewCount:      450,
-			OfficialTag:    enums.OfficialTag(0),
+			OfficialTag:    enums.OfficialTagNone,
 			PricePerUnit:   19.99,
-			ContactQRCode:  "",
-		},
-		{ // 新增数据 1
+		}),
+		newApprovedEvent(now, kafkaevents.PostData{ // 新增数据 1
 			ID:             403,
 			Title:          "探索 Elasticsearch 的聚合功能",
 			Content:        "本文将深入探讨 Elasticsearch 中强大的聚合功能及其在数据分析中的应用。",
 			AuthorID:       "es_analyzer_03",
 			AuthorAvatar:   "http://example.com/avatars/es_agg.png",
 			AuthorUsername: "数据分析师艾拉",
-			Status:         enums.Status(1), // 已发布
+			Status:         enums.Approved, // 已发布
 			ViewCount:      320,
-			OfficialTag:    enums.OfficialTag(0), // 普通
+			OfficialTag:    enums.OfficialTagNone, // 普通
 			PricePerUnit:   0.0,
-			ContactQRCode:  "http://example.com/qr/es_agg_contact.png",
-		},
-		{ // 新增数据 2
+			ContactInfo:    "http://example.com/qr/es_agg_contact.png",
+		}),
+		newApprovedEvent(now, kafkaevents.PostData{ // 新增数据 2
 			ID:             404,
 			Title:          "Docker 与 Kubernetes：容器编排实战",
 			Content:        "从 Docker 基础到 Kubernetes 高级部署策略，一步步掌握容器编排技术。",
 			AuthorID:       "cloud_native_04",
 			AuthorAvatar:   "http://example.com/avatars/k8s_pro.png",
 			AuthorUsername: "云原生小王子",
-			Status:         enums.Status(0), // 草稿状态
+			Status:         enums.Pending, // 待审核 (公开搜索默认不可见)
 			ViewCount:      15,
-			OfficialTag:    enums.OfficialTag(0),
+			OfficialTag:    enums.OfficialTagNone,
 			PricePerUnit:   49.50,
-			ContactQRCode:  "",
-		},
-		{ // 新增数据 3
+		}),
+		newApprovedEvent(now, kafkaevents.PostData{ // 新增数据 3
 			ID:             405,
 			Title:          "React 前端开发入门与进阶",
 			Content:        "全面介绍 React 框架，从 JSX 语法到 Redux 状态管理，助您成为前端高手。",
 			AuthorID:       "frontend_dev_05",
 			AuthorAvatar:   "http://example.com/avatars/react_dev.png",
 			AuthorUsername: "React爱好者莉莉",
-			Status:         enums.Status(1), // 已发布
+			Status:         enums.Approved, // 已发布
 			ViewCount:      880,
-			OfficialTag:    enums.OfficialTag(1), // 官方推荐
+			OfficialTag:    enums.OfficialTagCertified, // 官方认证
 			PricePerUnit:   0.0,
-			ContactQRCode:  "http://example.com/qr/react_course.png",
-		},
+			ContactInfo:    "http://example.com/qr/react_course.png",
+		}),
+	}
+	// 追加 -synthetic 指定数量的合成帖子，用于压测或填充较大的本地索引。
+	for i := 0; i < syntheticCount; i++ {
+		testPostApprovedEvents = append(testPostApprovedEvents, newApprovedEvent(now, syntheticPost(i, now)))
 	}
 
-	// --- 5. 发送帖子创建/更新事件到 Kafka ---
-	logger.Info("开始发送帖子创建/更新 (PostAudit) 事件到 Kafka...", zap.Int("消息数量", len(testPostAuditEvents)))
-	for _, postEvent := range testPostAuditEvents {
-		payloadBytes, err := json.Marshal(postEvent)
-		if err != nil {
-			logger.Error("序列化 KafkaPostAuditEvent 为 JSON 时发生错误",
-				zap.Uint64("帖子ID", postEvent.ID),
-				zap.Error(err))
-			continue
-		}
-		eventKey := strconv.FormatUint(postEvent.ID, 10)
-		msg := &sarama.ProducerMessage{
-			Topic: auditTopic, // 发送到审计主题
-			Key:   sarama.StringEncoder(eventKey),
-			Value: sarama.ByteEncoder(payloadBytes),
-		}
-		logger.Debug("准备发送的消息详情 (PostAudit)",
-			zap.String("消息键(Key)", eventKey),
-			zap.ByteString("消息体片段(Value snippet)", payloadBytes[:min(100, len(payloadBytes))]))
-		partition, offset, err := producer.SendMessage(msg)
-		if err != nil {
-			logger.Error("发送 PostAudit 事件到 Kafka 失败",
-				zap.String("目标主题", auditTopic),
-				zap.Uint64("帖子ID", postEvent.ID),
-				zap.Error(err),
-			)
-		} else {
-			logger.Info("PostAudit 事件成功发送到 Kafka",
-				zap.String("目标主题", auditTopic),
-				zap.Uint64("帖子ID", postEvent.ID),
-				zap.Int32("分区(Partition)", partition),
-				zap.Int64("偏移量(Offset)", offset),
-				zap.Time("发送时间戳", time.Now()),
-			)
-		}
-		time.Sleep(100 * time.Millisecond)
+	// --- 5. 发送帖子审核通过事件到 Kafka ---
+	logger.Info("开始发送帖子审核通过 (PostApproved) 事件到 Kafka...",
+		zap.Int("消息数量", len(testPostApprovedEvents)),
+		zap.Int("其中合成事件数量", syntheticCount),
+	)
+	for _, event := range testPostApprovedEvents {
+		sendEvent(producer, logger, auditTopic, event.Post.ID, event.EventID, event)
+		time.Sleep(sendInterval)
 	}
-	logger.Info("所有 PostAudit 事件已发送（或已尝试发送）到 Kafka。")
+	logger.Info("所有 PostApproved 事件已发送（或已尝试发送）到 Kafka。")
 
-	// --- 6. 定义帖子删除的测试数据 (PostDeleteEvents) ---
-	// 假设我们要删除上面创建的第一个帖子 (ID: 401) 和一个可能存在的旧帖子 (ID: 105)
-	testPostDeleteEvents := []models.KafkaPostDeleteEvent{
-		{
-			Operation: "delete",
-			PostID:    401, // 删除我们刚刚创建的帖子之一
-		},
-		{
-			Operation: "delete",
-			PostID:    105, // 尝试删除一个可能存在的旧帖子ID，用于测试删除不存在文档的情况
-		},
-		// 您可以根据需要添加更多删除事件
+	// --- 6. 定义帖子删除的测试数据 (PostDeletedEvent) ---
+	// 删除上面创建的第一个帖子 (ID: 401) 和一个可能不存在的旧帖子 (ID: 105)
+	testPostDeletedEvents := []kafkaevents.PostDeletedEvent{
+		{EventID: uuid.NewString(), Timestamp: now, PostID: 401}, // 删除我们刚刚创建的帖子之一
+		{EventID: uuid.NewString(), Timestamp: now, PostID: 105}, // 用于测试删除不存在文档的情况
 	}
 
 	// --- 7. 发送帖子删除事件到 Kafka ---
-	logger.Info("开始发送帖子删除 (PostDelete) 事件到 Kafka...", zap.Int("消息数量", len(testPostDeleteEvents)))
-	for _, deleteEvent := range testPostDeleteEvents {
-		payloadBytes, err := json.Marshal(deleteEvent)
-		if err != nil {
-			logger.Error("序列化 KafkaPostDeleteEvent 为 JSON 时发生错误",
-				zap.Uint64("帖子ID", deleteEvent.PostID),
-				zap.Error(err))
-			continue
-		}
-		eventKey := strconv.FormatUint(deleteEvent.PostID, 10) // 删除事件通常也使用 PostID 作为 Key
-		msg := &sarama.ProducerMessage{
-			Topic: deleteTopic, // 发送到删除主题
-			Key:   sarama.StringEncoder(eventKey),
-			Value: sarama.ByteEncoder(payloadBytes),
-		}
-		logger.Debug("准备发送的消息详情 (PostDelete)",
-			zap.String("消息键(Key)", eventKey),
-			zap.ByteString("消息体片段(Value snippet)", payloadBytes[:min(100, len(payloadBytes))]))
-		partition, offset, err := producer.SendMessage(msg)
-		if err != nil {
-			logger.Error("发送 PostDelete 事件到 Kafka 失败",
-				zap.String("目标主题", deleteTopic),
-				zap.Uint64("帖子ID", deleteEvent.PostID),
-				zap.Error(err),
-			)
-		} else {
-			logger.Info("PostDelete 事件成功发送到 Kafka",
-				zap.String("目标主题", deleteTopic),
-				zap.Uint64("帖子ID", deleteEvent.PostID),
-				zap.Int32("分区(Partition)", partition),
-				zap.Int64("偏移量(Offset)", offset),
-				zap.Time("发送时间戳", time.Now()),
-			)
-		}
-		time.Sleep(100 * time.Millisecond)
+	logger.Info("开始发送帖子删除 (PostDeleted) 事件到 Kafka...", zap.Int("消息数量", len(testPostDeletedEvents)))
+	for _, event := range testPostDeletedEvents {
+		sendEvent(producer, logger, deleteTopic, event.PostID, event.EventID, event)
+		time.Sleep(sendInterval)
 	}
-	logger.Info("所有 PostDelete 事件已发送（或已尝试发送）到 Kafka。")
+	logger.Info("所有 PostDeleted 事件已发送（或已尝试发送）到 Kafka。")
 
 	logger.Info("所有测试数据均已处理完毕。")
 }
 
+// sendInterval 是相邻两条消息之间的发送间隔，便于在消费端日志中逐条观察处理过程。
+const sendInterval = 100 * time.Millisecond
+
+// syntheticPostIDBase 是合成帖子 ID 的起始值，避开上面手写测试数据使用的 ID。
+const syntheticPostIDBase = 10000
+
+// newApprovedEvent 为帖子数据生成带有唯一 EventID 的审核通过事件，并补全未设置的创建/更新时间 (毫秒时间戳)。
+func newApprovedEvent(now time.Time, post kafkaevents.PostData) kafkaevents.PostApprovedEvent {
+	if post.CreatedAt == 0 {
+		post.CreatedAt = now.UnixMilli()
+	}
+	if post.UpdatedAt == 0 {
+		post.UpdatedAt = post.CreatedAt
+	}
+	return kafkaevents.PostApprovedEvent{
+		EventID:   uuid.NewString(),
+		Timestamp: now,
+		Post:      post,
+	}
+}
+
+// syntheticPost 生成第 i 个合成帖子。内容在少量模板之间轮换，创建时间依次向前推一小时，
+// 使关键词搜索、排序和时间范围筛选都能得到有区分度的结果。
+func syntheticPost(i int, now time.Time) kafkaevents.PostData {
+	topics := []string{"Go 并发编程", "Elasticsearch 调优", "Kafka 消费者组", "Redis 缓存设计", "Kubernetes 运维"}
+	topic := topics[i%len(topics)]
+	created := now.Add(-time.Duration(i) * time.Hour).UnixMilli()
+	return kafkaevents.PostData{
+		ID:             uint64(syntheticPostIDBase + i),
+		Title:          fmt.Sprintf("合成帖子 #%d: %s", i+1, topic),
+		Content:        fmt.Sprintf("这是由 Seeder 生成的第 %d 条合成帖子，主题是%s。", i+1, topic),
+		AuthorID:       fmt.Sprintf("synthetic_author_%02d", i%10),
+		AuthorUsername: fmt.Sprintf("合成作者%02d", i%10),
+		Status:         enums.Approved,
+		ViewCount:      int64((i * 37) % 1000),
+		OfficialTag:    enums.OfficialTagNone,
+		PricePerUnit:   float64(i%5) * 9.9,
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+}
+
+// sendEvent 将事件序列化为 JSON 并以帖子 ID 作为消息键发送到指定主题 (同一帖子的事件进入同一分区，保证顺序)。
+// 发送失败只记录错误，不中断后续消息的发送。
+func sendEvent(producer sarama.SyncProducer, logger *core.ZapLogger, topic string, postID uint64, eventID string, event interface{}) {
+	payloadBytes, err := json.Marshal(event)
+	if err != nil {
+		logger.Error("序列化事件为 JSON 时发生错误",
+			zap.Uint64("帖子ID", postID),
+			zap.String("事件ID", eventID),
+			zap.Error(err))
+		return
+	}
+	eventKey := strconv.FormatUint(postID, 10)
+	msg := &sarama.ProducerMessage{
+		Topic: topic,
+		Key:   sarama.StringEncoder(eventKey),
+		Value: sarama.ByteEncoder(payloadBytes),
+	}
+	logger.Debug("准备发送的消息详情",
+		zap.String("目标主题", topic),
+		zap.String("消息键(Key)", eventKey),
+		zap.ByteString("消息体片段(Value snippet)", payloadBytes[:min(100, len(payloadBytes))]))
+	partition, offset, err := producer.SendMessage(msg)
+	if err != nil {
+		logger.Error("发送事件到 Kafka 失败",
+			zap.String("目标主题", topic),
+			zap.Uint64("帖子ID", postID),
+			zap.String("事件ID", eventID),
+			zap.Error(err),
+		)
+		return
+	}
+	logger.Info("事件成功发送到 Kafka",
+		zap.String("目标主题", topic),
+		zap.Uint64("帖子ID", postID),
+		zap.String("事件ID", eventID),
+		zap.Int32("分区(Partition)", partition),
+		zap.Int64("偏移量(Offset)", offset),
+		zap.Time("发送时间戳", time.Now()),
+	)
+}
+
 func min(a, b int) int {
 	if a < b {
 		return a
@@ -0,0 +1,20 @@
+package config
+
+// Admin 接口支持的认证方式。
+const (
+	AdminAuthModeAPIKey     = "apiKey"     // 请求头携带静态密钥
+	AdminAuthModeGatewayJWT = "gatewayJWT" // 信任网关校验 JWT 后注入的 X-User-ID / X-User-Role 请求头
+)
+
+// AdminConfig 定义了管理/诊断类接口 (admin 路由组) 的访问控制配置。
+// 这些接口不对公众开放，调用方需要通过 Mode 指定的方式完成认证。
+type AdminConfig struct {
+	// Mode 是认证方式，可选 "apiKey" (默认) 或 "gatewayJWT"。
+	// gatewayJWT 模式依赖网关完成 JWT 校验，只能在服务不可绕过网关直接访问时使用。
+	Mode string `mapstructure:"mode" json:"mode" yaml:"mode" default:"apiKey"`
+	// APIKey 是访问 admin 接口所需的静态密钥 (apiKey 模式)。为空时所有 admin 接口一律拒绝访问 (默认关闭)。
+	// 建议通过环境变量 ADMINCONFIG_APIKEY 注入，而不是写在配置文件中；不参与 JSON 序列化，避免被打印到日志。
+	APIKey string `mapstructure:"apiKey" json:"-" yaml:"apiKey"`
+	// HeaderName 是携带密钥的请求头名称 (apiKey 模式)。
+	HeaderName string `mapstructure:"headerName" json:"headerName" yaml:"headerName" default:"X-Admin-Key"`
+}
@@ -0,0 +1,12 @@
+package config
+
+// CompressionConfig 控制 HTTP 响应压缩。开启后按请求的 Accept-Encoding 选择 gzip 或 deflate，
+// 响应体小于 MinSize 的响应不压缩 (压缩小响应几乎不减少体积，反而增加 CPU 开销)。
+type CompressionConfig struct {
+	// Enabled 为 true 时注册响应压缩中间件 (默认关闭)。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled" default:"false"`
+	// MinSize 是触发压缩的最小响应体字节数，小于等于 0 时使用默认值。
+	MinSize int `mapstructure:"minSize" json:"minSize" yaml:"minSize" default:"1024"`
+	// Level 是压缩级别：1 (最快) 到 9 (压缩率最高)；为 0 或超出范围时使用默认级别 (6)。
+	Level int `mapstructure:"level" json:"level" yaml:"level" default:"6"`
+}
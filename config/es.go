@@ -1,18 +1,104 @@
 package config
 
+import "time"
+
 // IndexSpecificConfig 定义了单个 Elasticsearch 索引的特定配置，如分片和副本数。
 // 我们将为每个需要独立配置的索引使用这个结构。
 type IndexSpecificConfig struct {
 	Name             string `mapstructure:"name" json:"name" yaml:"name"`                                     // 索引的名称
 	NumberOfShards   int    `mapstructure:"numberOfShards" json:"numberOfShards" yaml:"numberOfShards"`       // 该索引的主分片数量
 	NumberOfReplicas int    `mapstructure:"numberOfReplicas" json:"numberOfReplicas" yaml:"numberOfReplicas"` // 该索引的每个主分片的副本数量
+
+	// AutoScaleReplicas 为 true 时启动阶段通过 _cluster/health 检查数据节点数量：只有一个数据节点时副本数设为 0
+	// (单节点无法分配副本，索引会一直是 yellow)，多节点时使用 NumberOfReplicas。
+	// 调整后的副本数同时写入已存在的索引，适合单节点的本地开发环境；生产环境建议关闭，由 NumberOfReplicas 决定。
+	AutoScaleReplicas bool `mapstructure:"autoScaleReplicas" json:"autoScaleReplicas" yaml:"autoScaleReplicas"`
+
+	// RefreshInterval 是索引的 index.refresh_interval (例如 "1s"、"30s"，"-1" 表示关闭自动刷新)，为空时使用 ES 默认值 (1s)。
+	// 调大可以显著提高批量写入吞吐，代价是写入后需要更久才能被搜索到。
+	// 只在创建索引或更新模板时生效；已存在的帖子索引可通过 admin 接口 PUT /_settings/refresh-interval 动态调整。
+	RefreshInterval string `mapstructure:"refreshInterval" json:"refreshInterval" yaml:"refreshInterval"`
+	// TranslogDurability 是索引的 index.translog.durability："request" (默认，每次写请求都 fsync translog)
+	// 或 "async" (按 sync_interval 周期性 fsync，写入更快，但节点崩溃时可能丢失最近几秒已确认的写入)。为空时使用 ES 默认值。
+	TranslogDurability string `mapstructure:"translogDurability" json:"translogDurability" yaml:"translogDurability"`
+
+	// TextAnalyzer 是 title/content 字段使用的分析器，为空时使用 ik_smart (需要 IK 插件)。
+	// 没有 IK 插件的环境 (例如 CI 中的原生 ES) 可以设置为 "standard"。只在创建索引或更新模板时生效，
+	// 修改已存在索引的分析器需要重建索引。目前仅对主帖子索引生效。
+	TextAnalyzer string `mapstructure:"textAnalyzer" json:"textAnalyzer" yaml:"textAnalyzer" default:"ik_smart"`
+
+	// SearchAnalyzer 是 title/content 在查询时使用的分析器 (search_analyzer)，为空时与 TextAnalyzer 相同。
+	// 例如以 ik_smart 索引、以 ik_max_word 查询可以提高召回率。与 TextAnalyzer 不同，修改它不需要重建索引：
+	// 服务启动时会通过 _mapping API 更新已存在索引的 search_analyzer。目前仅对主帖子索引生效。
+	SearchAnalyzer string `mapstructure:"searchAnalyzer" json:"searchAnalyzer" yaml:"searchAnalyzer"`
+
+	// EnglishSubfields 为 true 时为 title/content 增加使用 english 分析器的 .en 子字段 (title.en、content.en)，
+	// 使英文关键词能经过词干提取后匹配 (例如 "running" 匹配 "run")。目前仅对主帖子索引生效。
+	// 注意：对已存在的索引开启后，旧文档需要重建索引 (或执行 _update_by_query) 才会获得 .en 子字段。
+	EnglishSubfields bool `mapstructure:"englishSubfields" json:"englishSubfields" yaml:"englishSubfields"`
+
+	// RouteByAuthor 为 true 时以 author_id 作为文档的 routing 值，使同一作者的帖子位于同一分片，
+	// 加速按作者筛选的搜索。目前仅对主帖子索引生效。
+	// 注意：开启或关闭都会改变文档的分片分布，切换前必须重建索引 (reindex) 已有数据。
+	RouteByAuthor bool `mapstructure:"routeByAuthor" json:"routeByAuthor" yaml:"routeByAuthor"`
+
+	// Template 配置启动时为该索引注册的索引模板，使匹配的新索引 (例如滚动索引) 自动获得映射和设置。
+	Template IndexTemplateConfig `mapstructure:"template" json:"template" yaml:"template"`
+}
+
+// IndexTemplateConfig 定义为某个索引注册的 Elasticsearch 可组合索引模板 (composable index template)。
+type IndexTemplateConfig struct {
+	Enabled  bool     `mapstructure:"enabled" json:"enabled" yaml:"enabled"`                  // 是否在启动时创建/更新模板
+	Name     string   `mapstructure:"name" json:"name" yaml:"name"`                           // 模板名称，为空时为 "<索引名>-template"
+	Patterns []string `mapstructure:"patterns" json:"patterns" yaml:"patterns"`               // 模板匹配的索引模式，为空时为 ["<索引名>", "<索引名>-*"]
+	Priority int      `mapstructure:"priority" json:"priority" yaml:"priority" default:"200"` // 模板优先级，多个模板匹配同一索引时取最高者
 }
 
 // ESConfig 定义了 Elasticsearch 的连接和索引配置
 type ESConfig struct {
 	Addresses []string `mapstructure:"addresses" json:"addresses" yaml:"addresses"`
 	Username  string   `mapstructure:"username" json:"username" yaml:"username"`
-	Password  string   `mapstructure:"password" json:"password" yaml:"password"`
+	Password  string   `mapstructure:"password" json:"-" yaml:"password"` // 不参与 JSON 序列化，避免被打印到日志。
+
+	// --- 客户端重试配置 ---
+	// MaxRetries 是单个请求在遇到可重试错误 (网络错误、RetryOnStatus 中的状态码、超时) 时的最大重试次数。
+	// 为 0 时使用默认值 3；如需完全禁用重试，请设置 DisableRetry。
+	MaxRetries int `mapstructure:"maxRetries" json:"maxRetries" yaml:"maxRetries" default:"3"`
+	// DisableRetry 为 true 时完全禁用客户端级别的重试。
+	DisableRetry bool `mapstructure:"disableRetry" json:"disableRetry" yaml:"disableRetry"`
+	// RetryOnStatus 是触发重试的 HTTP 状态码列表。为空时默认为 [502, 503, 504]。
+	RetryOnStatus []int `mapstructure:"retryOnStatus" json:"retryOnStatus" yaml:"retryOnStatus"`
+	// EnableRetryOnTimeout 为 true (默认) 时，请求超时也按 MaxRetries 重试；为 false 时超时错误直接返回给调用方。
+	// 未配置 (nil) 时视为 true。写入在超时后重试是安全的：索引与删除都以帖子 ID 为 _id 幂等执行。
+	EnableRetryOnTimeout *bool `mapstructure:"enableRetryOnTimeout" json:"enableRetryOnTimeout" yaml:"enableRetryOnTimeout" default:"true"`
+
+	// --- 启动 Ping 重试配置 ---
+	// ES 与本服务同时启动 (例如 docker compose) 时，ES 可能需要数秒才能就绪。
+	// 启动 Ping 会以指数退避加随机抖动重试，用尽次数后仍然失败才终止启动。
+	StartupPingAttempts int           `mapstructure:"startupPingAttempts" json:"startupPingAttempts" yaml:"startupPingAttempts" default:"5"` // 启动 Ping 的最大尝试次数 (含首次)，<=0 时使用默认值 5
+	StartupPingMaxWait  time.Duration `mapstructure:"startupPingMaxWait" json:"startupPingMaxWait" yaml:"startupPingMaxWait" default:"10s"`  // 两次尝试之间等待时间的上限，<=0 时使用默认值 10s
+
+	// --- 连接池配置 (作用于底层 http.Transport) ---
+	MaxIdleConns        int `mapstructure:"maxIdleConns" json:"maxIdleConns" yaml:"maxIdleConns" default:"100"`                     // 所有节点共享的最大空闲连接数
+	MaxIdleConnsPerHost int `mapstructure:"maxIdleConnsPerHost" json:"maxIdleConnsPerHost" yaml:"maxIdleConnsPerHost" default:"10"` // 每个 ES 节点的最大空闲连接数
+	MaxConnsPerHost     int `mapstructure:"maxConnsPerHost" json:"maxConnsPerHost" yaml:"maxConnsPerHost"`                          // 每个 ES 节点的最大连接数 (含活跃连接)，0 表示不限制
+
+	// --- 节点发现 (sniffing) 配置 ---
+	// 开启后客户端会通过 _nodes/http 接口获取集群节点列表，并用其替换 Addresses 中的静态地址。
+	// 单节点开发环境或节点发布地址 (publish_address) 对本服务不可达时 (如 Docker 网络) 应保持关闭。
+	DiscoverNodesOnStart  bool          `mapstructure:"discoverNodesOnStart" json:"discoverNodesOnStart" yaml:"discoverNodesOnStart"`    // 启动时执行一次节点发现
+	DiscoverNodesInterval time.Duration `mapstructure:"discoverNodesInterval" json:"discoverNodesInterval" yaml:"discoverNodesInterval"` // 周期性节点发现的间隔，0 表示禁用
+
+	// RequiredAnalyzers 是启动时 (创建索引之前) 通过 _analyze API 检查是否可用的分析器列表。
+	// 为空时检查主帖子索引的 textAnalyzer 与 searchAnalyzer。分析器缺失 (例如未安装 IK 插件) 时服务会直接启动失败并给出明确提示。
+	RequiredAnalyzers []string `mapstructure:"requiredAnalyzers" json:"requiredAnalyzers" yaml:"requiredAnalyzers"`
+
+	// MappingDriftCheck 控制启动时如何处理已存在索引的映射与期望映射不一致 (字段缺失、类型或分析器不同)：
+	// "warn" (默认) 记录警告后继续启动，"fail" 终止启动，"off" 不检查。线上多出的字段只记录警告，不会导致启动失败。
+	MappingDriftCheck string `mapstructure:"mappingDriftCheck" json:"mappingDriftCheck" yaml:"mappingDriftCheck" default:"warn"`
+
+	// PayloadLogRedaction 定义记录请求体日志时需要脱敏的字段。
+	PayloadLogRedaction PayloadLogRedactionConfig `mapstructure:"payloadLogRedaction" json:"payloadLogRedaction" yaml:"payloadLogRedaction"`
 
 	// 主帖子索引的配置
 	PrimaryIndex IndexSpecificConfig `mapstructure:"primaryIndex" json:"primaryIndex" yaml:"primaryIndex"`
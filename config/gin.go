@@ -0,0 +1,12 @@
+package config
+
+// GinConfig 控制 Gin 引擎的运行模式和受信任的代理。
+type GinConfig struct {
+	// Mode 是 Gin 的运行模式：debug、release 或 test，为空或无效时使用 release。
+	// debug 模式会打印路由表和 Gin 自带的访问日志 (与请求日志中间件重复)，只适合本地开发。
+	Mode string `mapstructure:"mode" json:"mode" yaml:"mode" default:"release"`
+	// TrustedProxies 是受信任的代理 (负载均衡器) 的 IP 或 CIDR 列表。只有来自这些地址的请求，
+	// 才会按 X-Forwarded-For / X-Real-IP 解析客户端 IP (限流、审计日志依赖该 IP)；
+	// 为空时不信任任何代理，客户端 IP 始终为 TCP 连接的对端地址。
+	TrustedProxies []string `mapstructure:"trustedProxies" json:"trustedProxies" yaml:"trustedProxies"`
+}
@@ -0,0 +1,61 @@
+package config
+
+import "time"
+
+// IndexingConfig 定义了将 Kafka 事件写入 Elasticsearch 前对文档内容的处理规则。
+type IndexingConfig struct {
+	// Title / Content 分别限制帖子标题和正文的最大长度。
+	Title   FieldLengthLimit `mapstructure:"title" json:"title" yaml:"title"`
+	Content FieldLengthLimit `mapstructure:"content" json:"content" yaml:"content"`
+
+	// LowercaseAuthorID 为 true 时，写入索引和按 author_id 筛选前都会把作者 ID 转为小写 (两端空白始终会被去除)。
+	// 搜索侧与索引侧共用此配置，保证两边规范化方式一致；开启前写入的文档需要重建索引才能被小写的 ID 匹配。
+	LowercaseAuthorID bool `mapstructure:"lowercaseAuthorID" json:"lowercaseAuthorID" yaml:"lowercaseAuthorID"`
+
+	// StatusPolicy 决定哪些状态的帖子写入索引："all" (默认) 索引所有状态；
+	// "searchableOnly" 只索引 searchConfig.searchableStatuses 中的状态，帖子变为其他状态时从索引中删除。
+	// searchableOnly 下每条不可搜索状态的事件会额外读取一次 ES (判断帖子是否在索引中)；开启后 admin 搜索也看不到这些帖子。
+	// 移出索引的帖子完整保存在暂存索引 "<主索引名>-parked" 中，部分更新事件恢复可搜索状态时据此完整重新索引。
+	StatusPolicy string `mapstructure:"statusPolicy" json:"statusPolicy" yaml:"statusPolicy" default:"all"`
+
+	// Expiry 控制是否定期删除已过期 (expires_at 早于当前时间) 的帖子。
+	Expiry ExpiryConfig `mapstructure:"expiry" json:"expiry" yaml:"expiry"`
+
+	// StripHTML 控制写入索引前是否去除正文 (及标题) 中的 HTML 标签。
+	StripHTML StripHTMLConfig `mapstructure:"stripHTML" json:"stripHTML" yaml:"stripHTML"`
+
+	// ViewCountOnlyPatch 为 true 时，审核事件相对于索引中的文档只改变了 view_count 时，只部分更新该字段而不整体重新索引。
+	// 代价是每条审核事件额外读取一次 ES (按 ID 读取当前文档)；读取失败时回退为完整重新索引。默认关闭。
+	ViewCountOnlyPatch bool `mapstructure:"viewCountOnlyPatch" json:"viewCountOnlyPatch" yaml:"viewCountOnlyPatch" default:"false"`
+}
+
+// StripHTMLConfig 定义写入索引前的 HTML 标签清理。
+// 上游部分正文包含 HTML，标签会被分词写入索引 (撑大索引) 并出现在高亮片段中。
+// 清理只去掉标签本身 (script/style 元素连同内容一起去掉)，文本保留，其中的 '&'、'<'、'>' 保持转义形式；在长度限制之前执行。
+type StripHTMLConfig struct {
+	// Enabled 为 true 时清理 content 中的 HTML 标签，默认关闭。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled" default:"false"`
+	// StripTitle 为 true 时同样清理 title (需要 Enabled 为 true)。
+	StripTitle bool `mapstructure:"stripTitle" json:"stripTitle" yaml:"stripTitle" default:"false"`
+	// PreserveOriginal 为 true 时，清理前的原文写入不分析、不索引的 content_raw / title_raw 字段 (只存在 _source 中)。
+	// 原文不受长度限制截断，会增加存储占用。
+	PreserveOriginal bool `mapstructure:"preserveOriginal" json:"preserveOriginal" yaml:"preserveOriginal" default:"false"`
+}
+
+// ExpiryConfig 定义过期帖子清理任务的参数。
+// 事件中携带 post.expires_at 的帖子会写入该字段；未携带的帖子永不过期。
+type ExpiryConfig struct {
+	// Enabled 为 true 时在后台周期性执行 _delete_by_query 删除已过期的帖子，默认关闭。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled" default:"false"`
+	// SweepInterval 是两次清理之间的间隔，<=0 时使用默认值 10m。
+	SweepInterval time.Duration `mapstructure:"sweepInterval" json:"sweepInterval" yaml:"sweepInterval" default:"10m"`
+}
+
+// FieldLengthLimit 定义单个文本字段的长度上限以及超限时的处理方式。
+// 过长的正文会显著增大索引体积并拖慢高亮，因此需要在入库前限制。
+type FieldLengthLimit struct {
+	// MaxLength 是允许的最大字符数 (按 Unicode 字符计算，而非字节)。0 表示不限制。
+	MaxLength int `mapstructure:"maxLength" json:"maxLength" yaml:"maxLength"`
+	// OnExceed 是超出上限时的处理方式："truncate" 截断后正常索引；"reject" 拒绝该事件 (视为永久性错误并发送到 DLQ)。
+	OnExceed string `mapstructure:"onExceed" json:"onExceed" yaml:"onExceed" default:"truncate"`
+}
@@ -9,22 +9,93 @@ type ConsumerGroupConfig struct {
 	// HeartbeatIntervalMs int `mapstructure:"heartbeatIntervalMs" default:"3000"` // 心跳间隔，通常是 SessionTimeoutMs 的 1/3
 }
 
+// ConsumeRetryConfig 控制消费者组 Consume 调用持续失败 (例如订阅的主题被删除) 时的重试与告警。
+// Consume 每失败一次连续失败次数加一，按指数退避等待后重试；一次会话正常结束后计数清零。
+type ConsumeRetryConfig struct {
+	InitialBackoff time.Duration `mapstructure:"initialBackoff" default:"5s"` // 第一次失败后的等待时间，之后每次翻倍，<=0 时使用默认值。
+	MaxBackoff     time.Duration `mapstructure:"maxBackoff" default:"5m"`     // 等待时间的上限，<=0 时使用默认值。
+	AlertThreshold int           `mapstructure:"alertThreshold" default:"5"`  // 连续失败达到此次数时记录告警日志 (post_search_kafka_consume_alerting 置为 1)，<=0 时使用默认值。
+	ExitAfter      int           `mapstructure:"exitAfter" default:"0"`       // 连续失败达到此次数时优雅关闭并以非零状态码退出进程 (交给 Kubernetes 重启)，0 表示从不退出。
+}
+
+// BulkIndexingConfig 控制审核通过事件的批量索引。开启后同一分区的审核事件攒成批次，通过一次 _bulk 请求写入；
+// 失败的条目中永久性错误 (映射冲突等 4xx) 逐条发送到 DLQ，暂时性错误 (429/5xx) 只重试这些条目，
+// 整批都已成功或进入 DLQ 后才标记该批消息的偏移量。
+type BulkIndexingConfig struct {
+	Enabled       bool          `mapstructure:"enabled" default:"false"`       // 是否启用批量索引，false 时逐条处理 (与旧版本行为一致)。
+	MaxBatchSize  int           `mapstructure:"maxBatchSize" default:"100"`    // 单批最多包含的消息数，<=0 时使用默认值。
+	FlushInterval time.Duration `mapstructure:"flushInterval" default:"200ms"` // 收到批次第一条消息后最多等待多久凑满一批，<=0 时使用默认值。
+	RetryInterval time.Duration `mapstructure:"retryInterval" default:"500ms"` // 暂时性失败条目首次重试前的等待时间，之后指数退避；重试次数沿用 maxRetryAttempts。
+}
+
 // ProducerConfig 包含用于发送消息到 kafka（特指 DLQ）的生产者客户端配置。
 type ProducerConfig struct {
 	Acks           string        `mapstructure:"acks" default:"all"`           // 确认级别 ("all", "1", "0")。
 	RequestTimeout time.Duration `mapstructure:"requestTimeout" default:"10s"` // 同步生产者发送请求的超时时间。
-	// Compression    string        `mapstructure:"compression" default:"none"`   // 消息压缩类型 (none, gzip, snappy, lz4, zstd)
+	Idempotent     bool          `mapstructure:"idempotent" default:"false"`   // 是否启用幂等生产者 (要求 Kafka >= 0.11，且会强制 acks=all)。
+	Compression    string        `mapstructure:"compression" default:"snappy"` // 消息压缩类型 (none, gzip, snappy, lz4, zstd)。
+	RetryMax       int           `mapstructure:"retryMax" default:"3"`         // 生产者内部发送失败 (如 Leader 切换) 时的最大重试次数，<=0 时使用默认值。
+	RetryBackoff   time.Duration `mapstructure:"retryBackoff" default:"250ms"` // 生产者内部两次重试之间的等待时间，<=0 时使用默认值。
 	// MaxMessageBytes int          `mapstructure:"maxMessageBytes" default:"1000000"` // 允许发送的最大消息大小
 }
 
+// DLQSendConfig 控制将消息发送到死信队列 (DLQ) 的行为：超时与重试，以及哪些消息需要转发。
+type DLQSendConfig struct {
+	Timeout         time.Duration `mapstructure:"timeout" default:"10s"`            // 单次发送到 DLQ 的超时时间。
+	MaxRetries      uint64        `mapstructure:"maxRetries" default:"2"`           // 发送失败 (暂时性错误) 时的最大重试次数，0 表示不重试。
+	RetryInterval   time.Duration `mapstructure:"retryInterval" default:"500ms"`    // 首次重试前的等待时间，之后按指数退避增长。
+	MaxPayloadBytes int           `mapstructure:"maxPayloadBytes" default:"921600"` // DLQ 消息体的最大字节数，超出时截断并添加 dlq_payload_truncated 头部；应小于 Broker 的 message.max.bytes。
+	UnknownTopics   bool          `mapstructure:"unknownTopics" default:"false"`    // 是否将没有注册处理函数的主题消息转发到 DLQ；false 时仅记录日志并跳过。
+
+	FailureWebhook DLQFailureWebhookConfig `mapstructure:"failureWebhook"` // 发送到 DLQ 也失败 (潜在数据丢失) 时的告警 webhook。
+}
+
+// DLQFailureWebhookConfig 配置消息发送到 DLQ 失败时调用的告警 webhook。
+// 请求体为 JSON，包含原消息的 topic/partition/offset，便于在 Broker 保留期内找回消息。
+type DLQFailureWebhookConfig struct {
+	URL     string        `mapstructure:"url"`                  // webhook 地址 (POST)，为空时不启用。
+	Timeout time.Duration `mapstructure:"timeout" default:"5s"` // 单次请求的超时时间。
+}
+
+// SchemaCompatibilityConfig 控制上游事件 schema 变更 (例如字段被重命名) 时的反序列化策略。
+// 新增字段始终被忽略，不受此配置影响；它只决定缺少预期字段的消息如何处理。
+type SchemaCompatibilityConfig struct {
+	Mode          string   `mapstructure:"mode" default:"strict"` // "strict": 缺少预期字段的消息发送到 DLQ；"lenient": 对已知版本只记录警告并索引其余字段。
+	KnownVersions []string `mapstructure:"knownVersions"`         // lenient 模式下允许宽松处理的事件 schemaVersion；未携带 schemaVersion 的消息视为已知版本。
+}
+
+// KafkaSecurityConfig 包含连接受保护的 Kafka 集群所需的 SASL 认证和 TLS 加密配置。
+type KafkaSecurityConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`           // 是否启用 SASL 认证。
+	Mechanism string `mapstructure:"mechanism"`         // SASL 认证机制 ("PLAIN", "SCRAM-SHA-256", "SCRAM-SHA-512")。
+	Username  string `mapstructure:"username"`          // SASL 用户名。
+	Password  string `mapstructure:"password" json:"-"` // SASL 密码。建议通过环境变量注入；不参与 JSON 序列化，避免被打印到日志。
+
+	TLSEnabled         bool   `mapstructure:"tlsEnabled"`         // 是否启用 TLS 加密连接。
+	CAFile             string `mapstructure:"caFile"`             // CA 证书路径 (PEM)，为空时使用系统根证书。
+	CertFile           string `mapstructure:"certFile"`           // 客户端证书路径 (PEM)，用于双向 TLS，需与 KeyFile 同时配置。
+	KeyFile            string `mapstructure:"keyFile"`            // 客户端私钥路径 (PEM)。
+	InsecureSkipVerify bool   `mapstructure:"insecureSkipVerify"` // 跳过服务端证书校验，仅用于测试环境。
+}
+
 // KafkaConfig 包含 kafka 消费者及其关联的死信队列（DLQ）生产者的所有配置。
 type KafkaConfig struct {
-	Brokers          []string            `mapstructure:"brokers"`                                                          // kafka Broker 地址列表。
-	GroupID          string              `mapstructure:"groupId"`                                                          // 消费者组 ID。
-	SubscribedTopics []string            `mapstructure:"subscribedTopics" json:"subscribedTopics" yaml:"subscribedTopics"` // 新增：订阅的主题列表
-	DLQTopic         string              `mapstructure:"dlqTopic"`                                                         // 死信队列主题名称。
-	KafkaVersion     string              `mapstructure:"kafkaVersion" default:"2.8.0"`                                     // Kafka 集群版本 (例如 "2.8.0")，用于 Sarama 兼容性。
-	MaxRetryAttempts uint64              `mapstructure:"maxRetryAttempts" default:"3"`                                     // 处理消息失败时的最大重试次数。
-	ConsumerGroup    ConsumerGroupConfig `mapstructure:"consumerGroup"`                                                    // 消费者组详细设置。
-	Producer         ProducerConfig      `mapstructure:"producer"`                                                         // DLQ 生产者设置。
+	Brokers          []string                  `mapstructure:"brokers"`                                                          // kafka Broker 地址列表。
+	GroupID          string                    `mapstructure:"groupId"`                                                          // 消费者组 ID。
+	AuditTopic       string                    `mapstructure:"auditTopic"`                                                       // 审核通过事件 (PostApprovedEvent) 主题，必填。
+	DeleteTopic      string                    `mapstructure:"deleteTopic"`                                                      // 帖子删除事件 (PostDeletedEvent) 主题，必填。
+	PatchTopic       string                    `mapstructure:"patchTopic"`                                                       // 帖子部分更新事件 (PostPatchedEvent) 主题，可选。
+	SubscribedTopics []string                  `mapstructure:"subscribedTopics" json:"subscribedTopics" yaml:"subscribedTopics"` // 已弃用：按位置 ([0] 审核、[1] 删除、[2] 部分更新) 解释的主题列表，仅在具名主题均未配置时使用；启动时由具名主题推导。
+	DLQTopic         string                    `mapstructure:"dlqTopic"`                                                         // 死信队列主题名称。
+	KafkaVersion     string                    `mapstructure:"kafkaVersion" default:"2.8.0"`                                     // Kafka 集群版本 (例如 "2.8.0")，用于 Sarama 兼容性。
+	MaxRetryAttempts uint64                    `mapstructure:"maxRetryAttempts" default:"3"`                                     // 处理消息失败时的最大重试次数。
+	MessageTimeout   time.Duration             `mapstructure:"messageTimeout" default:"30s"`                                     // 单条消息每次处理尝试的超时时间，超时视为可重试错误。
+	MaxMessageBytes  int                       `mapstructure:"maxMessageBytes" default:"1048576"`                                // 消息体的最大字节数，超出的消息不做反序列化，直接发送到 DLQ (dlq_error_stage=oversize)。
+	ConsumerGroup    ConsumerGroupConfig       `mapstructure:"consumerGroup"`                                                    // 消费者组详细设置。
+	Producer         ProducerConfig            `mapstructure:"producer"`                                                         // DLQ 生产者设置。
+	Security         KafkaSecurityConfig       `mapstructure:"security"`                                                         // SASL/TLS 安全设置。
+	DLQSend          DLQSendConfig             `mapstructure:"dlqSend"`                                                          // 发送到 DLQ 的超时与重试设置。
+	Schema           SchemaCompatibilityConfig `mapstructure:"schema"`                                                           // 事件 schema 兼容策略。
+	ConsumeRetry     ConsumeRetryConfig        `mapstructure:"consumeRetry"`                                                     // Consume 持续失败时的退避、告警与退出策略。
+	BulkIndexing     BulkIndexingConfig        `mapstructure:"bulkIndexing"`                                                     // 审核通过事件的批量索引设置。
 }
@@ -0,0 +1,11 @@
+package config
+
+// PayloadLogRedactionConfig 定义记录 Elasticsearch 请求体 (文档 JSON、查询 DSL) 日志时需要脱敏的字段。
+// 请求体只在 Debug 级别或慢查询日志中输出，脱敏后即使在预发环境开启 Debug 日志，
+// 联系方式等敏感信息也不会进入日志采集系统。
+type PayloadLogRedactionConfig struct {
+	// Fields 是需要脱敏的 JSON 字段名 (在请求体的任意层级匹配)，为空时使用 ["contact_qr_code", "contact_info"]。
+	Fields []string `mapstructure:"fields" json:"fields" yaml:"fields"`
+	// Mask 是替换敏感字段值的字符串。
+	Mask string `mapstructure:"mask" json:"mask" yaml:"mask" default:"***"`
+}
@@ -4,8 +4,16 @@ import "github.com/Xushengqwer/go-common/config"
 
 type PostSearchConfig struct {
 	Server              config.ServerConfig `mapstructure:"server" json:"server" config.development.yaml:"server"`
+	GinConfig           GinConfig           `mapstructure:"ginConfig" json:"ginConfig" yaml:"ginConfig"`
+	CompressionConfig   CompressionConfig   `mapstructure:"compressionConfig" json:"compressionConfig" yaml:"compressionConfig"`
 	ZapConfig           config.ZapConfig    `mapstructure:"zapConfig" json:"zapConfig" config.development.yaml:"zapConfig"`
 	TracerConfig        config.TracerConfig `mapstructure:"tracerConfig" json:"tracerConfig" yaml:"tracerConfig"`
 	KafkaConfig         KafkaConfig         `mapstructure:"kafkaConfig" json:"kafkaConfig" config.development.yaml:"kafkaConfig"`
 	ElasticsearchConfig ESConfig            `mapstructure:"elasticsearchConfig" json:"elasticsearchConfig" config.development.yaml:"elasticsearchConfig"`
+	SearchConfig        SearchConfig        `mapstructure:"searchConfig" json:"searchConfig" yaml:"searchConfig"`
+	IndexingConfig      IndexingConfig      `mapstructure:"indexingConfig" json:"indexingConfig" yaml:"indexingConfig"`
+	AdminConfig         AdminConfig         `mapstructure:"adminConfig" json:"adminConfig" yaml:"adminConfig"`
+	TraceIDConfig       TraceIDConfig       `mapstructure:"traceIDConfig" json:"traceIDConfig" yaml:"traceIDConfig"`
+	TenancyConfig       TenancyConfig       `mapstructure:"tenancyConfig" json:"tenancyConfig" yaml:"tenancyConfig"`
+	ShutdownConfig      ShutdownConfig      `mapstructure:"shutdownConfig" json:"shutdownConfig" yaml:"shutdownConfig"`
 }
@@ -0,0 +1,209 @@
+package config
+
+import "time"
+
+// SearchConfig 定义了搜索 API 的业务层面可调参数。
+// 这些参数允许运维在不重新编译的情况下调整搜索行为。
+type SearchConfig struct {
+	// MaxPageSize 是服务端实际生效的每页最大数量。
+	// SearchRequest 上的 binding 标签 (max=100) 仍作为硬性上限；当此值更小时，
+	// 超出的 size 会被 SearchService 静默截断 (clamp) 到此值，而不是返回 400。
+	MaxPageSize int `mapstructure:"maxPageSize" json:"maxPageSize" yaml:"maxPageSize" default:"100"`
+
+	// MaxMgetIDs 是批量获取接口 (POST /posts/mget) 单次请求允许的最大 ID 数量。
+	MaxMgetIDs int `mapstructure:"maxMgetIDs" json:"maxMgetIDs" yaml:"maxMgetIDs" default:"100"`
+
+	// MaxMultiSearchRequests 是批量搜索接口 (POST /msearch) 单次请求允许的最大子搜索数量。
+	MaxMultiSearchRequests int `mapstructure:"maxMultiSearchRequests" json:"maxMultiSearchRequests" yaml:"maxMultiSearchRequests" default:"10"`
+
+	// MaxExcludeIDs 是搜索请求中 exclude_ids 参数允许携带的最大 ID 数量。
+	// 过长的排除列表会生成庞大的 terms 查询，因此需要限制。
+	MaxExcludeIDs int `mapstructure:"maxExcludeIDs" json:"maxExcludeIDs" yaml:"maxExcludeIDs" default:"100"`
+
+	// MaxQueryLength 是搜索关键词 q 允许的最大字符数 (按 rune 计算，去除两端空白后)。
+	// 超长的关键词会生成庞大的 multi_match 查询并污染热门搜索词统计，超出时返回 400。
+	MaxQueryLength int `mapstructure:"maxQueryLength" json:"maxQueryLength" yaml:"maxQueryLength" default:"200"`
+	// MaxQueryTokens 是搜索关键词按空白切分后允许的最大词数 (包括以 - 开头的排除词)，超出时返回 400。
+	MaxQueryTokens int `mapstructure:"maxQueryTokens" json:"maxQueryTokens" yaml:"maxQueryTokens" default:"32"`
+
+	// SearchableStatuses 是公开搜索 (非 admin 请求) 可见的帖子状态 (0 待审核、1 审核通过、2 拒绝)，默认只有 1。
+	// 客户端的 status 参数只能在此范围内缩小结果，不能扩大；admin 请求不受此限制。
+	SearchableStatuses []int `mapstructure:"searchableStatuses" json:"searchableStatuses" yaml:"searchableStatuses" default:"[1]"`
+
+	// FieldBoosts 是关键词查询 (multi_match) 匹配的字段及其权重，例如 {"title": 3, "title.en": 1, "content": 1, "content.en": 0.5}。
+	// 可用字段: title、content、author_username，以及开启 primaryIndex.englishSubfields 后的 title.en、content.en。
+	// 未配置时使用 title^3、content^1、author_username^1 (开启英文子字段时再加上 title.en^1、content.en^0.5)。
+	// 原生分析器字段的权重应高于 .en 子字段，避免同一个词在两种分析结果上重复计分时英文子字段喧宾夺主。
+	FieldBoosts map[string]float64 `mapstructure:"fieldBoosts" json:"fieldBoosts" yaml:"fieldBoosts"`
+
+	// SortMissing 按排序字段设置缺少该字段 (值为 null 或未写入) 的文档排在哪一端，取值 "_last" 或 "_first"，
+	// 例如 {"price_per_unit": "_last"}。未配置的字段默认 "_last"，不受排序方向影响，始终排在结果末尾。
+	SortMissing map[string]string `mapstructure:"sortMissing" json:"sortMissing" yaml:"sortMissing"`
+
+	// DefaultSort 是带关键词搜索时、客户端未指定 sort_by 时使用的默认排序。
+	DefaultSort SortConfig `mapstructure:"defaultSort" json:"defaultSort" yaml:"defaultSort"`
+	// BrowseSort 是关键词为空 (浏览模式) 且客户端未指定 sort_by 时使用的默认排序，例如按浏览量倒序。
+	BrowseSort SortConfig `mapstructure:"browseSort" json:"browseSort" yaml:"browseSort"`
+
+	// MaxRecentLimit 是诊断接口 (GET /_recent) 单次允许返回的最大帖子数量。
+	MaxRecentLimit int `mapstructure:"maxRecentLimit" json:"maxRecentLimit" yaml:"maxRecentLimit" default:"50"`
+
+	// MaxConcurrentSearches 是同时进行的 ES 搜索请求 (含批量搜索) 的上限，达到上限时新的搜索立即返回 503 (带 Retry-After)，
+	// 不会排队等待。用于在突发流量下保护 ES 连接池，与 HTTP 层的限流相互独立。0 表示不限制。
+	MaxConcurrentSearches int `mapstructure:"maxConcurrentSearches" json:"maxConcurrentSearches" yaml:"maxConcurrentSearches" default:"0"`
+
+	// ArchiveIndices 是归档帖子所在的索引 (例如 posts_archive)，请求携带 include_archived=true 时与主帖子索引一起搜索。
+	// 归档索引应使用与主索引相同的映射 (可排序字段缺失时按 unmapped_type 处理)；不存在的归档索引会被跳过。
+	// 多租户请求的租户索引没有对应的归档索引，include_archived 对其不生效。为空时 include_archived 被忽略。
+	ArchiveIndices []string `mapstructure:"archiveIndices" json:"archiveIndices" yaml:"archiveIndices"`
+
+	// SlowSearchThresholdMs 是慢查询阈值 (毫秒)。ES 返回的 took 超过此值时，
+	// 以 Warn 级别记录完整的查询 DSL 和请求参数，便于定位慢查询。0 表示不记录慢查询日志。
+	SlowSearchThresholdMs int64 `mapstructure:"slowSearchThresholdMs" json:"slowSearchThresholdMs" yaml:"slowSearchThresholdMs" default:"500"`
+
+	// RecencyBoost 控制是否以及如何在相关性评分中提升较新的帖子。
+	RecencyBoost RecencyBoostConfig `mapstructure:"recencyBoost" json:"recencyBoost" yaml:"recencyBoost"`
+
+	// ResultCache 控制热门搜索结果的进程内缓存。
+	ResultCache ResultCacheConfig `mapstructure:"resultCache" json:"resultCache" yaml:"resultCache"`
+
+	// HotTermSampleRate 是计入热门搜索词统计的搜索比例 (0.0–1.0)，未设置时为 1.0，即每次搜索都计数。
+	// 小于 1 时只有约该比例的搜索会写入 ES，每次写入的计数增量为 floor(1/rate)，并以 1/rate 的小数部分为概率额外加 1，
+	// 使各词计数的期望值与不采样时相同。
+	// 代价是精度：低频词的计数会出现较大抖动 (可能是 0 也可能是约 1/rate 的倍数)，只有搜索量足够大的词排名才可靠。设为 0 时不记录任何搜索词。
+	// 使用指针以区分 "未设置" 与 0。
+	HotTermSampleRate *float64 `mapstructure:"hotTermSampleRate" json:"hotTermSampleRate" yaml:"hotTermSampleRate" default:"1.0"`
+
+	// ZeroResultFallback 控制关键词搜索零命中时是否附带兜底的热门帖子。
+	ZeroResultFallback ZeroResultFallbackConfig `mapstructure:"zeroResultFallback" json:"zeroResultFallback" yaml:"zeroResultFallback"`
+
+	// UnifiedSearch 控制统一搜索接口 (GET /unified) 中热门搜索词联想分支的参数。
+	UnifiedSearch UnifiedSearchConfig `mapstructure:"unifiedSearch" json:"unifiedSearch" yaml:"unifiedSearch"`
+
+	// Facets 控制请求携带 include_facets=true 时随搜索结果返回的聚合分面。
+	Facets FacetsConfig `mapstructure:"facets" json:"facets" yaml:"facets"`
+
+	// HotSuggest 控制基于热门搜索词的前缀联想接口 (GET /hot-suggest)。
+	HotSuggest HotSuggestConfig `mapstructure:"hotSuggest" json:"hotSuggest" yaml:"hotSuggest"`
+
+	// HotTerms 控制热门搜索词列表 (GET /hot-terms) 返回的词数，由仓库层统一执行。
+	HotTerms HotTermsConfig `mapstructure:"hotTerms" json:"hotTerms" yaml:"hotTerms"`
+
+	// Highlight 按字段覆盖高亮参数，键为可高亮的字段名 (title/content/author_username)。
+	// 未配置的字段沿用内置默认值：content 返回最多 3 个约 150 字符的片段，其余字段使用 ES 默认设置。
+	Highlight map[string]HighlightFieldConfig `mapstructure:"highlight" json:"highlight" yaml:"highlight"`
+
+	// HighlightRequireFieldMatch 为 true (默认) 时只高亮实际匹配了查询的字段，
+	// 例如只有 content 命中时不会高亮 title 中恰好出现的同一个词。请求可通过 highlight_require_field_match 参数覆盖。
+	// 未设置 (nil) 时视为 true，因此使用指针以区分 "未设置" 与 false。
+	HighlightRequireFieldMatch *bool `mapstructure:"highlightRequireFieldMatch" json:"highlightRequireFieldMatch" yaml:"highlightRequireFieldMatch" default:"true"`
+
+	// HighlightEncoder 是高亮片段的编码方式："html" (默认) 会先转义字段内容中的 HTML 字符 (例如 <script>)，
+	// 再插入 <strong> 标签，前端可以直接渲染片段而不会出现破损的标记或 XSS；"default" 不做转义，原样返回字段内容。
+	// 请求可通过 highlight_encoder 参数覆盖。
+	HighlightEncoder string `mapstructure:"highlightEncoder" json:"highlightEncoder" yaml:"highlightEncoder" default:"html"`
+}
+
+// HighlightFieldConfig 定义单个字段的高亮参数。
+type HighlightFieldConfig struct {
+	// FragmentSize 是每个高亮片段的最大字符数 (大致)，0 表示使用默认值。
+	FragmentSize int `mapstructure:"fragmentSize" json:"fragmentSize" yaml:"fragmentSize"`
+	// NumberOfFragments 是最多返回的高亮片段数。设为 0 时不分片段，返回整个字段内容并高亮其中的匹配词，
+	// 适合 title 这类短字段。未设置 (nil) 时使用默认值，因此使用指针以区分 "未设置" 与 0。
+	NumberOfFragments *int `mapstructure:"numberOfFragments" json:"numberOfFragments" yaml:"numberOfFragments"`
+	// BoundaryScanner 是切分高亮片段的边界方式："sentence" 在句子边界处切分，"word" 在词边界处切分；
+	// 为空时使用 ES 默认行为。中文正文按句子切分可以避免片段在句子中间断开。
+	BoundaryScanner string `mapstructure:"boundaryScanner" json:"boundaryScanner" yaml:"boundaryScanner"`
+	// BoundaryScannerLocale 是识别句子/词边界时使用的语言 (BCP 47 标签，例如 "zh-CN")，为空时使用 ES 默认值 (Locale.ROOT)。
+	BoundaryScannerLocale string `mapstructure:"boundaryScannerLocale" json:"boundaryScannerLocale" yaml:"boundaryScannerLocale"`
+}
+
+// ZeroResultFallbackConfig 定义了零结果兜底推荐的参数。
+// 开启后，每个零命中的关键词搜索都会额外发起一次 ES 查询 (match_all 按 view_count 倒序)，
+// 拼写错误或冷门关键词较多时会明显增加 ES 负载；兜底结果随搜索结果一同进入结果缓存。
+type ZeroResultFallbackConfig struct {
+	// Enabled 为 true 时启用兜底推荐，默认关闭。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled" default:"false"`
+	// Size 是兜底推荐的帖子数量，不超过 maxPageSize。
+	Size int `mapstructure:"size" json:"size" yaml:"size" default:"5"`
+}
+
+// FacetsConfig 定义了搜索结果分面 (聚合) 的参数。
+type FacetsConfig struct {
+	// PriceRangeBoundaries 是 price_per_unit 区间分面的分界点 (升序)，n 个分界点生成 n+1 个区间，
+	// 例如 [10, 50] 生成 <10、10-50、>=50 三个区间 (区间下限含、上限不含)。为空时使用 [10, 50]。
+	PriceRangeBoundaries []float64 `mapstructure:"priceRangeBoundaries" json:"priceRangeBoundaries" yaml:"priceRangeBoundaries" default:"[10,50]"`
+}
+
+// UnifiedSearchConfig 定义了统一搜索中与帖子搜索并发执行的热门搜索词联想分支的参数。
+type UnifiedSearchConfig struct {
+	// TermSuggestionLimit 是返回的热门搜索词数量，不超过 hotSuggest.maxResults。
+	TermSuggestionLimit int `mapstructure:"termSuggestionLimit" json:"termSuggestionLimit" yaml:"termSuggestionLimit" default:"5"`
+	// TermSuggestionTimeout 是热门搜索词分支的超时时间。超时或失败时只降级联想部分，帖子结果照常返回，
+	// 因此应明显短于帖子搜索的耗时，避免慢的热门搜索词索引拖慢整个响应。
+	TermSuggestionTimeout time.Duration `mapstructure:"termSuggestionTimeout" json:"termSuggestionTimeout" yaml:"termSuggestionTimeout" default:"300ms"`
+}
+
+// HotSuggestConfig 定义了热门搜索词前缀联想的参数。
+// 与基于标题的补全不同，联想结果来自其他用户实际搜索过的词，按搜索次数倒序返回。
+type HotSuggestConfig struct {
+	// MinPrefixLength 是触发联想所需的最小前缀字符数 (按 rune 计算)，过短的前缀会匹配大量词条且意义不大。
+	MinPrefixLength int `mapstructure:"minPrefixLength" json:"minPrefixLength" yaml:"minPrefixLength" default:"2"`
+	// MaxResults 是单次联想最多返回的词数，请求中的 limit 超过此值时截断。
+	MaxResults int `mapstructure:"maxResults" json:"maxResults" yaml:"maxResults" default:"10"`
+}
+
+// HotTermsConfig 定义了热门搜索词列表的返回数量。
+// 默认值与上限在仓库层 (HotSearchTermRepository.GetHotSearchTerms) 执行，无论调用方是 HTTP 接口还是内部代码都受上限约束。
+type HotTermsConfig struct {
+	// DefaultLimit 是未指定 limit (<=0) 时返回的词数，<=0 时使用默认值 10。
+	DefaultLimit int `mapstructure:"defaultLimit" json:"defaultLimit" yaml:"defaultLimit" default:"10"`
+	// MaxLimit 是单次最多返回的词数，limit 超过此值时截断；<=0 时使用默认值 50。
+	MaxLimit int `mapstructure:"maxLimit" json:"maxLimit" yaml:"maxLimit" default:"50"`
+}
+
+// ResultCacheConfig 定义了搜索结果缓存 (进程内 LRU) 的参数。
+// 缓存键为规范化后的完整 SearchRequest，命中时直接返回缓存的 SearchResult 而不查询 ES。
+// 由于结果最多延迟 TTL 才反映索引变化，TTL 应保持在秒级。
+type ResultCacheConfig struct {
+	// Enabled 是否启用结果缓存，默认关闭。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	// TTL 是缓存条目的有效期。
+	TTL time.Duration `mapstructure:"ttl" json:"ttl" yaml:"ttl" default:"5s"`
+	// MaxEntries 是缓存的最大条目数，超出时淘汰最久未使用的条目。
+	MaxEntries int `mapstructure:"maxEntries" json:"maxEntries" yaml:"maxEntries" default:"1000"`
+	// IncludeAllPages 为 false (默认) 时只缓存第一页，翻页请求分布分散，缓存命中率很低。
+	IncludeAllPages bool `mapstructure:"includeAllPages" json:"includeAllPages" yaml:"includeAllPages"`
+	// IncludeFiltered 为 false (默认) 时只缓存不带筛选条件 (作者、状态、标签、时间范围、排除 ID、联系方式二维码、min_score、归档索引) 的关键词搜索。
+	IncludeFiltered bool `mapstructure:"includeFiltered" json:"includeFiltered" yaml:"includeFiltered"`
+}
+
+// SortConfig 定义一个默认排序规则。
+type SortConfig struct {
+	SortBy    string `mapstructure:"sortBy" json:"sortBy" yaml:"sortBy" default:"updated_at"`    // 排序字段，必须是可排序字段之一
+	SortOrder string `mapstructure:"sortOrder" json:"sortOrder" yaml:"sortOrder" default:"desc"` // 排序顺序，asc 或 desc
+}
+
+// RecencyBoostConfig 定义了 "新帖加权" 评分函数 (function_score) 的参数。
+// 启用后，搜索查询会被包裹在 function_score 中：对 updated_at 施加衰减函数，
+// 并可选地按 view_count 施加 field_value_factor，使较新、较热的帖子排名靠前。
+type RecencyBoostConfig struct {
+	// Enabled 是请求未显式指定 boost_recency 参数时的默认行为。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled"`
+	// DecayFunction 是作用于 updated_at 的衰减函数类型，可选 "gauss" 或 "exp"。
+	DecayFunction string `mapstructure:"decayFunction" json:"decayFunction" yaml:"decayFunction" default:"gauss"`
+	// Scale 是距当前时间多远时得分衰减到 Decay，使用 ES 时间单位，例如 "7d"。
+	Scale string `mapstructure:"scale" json:"scale" yaml:"scale" default:"7d"`
+	// Offset 是不发生衰减的时间窗口，例如 "1d" 表示一天内的帖子得分相同。可为空。
+	Offset string `mapstructure:"offset" json:"offset" yaml:"offset"`
+	// Decay 是距离为 Scale 时的得分比例，取值 (0, 1)。
+	Decay float64 `mapstructure:"decay" json:"decay" yaml:"decay" default:"0.5"`
+	// Weight 是衰减函数得分的权重。
+	Weight float64 `mapstructure:"weight" json:"weight" yaml:"weight" default:"1"`
+	// ViewCountFactor 是 view_count 的 field_value_factor 系数，0 表示不按浏览量加权。
+	ViewCountFactor float64 `mapstructure:"viewCountFactor" json:"viewCountFactor" yaml:"viewCountFactor"`
+	// ViewCountModifier 是应用于 view_count 的修饰函数，例如 "log1p"、"sqrt"。
+	ViewCountModifier string `mapstructure:"viewCountModifier" json:"viewCountModifier" yaml:"viewCountModifier" default:"log1p"`
+	// BoostMode 决定函数得分与查询得分的组合方式，例如 "multiply"、"sum"。
+	BoostMode string `mapstructure:"boostMode" json:"boostMode" yaml:"boostMode" default:"multiply"`
+}
@@ -0,0 +1,11 @@
+package config
+
+import "time"
+
+// ShutdownConfig 定义了服务优雅关闭的参数。
+// 关闭按固定顺序分阶段进行 (HTTP 服务器 → Kafka 消费者组 → 异步任务 → ES 客户端 → DLQ 生产者)，
+// 所有阶段共享同一个总时间预算，超出预算的阶段会被放弃并记录警告，保证进程一定能在预算内退出。
+type ShutdownConfig struct {
+	// Timeout 是整个关闭流程的总时间预算。
+	Timeout time.Duration `mapstructure:"timeout" json:"timeout" yaml:"timeout" default:"30s"`
+}
@@ -0,0 +1,14 @@
+package config
+
+// TenancyConfig 控制可选的多租户支持：同一套服务为多个品牌提供搜索，每个租户使用独立的帖子索引。
+// 开启后请求通过 HeaderName 指定租户，帖子操作作用于 "<主索引名>_<租户>" (首次写入时自动创建，读取不会创建索引)；
+// 未携带该请求头的请求仍使用主索引，与单租户部署行为一致。
+type TenancyConfig struct {
+	// Enabled 为 true 时注册租户中间件并读取租户请求头/消息头 (默认关闭)。
+	Enabled bool `mapstructure:"enabled" json:"enabled" yaml:"enabled" default:"false"`
+	// HeaderName 是携带租户标识的 HTTP 请求头名称，Kafka 消息使用同名的消息头。
+	HeaderName string `mapstructure:"headerName" json:"headerName" yaml:"headerName" default:"X-Tenant-ID"`
+	// AllowedTenants 非空时只接受列表中的租户，其他租户的请求返回 400、消息发送到 DLQ；为空时接受任意格式合法的租户。
+	// 以 archive 开头的租户标识是保留的 (会与归档索引重名)，始终被拒绝。
+	AllowedTenants []string `mapstructure:"allowedTenants" json:"allowedTenants" yaml:"allowedTenants"`
+}
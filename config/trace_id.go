@@ -0,0 +1,10 @@
+package config
+
+// TraceIDConfig 控制是否在 HTTP 响应中返回当前请求的 OpenTelemetry trace ID。
+// 客户端在反馈问题时附上该 ID，即可直接定位对应的服务端日志与链路追踪。
+type TraceIDConfig struct {
+	// Disabled 为 true 时不注册 trace ID 中间件 (默认启用)。
+	Disabled bool `mapstructure:"disabled" json:"disabled" yaml:"disabled"`
+	// HeaderName 是携带 trace ID 的响应头名称。
+	HeaderName string `mapstructure:"headerName" json:"headerName" yaml:"headerName" default:"X-Trace-Id"`
+}
@@ -0,0 +1,159 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultAdminHeaderName 是未配置 AdminConfig.HeaderName 时携带 admin 密钥的请求头。
+const defaultAdminHeaderName = "X-Admin-Key"
+
+// 网关校验 JWT 后注入的用户信息请求头，与 go-common 的 UserContextMiddleware 读取的请求头一致。
+const (
+	gatewayUserIDHeader   = "X-User-ID"
+	gatewayUserRoleHeader = "X-User-Role"
+)
+
+// AdminAuthMiddleware 返回保护 admin 路由组的中间件，认证方式由 cfg.Mode 决定:
+//   - apiKey (默认): 请求头中的密钥必须与配置的 APIKey 一致。
+//   - gatewayJWT: 网关已校验 JWT 并注入用户信息，要求用户角色为 admin。
+//
+// 未认证 (缺少或错误的凭据) 返回 401；已认证但无权限或 admin 接口未启用返回 403。
+// 无法识别的 Mode 视为配置错误，所有 admin 请求返回 403，而不是退化为不认证。
+func AdminAuthMiddleware(cfg config.AdminConfig, logger *core.ZapLogger) gin.HandlerFunc {
+	switch cfg.Mode {
+	case "", config.AdminAuthModeAPIKey:
+		return apiKeyAuthMiddleware(cfg, logger)
+	case config.AdminAuthModeGatewayJWT:
+		logger.Info("admin 接口使用网关 JWT 认证，请确认服务只能经由网关访问")
+		return gatewayJWTAuthMiddleware(logger)
+	default:
+		logger.Error("无法识别的 admin 认证方式，所有 admin 接口将拒绝访问",
+			zap.String("configured_mode", cfg.Mode),
+			zap.Strings("supported_modes", []string{config.AdminAuthModeAPIKey, config.AdminAuthModeGatewayJWT}),
+		)
+		return func(c *gin.Context) {
+			respondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "管理接口未启用")
+			c.Abort()
+		}
+	}
+}
+
+// apiKeyAuthMiddleware 校验请求头中的静态密钥。
+//   - 未配置 APIKey：admin 接口被禁用，返回 403。
+//   - 请求未携带或携带了错误的密钥：返回 401。
+//
+// 密钥比较使用常量时间算法，避免通过响应时间推测密钥内容。
+func apiKeyAuthMiddleware(cfg config.AdminConfig, logger *core.ZapLogger) gin.HandlerFunc {
+	headerName := cfg.HeaderName
+	if headerName == "" {
+		headerName = defaultAdminHeaderName
+	}
+	if cfg.APIKey == "" {
+		logger.Warn("未配置 admin 接口密钥 (adminConfig.apiKey)，所有 admin 接口将拒绝访问")
+	}
+
+	return func(c *gin.Context) {
+		if cfg.APIKey == "" {
+			respondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "管理接口未启用")
+			c.Abort()
+			return
+		}
+		provided := c.GetHeader(headerName)
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.APIKey)) != 1 {
+			logger.Warn("admin 接口认证失败",
+				zap.String("path", c.FullPath()),
+				zap.String("client_ip", c.ClientIP()),
+				zap.Bool("key_provided", provided != ""),
+			)
+			respondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "管理接口认证失败")
+			c.Abort()
+			return
+		}
+		setAdminActor(c, "api_key", "")
+		c.Next()
+	}
+}
+
+// adminRequestContextKey 是 AdminIdentityMiddleware 在 gin.Context 中标记 admin 请求的键。
+const adminRequestContextKey = "is_admin_request"
+
+// AdminIdentityMiddleware 识别公开接口上携带了有效 admin 凭据的请求，并在 gin.Context 中做标记。
+// 与 AdminAuthMiddleware 不同，它从不拒绝请求：没有凭据或凭据无效时只是不做标记，按普通请求处理。
+// 识别规则与 AdminAuthMiddleware 使用的认证方式 (cfg.Mode) 一致。
+func AdminIdentityMiddleware(cfg config.AdminConfig) gin.HandlerFunc {
+	headerName := cfg.HeaderName
+	if headerName == "" {
+		headerName = defaultAdminHeaderName
+	}
+	return func(c *gin.Context) {
+		var isAdmin bool
+		switch cfg.Mode {
+		case "", config.AdminAuthModeAPIKey:
+			provided := c.GetHeader(headerName)
+			isAdmin = cfg.APIKey != "" && provided != "" &&
+				subtle.ConstantTimeCompare([]byte(provided), []byte(cfg.APIKey)) == 1
+		case config.AdminAuthModeGatewayJWT:
+			role, err := enums.RoleFromString(c.GetHeader(gatewayUserRoleHeader))
+			isAdmin = c.GetHeader(gatewayUserIDHeader) != "" && err == nil && role == enums.RoleAdmin
+		}
+		if isAdmin {
+			c.Set(adminRequestContextKey, true)
+		}
+		c.Next()
+	}
+}
+
+// isAdminRequest 判断请求是否已被 AdminIdentityMiddleware 识别为 admin 请求。
+func isAdminRequest(c *gin.Context) bool {
+	return c.GetBool(adminRequestContextKey)
+}
+
+// isAuthenticatedRequest 判断请求是否携带了用户身份 (网关注入的用户 ID、Authorization 头或 admin 密钥)。
+// 公开接口不要求认证，此函数只用于区分匿名请求与已认证请求 (例如决定是否使用结果缓存)。
+// admin 密钥的请求头名称可以配置 (AdminConfig.HeaderName)，因此已被 AdminIdentityMiddleware 识别的 admin 请求始终视为已认证。
+func isAuthenticatedRequest(c *gin.Context) bool {
+	return isAdminRequest(c) ||
+		c.GetHeader(gatewayUserIDHeader) != "" ||
+		c.GetHeader("Authorization") != "" ||
+		c.GetHeader(defaultAdminHeaderName) != ""
+}
+
+// gatewayJWTAuthMiddleware 根据网关注入的用户信息进行授权。
+//   - 缺少用户 ID (请求未经网关认证)：返回 401。
+//   - 角色不是 admin：返回 403。
+func gatewayJWTAuthMiddleware(logger *core.ZapLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetHeader(gatewayUserIDHeader)
+		if userID == "" {
+			logger.Warn("admin 接口认证失败：请求缺少网关注入的用户信息",
+				zap.String("path", c.FullPath()),
+				zap.String("client_ip", c.ClientIP()),
+			)
+			respondError(c, http.StatusUnauthorized, response.ErrCodeClientUnauthorized, "管理接口认证失败")
+			c.Abort()
+			return
+		}
+		roleHeader := c.GetHeader(gatewayUserRoleHeader)
+		role, err := enums.RoleFromString(roleHeader)
+		if err != nil || role != enums.RoleAdmin {
+			logger.Warn("admin 接口授权失败：用户角色不是 admin",
+				zap.String("path", c.FullPath()),
+				zap.String("user_id", userID),
+				zap.String("role", roleHeader),
+			)
+			respondError(c, http.StatusForbidden, response.ErrCodeClientForbidden, "无权访问管理接口")
+			c.Abort()
+			return
+		}
+		setAdminActor(c, "user", userID)
+		c.Next()
+	}
+}
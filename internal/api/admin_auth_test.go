@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/gin-gonic/gin"
+)
+
+func TestIsAuthenticatedRequest(t *testing.T) {
+	// 配置了自定义的 admin 密钥请求头，而不是默认的 X-Admin-Key。
+	cfg := config.AdminConfig{APIKey: "secret", HeaderName: "X-Ops-Key"}
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    bool
+	}{
+		{name: "匿名请求", want: false},
+		{name: "自定义请求头携带正确的 admin 密钥", headers: map[string]string{"X-Ops-Key": "secret"}, want: true},
+		{name: "自定义请求头携带错误的 admin 密钥", headers: map[string]string{"X-Ops-Key": "wrong"}, want: false},
+		{name: "默认 admin 请求头", headers: map[string]string{defaultAdminHeaderName: "anything"}, want: true},
+		{name: "网关注入的用户 ID", headers: map[string]string{gatewayUserIDHeader: "42"}, want: true},
+		{name: "Authorization 头", headers: map[string]string{"Authorization": "Bearer token"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			var got bool
+			router.GET("/search", AdminIdentityMiddleware(cfg), func(c *gin.Context) {
+				got = isAuthenticatedRequest(c)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/search", nil)
+			for name, value := range tt.headers {
+				req.Header.Set(name, value)
+			}
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got != tt.want {
+				t.Errorf("isAuthenticatedRequest = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// gin.Context 中保存审计信息的键。
+const (
+	adminActorContextKey   = "admin_actor"   // 认证中间件写入的操作者身份 (adminActor)
+	auditTargetsContextKey = "audit_targets" // 处理函数写入的受影响对象 (map[string]any)
+)
+
+// adminActor 是通过 admin 认证的操作者身份。
+type adminActor struct {
+	Type string // api_key: 使用静态密钥认证 (无法区分具体的人)；user: 网关注入的用户
+	ID   string // 用户 ID，api_key 认证时为空
+}
+
+// setAdminActor 由 admin 认证中间件在认证通过后调用，记录操作者身份供审计日志使用。
+func setAdminActor(c *gin.Context, actorType, id string) {
+	c.Set(adminActorContextKey, adminActor{Type: actorType, ID: id})
+}
+
+// setAuditTarget 记录本次 admin 变更请求影响的对象 (例如索引名、搜索词、帖子 ID)，写入审计日志的 targets 字段。
+// 只在 AuditMiddleware 之后的处理函数中调用才有意义；同一个 key 重复设置时以最后一次为准。
+func setAuditTarget(c *gin.Context, key string, value any) {
+	targets, _ := c.Get(auditTargetsContextKey)
+	m, ok := targets.(map[string]any)
+	if !ok {
+		m = make(map[string]any)
+		c.Set(auditTargetsContextKey, m)
+	}
+	m[key] = value
+}
+
+// AuditMiddleware 为 admin 路由组中所有会修改状态的请求 (GET/HEAD/OPTIONS 以外的方法) 记录一条审计日志，
+// 包含操作者、操作 (方法 + 路由)、受影响的对象、结果和耗时。日志带有 audit=true 字段，便于日志系统单独收集和长期保存。
+// 必须注册在 admin 认证中间件之前，这样认证失败 (401/403) 的变更尝试同样会被记录 (outcome 为 denied)。
+func AuditMiddleware(logger *core.ZapLogger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		start := time.Now()
+		c.Next()
+
+		status := c.Writer.Status()
+		outcome := "success"
+		switch {
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			outcome = "denied"
+		case status >= http.StatusBadRequest:
+			outcome = "failure"
+		}
+
+		actor := adminActor{Type: "anonymous"}
+		if value, ok := c.Get(adminActorContextKey); ok {
+			actor = value.(adminActor)
+		}
+		fields := []zap.Field{
+			zap.Bool("audit", true),
+			zap.String("actor_type", actor.Type),
+			zap.String("actor_id", actor.ID),
+			zap.String("client_ip", c.ClientIP()),
+			zap.String("action", c.Request.Method+" "+c.FullPath()),
+			zap.String("outcome", outcome),
+			zap.Int("status", status),
+			zap.Duration("duration", time.Since(start)),
+		}
+		if targets, ok := c.Get(auditTargetsContextKey); ok {
+			fields = append(fields, zap.Any("targets", targets))
+		}
+		if traceID := c.GetString(traceIDContextKey); traceID != "" {
+			fields = append(fields, zap.String("trace_id", traceID))
+		}
+		logger.Info("admin 变更操作审计", fields...)
+	}
+}
@@ -0,0 +1,250 @@
+package api
+
+import (
+	"bufio"
+	"compress/flate"
+	"compress/gzip"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+const (
+	// defaultCompressionMinSize 是未配置 CompressionConfig.MinSize 时触发压缩的最小响应体字节数。
+	defaultCompressionMinSize = 1024
+	// defaultCompressionLevel 是未配置或配置了无效 CompressionConfig.Level 时的压缩级别。
+	defaultCompressionLevel = 6
+
+	encodingGzip    = "gzip"
+	encodingDeflate = "deflate"
+)
+
+// compressor 是 gzip.Writer 与 flate.Writer 的公共方法。
+type compressor interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+// CompressionMiddleware 按请求的 Accept-Encoding 压缩响应体 (优先 gzip，其次 deflate)。
+// 响应体先缓冲，达到 MinSize 才开始压缩；处理结束时仍不足 MinSize 的响应原样返回。
+// 已设置 Content-Encoding 的响应、HEAD 请求以及没有响应体的状态码 (204、304) 不压缩。
+// 必须注册在请求超时中间件之前：超时中间件在另一个 goroutine 中执行后续处理，并可能在处理尚未结束时写入 504；
+// 注册在它之前时，结束压缩流 (finish) 发生在超时中间件返回之后，而不是与超时响应并发执行。
+func CompressionMiddleware(cfg config.CompressionConfig, logger *core.ZapLogger) gin.HandlerFunc {
+	minSize := cfg.MinSize
+	if minSize <= 0 {
+		minSize = defaultCompressionMinSize
+	}
+	level := cfg.Level
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		if level != 0 {
+			logger.Warn("响应压缩级别 (compressionConfig.level) 无效，将使用默认级别",
+				zap.Int("configured_level", level),
+				zap.Int("default_level", defaultCompressionLevel),
+			)
+		}
+		level = defaultCompressionLevel
+	}
+	// 压缩器内部的状态表较大 (deflate 约数百 KB)，复用以减少每个请求的内存分配。
+	pools := map[string]*sync.Pool{
+		encodingGzip: {New: func() any {
+			w, _ := gzip.NewWriterLevel(io.Discard, level) // level 已校验，不会返回错误
+			return w
+		}},
+		encodingDeflate: {New: func() any {
+			w, _ := flate.NewWriter(io.Discard, level)
+			return w
+		}},
+	}
+
+	return func(c *gin.Context) {
+		// 无论本次是否压缩，响应内容都随 Accept-Encoding 变化，需要告知缓存。
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		encoding := negotiateEncoding(c.GetHeader("Accept-Encoding"))
+		if encoding == "" || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		w := &compressWriter{ResponseWriter: c.Writer, encoding: encoding, minSize: minSize, pool: pools[encoding]}
+		c.Writer = w
+		// 不在结束时把 c.Writer 换回原值：超时后仍在运行的处理 goroutine 可能还在读取 c.Writer，
+		// gin 在复用 Context 时会重置 Writer。
+		defer func() {
+			if err := w.finish(); err != nil {
+				logger.Warn("写入压缩响应失败", zap.String("path", c.Request.URL.Path), zap.String("encoding", encoding), zap.Error(err))
+			}
+		}()
+		c.Next()
+	}
+}
+
+// negotiateEncoding 从 Accept-Encoding 中选择压缩算法：优先 gzip，其次 deflate，q=0 表示拒绝；都不接受时返回空字符串。
+func negotiateEncoding(acceptEncoding string) string {
+	accepted := make(map[string]bool, 2)
+	wildcard := false
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		ok := true
+		if key, value, found := strings.Cut(strings.TrimSpace(params), "="); found && strings.TrimSpace(key) == "q" {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			ok = err == nil && q > 0
+		}
+		if name == "*" {
+			wildcard = ok
+			continue
+		}
+		accepted[name] = ok
+	}
+	for _, encoding := range []string{encodingGzip, encodingDeflate} {
+		if ok, listed := accepted[encoding]; ok || (!listed && wildcard) {
+			return encoding
+		}
+	}
+	return ""
+}
+
+// compressWriter 缓冲响应体直到达到 minSize，再决定是否压缩。
+// 请求超时时，超时中间件写入 504 与仍在运行的处理 goroutine 可能并发使用同一个 compressWriter，
+// 因此写入相关的方法由 mu 串行化；finish 之后的写入返回 http.ErrHandlerTimeout，不再触及底层连接。
+type compressWriter struct {
+	gin.ResponseWriter
+	encoding string
+	minSize  int
+	pool     *sync.Pool
+
+	mu       sync.Mutex
+	buf      []byte
+	decided  bool       // 是否已决定压缩与否 (决定后 buf 不再使用)
+	zw       compressor // 决定压缩后非 nil
+	finished bool       // finish 已执行，响应已结束
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.write(p)
+}
+
+func (w *compressWriter) write(p []byte) (int, error) {
+	if w.finished {
+		return 0, http.ErrHandlerTimeout
+	}
+	if w.decided {
+		if w.zw != nil {
+			return w.zw.Write(p)
+		}
+		return w.ResponseWriter.Write(p)
+	}
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.start(true); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *compressWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// WriteHeader 在响应结束后忽略迟到的状态码。
+func (w *compressWriter) WriteHeader(code int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.finished {
+		w.ResponseWriter.WriteHeader(code)
+	}
+}
+
+// Written 在响应体已缓冲但尚未写出时也返回 true，避免超时中间件等在此时再写入另一个响应。
+func (w *compressWriter) Written() bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.finished || len(w.buf) > 0 || w.ResponseWriter.Written()
+}
+
+// Flush 立即写出已缓冲的内容：此时缓冲已达到 minSize 才压缩，否则后续内容都不压缩。
+func (w *compressWriter) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.finished {
+		return
+	}
+	if !w.decided {
+		if err := w.start(len(w.buf) >= w.minSize); err != nil {
+			return
+		}
+	}
+	if w.zw != nil {
+		if err := w.zw.Flush(); err != nil {
+			return
+		}
+	}
+	w.ResponseWriter.Flush()
+}
+
+// Hijack 接管连接后不再经过压缩 (例如 WebSocket)。
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.decided = true
+	return w.ResponseWriter.Hijack()
+}
+
+// start 决定是否压缩并写出已缓冲的内容。
+func (w *compressWriter) start(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	status := w.Status()
+	if compress && header.Get("Content-Encoding") == "" && status != http.StatusNoContent && status != http.StatusNotModified {
+		header.Set("Content-Encoding", w.encoding)
+		header.Del("Content-Length")
+		w.zw = w.pool.Get().(compressor)
+		w.zw.Reset(w.ResponseWriter)
+	}
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	var err error
+	if w.zw != nil {
+		_, err = w.zw.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// finish 在处理结束时写出不足 minSize 的缓冲内容 (不压缩)，或结束压缩流并归还压缩器。
+func (w *compressWriter) finish() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.finished = true
+	if !w.decided {
+		if err := w.start(false); err != nil {
+			return err
+		}
+	}
+	if w.zw == nil {
+		return nil
+	}
+	err := w.zw.Close()
+	w.zw.Reset(io.Discard)
+	w.pool.Put(w.zw)
+	w.zw = nil
+	return err
+}
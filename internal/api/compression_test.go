@@ -0,0 +1,133 @@
+package api
+
+import (
+	"compress/gzip"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	commonconfig "github.com/Xushengqwer/go-common/config"
+	"github.com/Xushengqwer/go-common/core"
+	commonMiddleware "github.com/Xushengqwer/go-common/middleware"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/gin-gonic/gin"
+)
+
+func newTestLogger(t *testing.T) *core.ZapLogger {
+	t.Helper()
+	logger, err := core.NewZapLogger(commonconfig.ZapConfig{Level: "error", Encoding: "console"})
+	if err != nil {
+		t.Fatalf("创建测试 logger 失败: %v", err)
+	}
+	return logger
+}
+
+// newCompressionTestRouter 按 router.SetupRouter 的顺序注册压缩与请求超时中间件。
+func newCompressionTestRouter(t *testing.T, timeout time.Duration, handler gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	logger := newTestLogger(t)
+	router := gin.New()
+	router.Use(CompressionMiddleware(config.CompressionConfig{Enabled: true, MinSize: 64}, logger))
+	router.Use(commonMiddleware.RequestTimeoutMiddleware(logger, timeout))
+	router.GET("/test", handler)
+	return router
+}
+
+func TestCompressionMiddleware(t *testing.T) {
+	large := strings.Repeat("帖子内容", 100)
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		body           string
+		wantEncoding   string
+	}{
+		{name: "大响应按 Accept-Encoding 压缩", acceptEncoding: "gzip, deflate", body: large, wantEncoding: "gzip"},
+		{name: "小于 minSize 的响应不压缩", acceptEncoding: "gzip", body: "ok", wantEncoding: ""},
+		{name: "客户端不接受压缩", acceptEncoding: "identity", body: large, wantEncoding: ""},
+		{name: "q=0 拒绝 gzip 时使用 deflate", acceptEncoding: "gzip;q=0, deflate", body: large, wantEncoding: "deflate"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := newCompressionTestRouter(t, time.Second, func(c *gin.Context) {
+				c.String(http.StatusOK, tt.body)
+			})
+			req := httptest.NewRequest(http.MethodGet, "/test", nil)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if got := rec.Header().Get("Content-Encoding"); got != tt.wantEncoding {
+				t.Fatalf("Content-Encoding = %q, want %q", got, tt.wantEncoding)
+			}
+			if got := rec.Header().Get("Vary"); got != "Accept-Encoding" {
+				t.Errorf("Vary = %q, want Accept-Encoding", got)
+			}
+			if tt.wantEncoding != "gzip" {
+				if tt.wantEncoding == "" && rec.Body.String() != tt.body {
+					t.Errorf("未压缩的响应体 = %q, want %q", rec.Body.String(), tt.body)
+				}
+				return
+			}
+			zr, err := gzip.NewReader(rec.Body)
+			if err != nil {
+				t.Fatalf("创建 gzip reader 失败: %v", err)
+			}
+			decoded, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatalf("解压响应体失败: %v", err)
+			}
+			if string(decoded) != tt.body {
+				t.Errorf("解压后的响应体与原文不一致 (%d 字节, want %d 字节)", len(decoded), len(tt.body))
+			}
+		})
+	}
+}
+
+func TestCompressionMiddlewareWithRequestTimeout(t *testing.T) {
+	if raceDetectorEnabled {
+		// go-common 的 RequestTimeoutMiddleware 超时时在两个 goroutine 中同时修改 gin.Context 的处理索引 (c.Next 与 c.Abort)，
+		// 与压缩无关，但会被 -race 报告。
+		t.Skip("RequestTimeoutMiddleware 自身的数据竞争会被 -race 报告")
+	}
+	lateWrite := make(chan error, 1)
+	router := newCompressionTestRouter(t, 20*time.Millisecond, func(c *gin.Context) {
+		// 处理时间超过请求超时：超时中间件先写入 504，之后这里的写入不应再到达客户端。
+		<-c.Request.Context().Done()
+		time.Sleep(30 * time.Millisecond)
+		_, err := c.Writer.Write([]byte(strings.Repeat("迟到的响应", 100)))
+		lateWrite <- err
+	})
+	req := httptest.NewRequest(http.MethodGet, "/test", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("status = %d, want 504", rec.Code)
+	}
+	// 504 响应体小于 minSize，原样返回；压缩流在超时中间件返回后才结束，不会与超时响应交织。
+	if got := rec.Header().Get("Content-Encoding"); got != "" {
+		t.Errorf("Content-Encoding = %q, 超时响应不应被压缩", got)
+	}
+	body := rec.Body.String()
+	if strings.Contains(body, "迟到的响应") || !strings.Contains(body, "请求超时") {
+		t.Errorf("响应体 = %q, want 只有超时响应", body)
+	}
+
+	select {
+	case err := <-lateWrite:
+		if !errors.Is(err, http.ErrHandlerTimeout) {
+			t.Errorf("超时后的写入返回 %v, want http.ErrHandlerTimeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("处理 goroutine 没有结束")
+	}
+	if strings.Contains(rec.Body.String(), "迟到的响应") {
+		t.Error("超时后的写入不应到达客户端")
+	}
+}
@@ -2,23 +2,49 @@ package api
 
 import (
 	"context" // 导入 context 包
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv" // 导入 strconv 包用于转换 limit 参数
 	"strings" // 导入 strings 包用于 TrimSpace
-	"time"    // 导入 time 包用于异步记录的超时
+	"sync"
+	"time" // 导入 time 包用于异步记录的超时
+	"unicode/utf8"
 
 	"github.com/Xushengqwer/gateway/pkg/response" // 确保这个包路径正确
 	"github.com/Xushengqwer/go-common/core"
 	"github.com/Xushengqwer/post_search/internal/models"
 	"github.com/Xushengqwer/post_search/internal/service"
 	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
 	"go.uber.org/zap"
 )
 
 // SearchHandler 封装搜索相关的 API 请求处理逻辑.
 type SearchHandler struct {
-	searchService *service.SearchService
-	logger        *core.ZapLogger
+	searchService    *service.SearchService
+	kafkaStatus      KafkaStatusProvider   // 可选，未设置时 /_kafka-status 返回 503。
+	hotTermsResetter HotTermsIndexResetter // 可选，未设置时 /_hot-terms/reset 返回 503。
+	asyncTasks       sync.WaitGroup        // 跟踪尚未完成的异步任务 (热门搜索词记录)，关闭时等待它们写入 ES。
+	logger           *core.ZapLogger
+}
+
+// WaitForAsyncTasks 等待所有已提交的异步任务 (热门搜索词记录) 完成，在关闭 ES 客户端之前调用。
+// ctx 到期时放弃等待并返回 ctx 的错误，未完成的记录会丢失 (热门搜索词统计允许少量误差)。
+func (h *SearchHandler) WaitForAsyncTasks(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		h.asyncTasks.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("等待异步任务完成超时: %w", ctx.Err())
+	}
 }
 
 // NewSearchHandler 创建 SearchHandler 实例.
@@ -43,54 +69,49 @@ func NewSearchHandler(searchSvc *service.SearchService, logger *core.ZapLogger)
 // @Tags         Search
 // @Accept       json
 // @Produce      json
-// @Param        q         query     string  false  "搜索关键词"
+// @Param        q         query     string  false  "搜索关键词，以 - 开头的词表示排除 (例如 go -kafka)。长度和词数受服务端配置限制 (默认 200 个字符、32 个词)"
+// @Param        query_mode query    string  false  "关键词语法: default (默认，支持 - 排除词) 或 advanced (Lucene 风格，例如 title:kafka author_username:xushen；可用字段 title, content, author_username, tags，不支持前导通配符与正则)" Enums(default, advanced)
 // @Param        page      query     int     false  "页码 (从1开始)" default(1) minimum(1)
 // @Param        size      query     int     false  "每页数量" default(10) minimum(1) maximum(100)
-// @Param        sort_by   query     string  false  "排序字段 (例如: updated_at, view_count, _score)" default(updated_at)
-// @Param        sort_order query    string  false  "排序顺序 (asc 或 desc)" default(desc) Enums(asc, desc)
+// @Param        sort_by   query     string  false  "排序字段 (updated_at, created_at, view_count, price_per_unit, id, _score)。未传递时按是否有关键词使用服务端配置的默认排序"
+// @Param        sort_order query    string  false  "排序顺序 (asc 或 desc)。未传递时使用默认排序的顺序" Enums(asc, desc)
+// @Param        author_id query     string  false  "按作者 ID 筛选。可与 q 组合，在该作者的帖子内按关键词搜索；不传 q 时返回该作者的全部帖子，按浏览模式的默认排序"
+// @Param        source_fields query []string false "只返回指定的帖子字段 (例如 id,title,author_username)，id 始终返回；未传递时返回全部字段" collectionFormat(csv)
+// @Param        tags      query     []string false "按标签筛选 (可重复传递)，默认命中任意一个标签即可" collectionFormat(multi)
+// @Param        match_all_tags query bool   false  "为 true 时要求帖子同时包含 tags 中的所有标签"
+// @Param        has_contact_qr_code query bool false "按是否有联系方式二维码筛选：true 只返回有二维码的帖子，false 只返回没有的；未传递时不筛选"
+// @Param        status    query     int     false  "按帖子状态筛选 (0 待审核、1 审核通过、2 拒绝)。非 admin 请求只能在服务端允许的状态 (默认仅 1) 内筛选，请求不可见的状态时返回空结果"
+// @Param        created_from query  int     false  "创建时间下限 (Unix 毫秒，含)"
+// @Param        created_to   query  int     false  "创建时间上限 (Unix 毫秒，含)"
+// @Param        updated_from query  int     false  "更新时间下限 (Unix 毫秒，含)"
+// @Param        updated_to   query  int     false  "更新时间上限 (Unix 毫秒，含)"
+// @Param        freshness    query  string  false  "只返回最近一段时间内更新的帖子：day (24 小时)、week (7 天)、month (30 天)；与 updated_from 同时传递时取更窄的窗口" Enums(day, week, month)
+// @Param        exclude_ids  query  []int   false  "需要从结果中排除的帖子 ID (可重复传递)" collectionFormat(multi)
+// @Param        boost_recency query bool    false  "是否提升较新帖子的相关性得分 (未传递时使用服务端配置)"
+// @Param        min_score query     number  false  "相关性得分下限，低于此得分的帖子不返回；仅在 q 含正向关键词时生效" minimum(0)
+// @Param        highlight_fields query []string false "需要高亮的字段 (title, content, author_username)，默认 title 和 content；传递空值表示关闭高亮" collectionFormat(csv)
+// @Param        highlight_require_field_match query bool false "是否只高亮实际匹配了查询的字段 (未传递时使用服务端配置，默认 true)"
+// @Param        highlight_encoder query string false "高亮片段编码: html 转义字段内容中的 HTML (防止 XSS)，default 原样返回 (未传递时使用服务端配置，默认 html)" Enums(html, default)
+// @Param        include_facets query bool  false  "为 true 时在 data.facets 中返回分面统计 (price_per_unit 区间的帖子数)"
+// @Param        include_archived query bool false "为 true 时同时搜索归档帖子索引 (未配置归档索引时忽略)"
+// @Param        filter_groups query string false "过滤条件组 (JSON 数组)，组内条件为 OR、组间为 AND，例如 [{\"should\":[{\"field\":\"official_tag\",\"op\":\"eq\",\"value\":1},{\"field\":\"view_count\",\"op\":\"gt\",\"value\":1000}]}]。可用字段: author_id, tags, official_tag, view_count, price_per_unit, created_at, updated_at"
+// @Param        debug     query     bool    false  "为 true 时在 data._debug.dsl 中返回实际执行的 ES 查询 DSL，仅对携带 admin 凭据的请求生效"
+// @Param        pretty    query     bool    false  "与 debug 同时使用，以缩进格式返回 DSL"
 // @Success      200       {object}  models.SwaggerSearchResultResponse "搜索成功，返回匹配的帖子列表及分页信息。"
-// @Failure      400       {object}  models.SwaggerErrorResponse "请求参数无效，例如页码超出范围或排序字段不支持。"
+// @Failure      400       {object}  models.SwaggerValidationErrorResponse "请求参数无效，data.errors 中列出每个无效字段及未通过的规则。"
 // @Failure      500       {object}  models.SwaggerErrorResponse "服务器内部错误，搜索服务遇到未预期的问题。"
+// @Failure      503       {object}  models.SwaggerErrorResponse "Elasticsearch 暂时不可用，或同时进行的搜索数已达上限 (响应带 Retry-After)，可稍后重试。"
 // @Router       /api/v1/search/search [get]
 func (h *SearchHandler) SearchPosts(c *gin.Context) {
-	var req models.SearchRequest
-
-	if err := c.ShouldBindQuery(&req); err != nil {
-		h.logger.Warn("请求参数绑定或验证失败", zap.Error(err)) // [cite: post_search/internal/api/handlers.go]
-		response.RespondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请求参数无效")
+	req, ok := h.bindSearchRequest(c)
+	if !ok {
 		return
 	}
-	h.logger.Debug("绑定后的搜索请求", zap.Any("request", req)) // [cite: post_search/internal/api/handlers.go]
+	h.logSearchQueryAsync(req)
 
-	// --- 新增：异步记录搜索关键词 ---
-	if strings.TrimSpace(req.Query) != "" {
-		// 使用 goroutine 异步执行，避免阻塞主搜索流程
-		// 复制 req.Query 到一个新变量，以避免在 goroutine 中捕获循环变量或请求对象的问题
-		queryToLog := req.Query
-		go func(query string) {
-			// 为这个异步操作创建一个独立的上下文，可以设置一个较短的超时
-			// 注意：c.Request.Context() 是针对整个HTTP请求的，如果请求结束，这个上下文会被取消。
-			// 对于后台任务，最好创建一个新的上下文。
-			logCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // 例如5秒超时
-			defer cancel()
-
-			if err := h.searchService.LogSearchQuery(logCtx, query); err != nil {
-				// 记录热门词失败通常不应影响主搜索请求的成功状态，所以只记录错误。
-				h.logger.Error("异步记录搜索关键词失败",
-					zap.String("query", query),
-					zap.Error(err),
-				)
-			} else {
-				h.logger.Debug("搜索关键词已异步提交记录", zap.String("query", query))
-			}
-		}(queryToLog)
-	}
-	// --- 结束新增部分 ---
-
-	results, err := h.searchService.Search(c.Request.Context(), req) // [cite: post_search/internal/api/handlers.go]
+	results, err := h.searchService.Search(h.searchContext(c), req) // [cite: post_search/internal/api/handlers.go]
 	if err != nil {
-		h.logger.Error("服务层搜索失败", zap.Error(err)) // [cite: post_search/internal/api/handlers.go]
-		response.RespondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "搜索服务内部错误")
+		h.respondSearchError(c, err, req, "")
 		return
 	}
 
@@ -98,34 +119,244 @@ func (h *SearchHandler) SearchPosts(c *gin.Context) {
 	response.RespondSuccess(c, results, "搜索成功")
 }
 
+// UnifiedSearch 处理统一搜索请求
+// @Summary      统一搜索 (帖子 + 热门搜索词联想)
+// @Description  参数与 /search 相同。并发查询帖子和以 q 开头的热门搜索词，分别在 posts 和 term_suggestions 中返回。
+// @Description  热门搜索词查询失败或超时时只降级该部分 (term_suggestions 为空且 term_suggestions_degraded 为 true)，帖子结果照常返回。
+// @Tags         Search
+// @Produce      json
+// @Param        q         query     string  false  "搜索关键词，同时作为热门搜索词联想的前缀"
+// @Param        page      query     int     false  "页码 (从1开始)" default(1) minimum(1)
+// @Param        size      query     int     false  "每页数量" default(10) minimum(1) maximum(100)
+// @Success      200       {object}  models.SwaggerUnifiedSearchResultResponse "搜索成功，返回帖子结果和热门搜索词联想。"
+// @Failure      400       {object}  models.SwaggerValidationErrorResponse "请求参数无效，data.errors 中列出每个无效字段及未通过的规则。"
+// @Failure      500       {object}  models.SwaggerErrorResponse "服务器内部错误，帖子搜索失败。"
+// @Failure      503       {object}  models.SwaggerErrorResponse "Elasticsearch 暂时不可用，或同时进行的搜索数已达上限 (响应带 Retry-After)，可稍后重试。"
+// @Router       /api/v1/search/unified [get]
+func (h *SearchHandler) UnifiedSearch(c *gin.Context) {
+	req, ok := h.bindSearchRequest(c)
+	if !ok {
+		return
+	}
+	h.logSearchQueryAsync(req)
+
+	result, err := h.searchService.UnifiedSearch(h.searchContext(c), req)
+	if err != nil {
+		h.respondSearchError(c, err, req, "")
+		return
+	}
+
+	h.logger.Info("统一搜索成功",
+		zap.Int("结果数量", len(result.Posts.Hits)),
+		zap.Int("term_suggestions", len(result.TermSuggestions)),
+		zap.Bool("term_suggestions_degraded", result.TermSuggestionsDegraded),
+	)
+	response.RespondSuccess(c, result, "搜索成功")
+}
+
+// bindSearchRequest 绑定并校验搜索请求参数，失败时已写入 400 响应并返回 false。
+func (h *SearchHandler) bindSearchRequest(c *gin.Context) (models.SearchRequest, bool) {
+	req, details := h.parseSearchRequest(c.Request.URL.Query(), isAdminRequest(c))
+	if len(details) > 0 {
+		respondValidationError(c, details)
+		return req, false
+	}
+	h.logger.Debug("绑定后的搜索请求", zap.Any("request", req)) // [cite: post_search/internal/api/handlers.go]
+	return req, true
+}
+
+// parseSearchRequest 将查询参数绑定为搜索请求并完成全部校验，返回的校验错误非空时请求无效。
+// GET /search 与批量搜索的每个子搜索共用此逻辑，保证两者接受的参数和校验规则完全一致。
+func (h *SearchHandler) parseSearchRequest(values url.Values, admin bool) (models.SearchRequest, []models.FieldValidationError) {
+	var req models.SearchRequest
+
+	err := binding.MapFormWithTag(&req, values, "form")
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&req)
+	}
+	if err != nil {
+		details := translateValidationErrors(err, &req)
+		h.logger.Warn("请求参数绑定或验证失败", zap.Error(err), zap.Any("validation_errors", details)) // [cite: post_search/internal/api/handlers.go]
+		return req, details
+	}
+	req.HighlightFields = models.NormalizeHighlightFields(req.HighlightFields)
+	req.SourceFields = models.NormalizeSourceFields(req.SourceFields)
+	// filter_groups 是结构化条件，无法用普通的查询参数绑定表达，以 JSON 字符串传递后在此解析。
+	if raw := values.Get("filter_groups"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &req.FilterGroups); err != nil {
+			return req, []models.FieldValidationError{{
+				Field:   "filter_groups",
+				Rule:    "json",
+				Message: fmt.Sprintf("参数 filter_groups 必须是 JSON 数组: %v", err),
+			}}
+		}
+	}
+	if details := validateSearchRequest(&req); len(details) > 0 {
+		h.logger.Warn("搜索请求参数未通过业务校验", zap.Any("validation_errors", details))
+		return req, details
+	}
+	// 回显查询 DSL 会暴露索引结构和评分细节，只对 admin 请求开放；其他请求携带 debug 参数时静默忽略。
+	// 同时清除单独传递的 pretty，避免它无意义地参与结果缓存键。
+	if !req.Debug || !admin {
+		req.Debug, req.Pretty = false, false
+	}
+	// 关键词长度/词数检查放在记录热门搜索词之前，超长的异常关键词既不查询 ES，也不计入统计。
+	if err := h.searchService.CheckQueryLimits(req.Query); err != nil {
+		return req, []models.FieldValidationError{h.queryLimitError(err, req.Query)}
+	}
+	return req, nil
+}
+
+// logSearchQueryAsync 异步记录搜索关键词，用于热门搜索词统计。
+// 空关键词不记录；高级查询 (query_mode=advanced) 包含字段限定词等语法，不适合作为热门搜索词，同样不记录。
+func (h *SearchHandler) logSearchQueryAsync(req models.SearchRequest) {
+	query := req.Query
+	if strings.TrimSpace(query) == "" || req.QueryMode == models.QueryModeAdvanced {
+		return
+	}
+	// 使用 goroutine 异步执行，避免阻塞主搜索流程
+	h.asyncTasks.Add(1)
+	go func() {
+		defer h.asyncTasks.Done()
+		// 为这个异步操作创建一个独立的上下文，可以设置一个较短的超时
+		// 注意：c.Request.Context() 是针对整个HTTP请求的，如果请求结束，这个上下文会被取消。
+		// 对于后台任务，最好创建一个新的上下文。
+		logCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second) // 例如5秒超时
+		defer cancel()
+
+		if err := h.searchService.LogSearchQuery(logCtx, query); err != nil {
+			// 记录热门词失败通常不应影响主搜索请求的成功状态，所以只记录错误。
+			h.logger.Error("异步记录搜索关键词失败",
+				zap.String("query", query),
+				zap.Error(err),
+			)
+		} else {
+			h.logger.Debug("搜索关键词已异步提交记录", zap.String("query", query))
+		}
+	}()
+}
+
+// searchContext 根据请求的身份返回搜索使用的上下文。
+func (h *SearchHandler) searchContext(c *gin.Context) context.Context {
+	searchCtx := c.Request.Context()
+	if isAuthenticatedRequest(c) {
+		// 已认证用户或 admin 的请求可能依赖实时数据，始终绕过结果缓存。
+		searchCtx = service.WithoutResultCache(searchCtx)
+	}
+	if isAdminRequest(c) {
+		// admin 请求不受可搜索状态 (searchConfig.searchableStatuses) 限制，可以检索草稿和被拒绝的帖子。
+		searchCtx = service.WithAdminAccess(searchCtx)
+	}
+	return searchCtx
+}
+
+// searchBusyRetryAfterSeconds 是并发搜索数达到上限时 Retry-After 响应头建议的重试等待秒数。
+// 搜索通常在数百毫秒内完成，名额很快会被释放。
+const searchBusyRetryAfterSeconds = 1
+
+// respondSearchError 将服务层搜索错误转换为响应：参数超限或查询无效返回 400，ES 不可用或搜索繁忙返回 503，其余返回 500。
+// fieldPrefix 加在校验错误的字段名前，批量搜索中用于指明出错的子搜索 (例如 "requests[1].")。
+func (h *SearchHandler) respondSearchError(c *gin.Context, err error, req models.SearchRequest, fieldPrefix string) {
+	if errors.Is(err, service.ErrTooManyExcludeIDs) {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   fieldPrefix + "exclude_ids",
+			Rule:    "max",
+			Param:   strconv.Itoa(h.searchService.MaxExcludeIDs()),
+			Value:   strconv.Itoa(len(req.ExcludeIDs)),
+			Message: fmt.Sprintf("参数 exclude_ids 的数量不能超过 %d", h.searchService.MaxExcludeIDs()),
+		}})
+		return
+	}
+	if errors.Is(err, service.ErrQueryTooLong) || errors.Is(err, service.ErrTooManyQueryTokens) {
+		detail := h.queryLimitError(err, req.Query)
+		detail.Field = fieldPrefix + detail.Field
+		respondValidationError(c, []models.FieldValidationError{detail})
+		return
+	}
+	if errors.Is(err, service.ErrBadQuery) {
+		h.logger.Warn("搜索请求生成的查询被判定为无效", zap.String("query", req.Query), zap.Error(err))
+		message := "搜索条件无效，请检查关键词和筛选参数"
+		if fieldPrefix != "" {
+			message = fmt.Sprintf("%s的搜索条件无效，请检查关键词和筛选参数", strings.TrimSuffix(fieldPrefix, "."))
+		}
+		respondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, message)
+		return
+	}
+	if errors.Is(err, service.ErrSearchBusy) {
+		c.Header("Retry-After", strconv.Itoa(searchBusyRetryAfterSeconds))
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "搜索服务繁忙，请稍后重试")
+		return
+	}
+	if errors.Is(err, service.ErrESUnavailable) {
+		h.logger.Error("搜索失败：Elasticsearch 暂时不可用", zap.Error(err))
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "搜索服务暂时不可用，请稍后重试")
+		return
+	}
+	h.logger.Error("服务层搜索失败", zap.Error(err)) // [cite: post_search/internal/api/handlers.go]
+	respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "搜索服务内部错误")
+}
+
+// queryLimitError 将关键词长度/词数超限的错误转换为参数 q 的字段级校验错误。
+// 超长关键词本身不回显到 Value 中，只给出实际的字符数或词数。
+func (h *SearchHandler) queryLimitError(err error, query string) models.FieldValidationError {
+	trimmed := strings.TrimSpace(query)
+	if errors.Is(err, service.ErrTooManyQueryTokens) {
+		return models.FieldValidationError{
+			Field:   "q",
+			Rule:    "max_tokens",
+			Param:   strconv.Itoa(h.searchService.MaxQueryTokens()),
+			Value:   strconv.Itoa(len(strings.Fields(trimmed))),
+			Message: fmt.Sprintf("参数 q 包含的词数不能超过 %d", h.searchService.MaxQueryTokens()),
+		}
+	}
+	return models.FieldValidationError{
+		Field:   "q",
+		Rule:    "max",
+		Param:   strconv.Itoa(h.searchService.MaxQueryLength()),
+		Value:   strconv.Itoa(utf8.RuneCountInString(trimmed)),
+		Message: fmt.Sprintf("参数 q 的长度不能超过 %d 个字符", h.searchService.MaxQueryLength()),
+	}
+}
+
 // GetHotSearchTerms 处理获取热门搜索词的请求
 // @Summary      获取热门搜索词
 // @Description  返回最流行或最近搜索词的列表。
 // @Tags         Search
 // @Produce      json
-// @Param        limit    query     int     false  "返回的热门搜索词数量" default(10) minimum(1) maximum(50)
+// @Param        limit    query     int     false  "返回的热门搜索词数量，未传或无效时使用 searchConfig.hotTerms.defaultLimit (默认 10)，超过 maxLimit (默认 50) 时截断" minimum(1)
+// @Param        sort     query     string  false  "排序方式: count (搜索次数，默认)、recent (最后搜索时间)、blended (搜索次数与最后搜索时间的综合得分)" Enums(count, recent, blended) default(count)
 // @Success      200      {object}  models.SwaggerHotSearchTermsResponse "成功，返回热门搜索词列表。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "sort 参数无效。"
 // @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，无法获取热门搜索词。"
 // @Router       /api/v1/search/hot-terms [get]
 func (h *SearchHandler) GetHotSearchTerms(c *gin.Context) {
-	// 从查询参数中获取 limit，并提供默认值和范围验证
-	limitStr := c.DefaultQuery("limit", "10")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit <= 0 {
-		limit = 10 // 如果转换失败或值无效，则使用默认值
-	} else if limit > 50 {
-		limit = 50 // 设置一个最大上限，防止请求过多数据
+	// 从查询参数中获取 limit；未传或无效时传 0，默认值与上限由仓库层按配置统一执行。
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit < 0 {
+		limit = 0
+	}
+
+	sort := c.DefaultQuery("sort", models.HotTermsSortCount)
+	if !models.IsValidHotTermsSort(sort) {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "sort",
+			Rule:    "oneof",
+			Param:   "count recent blended",
+			Value:   sort,
+			Message: "参数 sort 必须是 count、recent 或 blended",
+		}})
+		return
 	}
 
-	h.logger.Info("收到获取热门搜索词请求", zap.Int("limit", limit))
+	h.logger.Info("收到获取热门搜索词请求", zap.Int("limit", limit), zap.String("sort", sort))
 
 	// 调用服务层获取热门搜索词
 	// 使用 c.Request.Context() 将请求上下文传递给服务层
-	terms, err := h.searchService.GetHotSearchTerms(c.Request.Context(), limit)
+	terms, err := h.searchService.GetHotSearchTerms(c.Request.Context(), limit, sort)
 	if err != nil {
-		h.logger.Error("服务层获取热门搜索词失败", zap.Int("limit", limit), zap.Error(err))
+		h.logger.Error("服务层获取热门搜索词失败", zap.Int("limit", limit), zap.String("sort", sort), zap.Error(err))
 		// 使用您项目中定义的标准错误响应格式
-		response.RespondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取热门搜索词失败")
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取热门搜索词失败")
 		return
 	}
 
@@ -140,6 +371,222 @@ func (h *SearchHandler) GetHotSearchTerms(c *gin.Context) {
 	response.RespondSuccess(c, terms, "热门搜索词获取成功")
 }
 
+// GetSearchVolume 处理搜索量统计请求
+// @Summary      获取搜索量
+// @Description  基于热门搜索词索引统计最近一段时间的搜索次数和不同搜索词数量，例如 "今日搜索量"。
+// @Description  索引只保存每个词的累计计数和最后搜索时间，因此带时间窗口的 total_searches 是窗口内被搜索过的词的累计计数之和，
+// @Description  为窗口内搜索量的上限估计；采样记录和计数衰减也会带来偏差。distinct_terms 为窗口内被搜索过的不同词的精确数量。
+// @Tags         Search
+// @Produce      json
+// @Param        window   query     string  false  "统计窗口，Go duration 格式 (例如 24h、168h)；all 表示不限时间" default(24h)
+// @Success      200      {object}  models.SwaggerSearchVolumeResponse "成功，返回搜索量统计。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "window 参数无效。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，无法统计搜索量。"
+// @Router       /api/v1/search/_search-volume [get]
+func (h *SearchHandler) GetSearchVolume(c *gin.Context) {
+	windowParam := c.DefaultQuery("window", "24h")
+	var window time.Duration
+	if windowParam != "all" {
+		parsed, err := time.ParseDuration(windowParam)
+		if err != nil || parsed <= 0 {
+			respondValidationError(c, []models.FieldValidationError{{
+				Field:   "window",
+				Rule:    "duration",
+				Value:   windowParam,
+				Message: "参数 window 必须是正的时间长度 (例如 24h) 或 all",
+			}})
+			return
+		}
+		window = parsed
+	}
+
+	volume, err := h.searchService.SearchVolume(c.Request.Context(), window)
+	if err != nil {
+		h.logger.Error("服务层统计搜索量失败", zap.String("window", windowParam), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "统计搜索量失败")
+		return
+	}
+	response.RespondSuccess(c, volume, "搜索量统计获取成功")
+}
+
+// SuggestHotTerms 处理热门搜索词前缀联想请求
+// @Summary      热门搜索词联想
+// @Description  返回以 q 开头、其他用户实际搜索过的热门词，按搜索次数倒序。与基于帖子标题的补全不同，结果反映的是搜索行为。
+// @Tags         Search
+// @Produce      json
+// @Param        q        query     string  true   "用户已输入的前缀 (不区分大小写)"
+// @Param        limit    query     int     false  "返回数量，超过服务端上限 (searchConfig.hotSuggest.maxResults) 时截断" minimum(1)
+// @Success      200      {object}  models.SwaggerHotSearchTermsResponse "成功，返回匹配的热门搜索词列表。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "前缀过短或 limit 不是整数。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误。"
+// @Router       /api/v1/search/hot-suggest [get]
+func (h *SearchHandler) SuggestHotTerms(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "limit",
+			Rule:    "parse",
+			Value:   c.Query("limit"),
+			Message: "参数 limit 必须是整数",
+		}})
+		return
+	}
+
+	prefix := c.Query("q")
+	terms, err := h.searchService.SuggestHotTerms(c.Request.Context(), prefix, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrSuggestPrefixTooShort) {
+			respondValidationError(c, []models.FieldValidationError{{
+				Field:   "q",
+				Rule:    "min",
+				Param:   strconv.Itoa(h.searchService.MinSuggestPrefixLength()),
+				Value:   prefix,
+				Message: fmt.Sprintf("参数 q 至少需要 %d 个字符", h.searchService.MinSuggestPrefixLength()),
+			}})
+			return
+		}
+		h.logger.Error("服务层联想热门搜索词失败", zap.String("prefix", prefix), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取热门搜索词联想失败")
+		return
+	}
+	response.RespondSuccess(c, terms, "热门搜索词联想获取成功")
+}
+
+// GetPostsByIDs 处理批量获取帖子的请求
+// @Summary      批量获取帖子
+// @Description  根据帖子 ID 列表一次性返回已索引的帖子文档。结果保持请求中的 ID 顺序，不存在的 ID 会被省略；非 admin 请求同样省略不可搜索状态 (草稿、被拒绝等) 的帖子。
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MgetRequest  true  "帖子 ID 列表"
+// @Success      200      {object}  models.SwaggerPostListResponse "成功，返回找到的帖子列表。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "请求体无效或 ID 数量超过上限。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误。"
+// @Router       /api/v1/search/posts/mget [post]
+func (h *SearchHandler) GetPostsByIDs(c *gin.Context) {
+	var req models.MgetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		details := translateValidationErrors(err, &req)
+		h.logger.Warn("批量获取帖子请求体绑定或验证失败", zap.Error(err), zap.Any("validation_errors", details))
+		respondValidationError(c, details)
+		return
+	}
+
+	docs, err := h.searchService.GetPostsByIDs(h.searchContext(c), req.IDs)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyIDs) {
+			respondValidationError(c, []models.FieldValidationError{{
+				Field:   "ids",
+				Rule:    "max",
+				Param:   strconv.Itoa(h.searchService.MaxMgetIDs()),
+				Value:   strconv.Itoa(len(req.IDs)),
+				Message: fmt.Sprintf("参数 ids 的数量不能超过 %d", h.searchService.MaxMgetIDs()),
+			}})
+			return
+		}
+		h.logger.Error("服务层批量获取帖子失败", zap.Int("requested_ids_count", len(req.IDs)), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "批量获取帖子失败")
+		return
+	}
+
+	h.logger.Info("批量获取帖子成功", zap.Int("requested_ids_count", len(req.IDs)), zap.Int("found_count", len(docs)))
+	response.RespondSuccess(c, docs, "批量获取帖子成功")
+}
+
+// GetSearchSchema 返回搜索 API 可用的字段信息
+// @Summary      获取可搜索/排序/筛选的字段
+// @Description  返回关键词可匹配的字段、sort_by 可用的排序字段、filter_groups 可用的筛选字段 (含类型与比较方式)、可高亮字段以及高级查询的字段限定词。内容与后端的校验白名单一致，前端可据此动态生成排序和筛选控件。
+// @Tags         Search
+// @Produce      json
+// @Success      200      {object}  models.SwaggerSearchSchemaResponse "成功，返回字段信息。"
+// @Router       /api/v1/search/_schema [get]
+func (h *SearchHandler) GetSearchSchema(c *gin.Context) {
+	response.RespondSuccess(c, models.BuildSearchSchema(), "获取搜索字段信息成功")
+}
+
+// GetIndexStats 处理获取索引统计信息的请求
+// @Summary      获取索引统计信息
+// @Description  返回帖子索引和热门搜索词索引的文档数量、存储大小 (字节) 和分片数量，便于运维跟踪索引增长。
+// @Tags         Ops
+// @Produce      json
+// @Success      200      {object}  models.SwaggerIndexStatsResponse "成功，返回索引统计信息。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，无法从 Elasticsearch 获取统计信息。"
+// @Router       /api/v1/search/_index-stats [get]
+func (h *SearchHandler) GetIndexStats(c *gin.Context) {
+	stats, err := h.searchService.IndexStats(c.Request.Context())
+	if err != nil {
+		h.logger.Error("服务层获取索引统计信息失败", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取索引统计信息失败")
+		return
+	}
+	response.RespondSuccess(c, stats, "索引统计信息获取成功")
+}
+
+// RefreshIndex 处理手动刷新帖子索引的请求
+// @Summary      刷新帖子索引
+// @Description  调用 Elasticsearch 的 _refresh API，使此前写入的帖子立即可被搜索。供端到端测试在写入后获得确定的可见性，需要 admin 认证。生产环境不应频繁调用。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Success      200      {object}  models.SwaggerIndexRefreshResponse "刷新成功，返回分片刷新结果。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，刷新索引失败。"
+// @Router       /api/v1/search/_refresh [post]
+func (h *SearchHandler) RefreshIndex(c *gin.Context) {
+	result, err := h.searchService.RefreshIndex(c.Request.Context())
+	if err != nil {
+		h.logger.Error("服务层刷新帖子索引失败", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "刷新索引失败")
+		return
+	}
+	setAuditTarget(c, "index", result.Index)
+	response.RespondSuccess(c, result, "索引刷新成功")
+}
+
+// SetRefreshInterval 处理动态调整帖子索引 refresh_interval 的请求
+// @Summary      调整帖子索引刷新间隔
+// @Description  通过 _settings API 动态修改帖子索引的 index.refresh_interval。批量回填前可设置为 "-1" 关闭自动刷新，回填结束后传空字符串恢复为配置值。需要 admin 认证。
+// @Tags         Admin
+// @Accept       json
+// @Produce      json
+// @Security     AdminKey
+// @Param        body     body      models.IndexRefreshIntervalRequest true "新的刷新间隔"
+// @Success      200      {object}  models.SwaggerIndexRefreshIntervalResponse "调整成功，返回生效的刷新间隔。"
+// @Failure      400      {object}  models.SwaggerErrorResponse "请求体无效或刷新间隔格式错误。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，调整刷新间隔失败。"
+// @Router       /api/v1/search/_settings/refresh-interval [put]
+func (h *SearchHandler) SetRefreshInterval(c *gin.Context) {
+	var req models.IndexRefreshIntervalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		details := translateValidationErrors(err, &req)
+		h.logger.Warn("调整刷新间隔请求体绑定或验证失败", zap.Error(err), zap.Any("validation_errors", details))
+		respondValidationError(c, details)
+		return
+	}
+	setAuditTarget(c, "refresh_interval", req.RefreshInterval)
+
+	result, err := h.searchService.SetRefreshInterval(c.Request.Context(), req.RefreshInterval)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRefreshInterval) {
+			respondValidationError(c, []models.FieldValidationError{{
+				Field:   "refresh_interval",
+				Rule:    "format",
+				Value:   req.RefreshInterval,
+				Message: "refresh_interval 必须是带单位的时间值 (例如 30s) 或 -1",
+			}})
+			return
+		}
+		h.logger.Error("服务层调整帖子索引刷新间隔失败", zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "调整刷新间隔失败")
+		return
+	}
+	setAuditTarget(c, "index", result.Index)
+	response.RespondSuccess(c, result, "刷新间隔调整成功")
+}
+
 // HealthCheck 健康检查处理函数
 // ... (您现有的 HealthCheck 函数保持不变) ...
 func (h *SearchHandler) HealthCheck(c *gin.Context) { // [cite: post_search/internal/api/handlers.go]
@@ -147,6 +594,198 @@ func (h *SearchHandler) HealthCheck(c *gin.Context) { // [cite: post_search/inte
 	response.RespondSuccess(c, gin.H{"status": "ok"}, "服务存活")
 }
 
+// ReadinessCheck 就绪检查处理函数
+// @Summary      就绪检查
+// @Description  检查帖子索引和热门搜索词索引是否存在且健康状态至少为 yellow。每个索引作为一项独立的依赖列出，任意一项不健康时返回 503。Kafka 消费被暂停时 consumer_paused 为 true，但不影响就绪结果。
+// @Tags         Ops
+// @Produce      json
+// @Success      200      {object}  models.SwaggerReadinessResponse "所有依赖就绪。"
+// @Failure      503      {object}  models.SwaggerReadinessResponse "存在未就绪的依赖，data.dependencies 中列出具体原因。"
+// @Router       /api/v1/search/_ready [get]
+func (h *SearchHandler) ReadinessCheck(c *gin.Context) {
+	report := h.searchService.Readiness(c.Request.Context())
+	if h.kafkaStatus != nil {
+		report.ConsumerPaused = h.kafkaStatus.Status().Paused
+	}
+	if !report.Ready {
+		c.JSON(http.StatusServiceUnavailable, response.APIResponse[*models.ReadinessReport]{
+			Code:    response.ErrCodeServerInternal,
+			Message: "服务未就绪",
+			Data:    report,
+		})
+		return
+	}
+	response.RespondSuccess(c, report, "服务已就绪")
+}
+
+// GetRecentPosts 处理获取最近索引帖子的诊断请求
+// @Summary      获取最近索引的帖子 (诊断)
+// @Description  不带任何查询条件，按 updated_at 倒序返回最近被索引的帖子，用于排查索引流程。需要 admin 密钥。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Param        limit    query     int     false  "返回数量，超过服务端上限 (searchConfig.maxRecentLimit) 时截断" default(20) minimum(1)
+// @Success      200      {object}  models.SwaggerPostListResponse "成功，返回最近索引的帖子列表。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未携带或携带了错误的 admin 密钥。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误。"
+// @Router       /api/v1/search/_recent [get]
+func (h *SearchHandler) GetRecentPosts(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "0"))
+	if err != nil {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "limit",
+			Rule:    "parse",
+			Value:   c.Query("limit"),
+			Message: "参数 limit 必须是整数",
+		}})
+		return
+	}
+
+	docs, err := h.searchService.RecentPosts(c.Request.Context(), limit)
+	if err != nil {
+		h.logger.Error("服务层获取最近索引的帖子失败", zap.Int("limit", limit), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取最近索引的帖子失败")
+		return
+	}
+	response.RespondSuccess(c, docs, "获取最近索引的帖子成功")
+}
+
+// ListHotSearchTerms 处理分页浏览全部热门搜索词的管理请求
+// @Summary      分页浏览热门搜索词 (管理)
+// @Description  按计数倒序分页返回所有被统计的搜索词，并返回总数，供管理界面翻页浏览。公开的 /hot-terms 接口不受影响。需要 admin 密钥。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Param        from     query     int     false  "起始偏移量" default(0) minimum(0)
+// @Param        size     query     int     false  "每页数量，超过 100 时截断" default(20) minimum(1) maximum(100)
+// @Success      200      {object}  models.SwaggerHotSearchTermPageResponse "成功，返回当前页的搜索词及总数。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "参数不是整数，或 from + size 超出可浏览范围。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未携带或携带了错误的 admin 密钥。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误。"
+// @Router       /api/v1/search/_hot-terms [get]
+func (h *SearchHandler) ListHotSearchTerms(c *gin.Context) {
+	from, err := strconv.Atoi(c.DefaultQuery("from", "0"))
+	if err != nil || from < 0 {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "from",
+			Rule:    "parse",
+			Value:   c.Query("from"),
+			Message: "参数 from 必须是非负整数",
+		}})
+		return
+	}
+	size, err := strconv.Atoi(c.DefaultQuery("size", "0"))
+	if err != nil || size < 0 {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "size",
+			Rule:    "parse",
+			Value:   c.Query("size"),
+			Message: "参数 size 必须是非负整数",
+		}})
+		return
+	}
+
+	page, err := h.searchService.ListHotSearchTerms(c.Request.Context(), from, size)
+	if err != nil {
+		if errors.Is(err, service.ErrHotTermsPageOutOfRange) {
+			respondValidationError(c, []models.FieldValidationError{{
+				Field:   "from",
+				Rule:    "max",
+				Value:   strconv.Itoa(from),
+				Message: err.Error(),
+			}})
+			return
+		}
+		h.logger.Error("服务层分页获取热门搜索词失败", zap.Int("from", from), zap.Int("size", size), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "获取热门搜索词列表失败")
+		return
+	}
+	response.RespondSuccess(c, page, "热门搜索词列表获取成功")
+}
+
+// FindPostsByContact 处理按联系方式查找帖子的管理请求
+// @Summary      按联系方式查找帖子 (管理)
+// @Description  按 contact_info 精确匹配帖子，供欺诈排查使用。该接口涉及敏感信息，需要 admin 密钥，且每次访问都会记录审计日志。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Param        value    query     string  true  "需要查找的联系方式 (精确匹配)"
+// @Success      200      {object}  models.SwaggerPostListResponse "成功，返回匹配的帖子列表 (最多 100 条)。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "缺少 value 参数。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未携带或携带了错误的 admin 密钥。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误。"
+// @Router       /api/v1/search/posts/by-contact [get]
+func (h *SearchHandler) FindPostsByContact(c *gin.Context) {
+	value := strings.TrimSpace(c.Query("value"))
+	if value == "" {
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "value",
+			Rule:    "required",
+			Message: "参数 value 为必填项",
+		}})
+		return
+	}
+
+	docs, err := h.searchService.FindPostsByContact(c.Request.Context(), value)
+	// 审计日志：无论成功与否都记录谁在何时查询了哪个联系方式 (脱敏) 以及命中数量。
+	auditFields := []zap.Field{
+		zap.Bool("audit", true),
+		zap.String("action", "posts.by_contact"),
+		zap.String("client_ip", c.ClientIP()),
+		zap.String("user_agent", c.Request.UserAgent()),
+		zap.String("contact_masked", maskContact(value)),
+	}
+	if err != nil {
+		h.logger.Error("管理接口：按联系方式查找帖子失败", append(auditFields, zap.Error(err))...)
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "按联系方式查找帖子失败")
+		return
+	}
+	h.logger.Info("管理接口：按联系方式查找帖子", append(auditFields, zap.Int("result_count", len(docs)))...)
+	response.RespondSuccess(c, docs, "按联系方式查找帖子成功")
+}
+
+// maskContact 对联系方式脱敏后用于日志：只保留前 3 个和后 2 个字符，较短的值全部替换为 *。
+func maskContact(value string) string {
+	runes := []rune(value)
+	if len(runes) <= 5 {
+		return strings.Repeat("*", len(runes))
+	}
+	return string(runes[:3]) + strings.Repeat("*", len(runes)-5) + string(runes[len(runes)-2:])
+}
+
+// RegisterAdminRoutes 将管理/诊断类路由注册到已应用 admin 认证中间件的路由组上。
+func (h *SearchHandler) RegisterAdminRoutes(rg *gin.RouterGroup) {
+	rg.GET("/_recent", h.GetRecentPosts)
+	h.logger.Info("路由 GET /_recent 已注册到 SearchHandler.GetRecentPosts (admin)")
+
+	rg.GET("/posts/by-contact", h.FindPostsByContact)
+	h.logger.Info("路由 GET /posts/by-contact 已注册到 SearchHandler.FindPostsByContact (admin)")
+
+	rg.GET("/_kafka-status", h.GetKafkaStatus)
+	h.logger.Info("路由 GET /_kafka-status 已注册到 SearchHandler.GetKafkaStatus (admin)")
+
+	rg.POST("/_consumer/pause", h.PauseKafkaConsumer)
+	h.logger.Info("路由 POST /_consumer/pause 已注册到 SearchHandler.PauseKafkaConsumer (admin)")
+
+	rg.POST("/_consumer/resume", h.ResumeKafkaConsumer)
+	h.logger.Info("路由 POST /_consumer/resume 已注册到 SearchHandler.ResumeKafkaConsumer (admin)")
+
+	rg.POST("/_refresh", h.RefreshIndex)
+	h.logger.Info("路由 POST /_refresh 已注册到 SearchHandler.RefreshIndex (admin)")
+
+	rg.PUT("/_settings/refresh-interval", h.SetRefreshInterval)
+	h.logger.Info("路由 PUT /_settings/refresh-interval 已注册到 SearchHandler.SetRefreshInterval (admin)")
+
+	rg.GET("/_hot-terms", h.ListHotSearchTerms)
+	h.logger.Info("路由 GET /_hot-terms 已注册到 SearchHandler.ListHotSearchTerms (admin)")
+
+	rg.POST("/_hot-terms/reset", h.ResetHotTermsIndex)
+	h.logger.Info("路由 POST /_hot-terms/reset 已注册到 SearchHandler.ResetHotTermsIndex (admin)")
+}
+
 // RegisterRoutes 将搜索相关的路由注册到提供的 Gin 路由组 (RouterGroup) 上。
 func (h *SearchHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	h.logger.Info("开始注册 SearchHandler 的路由...") // [cite: post_search/internal/api/handlers.go]
@@ -155,13 +794,45 @@ func (h *SearchHandler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/search", h.SearchPosts)                               // [cite: post_search/internal/api/handlers.go]
 	h.logger.Info("路由 GET /search 已注册到 SearchHandler.SearchPosts") // [cite: post_search/internal/api/handlers.go]
 
+	// 注册批量搜索接口
+	rg.POST("/msearch", h.MultiSearch)
+	h.logger.Info("路由 POST /msearch 已注册到 SearchHandler.MultiSearch")
+
+	// 注册统一搜索接口 (帖子 + 热门搜索词联想)
+	rg.GET("/unified", h.UnifiedSearch)
+	h.logger.Info("路由 GET /unified 已注册到 SearchHandler.UnifiedSearch")
+
 	// 新增：注册获取热门搜索词接口
 	rg.GET("/hot-terms", h.GetHotSearchTerms)
 	h.logger.Info("路由 GET /hot-terms 已注册到 SearchHandler.GetHotSearchTerms")
 
+	// 注册热门搜索词前缀联想接口
+	rg.GET("/hot-suggest", h.SuggestHotTerms)
+	h.logger.Info("路由 GET /hot-suggest 已注册到 SearchHandler.SuggestHotTerms")
+
+	// 注册搜索量统计接口
+	rg.GET("/_search-volume", h.GetSearchVolume)
+	h.logger.Info("路由 GET /_search-volume 已注册到 SearchHandler.GetSearchVolume")
+
+	// 注册批量获取帖子接口
+	rg.POST("/posts/mget", h.GetPostsByIDs)
+	h.logger.Info("路由 POST /posts/mget 已注册到 SearchHandler.GetPostsByIDs")
+
+	// 注册搜索字段信息接口
+	rg.GET("/_schema", h.GetSearchSchema)
+	h.logger.Info("路由 GET /_schema 已注册到 SearchHandler.GetSearchSchema")
+
+	// 注册索引统计信息接口
+	rg.GET("/_index-stats", h.GetIndexStats)
+	h.logger.Info("路由 GET /_index-stats 已注册到 SearchHandler.GetIndexStats")
+
 	// 注册健康检查接口
 	rg.GET("/_health", h.HealthCheck)                               // [cite: post_search/internal/api/handlers.go]
 	h.logger.Info("路由 GET /_health 已注册到 SearchHandler.HealthCheck") // [cite: post_search/internal/api/handlers.go]
 
+	// 注册就绪检查接口
+	rg.GET("/_ready", h.ReadinessCheck)
+	h.logger.Info("路由 GET /_ready 已注册到 SearchHandler.ReadinessCheck")
+
 	h.logger.Info("SearchHandler 的所有路由已注册完成。") // [cite: post_search/internal/api/handlers.go]
 }
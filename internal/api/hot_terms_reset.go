@@ -0,0 +1,67 @@
+package api
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// HotTermsIndexResetter 删除并重建热门搜索词索引 (由 es.ESClient 实现)。
+// 以接口注入，避免 API 层直接依赖 Elasticsearch 客户端。
+type HotTermsIndexResetter interface {
+	HotTermsIndexName() string
+	ResetHotTermsIndex(ctx context.Context) (*models.HotTermsIndexResetResult, error)
+}
+
+// SetHotTermsIndexResetter 注入热门搜索词索引的重置实现，需在路由开始处理请求之前调用。
+func (h *SearchHandler) SetHotTermsIndexResetter(resetter HotTermsIndexResetter) {
+	h.hotTermsResetter = resetter
+}
+
+// ResetHotTermsIndex 删除并重建热门搜索词索引
+// @Summary      重置热门搜索词索引
+// @Description  删除热门搜索词索引并按服务内置的映射重新创建，清空全部热门搜索词数据，用于数据被错误任务破坏后的恢复。操作不可撤销，必须通过 confirm 参数传入热门搜索词索引的名称进行确认。需要 admin 认证。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Param        confirm  query     string  true  "确认参数，必须等于热门搜索词索引的名称"
+// @Success      200      {object}  models.SwaggerHotTermsIndexResetResponse "重置成功。"
+// @Failure      400      {object}  models.SwaggerErrorResponse "缺少确认参数或确认参数与索引名称不一致。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，重置失败。"
+// @Failure      503      {object}  models.SwaggerErrorResponse "重置功能未初始化。"
+// @Router       /api/v1/search/_hot-terms/reset [post]
+func (h *SearchHandler) ResetHotTermsIndex(c *gin.Context) {
+	if h.hotTermsResetter == nil {
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "热门搜索词索引重置功能未初始化")
+		return
+	}
+	indexName := h.hotTermsResetter.HotTermsIndexName()
+	setAuditTarget(c, "index", indexName)
+	if confirm := c.Query("confirm"); confirm != indexName {
+		h.logger.Warn("拒绝重置热门搜索词索引：确认参数与索引名称不一致",
+			zap.String("confirm", confirm),
+			zap.String("client_ip", c.ClientIP()),
+		)
+		respondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, "请通过 confirm 参数传入热门搜索词索引的名称以确认重置")
+		return
+	}
+
+	h.logger.Warn("收到重置热门搜索词索引的请求，将删除全部热门搜索词数据",
+		zap.String("index_name", indexName),
+		zap.String("client_ip", c.ClientIP()),
+	)
+	result, err := h.hotTermsResetter.ResetHotTermsIndex(c.Request.Context())
+	if err != nil {
+		h.logger.Error("重置热门搜索词索引失败", zap.String("index_name", indexName), zap.Error(err))
+		respondError(c, http.StatusInternalServerError, response.ErrCodeServerInternal, "重置热门搜索词索引失败")
+		return
+	}
+	setAuditTarget(c, "previous_doc_count", result.PreviousDocCount)
+	response.RespondSuccess(c, result, "热门搜索词索引已重置")
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// KafkaStatusProvider 提供 Kafka 消费者的运行时状态，并允许暂停/恢复消费 (由 kafka.ConsumerGroup 实现)。
+// 以接口注入，避免 API 层直接依赖 Kafka 实现。
+type KafkaStatusProvider interface {
+	Status() models.KafkaStatus
+	Pause()
+	Resume()
+}
+
+// SetKafkaStatusProvider 注入 Kafka 状态来源，需在路由开始处理请求之前调用。
+func (h *SearchHandler) SetKafkaStatusProvider(provider KafkaStatusProvider) {
+	h.kafkaStatus = provider
+}
+
+// GetKafkaStatus 返回 Kafka 消费者的运行时配置与状态
+// @Summary      Kafka 消费者状态 (诊断)
+// @Description  返回消费者组订阅的主题、已注册处理函数的主题、最大重试次数、DLQ 配置、就绪状态以及最近一次重平衡时间。只读，需要 admin 认证。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Success      200      {object}  models.SwaggerKafkaStatusResponse "成功，返回 Kafka 消费者状态。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      503      {object}  models.SwaggerErrorResponse "Kafka 消费者未初始化。"
+// @Router       /api/v1/search/_kafka-status [get]
+func (h *SearchHandler) GetKafkaStatus(c *gin.Context) {
+	if h.kafkaStatus == nil {
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "Kafka 消费者未初始化")
+		return
+	}
+	response.RespondSuccess(c, h.kafkaStatus.Status(), "获取 Kafka 消费者状态成功")
+}
+
+// PauseKafkaConsumer 暂停 Kafka 消费
+// @Summary      暂停 Kafka 消费
+// @Description  暂停消费者组对所有分区的拉取 (例如 ES 维护期间)，消息在 Kafka 中排队而不是重试失败后进入 DLQ。暂停期间不处理消息、不提交 offset，重平衡后新分配的分区同样保持暂停。重复调用是幂等的。需要 admin 认证。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Success      200      {object}  models.SwaggerKafkaStatusResponse "已暂停，返回最新的 Kafka 消费者状态。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      503      {object}  models.SwaggerErrorResponse "Kafka 消费者未初始化。"
+// @Router       /api/v1/search/_consumer/pause [post]
+func (h *SearchHandler) PauseKafkaConsumer(c *gin.Context) {
+	if h.kafkaStatus == nil {
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "Kafka 消费者未初始化")
+		return
+	}
+	h.kafkaStatus.Pause()
+	response.RespondSuccess(c, h.kafkaStatus.Status(), "Kafka 消费已暂停")
+}
+
+// ResumeKafkaConsumer 恢复 Kafka 消费
+// @Summary      恢复 Kafka 消费
+// @Description  恢复此前被暂停的消费者组，从已提交的 offset 继续处理排队的消息。重复调用是幂等的。需要 admin 认证。
+// @Tags         Admin
+// @Produce      json
+// @Security     AdminKey
+// @Success      200      {object}  models.SwaggerKafkaStatusResponse "已恢复，返回最新的 Kafka 消费者状态。"
+// @Failure      401      {object}  models.SwaggerErrorResponse "未认证。"
+// @Failure      403      {object}  models.SwaggerErrorResponse "无权限或服务端未启用 admin 接口。"
+// @Failure      503      {object}  models.SwaggerErrorResponse "Kafka 消费者未初始化。"
+// @Router       /api/v1/search/_consumer/resume [post]
+func (h *SearchHandler) ResumeKafkaConsumer(c *gin.Context) {
+	if h.kafkaStatus == nil {
+		respondError(c, http.StatusServiceUnavailable, response.ErrCodeServerInternal, "Kafka 消费者未初始化")
+		return
+	}
+	h.kafkaStatus.Resume()
+	response.RespondSuccess(c, h.kafkaStatus.Status(), "Kafka 消费已恢复")
+}
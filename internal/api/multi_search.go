@@ -0,0 +1,177 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/service"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// MultiSearch 处理批量搜索请求
+// @Summary      批量搜索帖子
+// @Description  在一次请求中执行多个相互独立的搜索 (ES _msearch)，适用于首页等需要同时展示多个版块的场景。
+// @Description  每个子搜索接受与 /search 相同的参数并单独校验，校验错误的字段名带有 requests[i]. 前缀。
+// @Description  任一子搜索失败时整个请求失败；批量搜索不计入热门搜索词统计。
+// @Tags         Search
+// @Accept       json
+// @Produce      json
+// @Param        request  body      models.MultiSearchRequest true "子搜索列表"
+// @Success      200      {object}  models.SwaggerMultiSearchResponse "搜索成功，data 中的结果与 requests 顺序一致。"
+// @Failure      400      {object}  models.SwaggerValidationErrorResponse "请求体无效、子搜索数量超过上限或某个子搜索的参数无效。"
+// @Failure      500      {object}  models.SwaggerErrorResponse "服务器内部错误，批量搜索失败。"
+// @Failure      503      {object}  models.SwaggerErrorResponse "Elasticsearch 暂时不可用，或同时进行的搜索数已达上限 (响应带 Retry-After)，可稍后重试。"
+// @Router       /api/v1/search/msearch [post]
+func (h *SearchHandler) MultiSearch(c *gin.Context) {
+	var body models.MultiSearchRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		h.logger.Warn("批量搜索请求体绑定失败", zap.Error(err))
+		respondValidationError(c, []models.FieldValidationError{{
+			Field:   "requests",
+			Rule:    "required",
+			Message: fmt.Sprintf("请求体必须是包含非空 requests 数组的 JSON 对象: %v", err),
+		}})
+		return
+	}
+	if maxRequests := h.searchService.MaxMultiSearchRequests(); len(body.Requests) > maxRequests {
+		respondValidationError(c, []models.FieldValidationError{h.tooManySearchRequestsError(len(body.Requests), maxRequests)})
+		return
+	}
+
+	admin := isAdminRequest(c)
+	reqs := make([]models.SearchRequest, len(body.Requests))
+	var details []models.FieldValidationError
+	for i, params := range body.Requests {
+		prefix := fmt.Sprintf("requests[%d].", i)
+		values, err := searchParamsToValues(params)
+		if err != nil {
+			details = append(details, models.FieldValidationError{
+				Field:   prefix + err.field,
+				Rule:    "json",
+				Message: err.Error(),
+			})
+			continue
+		}
+		req, itemDetails := h.parseSearchRequest(values, admin)
+		for _, detail := range itemDetails {
+			detail.Field = prefix + detail.Field
+			details = append(details, detail)
+		}
+		reqs[i] = req
+	}
+	if len(details) > 0 {
+		respondValidationError(c, details)
+		return
+	}
+
+	results, err := h.searchService.MultiSearch(h.searchContext(c), reqs)
+	if err != nil {
+		var itemErr *service.MultiSearchItemError
+		if errors.As(err, &itemErr) {
+			h.respondSearchError(c, itemErr.Err, reqs[itemErr.Index], fmt.Sprintf("requests[%d].", itemErr.Index))
+			return
+		}
+		if errors.Is(err, service.ErrTooManySearchRequests) {
+			respondValidationError(c, []models.FieldValidationError{
+				h.tooManySearchRequestsError(len(reqs), h.searchService.MaxMultiSearchRequests()),
+			})
+			return
+		}
+		h.respondSearchError(c, err, models.SearchRequest{}, "")
+		return
+	}
+
+	h.logger.Info("批量搜索成功", zap.Int("子搜索数量", len(results)))
+	response.RespondSuccess(c, results, "批量搜索成功")
+}
+
+// tooManySearchRequestsError 生成子搜索数量超过上限时的校验错误。
+func (h *SearchHandler) tooManySearchRequestsError(count, maxRequests int) models.FieldValidationError {
+	return models.FieldValidationError{
+		Field:   "requests",
+		Rule:    "max",
+		Param:   fmt.Sprint(maxRequests),
+		Value:   fmt.Sprint(count),
+		Message: fmt.Sprintf("参数 requests 最多包含 %d 个子搜索", maxRequests),
+	}
+}
+
+// searchParamError 表示子搜索中某个参数的 JSON 值无法转换为查询参数。
+type searchParamError struct {
+	field string
+	err   error
+}
+
+func (e *searchParamError) Error() string {
+	return fmt.Sprintf("参数 %s 的值无效: %v", e.field, e.err)
+}
+
+// searchParamsToValues 将子搜索的 JSON 对象转换为与 GET /search 等价的查询参数，以复用同一套绑定和校验逻辑：
+// 字符串原样使用，数字和布尔值使用其 JSON 文本，只含标量的数组展开为重复参数，
+// 对象以及包含对象的数组 (例如 filter_groups) 保留为 JSON 文本。null 和空数组视为未传递。
+func searchParamsToValues(params map[string]json.RawMessage) (url.Values, *searchParamError) {
+	values := make(url.Values, len(params))
+	for key, raw := range params {
+		raw = bytes.TrimSpace(raw)
+		if len(raw) == 0 || bytes.Equal(raw, []byte("null")) {
+			continue
+		}
+		switch raw[0] {
+		case '[':
+			var items []json.RawMessage
+			if err := json.Unmarshal(raw, &items); err != nil {
+				return nil, &searchParamError{field: key, err: err}
+			}
+			if !allScalars(items) {
+				values.Set(key, string(raw))
+				continue
+			}
+			for _, item := range items {
+				value, err := scalarText(item)
+				if err != nil {
+					return nil, &searchParamError{field: key, err: err}
+				}
+				values.Add(key, value)
+			}
+		case '{':
+			values.Set(key, string(raw))
+		default:
+			value, err := scalarText(raw)
+			if err != nil {
+				return nil, &searchParamError{field: key, err: err}
+			}
+			values.Set(key, value)
+		}
+	}
+	return values, nil
+}
+
+// allScalars 判断数组元素是否都是标量 (不是对象或数组)。
+func allScalars(items []json.RawMessage) bool {
+	for _, item := range items {
+		item = bytes.TrimSpace(item)
+		if len(item) > 0 && (item[0] == '{' || item[0] == '[') {
+			return false
+		}
+	}
+	return true
+}
+
+// scalarText 返回标量 JSON 值对应的查询参数文本：字符串去掉引号，其他值使用原始 JSON 文本。
+func scalarText(raw json.RawMessage) (string, error) {
+	raw = bytes.TrimSpace(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", err
+		}
+		return s, nil
+	}
+	return string(raw), nil
+}
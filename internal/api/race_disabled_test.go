@@ -0,0 +1,6 @@
+//go:build !race
+
+package api
+
+// raceDetectorEnabled 表示测试是否以 -race 运行。
+const raceDetectorEnabled = false
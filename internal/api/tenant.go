@@ -0,0 +1,41 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// defaultTenantHeader 是未配置 TenancyConfig.HeaderName 时携带租户标识的请求头。
+const defaultTenantHeader = "X-Tenant-ID"
+
+// TenantMiddleware 从请求头读取租户标识，校验后写入请求上下文，帖子仓库据此选择租户索引。
+// 未携带请求头的请求不设置租户 (使用主索引)；租户标识无效或不在允许列表中时返回 400。
+func TenantMiddleware(cfg config.TenancyConfig, logger *core.ZapLogger) gin.HandlerFunc {
+	headerName := cfg.HeaderName
+	if headerName == "" {
+		headerName = defaultTenantHeader
+	}
+	return func(c *gin.Context) {
+		tenant, err := repositories.ParseTenant(c.GetHeader(headerName), cfg.AllowedTenants)
+		if err != nil {
+			logger.Warn("请求携带的租户标识无效",
+				zap.String("path", c.FullPath()),
+				zap.String("header_name", headerName),
+				zap.Error(err),
+			)
+			respondError(c, http.StatusBadRequest, response.ErrCodeClientInvalidInput, err.Error())
+			c.Abort()
+			return
+		}
+		if tenant != "" {
+			c.Request = c.Request.WithContext(repositories.WithTenant(c.Request.Context(), tenant))
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// defaultTraceIDHeader 是未配置 TraceIDConfig.HeaderName 时携带 trace ID 的响应头。
+const defaultTraceIDHeader = "X-Trace-Id"
+
+// traceIDContextKey 是 trace ID 在 gin.Context 中的键，供 respondError 等函数读取。
+const traceIDContextKey = "trace_id"
+
+// TraceIDMiddleware 从请求上下文中的 OTel span 读取 trace ID，写入响应头并保存到 gin.Context。
+// 必须注册在 otelgin 中间件之后，否则请求上下文中还没有 span。
+// 未启用链路追踪时 span 无效 (trace ID 全零)，此时不输出响应头。
+func TraceIDMiddleware(cfg config.TraceIDConfig) gin.HandlerFunc {
+	headerName := cfg.HeaderName
+	if headerName == "" {
+		headerName = defaultTraceIDHeader
+	}
+	return func(c *gin.Context) {
+		if sc := trace.SpanContextFromContext(c.Request.Context()); sc.HasTraceID() {
+			traceID := sc.TraceID().String()
+			c.Set(traceIDContextKey, traceID)
+			c.Header(headerName, traceID)
+		}
+		c.Next()
+	}
+}
+
+// respondError 与 response.RespondError 相同，但在有 trace ID 时把它放入 data.trace_id，
+// 客户端即使没有读取响应头，也能在错误响应体中拿到用于排查的 ID。
+func respondError(c *gin.Context, statusCode int, code int, message string) {
+	traceID := c.GetString(traceIDContextKey)
+	if traceID == "" {
+		response.RespondError(c, statusCode, code, message)
+		return
+	}
+	c.JSON(statusCode, response.APIResponse[models.ErrorData]{
+		Code:    code,
+		Message: message,
+		Data:    models.ErrorData{TraceID: traceID},
+	})
+}
@@ -0,0 +1,269 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/Xushengqwer/gateway/pkg/response"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+)
+
+// translateValidationErrors 将 ShouldBind* 返回的错误转换为字段级别的校验错误列表。
+// 参数:
+//   - err: 绑定或校验过程中返回的错误。
+//   - obj: 绑定的目标结构体 (或其指针)，用于把 Go 字段名还原为客户端使用的参数名 (form/json 标签)。
+//
+// 返回值:
+//   - []models.FieldValidationError: 如果 err 是 validator.ValidationErrors，则返回每个失败字段的详情；
+//     否则 (例如类型转换失败 "size=abc") 返回一条不含具体字段的通用错误。
+func translateValidationErrors(err error, obj interface{}) []models.FieldValidationError {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		// 非校验规则类的错误 (如 strconv 解析失败)，validator 无法给出字段信息。
+		return []models.FieldValidationError{{
+			Field:   "",
+			Rule:    "parse",
+			Message: err.Error(),
+		}}
+	}
+
+	details := make([]models.FieldValidationError, 0, len(validationErrs))
+	for _, fe := range validationErrs {
+		field := paramNameOf(obj, fe.StructField())
+		details = append(details, models.FieldValidationError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Param:   fe.Param(),
+			Value:   fmt.Sprintf("%v", fe.Value()),
+			Message: validationMessage(field, fe),
+		})
+	}
+	return details
+}
+
+// paramNameOf 根据结构体字段名查找客户端可见的参数名。
+// 优先使用 form 标签 (查询参数)，其次是 json 标签 (请求体)，都没有时回退到 Go 字段名。
+func paramNameOf(obj interface{}, structField string) string {
+	t := reflect.TypeOf(obj)
+	for t != nil && (t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice) {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return structField
+	}
+	sf, ok := t.FieldByName(structField)
+	if !ok {
+		return structField
+	}
+	for _, tagKey := range []string{"form", "json"} {
+		if tag := sf.Tag.Get(tagKey); tag != "" {
+			if name := strings.Split(tag, ",")[0]; name != "" && name != "-" {
+				return name
+			}
+		}
+	}
+	return structField
+}
+
+// validationMessage 为常见的校验规则生成可读的中文描述。
+func validationMessage(field string, fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return fmt.Sprintf("参数 %s 为必填项", field)
+	case "min":
+		return fmt.Sprintf("参数 %s 不能小于 %s", field, fe.Param())
+	case "max":
+		return fmt.Sprintf("参数 %s 不能大于 %s", field, fe.Param())
+	case "oneof":
+		return fmt.Sprintf("参数 %s 必须是以下值之一: %s", field, fe.Param())
+	case "uuid|alphanum":
+		return fmt.Sprintf("参数 %s 必须是 UUID 或仅包含字母数字", field)
+	default:
+		return fmt.Sprintf("参数 %s 未通过校验规则 '%s'", field, fe.Tag())
+	}
+}
+
+// respondValidationError 以 HTTP 400 和统一的响应信封返回字段级别的校验错误。
+// data 字段中携带 models.ValidationErrorData，便于客户端定位具体哪个参数有误。
+func respondValidationError(c *gin.Context, details []models.FieldValidationError) {
+	c.JSON(http.StatusBadRequest, response.APIResponse[models.ValidationErrorData]{
+		Code:    response.ErrCodeClientInvalidInput,
+		Message: "请求参数无效",
+		Data:    models.ValidationErrorData{Errors: details, TraceID: c.GetString(traceIDContextKey)},
+	})
+}
+
+// validateSearchRequest 执行 binding 标签无法表达的跨字段或白名单校验。
+// 返回空切片表示校验通过。
+func validateSearchRequest(req *models.SearchRequest) []models.FieldValidationError {
+	var details []models.FieldValidationError
+	// author_id 先去除两端空白再校验，避免客户端误带的空格导致请求被拒绝；真正的规范化在 SearchService 中完成。
+	if authorID := strings.TrimSpace(req.AuthorID); authorID != "" {
+		if err := binding.Validator.Engine().(*validator.Validate).Var(authorID, "uuid|alphanum"); err != nil {
+			details = append(details, models.FieldValidationError{
+				Field:   "author_id",
+				Rule:    "uuid|alphanum",
+				Value:   req.AuthorID,
+				Message: "参数 author_id 必须是 UUID 或仅包含字母数字",
+			})
+		}
+	}
+	if req.QueryMode == models.QueryModeAdvanced {
+		if err := models.ValidateAdvancedQuery(req.Query); err != nil {
+			details = append(details, models.FieldValidationError{
+				Field:   "q",
+				Rule:    "advanced_query",
+				Value:   req.Query,
+				Message: "高级查询语法无效: " + err.Error(),
+			})
+		}
+	}
+	if req.SortBy != "" && !models.IsSortableField(req.SortBy) {
+		details = append(details, models.FieldValidationError{
+			Field:   "sort_by",
+			Rule:    "sortable",
+			Value:   req.SortBy,
+			Message: fmt.Sprintf("参数 sort_by 不支持按字段 '%s' 排序", req.SortBy),
+		})
+	}
+	for _, f := range req.HighlightFields {
+		if !models.HighlightableFields[f] {
+			details = append(details, models.FieldValidationError{
+				Field:   "highlight_fields",
+				Rule:    "highlightable",
+				Value:   f,
+				Message: fmt.Sprintf("参数 highlight_fields 不支持字段 '%s'，可选值: title, content, author_username", f),
+			})
+		}
+	}
+	for _, f := range req.SourceFields {
+		if !models.SourceFields[f] {
+			details = append(details, models.FieldValidationError{
+				Field:   "source_fields",
+				Rule:    "source_field",
+				Value:   f,
+				Message: fmt.Sprintf("参数 source_fields 不支持字段 '%s'", f),
+			})
+		}
+	}
+	details = append(details, validateFilterGroups(req.FilterGroups)...)
+	if req.CreatedFrom != nil && req.CreatedTo != nil && *req.CreatedFrom > *req.CreatedTo {
+		details = append(details, models.FieldValidationError{
+			Field:   "created_from",
+			Rule:    "ltefield",
+			Param:   "created_to",
+			Value:   fmt.Sprintf("%d", *req.CreatedFrom),
+			Message: "参数 created_from 不能晚于 created_to",
+		})
+	}
+	if req.UpdatedFrom != nil && req.UpdatedTo != nil && *req.UpdatedFrom > *req.UpdatedTo {
+		details = append(details, models.FieldValidationError{
+			Field:   "updated_from",
+			Rule:    "ltefield",
+			Param:   "updated_to",
+			Value:   fmt.Sprintf("%d", *req.UpdatedFrom),
+			Message: "参数 updated_from 不能晚于 updated_to",
+		})
+	}
+	return details
+}
+
+// validateFilterGroups 校验过滤条件组的数量、字段白名单、比较方式以及值的类型。
+// 错误的 Field 形如 "filter_groups[0].should[1].value"，便于客户端定位具体条件。
+func validateFilterGroups(groups []models.FilterGroup) []models.FieldValidationError {
+	var details []models.FieldValidationError
+	if len(groups) > models.MaxFilterGroups {
+		return append(details, models.FieldValidationError{
+			Field:   "filter_groups",
+			Rule:    "max",
+			Param:   fmt.Sprintf("%d", models.MaxFilterGroups),
+			Value:   fmt.Sprintf("%d", len(groups)),
+			Message: fmt.Sprintf("参数 filter_groups 最多包含 %d 组条件", models.MaxFilterGroups),
+		})
+	}
+	for i, group := range groups {
+		groupPath := fmt.Sprintf("filter_groups[%d]", i)
+		if len(group.Should) == 0 || len(group.Should) > models.MaxFilterGroupConditions {
+			details = append(details, models.FieldValidationError{
+				Field:   groupPath + ".should",
+				Rule:    "range",
+				Value:   fmt.Sprintf("%d", len(group.Should)),
+				Message: fmt.Sprintf("每组过滤条件需包含 1 到 %d 个条件", models.MaxFilterGroupConditions),
+			})
+			continue
+		}
+		if group.MinimumShouldMatch < 0 || group.MinimumShouldMatch > len(group.Should) {
+			details = append(details, models.FieldValidationError{
+				Field:   groupPath + ".minimum_should_match",
+				Rule:    "range",
+				Value:   fmt.Sprintf("%d", group.MinimumShouldMatch),
+				Message: fmt.Sprintf("minimum_should_match 必须在 0 到 %d (组内条件数) 之间", len(group.Should)),
+			})
+		}
+		for j, cond := range group.Should {
+			if msg := filterConditionError(cond); msg != "" {
+				details = append(details, models.FieldValidationError{
+					Field:   fmt.Sprintf("%s.should[%d]", groupPath, j),
+					Rule:    "filter_condition",
+					Value:   cond.Field,
+					Message: msg,
+				})
+			}
+		}
+	}
+	return details
+}
+
+// filterConditionError 返回单个过滤条件的错误描述，条件有效时返回空字符串。
+func filterConditionError(cond models.FilterCondition) string {
+	kind, ok := models.FilterableFields[cond.Field]
+	if !ok {
+		return fmt.Sprintf("不支持按字段 '%s' 过滤", cond.Field)
+	}
+	if !slices.Contains(models.FilterOpsForKind(kind), cond.Op) {
+		if models.IsRangeFilterOp(cond.Op) {
+			return fmt.Sprintf("字段 %s 不支持范围比较 (%s)", cond.Field, cond.Op)
+		}
+		return fmt.Sprintf("不支持的 op '%s'，可选值: %s", cond.Op, strings.Join(models.FilterOpsForKind(kind), ", "))
+	}
+	switch {
+	case cond.Op == models.FilterOpEq:
+		if !filterValueMatches(kind, cond.Value) {
+			return fmt.Sprintf("字段 %s 的 value 类型无效", cond.Field)
+		}
+	case cond.Op == models.FilterOpIn:
+		values, ok := cond.Value.([]interface{})
+		if !ok || len(values) == 0 || len(values) > models.MaxFilterConditionInValues {
+			return fmt.Sprintf("op 为 in 时 value 必须是包含 1 到 %d 个元素的数组", models.MaxFilterConditionInValues)
+		}
+		for _, v := range values {
+			if !filterValueMatches(kind, v) {
+				return fmt.Sprintf("字段 %s 的 value 数组中包含类型无效的元素", cond.Field)
+			}
+		}
+	case models.IsRangeFilterOp(cond.Op):
+		if !filterValueMatches(kind, cond.Value) {
+			return fmt.Sprintf("字段 %s 的 value 类型无效", cond.Field)
+		}
+	}
+	return ""
+}
+
+// filterValueMatches 判断 JSON 解码后的值是否符合字段类型：keyword 为非空字符串，数值和日期 (Unix 毫秒) 为数字。
+func filterValueMatches(kind string, v interface{}) bool {
+	switch kind {
+	case "keyword":
+		s, ok := v.(string)
+		return ok && s != ""
+	default:
+		_, ok := v.(float64)
+		return ok
+	}
+}
@@ -0,0 +1,157 @@
+package api
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"github.com/gin-gonic/gin/binding"
+)
+
+// bindTestSearchRequest 按 parseSearchRequest 的方式绑定并校验查询字符串 (只包含 binding 标签的校验)。
+func bindTestSearchRequest(t *testing.T, rawQuery string) (models.SearchRequest, error) {
+	t.Helper()
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		t.Fatalf("解析查询字符串 %q 失败: %v", rawQuery, err)
+	}
+	var req models.SearchRequest
+	err = binding.MapFormWithTag(&req, values, "form")
+	if err == nil {
+		err = binding.Validator.ValidateStruct(&req)
+	}
+	return req, err
+}
+
+func floatPtr(v float64) *float64 { return &v }
+
+func TestValidateSearchRequestAuthorID(t *testing.T) {
+	tests := []struct {
+		name     string
+		authorID string
+		wantErr  bool
+	}{
+		{name: "未传递", authorID: ""},
+		{name: "只有空白视为未传递", authorID: "   "},
+		{name: "字母数字", authorID: "Author1"},
+		{name: "UUID", authorID: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+		{name: "两端带空白", authorID: " Author1\t"},
+		{name: "两端带空白的 UUID", authorID: " 3f2504e0-4f89-11d3-9a0c-0305e82c3301 "},
+		{name: "中间有空白", authorID: "author 1", wantErr: true},
+		{name: "非法字符", authorID: "author_1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			details := validateSearchRequest(&models.SearchRequest{AuthorID: tt.authorID})
+			var authorErr bool
+			for _, d := range details {
+				if d.Field == "author_id" {
+					authorErr = true
+				}
+			}
+			if authorErr != tt.wantErr {
+				t.Errorf("validateSearchRequest(author_id=%q) = %+v, wantErr %v", tt.authorID, details, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSearchRequestMinScoreBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *float64
+		wantErr bool
+	}{
+		{name: "未传递", query: "q=kafka"},
+		{name: "正数", query: "q=kafka&min_score=1.5", want: floatPtr(1.5)},
+		{name: "零", query: "q=kafka&min_score=0", want: floatPtr(0)},
+		{name: "负数", query: "q=kafka&min_score=-1", wantErr: true},
+		{name: "不是数字", query: "q=kafka&min_score=high", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := bindTestSearchRequest(t, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("绑定 %q 的错误 = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if (req.MinScore == nil) != (tt.want == nil) || (req.MinScore != nil && *req.MinScore != *tt.want) {
+				t.Errorf("MinScore = %v, want %v", req.MinScore, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchRequestMinScoreAppliedOnlyWithKeyword(t *testing.T) {
+	// 从查询字符串绑定到构建的 DSL：没有参与评分的关键词时 (只有过滤条件)，所有文档得分相同，min_score 会误伤全部结果，应被忽略。
+	builder := repositories.NewSearchQueryBuilder(config.SearchConfig{}, config.IndexSpecificConfig{}, newTestLogger(t))
+	tests := []struct {
+		name      string
+		query     string
+		wantScore interface{} // nil 表示请求体中不应包含 min_score
+	}{
+		{name: "关键词查询", query: "q=kafka&min_score=1.5", wantScore: 1.5},
+		{name: "关键词加过滤条件", query: "q=kafka&author_id=author1&status=1&min_score=2", wantScore: 2.0},
+		{name: "只有作者过滤", query: "author_id=author1&min_score=1.5"},
+		{name: "只有状态与标签过滤", query: "status=1&tags=go&min_score=1.5"},
+		{name: "只有排除词", query: "q=-kafka&author_id=author1&min_score=1.5"},
+		{name: "空白关键词", query: "q=%20%20&min_score=1.5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := bindTestSearchRequest(t, tt.query)
+			if err != nil {
+				t.Fatalf("绑定 %q 失败: %v", tt.query, err)
+			}
+			body, err := builder.SearchBody(req)
+			if err != nil {
+				t.Fatalf("SearchBody 返回错误: %v", err)
+			}
+			var dsl map[string]interface{}
+			if err := json.Unmarshal(body, &dsl); err != nil {
+				t.Fatalf("解析 DSL 失败: %v", err)
+			}
+			got, ok := dsl["min_score"]
+			if tt.wantScore == nil {
+				if ok {
+					t.Errorf("min_score = %v, want 不包含 (DSL: %s)", got, body)
+				}
+				return
+			}
+			if got != tt.wantScore {
+				t.Errorf("min_score = %v, want %v", got, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestSearchRequestHighlightEncoderBinding(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    string
+		wantErr bool
+	}{
+		{name: "未传递时由服务端配置决定", query: "q=kafka", want: ""},
+		{name: "html", query: "q=kafka&highlight_encoder=html", want: models.HighlightEncoderHTML},
+		{name: "default", query: "q=kafka&highlight_encoder=default", want: models.HighlightEncoderDefault},
+		{name: "无效取值", query: "q=kafka&highlight_encoder=base64", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := bindTestSearchRequest(t, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("绑定 %q 的错误 = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+			if !tt.wantErr && req.HighlightEncoder != tt.want {
+				t.Errorf("HighlightEncoder = %q, want %q", req.HighlightEncoder, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// analyzerCheckText 是调用 _analyze 时使用的样例文本，内容本身不重要，只用于确认分析器可用。
+const analyzerCheckText = "帖子搜索服务"
+
+// verifyAnalyzers 在创建索引之前通过 _analyze API 确认所需的分析器在集群中可用。
+// 为什么需要这个检查?
+// 帖子索引映射默认依赖 IK 插件提供的 ik_smart 分析器。插件未安装时，索引创建会失败，
+// 错误信息埋在 createIndexIfNotExists 记录的响应体中，很难一眼看出原因。
+// 提前检查可以快速失败，并给出明确的提示。
+func verifyAnalyzers(ctx context.Context, esClient *elasticsearch.Client, analyzers []string, logger *core.ZapLogger) error {
+	for _, analyzer := range analyzers {
+		if err := checkAnalyzer(ctx, esClient, analyzer); err != nil {
+			logger.Error("Elasticsearch 分析器检查失败", zap.String("analyzer", analyzer), zap.Error(err))
+			return err
+		}
+		logger.Info("Elasticsearch 分析器可用", zap.String("analyzer", analyzer))
+	}
+	return nil
+}
+
+// checkAnalyzer 使用全局 _analyze API (不指定索引) 分析一段样例文本；分析器不存在时 ES 返回 400。
+func checkAnalyzer(ctx context.Context, esClient *elasticsearch.Client, analyzer string) error {
+	body, err := json.Marshal(map[string]string{
+		"analyzer": analyzer,
+		"text":     analyzerCheckText,
+	})
+	if err != nil {
+		return fmt.Errorf("序列化 _analyze 请求体失败: %w", err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	res, err := esapi.IndicesAnalyzeRequest{Body: bytes.NewReader(body)}.Do(checkCtx, esClient)
+	if err != nil {
+		return fmt.Errorf("检查分析器 %s 时请求 _analyze 失败: %w", analyzer, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == 400 {
+		respBody, _ := io.ReadAll(res.Body)
+		if strings.HasPrefix(analyzer, "ik_") {
+			return fmt.Errorf("analyzer %s not available — is the IK plugin installed? (分析器 %s 不可用，请确认集群已安装 IK 插件；响应: %s)",
+				analyzer, analyzer, string(respBody))
+		}
+		return fmt.Errorf("analyzer %s not available (分析器 %s 不可用；响应: %s)", analyzer, analyzer, string(respBody))
+	}
+	if res.IsError() {
+		respBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("检查分析器 %s 时 _analyze 返回错误，状态码: %s，响应: %s", analyzer, res.Status(), string(respBody))
+	}
+	return nil
+}
@@ -3,14 +3,17 @@ package es
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"net"
 	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Xushengqwer/go-common/core"
 	"github.com/Xushengqwer/post_search/config" // 确保导入了更新后的 config 包
+	"github.com/cenkalti/backoff/v4"
 
 	"github.com/elastic/go-elasticsearch/v8"
 	"github.com/elastic/go-elasticsearch/v8/esapi"
@@ -19,26 +22,78 @@ import (
 
 // ESClient 包含初始化后的 Elasticsearch 客户端及相关信息
 type ESClient struct {
-	Client          *elasticsearch.Client
-	PrimaryIndexCfg config.IndexSpecificConfig // 存储主索引的配置，方便其他地方引用（如果需要）
-	// HotTermsIndexCfg config.IndexSpecificConfig // 热门搜索词索引的配置也可以在这里存储，或者直接在 main.go 中传递给其仓库
+	Client           *elasticsearch.Client
+	PrimaryIndexCfg  config.IndexSpecificConfig // 存储主索引的配置，方便其他地方引用（如果需要）
+	HotTermsIndexCfg config.IndexSpecificConfig // 热门搜索词索引的配置，供重置热门搜索词索引 (ResetHotTermsIndex) 使用
+
+	logger *core.ZapLogger // 供 ResetHotTermsIndex 等运维操作记录日志。
+
+	transport http.RoundTripper // 创建客户端时使用的 HTTP Transport，Close 时释放其空闲连接。
+}
+
+// Close 释放 ES 客户端持有的空闲 HTTP 连接。
+// go-elasticsearch 客户端本身没有 Close 方法，连接由底层 Transport 管理；
+// 调用 Close 后不应再通过该客户端发起请求 (新请求会重新建立连接，但关闭流程中不应出现)。
+func (c *ESClient) Close() {
+	if closer, ok := c.transport.(interface{ CloseIdleConnections() }); ok {
+		closer.CloseIdleConnections()
+	}
+}
+
+// defaultTextAnalyzer 是未配置 IndexSpecificConfig.TextAnalyzer 时 title/content 使用的分析器 (IK 插件提供)。
+const defaultTextAnalyzer = "ik_smart"
+
+// textAnalyzerOf 返回索引配置中 title/content 实际使用的分析器名称。
+func textAnalyzerOf(indexCfg config.IndexSpecificConfig) string {
+	if indexCfg.TextAnalyzer == "" {
+		return defaultTextAnalyzer
+	}
+	return indexCfg.TextAnalyzer
+}
+
+// textFieldMapping 返回 title/content 字段的映射：使用配置的索引分析器，配置了不同的 SearchAnalyzer 时附加 search_analyzer，
+// 开启 EnglishSubfields 时附加 english 分析器的 .en 子字段。
+func textFieldMapping(indexCfg config.IndexSpecificConfig) string {
+	analyzer := textAnalyzerOf(indexCfg)
+	analyzers := fmt.Sprintf(`"analyzer": %q`, analyzer)
+	if indexCfg.SearchAnalyzer != "" && indexCfg.SearchAnalyzer != analyzer {
+		analyzers += fmt.Sprintf(`, "search_analyzer": %q`, indexCfg.SearchAnalyzer)
+	}
+	if !indexCfg.EnglishSubfields {
+		return fmt.Sprintf(`{ "type": "text", %s }`, analyzers)
+	}
+	return fmt.Sprintf(`{ "type": "text", %s, "fields": { "en": { "type": "text", "analyzer": "english" } } }`, analyzers)
+}
+
+// indexSettings 返回索引创建时的 settings 部分：分片数、副本数，以及配置了的 refresh_interval 与 translog 持久化方式。
+func indexSettings(indexCfg config.IndexSpecificConfig) string {
+	settings := map[string]interface{}{
+		"number_of_shards":   indexCfg.NumberOfShards,
+		"number_of_replicas": indexCfg.NumberOfReplicas,
+	}
+	if indexCfg.RefreshInterval != "" {
+		settings["refresh_interval"] = indexCfg.RefreshInterval
+	}
+	if indexCfg.TranslogDurability != "" {
+		settings["translog"] = map[string]interface{}{"durability": indexCfg.TranslogDurability}
+	}
+	// map 中只有基础类型，序列化不会失败。
+	out, _ := json.Marshal(settings)
+	return string(out)
 }
 
 // getPostsIndexMapping 定义了主帖子索引的映射和设置。
 // 参数:
-//   - shards: 主分片数量。
-//   - replicas: 每个主分片的副本数量。
-func getPostsIndexMapping(shards int, replicas int) string {
+//   - indexCfg: 索引配置，使用其中的索引设置 (分片数、副本数、刷新间隔、translog)、title/content 的索引/搜索分析器 (TextAnalyzer/SearchAnalyzer) 以及是否附加英文子字段。
+func getPostsIndexMapping(indexCfg config.IndexSpecificConfig) string {
+	textField := textFieldMapping(indexCfg)
 	return fmt.Sprintf(`{
-       "settings": {
-          "number_of_shards": %d,
-          "number_of_replicas": %d
-       },
+       "settings": %s,
        "mappings": {
           "properties": {
              "id": { "type": "unsigned_long" },
-             "title": { "type": "text", "analyzer": "ik_smart" },
-             "content": { "type": "text", "analyzer": "ik_smart" },
+             "title": %s,
+             "content": %s,
              "author_id": { "type": "keyword" },
              "author_avatar": { "type": "keyword", "index": false },
              "author_username": {
@@ -52,23 +107,25 @@ func getPostsIndexMapping(shards int, replicas int) string {
              "view_count": { "type": "long" },
              "official_tag": { "type": "integer" },
              "price_per_unit": { "type": "double" },
-             "contact_qr_code": { "type": "keyword", "index": false },
+             "contact_qr_code": { "type": "keyword" },
+             "contact_info": { "type": "keyword", "ignore_above": 256 },
+             "tags": { "type": "keyword" },
+             "created_at": { "type": "date", "format": "epoch_millis||strict_date_optional_time" },
+             "expires_at": { "type": "date", "format": "epoch_millis||strict_date_optional_time" },
+             "title_raw": { "type": "keyword", "index": false, "doc_values": false },
+             "content_raw": { "type": "keyword", "index": false, "doc_values": false },
              "updated_at": { "type": "date" }
           }
        }
-    }`, shards, replicas)
+    }`, indexSettings(indexCfg), textField, textField)
 }
 
 // getHotSearchTermsIndexMapping 定义了热门搜索词索引的映射和设置。
 // 参数:
-//   - shards: 主分片数量。
-//   - replicas: 每个主分片的副本数量。
-func getHotSearchTermsIndexMapping(shards int, replicas int) string {
+//   - indexCfg: 索引配置，使用其中的索引设置 (分片数、副本数、刷新间隔、translog；term 字段为 keyword，不涉及分析器)。
+func getHotSearchTermsIndexMapping(indexCfg config.IndexSpecificConfig) string {
 	return fmt.Sprintf(`{
-        "settings": {
-            "number_of_shards": %d,
-            "number_of_replicas": %d
-        },
+        "settings": %s,
         "mappings": {
             "properties": {
                 "term": { "type": "keyword" },
@@ -76,7 +133,7 @@ func getHotSearchTermsIndexMapping(shards int, replicas int) string {
                 "last_searched_at": { "type": "date" }
             }
         }
-    }`, shards, replicas)
+    }`, indexSettings(indexCfg))
 }
 
 // createIndexIfNotExists 是一个辅助函数，用于检查索引是否存在，如果不存在则创建它。
@@ -84,7 +141,7 @@ func createIndexIfNotExists(
 	ctx context.Context,
 	esClient *elasticsearch.Client,
 	indexCfg config.IndexSpecificConfig,
-	mappingFunc func(shards, replicas int) string,
+	mappingFunc func(indexCfg config.IndexSpecificConfig) string,
 	logger *core.ZapLogger,
 	indexLogicalName string, // 用于日志记录的逻辑名称，例如 "主帖子" 或 "热门搜索词"
 ) error {
@@ -129,7 +186,7 @@ func createIndexIfNotExists(
 			zap.Int("replicas", indexCfg.NumberOfReplicas),
 		)
 
-		mapping := mappingFunc(indexCfg.NumberOfShards, indexCfg.NumberOfReplicas)
+		mapping := mappingFunc(indexCfg)
 		createCtx, createCancel := context.WithTimeout(ctx, 10*time.Second)
 		defer createCancel()
 
@@ -193,14 +250,146 @@ func createIndexIfNotExists(
 	return nil
 }
 
+// 客户端级别重试的默认值。
+const (
+	defaultMaxRetries       = 3
+	retryBackoffBase        = 100 * time.Millisecond // 第一次重试前的等待时间
+	retryBackoffMaxInterval = 2 * time.Second        // 单次重试等待的上限
+
+	defaultStartupPingAttempts = 5                      // 启动 Ping 的默认最大尝试次数
+	defaultStartupPingMaxWait  = 10 * time.Second       // 启动 Ping 两次尝试之间等待时间的默认上限
+	startupPingInitialWait     = 500 * time.Millisecond // 启动 Ping 第一次重试前的等待时间
+	startupPingTimeout         = 5 * time.Second        // 单次 Ping 的超时
+)
+
+// defaultRetryOnStatus 是未配置 RetryOnStatus 时触发重试的状态码 (网关错误/服务暂不可用/网关超时)。
+var defaultRetryOnStatus = []int{502, 503, 504}
+
+// retryBackoff 为客户端重试提供指数退避：100ms, 200ms, 400ms ... 最大 2s。
+// attempt 从 1 开始计数。
+func retryBackoff(attempt int) time.Duration {
+	d := retryBackoffBase << uint(attempt-1)
+	if d <= 0 || d > retryBackoffMaxInterval {
+		return retryBackoffMaxInterval
+	}
+	return d
+}
+
+// retryOnErrorFunc 返回传给客户端的 RetryOnError：retryOnTimeout 为 true 时返回 nil (所有网络错误都重试)，
+// 否则超时错误 (net.Error.Timeout 或 context.DeadlineExceeded) 不再重试，其他网络错误照常重试。
+func retryOnErrorFunc(retryOnTimeout bool) func(*http.Request, error) bool {
+	if retryOnTimeout {
+		return nil
+	}
+	return func(_ *http.Request, err error) bool {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return false
+		}
+		return !errors.Is(err, context.DeadlineExceeded)
+	}
+}
+
+// pingWithRetry 在启动时 Ping Elasticsearch，失败时以指数退避加随机抖动重试，直到成功或用尽尝试次数。
+// 为什么需要重试?
+// ES 与本服务同时启动时，ES 往往需要数秒才能接受请求；只 Ping 一次会让服务直接退出并陷入重启循环。
+// 每次尝试仍使用带 5 秒超时的上下文，客户端内部对连接错误的重试也受此超时约束；
+// 用尽尝试次数后返回最后一次的错误，ES 确实不可达时启动仍会失败。
+func pingWithRetry(esClient *elasticsearch.Client, cfg config.ESConfig, logger *core.ZapLogger) error {
+	attempts := cfg.StartupPingAttempts
+	if attempts <= 0 {
+		attempts = defaultStartupPingAttempts
+	}
+	maxWait := cfg.StartupPingMaxWait
+	if maxWait <= 0 {
+		maxWait = defaultStartupPingMaxWait
+	}
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = startupPingInitialWait
+	bo.MaxInterval = maxWait
+	bo.RandomizationFactor = 0.5 // 随机抖动，避免多个实例同时重试
+	bo.MaxElapsedTime = 0        // 重试次数由 WithMaxRetries 控制
+
+	attempt := 0
+	operation := func() error {
+		attempt++
+		ctxPing, cancelPing := context.WithTimeout(context.Background(), startupPingTimeout)
+		defer cancelPing()
+
+		pingRes, err := esClient.Ping(esClient.Ping.WithContext(ctxPing))
+		if err != nil {
+			return fmt.Errorf("ping Elasticsearch 失败: %w", err)
+		}
+		defer pingRes.Body.Close()
+		if pingRes.IsError() {
+			var errorBody strings.Builder
+			if _, readErr := io.Copy(&errorBody, pingRes.Body); readErr != nil {
+				return fmt.Errorf("elasticsearch Ping 不成功: %s (读取响应体失败: %v)", pingRes.Status(), readErr)
+			}
+			return fmt.Errorf("elasticsearch Ping 不成功: %s, 响应: %s", pingRes.Status(), errorBody.String())
+		}
+		logger.Info("Elasticsearch 客户端连接成功 (Ping 成功)",
+			zap.String("status", pingRes.Status()),
+			zap.Int("attempt", attempt),
+		)
+		return nil
+	}
+
+	notify := func(err error, next time.Duration) {
+		logger.Warn("Ping Elasticsearch 失败，准备重试",
+			zap.Int("attempt", attempt),
+			zap.Int("max_attempts", attempts),
+			zap.Duration("next_retry_in", next),
+			zap.Error(err),
+		)
+	}
+
+	if err := backoff.RetryNotify(operation, backoff.WithMaxRetries(bo, uint64(attempts-1)), notify); err != nil {
+		logger.Error("Ping Elasticsearch 失败，已用尽重试次数", zap.Int("attempts", attempt), zap.Error(err))
+		return err
+	}
+	return nil
+}
+
 // NewESClient 初始化 Elasticsearch 客户端并执行基本检查（Ping 和索引存在性检查）。
 // 如果配置的索引不存在，它会尝试创建它们。
 func NewESClient(cfg config.ESConfig, logger *core.ZapLogger, transport http.RoundTripper) (*ESClient, error) {
+	maxRetries := cfg.MaxRetries
+	if maxRetries <= 0 {
+		maxRetries = defaultMaxRetries
+	}
+	retryOnStatus := cfg.RetryOnStatus
+	if len(retryOnStatus) == 0 {
+		retryOnStatus = defaultRetryOnStatus
+	}
+	retryOnTimeout := true
+	if cfg.EnableRetryOnTimeout != nil {
+		retryOnTimeout = *cfg.EnableRetryOnTimeout
+	}
+
 	esClientCfg := elasticsearch.Config{ // 变量名修改以避免与参数 cfg 冲突
 		Addresses: cfg.Addresses,
 		Username:  cfg.Username,
 		Password:  cfg.Password,
 		Transport: transport,
+
+		// 为什么配置客户端级别的重试?
+		// ES 在滚动重启或节点繁忙时会短暂返回 502/503，这类错误通常稍后即可恢复，
+		// 在客户端内部重试可以避免把瞬时故障直接暴露给搜索 API 或 Kafka 消费流程。
+		// 当前版本的 elasticsearch.Config 没有 EnableRetryOnTimeout 选项 (传输层会重试所有网络错误)，
+		// 通过 RetryOnError 实现：关闭超时重试时，超时错误不再重试。
+		DisableRetry:  cfg.DisableRetry,
+		MaxRetries:    maxRetries,
+		RetryOnStatus: retryOnStatus,
+		RetryOnError:  retryOnErrorFunc(retryOnTimeout),
+		RetryBackoff:  retryBackoff,
+
+		// 节点发现：启动时的发现在 Ping 成功后由我们显式执行，以便记录失败原因；
+		// 周期性发现交给客户端内部调度。
+		DiscoverNodesInterval: cfg.DiscoverNodesInterval,
+		// 包装默认连接池，记录节点列表变化以及节点被标记为不可用/恢复的事件。
+		ConnectionPoolFunc: newLoggingConnectionPoolFunc(logger),
 	}
 
 	esClient, err := elasticsearch.NewClient(esClientCfg)
@@ -208,31 +397,59 @@ func NewESClient(cfg config.ESConfig, logger *core.ZapLogger, transport http.Rou
 		logger.Error("创建 Elasticsearch 客户端失败", zap.Error(err))
 		return nil, fmt.Errorf("创建 Elasticsearch 客户端失败: %w", err)
 	}
-	logger.Info("Elasticsearch 客户端配置完成", zap.Strings("addresses", cfg.Addresses))
+	logger.Info("Elasticsearch 客户端配置完成",
+		zap.Strings("addresses", cfg.Addresses),
+		zap.Bool("retry_disabled", cfg.DisableRetry),
+		zap.Int("max_retries", maxRetries),
+		zap.Ints("retry_on_status", retryOnStatus),
+		zap.Bool("discover_nodes_on_start", cfg.DiscoverNodesOnStart),
+		zap.Duration("discover_nodes_interval", cfg.DiscoverNodesInterval),
+	)
 
-	// --- Ping 检查 ---
-	ctxPing, cancelPing := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelPing()
-	pingRes, err := esClient.Ping(esClient.Ping.WithContext(ctxPing))
-	if err != nil {
-		logger.Error("Ping Elasticsearch 失败", zap.Error(err))
-		return nil, fmt.Errorf("ping Elasticsearch 失败: %w", err)
+	// --- Ping 检查 (带重试) ---
+	if err := pingWithRetry(esClient, cfg, logger); err != nil {
+		return nil, err
 	}
-	defer pingRes.Body.Close()
-	if pingRes.IsError() {
-		var errorBody strings.Builder
-		if _, readErr := io.Copy(&errorBody, pingRes.Body); readErr != nil {
-			logger.Error("Elasticsearch Ping 不成功，且无法读取错误响应体", zap.String("status", pingRes.Status()), zap.Error(readErr))
-		} else {
-			logger.Error("Elasticsearch Ping 不成功", zap.String("status", pingRes.Status()), zap.String("response", errorBody.String()))
+
+	// --- 启动时节点发现 ---
+	// 发现失败不是致命错误：客户端会继续使用 Addresses 中配置的静态地址。
+	if cfg.DiscoverNodesOnStart {
+		if err := esClient.DiscoverNodes(); err != nil {
+			logger.Warn("Elasticsearch 节点发现失败，将继续使用配置的静态地址",
+				zap.Strings("addresses", cfg.Addresses), zap.Error(err))
 		}
-		return nil, fmt.Errorf("elasticsearch Ping 不成功: %s", pingRes.Status())
 	}
-	logger.Info("Elasticsearch 客户端连接成功 (Ping 成功)", zap.String("status", pingRes.Status()))
 
 	// 使用后台上下文进行索引创建，因为这通常是启动过程的一部分
 	backgroundCtx := context.Background()
 
+	// --- 检查映射依赖的分析器 ---
+	// 必须在注册模板和创建索引之前执行，否则分析器缺失时只会得到难以理解的映射解析错误。
+	// 未显式配置 RequiredAnalyzers 时，检查主帖子索引映射实际使用的分析器 (索引分析器与搜索分析器)。
+	requiredAnalyzers := cfg.RequiredAnalyzers
+	if len(requiredAnalyzers) == 0 {
+		requiredAnalyzers = []string{textAnalyzerOf(cfg.PrimaryIndex)}
+		if sa := cfg.PrimaryIndex.SearchAnalyzer; sa != "" && sa != requiredAnalyzers[0] {
+			requiredAnalyzers = append(requiredAnalyzers, sa)
+		}
+	}
+	if err := verifyAnalyzers(backgroundCtx, esClient, requiredAnalyzers, logger); err != nil {
+		return nil, err
+	}
+
+	// --- 按数据节点数量调整副本数 (可选) ---
+	// 必须在注册模板和创建索引之前执行，使新建的索引 (包括之后按主索引配置创建的租户索引) 直接使用调整后的副本数。
+	cfg = autoScaleReplicas(backgroundCtx, esClient, cfg, logger)
+
+	// --- 注册索引模板 (可选) ---
+	// 模板需要先于索引创建注册，这样由本服务或外部 (例如滚动策略) 新建的索引都能匹配到最新的映射。
+	if err := ensureIndexTemplate(backgroundCtx, esClient, cfg.PrimaryIndex, getPostsIndexMapping, logger, "主帖子"); err != nil {
+		return nil, err
+	}
+	if err := ensureIndexTemplate(backgroundCtx, esClient, cfg.HotTermsIndex, getHotSearchTermsIndexMapping, logger, "热门搜索词"); err != nil {
+		return nil, err
+	}
+
 	// --- 检查并创建主帖子索引 ---
 	err = createIndexIfNotExists(backgroundCtx, esClient, cfg.PrimaryIndex, getPostsIndexMapping, logger, "主帖子")
 	if err != nil {
@@ -248,8 +465,24 @@ func NewESClient(cfg config.ESConfig, logger *core.ZapLogger, transport http.Rou
 		return nil, err
 	}
 
+	// --- 将调整后的副本数应用到已存在的索引 ---
+	applyReplicaCount(backgroundCtx, esClient, cfg.PrimaryIndex, logger, "主帖子")
+	applyReplicaCount(backgroundCtx, esClient, cfg.HotTermsIndex, logger, "热门搜索词")
+
+	// --- 将搜索分析器应用到已存在的帖子索引 (无需重建索引) ---
+	applySearchAnalyzer(backgroundCtx, esClient, cfg.PrimaryIndex, logger)
+
+	// --- 检查已存在索引的映射漂移 ---
+	if err := checkMappingDrift(backgroundCtx, esClient, cfg, logger); err != nil {
+		logger.Error("索引映射漂移检查未通过", zap.Error(err))
+		return nil, err
+	}
+
 	return &ESClient{
-		Client:          esClient,
-		PrimaryIndexCfg: cfg.PrimaryIndex, // 存储主索引配置
+		Client:           esClient,
+		PrimaryIndexCfg:  cfg.PrimaryIndex, // 存储主索引配置
+		HotTermsIndexCfg: cfg.HotTermsIndex,
+		logger:           logger,
+		transport:        transport,
 	}, nil
 }
@@ -0,0 +1,102 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+)
+
+// postsMappingProperty 解析主帖子索引映射，返回 mappings.properties 中 field 的定义。
+func postsMappingProperty(t *testing.T, indexCfg config.IndexSpecificConfig, field string) map[string]interface{} {
+	t.Helper()
+	var mapping struct {
+		Mappings struct {
+			Properties map[string]map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(getPostsIndexMapping(indexCfg)), &mapping); err != nil {
+		t.Fatalf("主帖子索引映射不是合法的 JSON: %v", err)
+	}
+	property, ok := mapping.Mappings.Properties[field]
+	if !ok {
+		t.Fatalf("映射中缺少字段 %s", field)
+	}
+	return property
+}
+
+func TestGetPostsIndexMappingEnglishSubfields(t *testing.T) {
+	tests := []struct {
+		name     string
+		indexCfg config.IndexSpecificConfig
+		want     map[string]interface{}
+	}{
+		{
+			name:     "未开启英文子字段",
+			indexCfg: config.IndexSpecificConfig{},
+			want:     map[string]interface{}{"type": "text", "analyzer": "ik_smart"},
+		},
+		{
+			name:     "开启英文子字段",
+			indexCfg: config.IndexSpecificConfig{EnglishSubfields: true},
+			want: map[string]interface{}{
+				"type":     "text",
+				"analyzer": "ik_smart",
+				"fields":   map[string]interface{}{"en": map[string]interface{}{"type": "text", "analyzer": "english"}},
+			},
+		},
+		{
+			name:     "自定义分析器与英文子字段",
+			indexCfg: config.IndexSpecificConfig{TextAnalyzer: "ik_max_word", EnglishSubfields: true},
+			want: map[string]interface{}{
+				"type":     "text",
+				"analyzer": "ik_max_word",
+				"fields":   map[string]interface{}{"en": map[string]interface{}{"type": "text", "analyzer": "english"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, field := range []string{"title", "content"} {
+				if got := postsMappingProperty(t, tt.indexCfg, field); !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("%s 映射 = %v, want %v", field, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+// timeoutError 是 Timeout() 为 true 的 net.Error。
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestRetryOnErrorFunc(t *testing.T) {
+	if retryOnErrorFunc(true) != nil {
+		t.Errorf("开启超时重试时应返回 nil (所有网络错误都重试)")
+	}
+
+	retry := retryOnErrorFunc(false)
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "网络超时", err: fmt.Errorf("dial: %w", timeoutError{}), want: false},
+		{name: "上下文超时", err: fmt.Errorf("request: %w", context.DeadlineExceeded), want: false},
+		{name: "连接被拒绝", err: errors.New("connect: connection refused"), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retry(nil, tt.err); got != tt.want {
+				t.Errorf("retry(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// HotTermsIndexName 返回热门搜索词索引的名称，admin 重置接口要求调用方以此作为确认参数。
+func (c *ESClient) HotTermsIndexName() string {
+	return c.HotTermsIndexCfg.Name
+}
+
+// ResetHotTermsIndex 删除并按 getHotSearchTermsIndexMapping 重新创建热门搜索词索引，清空所有热门搜索词数据。
+// 用于热门搜索词数据被错误的任务破坏后的恢复，调用方负责确认操作 (见 admin 接口 POST /_hot-terms/reset)。
+//
+// 删除与重建之间如果恰好有搜索在记录热门搜索词，ES 可能以动态映射自动创建该索引，
+// 因此重建后再比较一次映射，不一致时返回错误，提示重新执行重置。
+func (c *ESClient) ResetHotTermsIndex(ctx context.Context) (*models.HotTermsIndexResetResult, error) {
+	indexCfg := c.HotTermsIndexCfg
+	previousDocCount, err := c.countDocs(ctx, indexCfg.Name)
+	if err != nil {
+		// 计数只用于记录被清除的数据量，失败不影响重置。
+		c.logger.Warn("获取热门搜索词索引文档数失败", zap.String("index_name", indexCfg.Name), zap.Error(err))
+	}
+
+	deleteCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	res, err := esapi.IndicesDeleteRequest{Index: []string{indexCfg.Name}}.Do(deleteCtx, c.Client)
+	if err != nil {
+		return nil, fmt.Errorf("请求删除热门搜索词索引 '%s' 失败: %w", indexCfg.Name, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() && res.StatusCode != http.StatusNotFound {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("删除热门搜索词索引 '%s' 失败，状态码: %s，响应: %s", indexCfg.Name, res.Status(), string(body))
+	}
+	c.logger.Warn("热门搜索词索引已删除，准备重新创建",
+		zap.String("index_name", indexCfg.Name),
+		zap.Int64("previous_doc_count", previousDocCount),
+	)
+
+	if err := createIndexIfNotExists(ctx, c.Client, indexCfg, getHotSearchTermsIndexMapping, c.logger, "热门搜索词"); err != nil {
+		return nil, fmt.Errorf("重新创建热门搜索词索引失败: %w", err)
+	}
+
+	drifts, err := CheckHotTermsIndexMapping(ctx, c.Client, indexCfg)
+	if err != nil {
+		return nil, fmt.Errorf("检查重建后的热门搜索词索引映射失败: %w", err)
+	}
+	if len(drifts) > 0 {
+		return nil, fmt.Errorf("重建后的热门搜索词索引映射与期望不一致 (可能在重建期间被自动创建)，请重新执行重置: %s", drifts[0])
+	}
+
+	c.logger.Warn("热门搜索词索引已重置",
+		zap.String("index_name", indexCfg.Name),
+		zap.Int64("previous_doc_count", previousDocCount),
+	)
+	return &models.HotTermsIndexResetResult{
+		Index:            indexCfg.Name,
+		PreviousDocCount: previousDocCount,
+		ResetAt:          time.Now().UTC(),
+	}, nil
+}
+
+// countDocs 返回索引中的文档数，索引不存在时返回 0。
+func (c *ESClient) countDocs(ctx context.Context, indexName string) (int64, error) {
+	res, err := esapi.CountRequest{Index: []string{indexName}}.Do(ctx, c.Client)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return 0, nil
+	}
+	if res.IsError() {
+		return 0, fmt.Errorf("_count 请求失败，状态码: %s", res.Status())
+	}
+	var body struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("解码 _count 响应失败: %w", err)
+	}
+	return body.Count, nil
+}
@@ -0,0 +1,114 @@
+package es
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// defaultIndexTemplatePriority 是未配置优先级时索引模板使用的 priority。
+// 高于 ES 内置模板 (通常为 100 以下)，避免被内置模板覆盖。
+const defaultIndexTemplatePriority = 200
+
+// ensureIndexTemplate 为指定索引注册 (创建或更新) ES 可组合索引模板 (composable index template)。
+// 为什么需要索引模板?
+// createIndexIfNotExists 只在索引不存在时使用内联映射创建索引，之后修改映射不会影响任何索引。
+// 注册模板后，任何匹配 index_patterns 的新索引 (例如滚动索引 posts_index-000002) 都会自动获得
+// 当前代码中定义的映射和设置，这是滚动索引策略的前提。
+// 模板使用 PUT 覆盖写入，因此每次启动都会把模板同步为代码中的最新映射；已存在的索引不受影响。
+func ensureIndexTemplate(
+	ctx context.Context,
+	esClient *elasticsearch.Client,
+	indexCfg config.IndexSpecificConfig,
+	mappingFunc func(indexCfg config.IndexSpecificConfig) string,
+	logger *core.ZapLogger,
+	indexLogicalName string,
+) error {
+	tplCfg := indexCfg.Template
+	if !tplCfg.Enabled {
+		return nil
+	}
+
+	name := tplCfg.Name
+	if name == "" {
+		name = indexCfg.Name + "-template"
+	}
+	patterns := tplCfg.Patterns
+	if len(patterns) == 0 {
+		patterns = []string{indexCfg.Name, indexCfg.Name + "-*"}
+	}
+	priority := tplCfg.Priority
+	if priority <= 0 {
+		priority = defaultIndexTemplatePriority
+	}
+
+	// 映射函数返回的是创建索引时的请求体 ({"settings":..., "mappings":...})，正好是模板的 template 部分。
+	var templateBody map[string]interface{}
+	if err := json.Unmarshal([]byte(mappingFunc(indexCfg)), &templateBody); err != nil {
+		return fmt.Errorf("解析%s索引映射以构建模板 '%s' 失败: %w", indexLogicalName, name, err)
+	}
+	payload, err := json.Marshal(map[string]interface{}{
+		"index_patterns": patterns,
+		"priority":       priority,
+		"template":       templateBody,
+		"_meta": map[string]interface{}{
+			"managed_by": "post_search",
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("序列化%s索引模板 '%s' 失败: %w", indexLogicalName, name, err)
+	}
+
+	reqCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	// 先检查模板是否已存在，仅用于日志区分 "创建" 与 "更新"。
+	existed := false
+	existsRes, err := esapi.IndicesExistsIndexTemplateRequest{Name: name}.Do(reqCtx, esClient)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("检查%s索引模板是否存在失败，将直接写入模板", indexLogicalName),
+			zap.String("template_name", name), zap.Error(err))
+	} else {
+		existed = existsRes.StatusCode == 200
+		existsRes.Body.Close()
+	}
+
+	putRes, err := esapi.IndicesPutIndexTemplateRequest{
+		Name: name,
+		Body: bytes.NewReader(payload),
+	}.Do(reqCtx, esClient)
+	if err != nil {
+		logger.Error(fmt.Sprintf("发送%s索引模板请求失败", indexLogicalName), zap.String("template_name", name), zap.Error(err))
+		return fmt.Errorf("写入%s索引模板 '%s' 失败: %w", indexLogicalName, name, err)
+	}
+	defer putRes.Body.Close()
+	if putRes.IsError() {
+		body, _ := io.ReadAll(putRes.Body)
+		logger.Error(fmt.Sprintf("写入%s索引模板失败", indexLogicalName),
+			zap.String("template_name", name),
+			zap.String("status", putRes.Status()),
+			zap.String("response", string(body)),
+		)
+		return fmt.Errorf("写入%s索引模板 '%s' 失败, 状态码: %s, 响应: %s", indexLogicalName, name, putRes.Status(), string(body))
+	}
+
+	action := "创建"
+	if existed {
+		action = "更新"
+	}
+	logger.Info(fmt.Sprintf("已%s%s索引模板", action, indexLogicalName),
+		zap.String("template_name", name),
+		zap.Strings("index_patterns", patterns),
+		zap.Int("priority", priority),
+	)
+	return nil
+}
@@ -0,0 +1,158 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// MappingDrift 描述线上索引映射与本服务期望映射之间的一处差异。
+type MappingDrift struct {
+	Index    string `json:"index"`    // 实际的索引名 (通过别名查询时为别名指向的具体索引)
+	Field    string `json:"field"`    // 字段路径，多字段 (fields) 以点号连接，例如 title.en
+	Property string `json:"property"` // 不一致的属性: type 或 analyzer
+	Expected string `json:"expected"` // 期望值，为空表示期望映射中没有该字段
+	Actual   string `json:"actual"`   // 实际值，为空表示线上映射中缺少该字段
+}
+
+// String 返回适合日志和命令行输出的差异描述。
+func (d MappingDrift) String() string {
+	switch {
+	case d.Actual == "":
+		return fmt.Sprintf("[%s] 字段 %s 缺失 (期望 %s=%s)", d.Index, d.Field, d.Property, d.Expected)
+	case d.Expected == "":
+		return fmt.Sprintf("[%s] 字段 %s 不在期望映射中 (实际 %s=%s)", d.Index, d.Field, d.Property, d.Actual)
+	default:
+		return fmt.Sprintf("[%s] 字段 %s 的 %s 不一致: 期望 %s，实际 %s", d.Index, d.Field, d.Property, d.Expected, d.Actual)
+	}
+}
+
+// CheckPostsIndexMapping 比较帖子索引的线上映射与 getPostsIndexMapping 生成的期望映射。
+func CheckPostsIndexMapping(ctx context.Context, esClient *elasticsearch.Client, indexCfg config.IndexSpecificConfig) ([]MappingDrift, error) {
+	return compareIndexMapping(ctx, esClient, indexCfg.Name, getPostsIndexMapping(indexCfg))
+}
+
+// CheckHotTermsIndexMapping 比较热门搜索词索引的线上映射与 getHotSearchTermsIndexMapping 生成的期望映射。
+func CheckHotTermsIndexMapping(ctx context.Context, esClient *elasticsearch.Client, indexCfg config.IndexSpecificConfig) ([]MappingDrift, error) {
+	return compareIndexMapping(ctx, esClient, indexCfg.Name, getHotSearchTermsIndexMapping(indexCfg))
+}
+
+// mappingProperty 是映射中单个字段的关键属性。只比较会影响写入和查询行为的 type 与 analyzer，
+// ignore_above、format 等属性的差异不视为漂移。
+type mappingProperty struct {
+	Type       string                     `json:"type"`
+	Analyzer   string                     `json:"analyzer"`
+	Fields     map[string]mappingProperty `json:"fields"`     // 多字段 (例如 title.en)
+	Properties map[string]mappingProperty `json:"properties"` // 对象类型字段的子字段
+}
+
+// compareIndexMapping 通过 Indices.GetMapping 获取索引 (或别名) 的线上映射，逐字段与期望映射比较。
+// 返回的差异按索引名、字段路径排序；索引不存在或请求失败时返回错误。
+func compareIndexMapping(ctx context.Context, esClient *elasticsearch.Client, indexName, expectedMappingJSON string) ([]MappingDrift, error) {
+	var expected struct {
+		Mappings struct {
+			Properties map[string]mappingProperty `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.Unmarshal([]byte(expectedMappingJSON), &expected); err != nil {
+		return nil, fmt.Errorf("解析索引 %s 的期望映射失败: %w", indexName, err)
+	}
+
+	checkCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := esapi.IndicesGetMappingRequest{Index: []string{indexName}}.Do(checkCtx, esClient)
+	if err != nil {
+		return nil, fmt.Errorf("获取索引 %s 的映射失败: %w", indexName, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("获取索引 %s 的映射失败，状态码: %s，响应: %s", indexName, res.Status(), string(body))
+	}
+
+	var live map[string]struct {
+		Mappings struct {
+			Properties map[string]mappingProperty `json:"properties"`
+		} `json:"mappings"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&live); err != nil {
+		return nil, fmt.Errorf("解码索引 %s 的映射响应失败: %w", indexName, err)
+	}
+
+	expectedFields := flattenMapping(expected.Mappings.Properties, "")
+	var drifts []MappingDrift
+	for concreteIndex, mapping := range live {
+		actualFields := flattenMapping(mapping.Mappings.Properties, "")
+		for field, exp := range expectedFields {
+			act, ok := actualFields[field]
+			if !ok {
+				drifts = append(drifts, MappingDrift{Index: concreteIndex, Field: field, Property: "type", Expected: exp.Type})
+				continue
+			}
+			if exp.Type != act.Type {
+				drifts = append(drifts, MappingDrift{Index: concreteIndex, Field: field, Property: "type", Expected: exp.Type, Actual: act.Type})
+			}
+			// text 字段未显式指定分析器时 ES 使用 standard，线上映射中也不会出现 analyzer。
+			if exp.Analyzer != "" && exp.Analyzer != analyzerOrDefault(act) {
+				drifts = append(drifts, MappingDrift{Index: concreteIndex, Field: field, Property: "analyzer", Expected: exp.Analyzer, Actual: analyzerOrDefault(act)})
+			}
+		}
+		for field, act := range actualFields {
+			if _, ok := expectedFields[field]; !ok {
+				drifts = append(drifts, MappingDrift{Index: concreteIndex, Field: field, Property: "type", Actual: act.Type})
+			}
+		}
+	}
+
+	sort.Slice(drifts, func(i, j int) bool {
+		if drifts[i].Index != drifts[j].Index {
+			return drifts[i].Index < drifts[j].Index
+		}
+		if drifts[i].Field != drifts[j].Field {
+			return drifts[i].Field < drifts[j].Field
+		}
+		return drifts[i].Property < drifts[j].Property
+	})
+	return drifts, nil
+}
+
+// flattenMapping 将嵌套的 properties/fields 展开为 "字段路径 -> 属性" 的映射，例如 author_username.keyword。
+func flattenMapping(properties map[string]mappingProperty, prefix string) map[string]mappingProperty {
+	flat := make(map[string]mappingProperty)
+	for name, prop := range properties {
+		path := name
+		if prefix != "" {
+			path = prefix + "." + name
+		}
+		if prop.Type == "" && len(prop.Properties) > 0 {
+			prop.Type = "object"
+		}
+		flat[path] = mappingProperty{Type: prop.Type, Analyzer: prop.Analyzer}
+		for sub, subProp := range flattenMapping(prop.Fields, path) {
+			flat[sub] = subProp
+		}
+		for sub, subProp := range flattenMapping(prop.Properties, path) {
+			flat[sub] = subProp
+		}
+	}
+	return flat
+}
+
+// analyzerOrDefault 返回字段实际生效的分析器：text 字段未指定时为 standard，其余类型为空。
+func analyzerOrDefault(p mappingProperty) string {
+	if p.Analyzer != "" {
+		return p.Analyzer
+	}
+	if strings.EqualFold(p.Type, "text") {
+		return "standard"
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/elastic/go-elasticsearch/v8"
+	"go.uber.org/zap"
+)
+
+// 启动时映射漂移检查的处理方式 (ESConfig.MappingDriftCheck)。
+const (
+	mappingDriftOff  = "off"
+	mappingDriftWarn = "warn"
+	mappingDriftFail = "fail"
+)
+
+// checkMappingDrift 在索引就绪后比较两个索引的线上映射与期望映射。
+// createIndexIfNotExists 只负责创建缺失的索引，已存在索引的映射被手动修改或由动态映射推断出错误类型时不会被发现，
+// 之后的写入和查询会出现难以排查的不一致。
+//
+// 期望映射中的字段缺失、类型或分析器不一致视为漂移：warn 模式下记录警告，fail 模式下返回错误终止启动。
+// 线上映射中多出的字段 (例如动态映射新增的字段) 只记录警告，不会导致启动失败。
+// 获取映射本身失败时只记录警告：检查是辅助性的，不应因此阻止服务启动。
+func checkMappingDrift(ctx context.Context, esClient *elasticsearch.Client, cfg config.ESConfig, logger *core.ZapLogger) error {
+	mode := strings.ToLower(cfg.MappingDriftCheck)
+	switch mode {
+	case "":
+		mode = mappingDriftWarn
+	case mappingDriftOff:
+		logger.Info("已关闭启动时的索引映射漂移检查 (elasticsearchConfig.mappingDriftCheck=off)")
+		return nil
+	case mappingDriftWarn, mappingDriftFail:
+	default:
+		logger.Warn("无法识别的映射漂移检查方式，按 warn 处理", zap.String("configured_mode", cfg.MappingDriftCheck))
+		mode = mappingDriftWarn
+	}
+
+	checks := []struct {
+		logicalName string
+		indexName   string
+		check       func() ([]MappingDrift, error)
+	}{
+		{"主帖子", cfg.PrimaryIndex.Name, func() ([]MappingDrift, error) {
+			return CheckPostsIndexMapping(ctx, esClient, cfg.PrimaryIndex)
+		}},
+		{"热门搜索词", cfg.HotTermsIndex.Name, func() ([]MappingDrift, error) {
+			return CheckHotTermsIndexMapping(ctx, esClient, cfg.HotTermsIndex)
+		}},
+	}
+
+	var blocking []string
+	for _, c := range checks {
+		drifts, err := c.check()
+		if err != nil {
+			logger.Warn(fmt.Sprintf("%s索引映射漂移检查失败，已跳过", c.logicalName), zap.String("index_name", c.indexName), zap.Error(err))
+			continue
+		}
+		if len(drifts) == 0 {
+			logger.Info(fmt.Sprintf("%s索引映射与期望一致", c.logicalName), zap.String("index_name", c.indexName))
+			continue
+		}
+		for _, d := range drifts {
+			logger.Warn(fmt.Sprintf("%s索引映射与期望不一致", c.logicalName),
+				zap.String("index", d.Index),
+				zap.String("field", d.Field),
+				zap.String("property", d.Property),
+				zap.String("expected", d.Expected),
+				zap.String("actual", d.Actual),
+			)
+			if d.Expected != "" {
+				blocking = append(blocking, d.String())
+			}
+		}
+	}
+
+	if len(blocking) > 0 && mode == mappingDriftFail {
+		return fmt.Errorf("索引映射与期望不一致 (elasticsearchConfig.mappingDriftCheck=fail)，请重建索引或修正映射: %s", strings.Join(blocking, "; "))
+	}
+	if len(blocking) > 0 {
+		logger.Warn("检测到索引映射漂移，相关字段的写入和查询结果可能不符合预期；修正需要重建索引", zap.Int("drift_count", len(blocking)))
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package es
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/elastic/elastic-transport-go/v8/elastictransport"
+	"go.uber.org/zap"
+)
+
+// loggingConnectionPool 包装 elastictransport 默认的连接池，在节点被标记为不可用 (dead)
+// 或恢复可用时记录日志。
+// 为什么需要包装?
+// 默认连接池只在开启 EnableDebugLogger 时才向标准输出打印节点状态变化，
+// 多节点集群中某个节点故障时，我们希望能直接在服务日志中看到它被摘除和恢复的过程。
+//
+// 嵌入接口只会提升 ConnectionPool 的方法，传输层通过类型断言使用的其他能力需要显式转发：
+// sync.Locker (节点发现替换连接池时加锁) 与 UpdatableConnectionPool (合并新节点列表，保留不可用节点的状态)。
+// 传输层的 connectionable 接口只有未导出方法，包外无法实现，只影响 EnableMetrics 中按连接统计的指标 (本服务未开启)。
+type loggingConnectionPool struct {
+	elastictransport.ConnectionPool
+	logger *core.ZapLogger
+}
+
+var (
+	_ sync.Locker                              = (*loggingConnectionPool)(nil)
+	_ elastictransport.UpdatableConnectionPool = (*loggingConnectionPool)(nil)
+)
+
+// newLoggingConnectionPoolFunc 返回可用于 elasticsearch.Config.ConnectionPoolFunc 的构造函数。
+// 客户端初始化以及每次节点发现 (DiscoverNodes) 完成后都会调用它。
+// 节点发现时传输层已持有当前连接池的锁：底层连接池支持 Update 时原地合并新的节点列表并返回同一个连接池，
+// 不可用节点的状态 (失败次数、恢复计划) 得以保留；否则 (单节点连接池) 才重新创建。
+func newLoggingConnectionPoolFunc(logger *core.ZapLogger) func([]*elastictransport.Connection, elastictransport.Selector) elastictransport.ConnectionPool {
+	var current *loggingConnectionPool
+	return func(conns []*elastictransport.Connection, selector elastictransport.Selector) elastictransport.ConnectionPool {
+		nodes := make([]string, 0, len(conns))
+		for _, c := range conns {
+			nodes = append(nodes, describeConnection(c))
+		}
+
+		if current != nil {
+			if _, updatable := current.ConnectionPool.(elastictransport.UpdatableConnectionPool); updatable {
+				if err := current.Update(conns); err != nil {
+					// 例如节点发现返回了空列表：保留原有连接池，而不是换成一个没有任何节点的连接池。
+					logger.Warn("更新 Elasticsearch 连接池失败，沿用原有节点列表", zap.Strings("nodes", nodes), zap.Error(err))
+					return current
+				}
+				logger.Info("Elasticsearch 连接池已更新", zap.Int("node_count", len(conns)), zap.Strings("nodes", nodes))
+				return current
+			}
+		}
+
+		// NewConnectionPool 在当前版本中不会返回错误 (空节点列表同样会创建连接池，请求时才报错)。
+		pool, _ := elastictransport.NewConnectionPool(conns, selector)
+		logger.Info("Elasticsearch 连接池已创建", zap.Int("node_count", len(conns)), zap.Strings("nodes", nodes))
+		current = &loggingConnectionPool{ConnectionPool: pool, logger: logger}
+		return current
+	}
+}
+
+// Lock 转发给底层连接池 (默认的多节点连接池自带互斥锁)；底层连接池不支持加锁时为空操作，与未包装时的行为一致。
+func (p *loggingConnectionPool) Lock() {
+	if l, ok := p.ConnectionPool.(sync.Locker); ok {
+		l.Lock()
+	}
+}
+
+// Unlock 释放 Lock 获取的锁。
+func (p *loggingConnectionPool) Unlock() {
+	if l, ok := p.ConnectionPool.(sync.Locker); ok {
+		l.Unlock()
+	}
+}
+
+// Update 把节点发现得到的节点列表合并到底层连接池；调用方需已持有连接池的锁。
+func (p *loggingConnectionPool) Update(conns []*elastictransport.Connection) error {
+	updatable, ok := p.ConnectionPool.(elastictransport.UpdatableConnectionPool)
+	if !ok {
+		return errors.New("底层连接池不支持更新节点列表")
+	}
+	return updatable.Update(conns)
+}
+
+// OnSuccess 在请求成功时调用；如果该节点此前被标记为不可用，则记录其恢复。
+func (p *loggingConnectionPool) OnSuccess(c *elastictransport.Connection) error {
+	wasDead := isConnectionDead(c)
+	err := p.ConnectionPool.OnSuccess(c)
+	if wasDead && !isConnectionDead(c) {
+		p.logger.Info("Elasticsearch 节点已恢复可用", zap.String("node", describeConnection(c)))
+	}
+	return err
+}
+
+// OnFailure 在请求失败时调用；如果该节点因此被标记为不可用，则记录警告。
+func (p *loggingConnectionPool) OnFailure(c *elastictransport.Connection) error {
+	wasDead := isConnectionDead(c)
+	err := p.ConnectionPool.OnFailure(c)
+	if !wasDead && isConnectionDead(c) {
+		c.Lock()
+		failures := c.Failures
+		c.Unlock()
+		p.logger.Warn("Elasticsearch 节点被标记为不可用，将在稍后尝试恢复",
+			zap.String("node", describeConnection(c)),
+			zap.Int("failures", failures),
+		)
+	}
+	return err
+}
+
+// isConnectionDead 在持有连接锁的情况下读取其状态。
+func isConnectionDead(c *elastictransport.Connection) bool {
+	c.Lock()
+	defer c.Unlock()
+	return c.IsDead
+}
+
+// describeConnection 返回便于日志阅读的节点描述：节点发现得到的连接带有名称，静态配置的地址则只有 URL。
+func describeConnection(c *elastictransport.Connection) string {
+	if c.Name != "" {
+		return c.Name + " (" + c.URL.String() + ")"
+	}
+	return c.URL.String()
+}
@@ -0,0 +1,114 @@
+package es
+
+import (
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	commonconfig "github.com/Xushengqwer/go-common/config"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/elastic/elastic-transport-go/v8/elastictransport"
+)
+
+func newTestLogger(t *testing.T) *core.ZapLogger {
+	t.Helper()
+	logger, err := core.NewZapLogger(commonconfig.ZapConfig{Level: "error", Encoding: "console"})
+	if err != nil {
+		t.Fatalf("创建测试 logger 失败: %v", err)
+	}
+	return logger
+}
+
+// testConnections 为每个地址创建一个新的连接对象 (与节点发现每次返回新对象一致)。
+func testConnections(t *testing.T, addrs ...string) []*elastictransport.Connection {
+	t.Helper()
+	conns := make([]*elastictransport.Connection, 0, len(addrs))
+	for _, addr := range addrs {
+		u, err := url.Parse(addr)
+		if err != nil {
+			t.Fatalf("解析地址 %s 失败: %v", addr, err)
+		}
+		conns = append(conns, &elastictransport.Connection{URL: u})
+	}
+	return conns
+}
+
+func urlStrings(urls []*url.URL) []string {
+	out := make([]string, 0, len(urls))
+	for _, u := range urls {
+		out = append(out, u.String())
+	}
+	return out
+}
+
+func TestLoggingConnectionPoolForwardsInterfaces(t *testing.T) {
+	poolFunc := newLoggingConnectionPoolFunc(newTestLogger(t))
+	pool := poolFunc(testConnections(t, "http://es1:9200", "http://es2:9200"), nil)
+
+	// 传输层在节点发现时通过类型断言使用这些接口，包装后必须仍然满足。
+	if _, ok := pool.(sync.Locker); !ok {
+		t.Error("包装后的连接池应实现 sync.Locker")
+	}
+	if _, ok := pool.(elastictransport.UpdatableConnectionPool); !ok {
+		t.Error("包装后的连接池应实现 UpdatableConnectionPool")
+	}
+
+	// Lock 应转发给底层连接池的互斥锁：持有锁时 URLs (内部加锁) 会阻塞。
+	locker := pool.(sync.Locker)
+	locker.Lock()
+	done := make(chan struct{})
+	go func() {
+		pool.URLs()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Error("持有连接池锁时 URLs 不应返回，Lock 未转发给底层连接池")
+	case <-time.After(50 * time.Millisecond):
+	}
+	locker.Unlock()
+	<-done
+}
+
+func TestLoggingConnectionPoolDiscoveryKeepsDeadNodes(t *testing.T) {
+	poolFunc := newLoggingConnectionPoolFunc(newTestLogger(t))
+	conns := testConnections(t, "http://es1:9200", "http://es2:9200")
+	pool := poolFunc(conns, nil)
+
+	if err := pool.OnFailure(conns[0]); err != nil {
+		t.Fatalf("OnFailure 返回错误: %v", err)
+	}
+	if got := urlStrings(pool.URLs()); len(got) != 1 || got[0] != "http://es2:9200" {
+		t.Fatalf("标记 es1 不可用后 URLs = %v, want [http://es2:9200]", got)
+	}
+
+	// 节点发现返回同样的节点 (新的连接对象) 与一个新节点：应原地合并，es1 仍保持不可用。
+	locker := pool.(sync.Locker)
+	locker.Lock()
+	updated := poolFunc(testConnections(t, "http://es1:9200", "http://es2:9200", "http://es3:9200"), nil)
+	locker.Unlock()
+
+	if updated != pool {
+		t.Error("节点发现后应返回同一个连接池，而不是重新创建")
+	}
+	got := urlStrings(updated.URLs())
+	want := []string{"http://es2:9200", "http://es3:9200"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("节点发现后 URLs = %v, want %v (es1 应仍为不可用)", got, want)
+	}
+}
+
+func TestLoggingConnectionPoolKeepsNodesOnEmptyDiscovery(t *testing.T) {
+	poolFunc := newLoggingConnectionPoolFunc(newTestLogger(t))
+	pool := poolFunc(testConnections(t, "http://es1:9200", "http://es2:9200"), nil)
+
+	updated := poolFunc(nil, nil)
+
+	if updated != pool {
+		t.Error("节点发现返回空列表时应沿用原有连接池")
+	}
+	if got := updated.URLs(); len(got) != 2 {
+		t.Errorf("URLs = %v, want 原有的 2 个节点", urlStrings(got))
+	}
+}
@@ -0,0 +1,114 @@
+package es
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// countDataNodes 通过 _cluster/health 返回集群中数据节点的数量。
+func countDataNodes(ctx context.Context, esClient *elasticsearch.Client) (int, error) {
+	healthCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	res, err := esClient.Cluster.Health(esClient.Cluster.Health.WithContext(healthCtx))
+	if err != nil {
+		return 0, fmt.Errorf("请求集群健康状态失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return 0, fmt.Errorf("请求集群健康状态失败，状态码: %s，响应: %s", res.Status(), string(body))
+	}
+
+	var health struct {
+		NumberOfDataNodes int `json:"number_of_data_nodes"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&health); err != nil {
+		return 0, fmt.Errorf("解析集群健康状态响应失败: %w", err)
+	}
+	return health.NumberOfDataNodes, nil
+}
+
+// autoScaleReplicas 为开启了 AutoScaleReplicas 的索引确定实际使用的副本数：
+// 集群只有一个数据节点时副本永远无法分配 (索引一直是 yellow)，改为 0；多节点集群保持配置的副本数。
+// 返回调整后的配置；查询节点数失败时记录警告并保持原配置，不影响启动。
+func autoScaleReplicas(ctx context.Context, esClient *elasticsearch.Client, cfg config.ESConfig, logger *core.ZapLogger) config.ESConfig {
+	if !cfg.PrimaryIndex.AutoScaleReplicas && !cfg.HotTermsIndex.AutoScaleReplicas {
+		return cfg
+	}
+
+	dataNodes, err := countDataNodes(ctx, esClient)
+	if err != nil {
+		logger.Warn("获取集群数据节点数量失败，索引将使用配置的副本数", zap.Error(err))
+		return cfg
+	}
+
+	scale := func(indexCfg *config.IndexSpecificConfig, indexLogicalName string) {
+		if !indexCfg.AutoScaleReplicas {
+			return
+		}
+		if dataNodes <= 1 && indexCfg.NumberOfReplicas > 0 {
+			logger.Info(fmt.Sprintf("集群只有一个数据节点，%s索引的副本数将设为 0", indexLogicalName),
+				zap.String("index_name", indexCfg.Name),
+				zap.Int("data_nodes", dataNodes),
+				zap.Int("configured_replicas", indexCfg.NumberOfReplicas),
+			)
+			indexCfg.NumberOfReplicas = 0
+			return
+		}
+		logger.Info(fmt.Sprintf("%s索引使用配置的副本数", indexLogicalName),
+			zap.String("index_name", indexCfg.Name),
+			zap.Int("data_nodes", dataNodes),
+			zap.Int("replicas", indexCfg.NumberOfReplicas),
+		)
+	}
+	scale(&cfg.PrimaryIndex, "主帖子")
+	scale(&cfg.HotTermsIndex, "热门搜索词")
+	return cfg
+}
+
+// applyReplicaCount 将副本数写入已存在的索引 (index.number_of_replicas 可以动态修改)，
+// 使此前以其他副本数创建的索引 (例如单节点开发环境中一直为 yellow 的索引) 与当前集群规模一致。
+// 只对开启了 AutoScaleReplicas 的索引生效；更新失败只记录警告。
+func applyReplicaCount(ctx context.Context, esClient *elasticsearch.Client, indexCfg config.IndexSpecificConfig, logger *core.ZapLogger, indexLogicalName string) {
+	if !indexCfg.AutoScaleReplicas {
+		return
+	}
+
+	putCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	body := fmt.Sprintf(`{ "index": { "number_of_replicas": %d } }`, indexCfg.NumberOfReplicas)
+	res, err := esapi.IndicesPutSettingsRequest{
+		Index: []string{indexCfg.Name},
+		Body:  strings.NewReader(body),
+	}.Do(putCtx, esClient)
+	if err != nil {
+		logger.Warn(fmt.Sprintf("更新%s索引的副本数失败", indexLogicalName), zap.String("index_name", indexCfg.Name), zap.Error(err))
+		return
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		respBody, _ := io.ReadAll(res.Body)
+		logger.Warn(fmt.Sprintf("更新%s索引的副本数失败", indexLogicalName),
+			zap.String("index_name", indexCfg.Name),
+			zap.Int("replicas", indexCfg.NumberOfReplicas),
+			zap.String("status", res.Status()),
+			zap.String("response", string(respBody)),
+		)
+		return
+	}
+	logger.Info(fmt.Sprintf("%s索引的副本数已更新", indexLogicalName),
+		zap.String("index_name", indexCfg.Name),
+		zap.Int("replicas", indexCfg.NumberOfReplicas),
+	)
+}
@@ -0,0 +1,59 @@
+package es
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// applySearchAnalyzer 将配置的 SearchAnalyzer 写入已存在帖子索引的 title/content 映射。
+// 与索引分析器不同，search_analyzer 只影响查询时对关键词的分词，ES 允许通过 _mapping API 直接修改，
+// 因此调整它无需重建索引，服务重启后即对新的查询生效。
+// 未配置 SearchAnalyzer 时不做任何修改；更新失败 (例如线上映射的索引分析器与配置不一致) 只记录警告。
+func applySearchAnalyzer(ctx context.Context, esClient *elasticsearch.Client, indexCfg config.IndexSpecificConfig, logger *core.ZapLogger) {
+	if indexCfg.SearchAnalyzer == "" {
+		return
+	}
+	// 请求体中的字段定义必须与线上映射一致 (类型、索引分析器、子字段)，只有 search_analyzer 允许不同。
+	textField := textFieldMapping(indexCfg)
+	if !strings.Contains(textField, `"search_analyzer"`) {
+		// 配置的搜索分析器与索引分析器相同：显式写入，以便覆盖此前设置过的不同值。
+		textField = strings.Replace(textField, `"type": "text",`, fmt.Sprintf(`"type": "text", "search_analyzer": %q,`, indexCfg.SearchAnalyzer), 1)
+	}
+	body := fmt.Sprintf(`{ "properties": { "title": %s, "content": %s } }`, textField, textField)
+
+	putCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	res, err := esapi.IndicesPutMappingRequest{
+		Index: []string{indexCfg.Name},
+		Body:  strings.NewReader(body),
+	}.Do(putCtx, esClient)
+	if err != nil {
+		logger.Warn("更新帖子索引的 search_analyzer 失败", zap.String("index_name", indexCfg.Name), zap.Error(err))
+		return
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		respBody, _ := io.ReadAll(res.Body)
+		logger.Warn("更新帖子索引的 search_analyzer 失败，title/content 将继续使用线上映射中的分析器查询",
+			zap.String("index_name", indexCfg.Name),
+			zap.String("search_analyzer", indexCfg.SearchAnalyzer),
+			zap.String("status", res.Status()),
+			zap.String("response", string(respBody)),
+		)
+		return
+	}
+	logger.Info("帖子索引 title/content 的 search_analyzer 已生效",
+		zap.String("index_name", indexCfg.Name),
+		zap.String("index_analyzer", textAnalyzerOf(indexCfg)),
+		zap.String("search_analyzer", indexCfg.SearchAnalyzer),
+	)
+}
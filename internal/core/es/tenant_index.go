@@ -0,0 +1,19 @@
+package es
+
+import (
+	"context"
+	"strings"
+)
+
+// EnsurePostsIndex 确保指定名称的帖子索引存在，不存在时按主索引的分片、副本、分析器配置和映射创建。
+// 供帖子仓库在首次写入租户索引时调用 (见 repositories.IndexEnsurer)。
+// 多个实例可能同时为同一个租户创建索引，ES 返回 resource_already_exists_exception 时视为成功。
+func (c *ESClient) EnsurePostsIndex(ctx context.Context, indexName string) error {
+	indexCfg := c.PrimaryIndexCfg
+	indexCfg.Name = indexName
+	err := createIndexIfNotExists(ctx, c.Client, indexCfg, getPostsIndexMapping, c.logger, "租户帖子")
+	if err != nil && strings.Contains(err.Error(), "resource_already_exists_exception") {
+		return nil
+	}
+	return err
+}
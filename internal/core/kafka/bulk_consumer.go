@@ -0,0 +1,320 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"github.com/cenkalti/backoff/v4"
+	"go.uber.org/zap"
+)
+
+// 批量索引 (kafkaConfig.bulkIndexing) 说明:
+// 开启后，审核通过事件主题的每个分区按批次消费：收到第一条消息后最多等待 flushInterval 或凑满 maxBatchSize 条，
+// 逐条反序列化、校验并生成文档 (与单条处理共用 preparePostApprovedDocument)，再通过一次 _bulk 请求写入。
+//
+// 偏移量提交语义:
+//   - 一批消息全部有了最终结果 (写入成功、已发送到 DLQ 或被跳过) 之后，才按消息顺序逐条标记 (MarkMessage)。
+//     Kafka 按分区提交的是 "最高已标记偏移量"，在混合批次中提前标记成功的消息，
+//     会让仍在重试的、偏移量更小的消息在重启后丢失。
+//   - 单个条目失败时，永久性错误 (4xx，如映射冲突) 不重试，标记前发送到 DLQ；
+//     暂时性错误 (429、5xx) 只重试失败的条目，按 retryInterval 指数退避，最多 maxRetryAttempts 次，
+//     仍失败则发送到 DLQ。整个 _bulk 请求失败 (网络错误、非 2xx 响应) 时本轮全部文档按暂时性错误重试。
+//   - 会话上下文在批次处理完成之前被取消 (重平衡或关闭) 时，本批消息都不标记，重新分配分区后会再次消费；
+//     索引操作是幂等的，重复写入不会产生副作用。
+//   - 同一帖子在批次中再次出现，或租户与前面的文档不同时，先写入已积累的文档，再处理这条消息，
+//     保证同一帖子的事件按顺序生效 (状态策略可能读取或删除该帖子)。
+
+// 批量索引设置的默认值，在配置缺失或无效时使用。
+const (
+	defaultBulkMaxBatchSize  = 100
+	defaultBulkFlushInterval = 200 * time.Millisecond
+	defaultBulkRetryInterval = 500 * time.Millisecond
+)
+
+// SetBulkIndexing 启用审核通过事件的批量索引，cfg.Enabled 为 false 时不做任何处理；无效的数值使用默认值。
+// 与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetBulkIndexing(cfg config.BulkIndexingConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultBulkMaxBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultBulkFlushInterval
+	}
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaultBulkRetryInterval
+	}
+	h.bulk = &cfg
+	h.logger.Info("审核通过事件将按批次通过 _bulk 写入",
+		zap.String("topic", h.auditTopic),
+		zap.Int("max_batch_size", cfg.MaxBatchSize),
+		zap.Duration("flush_interval", cfg.FlushInterval),
+		zap.Duration("retry_interval", cfg.RetryInterval),
+	)
+}
+
+// bulkEntry 是批次中的一条消息及其最终处理结果。
+type bulkEntry struct {
+	message *sarama.ConsumerMessage
+	skipped bool  // 超大消息已在处理批次时转发到 DLQ，只需标记
+	err     error // 最终失败原因，非 nil 时在标记前发送到 DLQ
+}
+
+// bulkRun 是等待通过同一次 _bulk 请求写入的文档：属于同一租户，且帖子 ID 互不相同。
+type bulkRun struct {
+	ctx     context.Context // 携带租户标识的上下文
+	tenant  string
+	docs    []models.EsPostDocument
+	entries []*bulkEntry // 与 docs 按位置一一对应
+	postIDs map[uint64]bool
+}
+
+func (r *bulkRun) add(ctx context.Context, tenant string, doc models.EsPostDocument, entry *bulkEntry) {
+	if len(r.docs) == 0 {
+		r.ctx, r.tenant, r.postIDs = ctx, tenant, make(map[uint64]bool)
+	}
+	r.docs = append(r.docs, doc)
+	r.entries = append(r.entries, entry)
+	r.postIDs[doc.ID] = true
+}
+
+// conflicts 判断文档能否与已积累的文档放进同一个请求。
+func (r *bulkRun) conflicts(tenant string, postID uint64) bool {
+	return len(r.docs) > 0 && (r.tenant != tenant || r.postIDs[postID])
+}
+
+func (r *bulkRun) reset() {
+	r.ctx, r.tenant, r.docs, r.entries, r.postIDs = nil, "", nil, nil, nil
+}
+
+// consumeClaimBulk 以批次方式消费审核通过事件主题的一个分区，语义见文件开头的说明。
+func (h *Handler) consumeClaimBulk(session sarama.ConsumerGroupSession, claim sarama.ConsumerGroupClaim) error {
+	ctx := session.Context()
+	for {
+		batch, open := h.collectBatch(ctx, claim.Messages())
+		if len(batch) > 0 {
+			if err := h.processBulkBatch(session, batch); err != nil {
+				h.logger.Info("会话上下文在批量索引期间被取消，本批消息未标记，重新分配分区后将再次消费",
+					zap.String("topic", claim.Topic()),
+					zap.Int32("partition", claim.Partition()),
+					zap.Int64("first_offset", batch[0].Offset),
+					zap.Int("batch_size", len(batch)),
+					zap.Error(err),
+				)
+				return err
+			}
+		}
+		if !open {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			h.logger.Info("已完成消费分区中的所有消息（或会话结束）",
+				zap.String("topic", claim.Topic()),
+				zap.Int32("partition", claim.Partition()),
+			)
+			return nil
+		}
+	}
+}
+
+// collectBatch 阻塞等待批次的第一条消息，之后继续收集，直到凑满 maxBatchSize、等待超过 flushInterval 或通道关闭。
+// 第二个返回值为 false 表示通道已关闭或会话已取消，调用方处理完返回的批次后应停止消费；
+// 会话取消时返回空批次，已收到的消息不标记，重新分配分区后再次消费。
+func (h *Handler) collectBatch(ctx context.Context, messages <-chan *sarama.ConsumerMessage) ([]*sarama.ConsumerMessage, bool) {
+	var batch []*sarama.ConsumerMessage
+	select {
+	case message, ok := <-messages:
+		if !ok {
+			return nil, false
+		}
+		batch = append(batch, message)
+	case <-ctx.Done():
+		return nil, false
+	}
+
+	timer := time.NewTimer(h.bulk.FlushInterval)
+	defer timer.Stop()
+	for len(batch) < h.bulk.MaxBatchSize {
+		select {
+		case message, ok := <-messages:
+			if !ok {
+				return batch, false
+			}
+			batch = append(batch, message)
+		case <-timer.C:
+			return batch, true
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+	return batch, true
+}
+
+// processBulkBatch 处理一批审核通过事件，所有消息都有了最终结果后按顺序标记。
+// 只有会话上下文被取消时返回错误，此时本批消息都不标记。
+func (h *Handler) processBulkBatch(session sarama.ConsumerGroupSession, batch []*sarama.ConsumerMessage) error {
+	ctx := session.Context()
+	entries := make([]*bulkEntry, len(batch))
+	run := &bulkRun{}
+
+	for i, message := range batch {
+		entry := &bulkEntry{message: message}
+		entries[i] = entry
+
+		if len(message.Value) > h.maxMessageSize {
+			h.handleOversizeMessage(message)
+			entry.skipped = true
+			continue
+		}
+		msgCtx, err := h.withMessageTenant(ctx, message)
+		if err != nil {
+			entry.err = err
+			continue
+		}
+		event, ext, err := h.decodePostApprovedEvent(message)
+		if err != nil {
+			entry.err = unwrapPermanent(err)
+			continue
+		}
+
+		tenant := repositories.TenantFromContext(msgCtx)
+		if run.conflicts(tenant, event.Post.ID) {
+			if err := h.flushBulkRun(ctx, run); err != nil {
+				return err
+			}
+		}
+
+		// 校验与状态策略等步骤仍按单条消息的方式重试；只有最终的写入合并为 _bulk 请求。
+		var doc *models.EsPostDocument
+		entry.err = h.processWithRetry(msgCtx, message, func(attemptCtx context.Context, _ *sarama.ConsumerMessage) error {
+			prepared, err := h.eventService.preparePostApprovedDocument(attemptCtx, event, ext.Post.Tags, ext.Post.ExpiresAt, ext.Post.ContactQRCode)
+			doc = prepared
+			return err
+		})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if entry.err != nil || doc == nil {
+			continue
+		}
+		run.add(msgCtx, tenant, *doc, entry)
+	}
+	if err := h.flushBulkRun(ctx, run); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.skipped {
+			session.MarkMessage(entry.message, "")
+			recordPartitionProgress(entry.message, "skipped")
+			continue
+		}
+		h.finishMessage(session, entry.message, entry.err)
+	}
+	return nil
+}
+
+// flushBulkRun 通过 _bulk 写入已积累的文档，并把每个条目的最终结果记录到对应的 bulkEntry。
+// 暂时性失败的条目按 retryInterval 指数退避重试，最多 maxRetry 次；永久性失败的条目不重试。
+// 只有会话上下文被取消时返回错误。
+func (h *Handler) flushBulkRun(ctx context.Context, run *bulkRun) error {
+	if len(run.docs) == 0 {
+		return nil
+	}
+	defer run.reset()
+
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = h.bulk.RetryInterval
+	bo.MaxElapsedTime = 0 // 重试次数由 maxRetry 控制
+	bo.Reset()
+
+	docs, entries := run.docs, run.entries
+	for attempt := uint64(0); ; attempt++ {
+		attemptCtx, cancel := context.WithTimeout(run.ctx, h.messageTimeout)
+		failures, err := h.eventService.postRepo.BulkIndexPosts(attemptCtx, docs)
+		cancel()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var retryDocs []models.EsPostDocument
+		var retryEntries []*bulkEntry
+		if err != nil {
+			// 整个请求失败时无法判断哪些文档已写入，本轮的全部文档按暂时性错误重试。
+			for _, entry := range entries {
+				entry.err = fmt.Errorf("批量索引帖子到 Elasticsearch 失败: %w", err)
+			}
+			retryDocs, retryEntries = docs, entries
+		} else {
+			failed := make(map[int]repositories.BulkItemFailure, len(failures))
+			for _, failure := range failures {
+				failed[failure.Index] = failure
+			}
+			for i, entry := range entries {
+				failure, ok := failed[i]
+				if !ok {
+					entry.err = nil
+					continue
+				}
+				entry.err = fmt.Errorf("索引帖子 ID '%d' 到 Elasticsearch 失败: %w", failure.PostID, failure)
+				if failure.Permanent {
+					bulkItemFailures.Inc(entry.message.Topic, "permanent")
+					h.logger.Error("批量索引条目遇到永久性错误，将发送到死信队列 (DLQ)",
+						zap.String("topic", entry.message.Topic),
+						zap.Int64("offset", entry.message.Offset),
+						zap.Int32("partition", entry.message.Partition),
+						zap.Error(failure),
+					)
+					continue
+				}
+				bulkItemFailures.Inc(entry.message.Topic, "transient")
+				retryDocs = append(retryDocs, docs[i])
+				retryEntries = append(retryEntries, entry)
+			}
+		}
+
+		if len(retryEntries) == 0 {
+			return nil
+		}
+		if attempt >= h.maxRetry {
+			h.logger.Error("批量索引的暂时性失败条目已用尽重试次数，将发送到死信队列 (DLQ)",
+				zap.Int("failed_count", len(retryEntries)),
+				zap.Uint64("retries", attempt),
+			)
+			return nil
+		}
+
+		wait := bo.NextBackOff()
+		h.logger.Warn("批量索引部分条目暂时失败，准备只重试这些条目",
+			zap.Int("retry_count", len(retryEntries)),
+			zap.Int("doc_count", len(docs)),
+			zap.Duration("next_retry_in", wait),
+			zap.Error(retryEntries[0].err),
+		)
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		docs, entries = retryDocs, retryEntries
+	}
+}
+
+// unwrapPermanent 去掉 backoff.Permanent 的包装，使 DLQ 头部记录的是原始错误。
+func unwrapPermanent(err error) error {
+	var permanent *backoff.PermanentError
+	if errors.As(err, &permanent) {
+		return permanent.Err
+	}
+	return err
+}
@@ -0,0 +1,244 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+const (
+	testApprovedTopic = "post_audit_approved"
+	testDeletedTopic  = "post_deleted"
+	testDLQTopic      = "post_search_dlq"
+)
+
+type bulkTestEnv struct {
+	handler  *Handler
+	repo     *fakePostRepo
+	producer *fakeDLQProducer
+	session  *fakeSession
+	events   *eventLog
+	cancel   context.CancelFunc
+}
+
+func newBulkTestEnv(t *testing.T, maxRetries uint64, bulkRespond func(call int, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error)) *bulkTestEnv {
+	t.Helper()
+	logger := newTestLogger(t)
+	events := &eventLog{}
+	repo := &fakePostRepo{events: events, bulkRespond: bulkRespond}
+	producer := &fakeDLQProducer{events: events}
+
+	handler := NewHandler(NewEventService(repo, config.IndexingConfig{}, logger), producer, testDLQTopic, testApprovedTopic, testDeletedTopic, logger, maxRetries, config.DLQSendConfig{})
+	handler.SetBulkIndexing(config.BulkIndexingConfig{
+		Enabled:       true,
+		MaxBatchSize:  10,
+		FlushInterval: 50 * time.Millisecond,
+		RetryInterval: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	return &bulkTestEnv{
+		handler:  handler,
+		repo:     repo,
+		producer: producer,
+		session:  &fakeSession{ctx: ctx, events: events},
+		events:   events,
+		cancel:   cancel,
+	}
+}
+
+// consume 把 messages 放入分区后关闭通道，并运行 ConsumeClaim 直到它返回。
+func (env *bulkTestEnv) consume(t *testing.T, messages ...*sarama.ConsumerMessage) error {
+	t.Helper()
+	claim := &fakeClaim{topic: testApprovedTopic, messages: make(chan *sarama.ConsumerMessage, len(messages))}
+	for _, message := range messages {
+		claim.messages <- message
+	}
+	close(claim.messages)
+	return env.handler.ConsumeClaim(env.session, claim)
+}
+
+// indexOfPrefix 返回 events 中第一条以 prefix 开头的记录的位置，不存在时返回 -1。
+func indexOfPrefix(events []string, prefix string) int {
+	for i, event := range events {
+		if strings.HasPrefix(event, prefix) {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastIndex 返回 events 中最后一条以 prefix 开头的记录的位置，不存在时返回 -1。
+func lastIndex(events []string, prefix string) int {
+	last := -1
+	for i, event := range events {
+		if strings.HasPrefix(event, prefix) {
+			last = i
+		}
+	}
+	return last
+}
+
+func TestConsumeClaimBulkMixedResults(t *testing.T) {
+	env := newBulkTestEnv(t, 3, func(call int, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error) {
+		if call > 0 {
+			return nil, nil // 重试时暂时性失败的条目写入成功
+		}
+		return []repositories.BulkItemFailure{
+			{Index: 1, PostID: docs[1].ID, Status: 400, ErrorType: "mapper_parsing_exception", Reason: "failed to parse", Permanent: true},
+			{Index: 2, PostID: docs[2].ID, Status: 429, ErrorType: "es_rejected_execution_exception", Reason: "rejected execution"},
+		}, nil
+	})
+
+	malformed := &sarama.ConsumerMessage{Topic: testApprovedTopic, Offset: 4, Value: []byte(`{"event_id":`)}
+	err := env.consume(t,
+		approvedMessage(t, testApprovedTopic, 0, 1),
+		approvedMessage(t, testApprovedTopic, 1, 2),
+		approvedMessage(t, testApprovedTopic, 2, 3),
+		approvedMessage(t, testApprovedTopic, 3, 4),
+		malformed,
+	)
+	if err != nil {
+		t.Fatalf("ConsumeClaim 返回错误: %v", err)
+	}
+
+	if got, want := env.repo.bulkCallIDs(), [][]uint64{{1, 2, 3, 4}, {3}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("_bulk 调用 = %v, want %v (只重试暂时性失败的条目)", got, want)
+	}
+	if got, want := env.producer.sentOffsets(), []int64{1, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("发送到 DLQ 的偏移量 = %v, want %v", got, want)
+	}
+	if got, want := env.session.markedOffsets(), []int64{0, 1, 2, 3, 4}; !reflect.DeepEqual(got, want) {
+		t.Errorf("标记的偏移量 = %v, want %v", got, want)
+	}
+
+	env.producer.mu.Lock()
+	stages := []string{dlqHeader(env.producer.sent[0], "dlq_error_stage"), dlqHeader(env.producer.sent[1], "dlq_error_stage")}
+	reason := dlqHeader(env.producer.sent[0], "dlq_processing_error")
+	env.producer.mu.Unlock()
+	if want := []string{ErrorStageIndexing, ErrorStageDeserialization}; !reflect.DeepEqual(stages, want) {
+		t.Errorf("dlq_error_stage = %v, want %v", stages, want)
+	}
+	if !strings.Contains(reason, "mapper_parsing_exception") || !strings.Contains(reason, "帖子 ID 2") {
+		t.Errorf("dlq_processing_error = %q, want 包含条目的帖子 ID 与错误类型", reason)
+	}
+
+	// 所有标记都发生在最后一次 _bulk 写入之后，之前不能提前标记成功的条目。
+	events := env.events.all()
+	if firstMark, lastBulk := indexOfPrefix(events, "mark "), lastIndex(events, "bulk "); firstMark < lastBulk {
+		t.Errorf("消息在批次处理完成之前被标记: %v", events)
+	}
+}
+
+func TestConsumeClaimBulkTransientFailuresExhaustRetries(t *testing.T) {
+	env := newBulkTestEnv(t, 2, func(_ int, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error) {
+		var failures []repositories.BulkItemFailure
+		for i, doc := range docs {
+			if doc.ID == 1 {
+				failures = append(failures, repositories.BulkItemFailure{Index: i, PostID: doc.ID, Status: 503, ErrorType: "unavailable_shards_exception"})
+			}
+		}
+		return failures, nil
+	})
+
+	if err := env.consume(t, approvedMessage(t, testApprovedTopic, 0, 1), approvedMessage(t, testApprovedTopic, 1, 2)); err != nil {
+		t.Fatalf("ConsumeClaim 返回错误: %v", err)
+	}
+
+	if got, want := env.repo.bulkCallIDs(), [][]uint64{{1, 2}, {1}, {1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("_bulk 调用 = %v, want %v (首次写入加 2 次重试)", got, want)
+	}
+	if got, want := env.producer.sentOffsets(), []int64{0}; !reflect.DeepEqual(got, want) {
+		t.Errorf("发送到 DLQ 的偏移量 = %v, want %v", got, want)
+	}
+	if got, want := env.session.markedOffsets(), []int64{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("标记的偏移量 = %v, want %v", got, want)
+	}
+}
+
+func TestConsumeClaimBulkRequestFailureRetriesWholeBatch(t *testing.T) {
+	env := newBulkTestEnv(t, 3, func(call int, _ []models.EsPostDocument) ([]repositories.BulkItemFailure, error) {
+		if call == 0 {
+			return nil, errors.New("connection reset by peer")
+		}
+		return nil, nil
+	})
+
+	if err := env.consume(t, approvedMessage(t, testApprovedTopic, 0, 1), approvedMessage(t, testApprovedTopic, 1, 2)); err != nil {
+		t.Fatalf("ConsumeClaim 返回错误: %v", err)
+	}
+
+	if got, want := env.repo.bulkCallIDs(), [][]uint64{{1, 2}, {1, 2}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("_bulk 调用 = %v, want %v", got, want)
+	}
+	if sent := env.producer.sentOffsets(); len(sent) != 0 {
+		t.Errorf("发送到 DLQ 的偏移量 = %v, want 无", sent)
+	}
+	if got, want := env.session.markedOffsets(), []int64{0, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("标记的偏移量 = %v, want %v", got, want)
+	}
+}
+
+func TestConsumeClaimBulkSplitsRepeatedPost(t *testing.T) {
+	env := newBulkTestEnv(t, 3, nil)
+
+	err := env.consume(t,
+		approvedMessage(t, testApprovedTopic, 0, 1),
+		approvedMessage(t, testApprovedTopic, 1, 2),
+		approvedMessage(t, testApprovedTopic, 2, 1),
+	)
+	if err != nil {
+		t.Fatalf("ConsumeClaim 返回错误: %v", err)
+	}
+
+	// 同一帖子的第二个事件必须在第一个写入之后处理，因此拆成两次请求。
+	if got, want := env.repo.bulkCallIDs(), [][]uint64{{1, 2}, {1}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("_bulk 调用 = %v, want %v", got, want)
+	}
+	if got, want := env.session.markedOffsets(), []int64{0, 1, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("标记的偏移量 = %v, want %v", got, want)
+	}
+}
+
+func TestConsumeClaimBulkSessionCanceledLeavesBatchUnmarked(t *testing.T) {
+	var env *bulkTestEnv
+	env = newBulkTestEnv(t, 3, func(_ int, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error) {
+		env.cancel() // 模拟写入期间发生重平衡
+		return []repositories.BulkItemFailure{{Index: 0, PostID: docs[0].ID, Status: 429}}, nil
+	})
+
+	err := env.consume(t, approvedMessage(t, testApprovedTopic, 0, 1), approvedMessage(t, testApprovedTopic, 1, 2))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ConsumeClaim 返回 %v, want context.Canceled", err)
+	}
+	if marked := env.session.markedOffsets(); len(marked) != 0 {
+		t.Errorf("标记的偏移量 = %v, want 无 (整批在重新分配分区后再次消费)", marked)
+	}
+	if sent := env.producer.sentOffsets(); len(sent) != 0 {
+		t.Errorf("发送到 DLQ 的偏移量 = %v, want 无", sent)
+	}
+}
+
+func TestConsumeClaimWithoutBulkIndexesEachMessage(t *testing.T) {
+	env := newBulkTestEnv(t, 3, nil)
+	env.handler.bulk = nil
+
+	if err := env.consume(t, approvedMessage(t, testApprovedTopic, 0, 1), approvedMessage(t, testApprovedTopic, 1, 2)); err != nil {
+		t.Fatalf("ConsumeClaim 返回错误: %v", err)
+	}
+	if calls := env.repo.bulkCallIDs(); len(calls) != 0 {
+		t.Errorf("_bulk 调用 = %v, 未开启批量索引时应逐条写入", calls)
+	}
+	if got, want := env.events.all(), []string{"index 1", "mark 0", "index 2", "mark 1"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("调用顺序 = %v, want %v", got, want)
+	}
+}
@@ -2,6 +2,7 @@ package kafka
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/IBM/sarama"                     // 导入 Sarama Kafka 客户端库
@@ -10,6 +11,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// 生产者内部重试的默认值，在配置缺失或无效时使用。
+const (
+	defaultProducerRetryMax     = 3
+	defaultProducerRetryBackoff = 250 * time.Millisecond
+)
+
 // ConfigureSarama 根据应用程序的 Kafka 配置，创建一个适用于消费者和生产者的 Sarama 配置对象。
 // 此函数旨在将应用层配置（config.KafkaConfig）与 Sarama 库的配置细节解耦。
 // 参数:
@@ -160,21 +167,100 @@ func ConfigureSarama(cfg config.KafkaConfig, logger *core.ZapLogger) (*sarama.Co
 		zap.Int16("acks_value_internal", int16(saramaCfg.Producer.RequiredAcks)), // 同时记录内部 int16 值
 	)
 
+	// 为什么要配置生产者重试 (retries / retry.backoff.ms)?
+	// Broker 重启或 Leader 切换期间，发送会短暂收到 NotLeaderForPartition 等错误，刷新元数据后即可恢复。
+	// Sarama 在生产者内部按 Retry.Max/Retry.Backoff 重试这类错误，避免每次抖动都消耗一次 DLQ 发送重试。
+	// 默认的 100ms 间隔通常不足以等到新 Leader 选出，因此默认放宽到 250ms。
+	saramaCfg.Producer.Retry.Max = defaultProducerRetryMax
+	if cfg.Producer.RetryMax > 0 {
+		saramaCfg.Producer.Retry.Max = cfg.Producer.RetryMax
+	}
+	saramaCfg.Producer.Retry.Backoff = defaultProducerRetryBackoff
+	if cfg.Producer.RetryBackoff > 0 {
+		saramaCfg.Producer.Retry.Backoff = cfg.Producer.RetryBackoff
+	}
+	logger.Info("生产者内部重试设置",
+		zap.Int("retry_max", saramaCfg.Producer.Retry.Max),
+		zap.Duration("retry_backoff", saramaCfg.Producer.Retry.Backoff),
+	)
+
+	// 为什么要支持幂等生产者 (enable.idempotence)?
+	// DLQ 是消息处理失败时的最后保障。Sarama 在发送超时或连接中断时会在内部重试，
+	// 如果 Broker 其实已经写入了第一次发送的消息，就会在 DLQ 中产生重复记录。
+	// 幂等生产者通过 Producer ID 和序列号让 Broker 去重，保证每条失败消息只进入 DLQ 一次。
+	// 它要求 Kafka >= 0.11、acks=all、Producer.Retry.Max >= 1 以及 Net.MaxOpenRequests = 1。
+	// 注意：此 Sarama 配置同时被消费者组使用，MaxOpenRequests = 1 也会作用于消费者连接。
+	if cfg.Producer.Idempotent {
+		if !saramaCfg.Version.IsAtLeast(sarama.V0_11_0_0) {
+			logger.Error("幂等生产者要求 Kafka 版本不低于 0.11.0.0",
+				zap.String("kafka_version", saramaCfg.Version.String()))
+			return nil, fmt.Errorf("幂等生产者要求 Kafka 版本不低于 0.11.0.0，当前配置为 %s", saramaCfg.Version.String())
+		}
+		if saramaCfg.Producer.RequiredAcks != sarama.WaitForAll {
+			logger.Warn("幂等生产者要求 acks=all，已覆盖配置的确认级别",
+				zap.String("configured_acks", originalAcks))
+			saramaCfg.Producer.RequiredAcks = sarama.WaitForAll
+		}
+		if saramaCfg.Producer.Retry.Max < 1 {
+			saramaCfg.Producer.Retry.Max = 1
+		}
+		saramaCfg.Producer.Idempotent = true
+		saramaCfg.Net.MaxOpenRequests = 1
+		logger.Info("幂等生产者已启用",
+			zap.Int("producer_retry_max", saramaCfg.Producer.Retry.Max),
+			zap.Int("net_max_open_requests", saramaCfg.Net.MaxOpenRequests),
+		)
+	}
+
+	// 为什么要配置消息压缩 (compression.type)?
+	// DLQ 消息携带完整的原始负载和上下文头部，故障期间可能在短时间内大量写入 DLQ 主题。
+	// 压缩可以显著减少网络带宽和 Broker 存储；snappy 在压缩率和 CPU 开销之间较为均衡，因此作为默认值。
+	codec, err := parseCompressionCodec(cfg.Producer.Compression)
+	if err != nil {
+		logger.Error("无效的生产者压缩类型配置",
+			zap.String("configured_compression", cfg.Producer.Compression),
+			zap.Error(err))
+		return nil, err
+	}
+	if codec == sarama.CompressionZSTD && !saramaCfg.Version.IsAtLeast(sarama.V2_1_0_0) {
+		logger.Error("zstd 压缩要求 Kafka 版本不低于 2.1.0", zap.String("kafka_version", saramaCfg.Version.String()))
+		return nil, fmt.Errorf("zstd 压缩要求 Kafka 版本不低于 2.1.0，当前配置为 %s", saramaCfg.Version.String())
+	}
+	saramaCfg.Producer.Compression = codec
+	logger.Info("生产者消息压缩类型设置为",
+		zap.String("compression", codec.String()),
+		zap.String("configured_value", cfg.Producer.Compression),
+	)
+
 	// 根据需要添加其他生产者配置, 例如:
-	// saramaCfg.Producer.Compression = sarama.CompressionSnappy // 开启压缩以减少网络带宽
 	// saramaCfg.Producer.MaxMessageBytes = 1000000 // 生产者能发送的最大消息大小
-	// saramaCfg.Producer.Idempotent = true // 开启幂等生产者，防止消息重复 (需要 Broker 版本 >= 0.11.0.0 且 acks=all)
-
-	// --- 安全设置 (示例 SASL/PLAIN, 根据需要取消注释和配置) ---
-	// if cfg.Security.Enabled { // 假设 cfg 中有 Security 结构体
-	//     saramaCfg.Net.SASL.Enable = true
-	//     saramaCfg.Net.SASL.Mechanism = sarama.SASLTypePlaintext // 或 SCRAMSHA256, SCRAMSHA512
-	//     saramaCfg.Net.SASL.User = cfg.Security.Username
-	//     saramaCfg.Net.SASL.Password = cfg.Security.Password
-	//     // saramaCfg.Net.TLS.Enable = true // 如果使用 TLS
-	//     // Configure TLS settings...
-	//     logger.Info("Kafka 安全配置已启用", zap.String("sasl_mechanism", string(saramaCfg.Net.SASL.Mechanism)))
-	// }
+
+	// --- 安全设置 (SASL 认证 / TLS 加密) ---
+	// 为什么在这里校验安全配置?
+	// 认证配置错误 (例如缺少密码) 会导致所有 Broker 连接失败，且 Sarama 的报错不够直观。
+	// 在启动阶段就拒绝不完整的配置，可以让问题尽早暴露。
+	if err := configureSecurity(saramaCfg, cfg.Security, logger); err != nil {
+		return nil, fmt.Errorf("kafka 安全配置无效: %w", err)
+	}
 
 	return saramaCfg, nil
 }
+
+// parseCompressionCodec 将配置中的压缩类型字符串转换为 Sarama 的压缩编解码器。
+// 空字符串表示使用默认值 snappy。
+func parseCompressionCodec(compression string) (sarama.CompressionCodec, error) {
+	switch strings.ToLower(strings.TrimSpace(compression)) {
+	case "", "snappy":
+		return sarama.CompressionSnappy, nil
+	case "none":
+		return sarama.CompressionNone, nil
+	case "gzip":
+		return sarama.CompressionGZIP, nil
+	case "lz4":
+		return sarama.CompressionLZ4, nil
+	case "zstd":
+		return sarama.CompressionZSTD, nil
+	default:
+		return sarama.CompressionNone, fmt.Errorf("无效的生产者压缩类型 '%s'，可选值: none, gzip, snappy, lz4, zstd", compression)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/IBM/sarama"
 	"github.com/Xushengqwer/go-common/core"     // 假设这是你的日志库路径
 	"github.com/Xushengqwer/post_search/config" // 假设这是你的配置包路径
+	"github.com/Xushengqwer/post_search/internal/models"
 	"go.uber.org/zap"
 )
 
@@ -26,6 +28,14 @@ type ConsumerGroup struct {
 	wg      *sync.WaitGroup // WaitGroup 用于同步，确保在关闭时等待消费循环 goroutine 安全退出。
 	logger  *core.ZapLogger // 注入的 Logger 实例，用于结构化日志记录。
 	groupID string          // 存储消费者组的 Group ID，主要用于日志记录，方便追踪。
+	running atomic.Bool     // 消费循环 goroutine 是否在运行，供 Status 诊断接口读取。
+
+	paused   atomic.Bool               // 是否已通过 Pause 暂停消费 (见 consumer_pause.go)。
+	pausedAt atomic.Pointer[time.Time] // 最近一次暂停的时间，未暂停时为 nil。
+
+	retryCfg        config.ConsumeRetryConfig // Consume 失败时的退避、告警与退出策略 (见 consumer_restart.go)。
+	consumeFailures atomic.Int64              // Consume 当前的连续失败次数。
+	fatal           chan error                // 连续失败达到退出阈值时发送一个错误，见 Fatal()。
 }
 
 // NewConsumerGroup 初始化并设置 Kafka 消费者组实例。
@@ -75,12 +85,24 @@ func NewConsumerGroup(
 		logger.Error("初始化消费者组失败：订阅的主题列表 (SubscribedTopics) 不能为空")
 		return nil, errors.New("初始化消费者组失败：订阅的主题列表 (SubscribedTopics) 不能为空")
 	}
+	// 重复的主题会让不同的处理器注册到同一个主题上 (后注册的覆盖前者)；
+	// 订阅 DLQ 主题则会把处理失败的消息重新消费、再次失败后又写回 DLQ，形成无限循环。
 	validTopics := make([]string, 0, len(cfg.SubscribedTopics))
+	seenTopics := make(map[string]bool, len(cfg.SubscribedTopics))
 	for _, topic := range cfg.SubscribedTopics {
 		if topic == "" {
 			logger.Error("初始化消费者组失败：订阅的主题列表中包含空主题名称", zap.Strings("configured_topics", cfg.SubscribedTopics))
 			return nil, errors.New("初始化消费者组失败：订阅的主题列表中包含空主题名称")
 		}
+		if seenTopics[topic] {
+			logger.Error("初始化消费者组失败：订阅的主题列表中包含重复的主题", zap.String("topic", topic), zap.Strings("configured_topics", cfg.SubscribedTopics))
+			return nil, fmt.Errorf("初始化消费者组失败：订阅的主题列表中包含重复的主题 '%s'", topic)
+		}
+		if cfg.DLQTopic != "" && topic == cfg.DLQTopic {
+			logger.Error("初始化消费者组失败：死信队列主题 (DLQTopic) 不能出现在订阅的主题列表中", zap.String("dlq_topic", cfg.DLQTopic), zap.Strings("configured_topics", cfg.SubscribedTopics))
+			return nil, fmt.Errorf("初始化消费者组失败：死信队列主题 '%s' 同时出现在订阅的主题列表中，会导致失败消息被循环消费", topic)
+		}
+		seenTopics[topic] = true
 		validTopics = append(validTopics, topic)
 	}
 	logger.Info("消费者将订阅以下主题", zap.Strings("topics", validTopics), zap.String("group_id", cfg.GroupID))
@@ -107,6 +129,9 @@ func NewConsumerGroup(
 		wg:      new(sync.WaitGroup),
 		logger:  logger,
 		groupID: cfg.GroupID,
+
+		retryCfg: normalizeConsumeRetryConfig(cfg.ConsumeRetry),
+		fatal:    make(chan error, 1),
 	}, nil
 }
 
@@ -124,6 +149,8 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 
 	go func() {
 		defer c.wg.Done() // 当 goroutine 退出时，减少 WaitGroup 计数器
+		c.running.Store(true)
+		defer c.running.Store(false)
 		c.logger.Info("消费者组的消费 goroutine 已启动", zap.String("group_id", c.groupID))
 
 		// 为什么使用无限循环?
@@ -133,7 +160,8 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 			// Consume 方法是阻塞的，它会处理与 Broker 的连接、分区分配以及将消息传递给 handler。
 			// 它只在发生不可恢复的错误、上下文被取消或消费者组关闭时返回错误。
 			// 在重平衡 (rebalance) 期间，Consume 可能会正常返回 nil 错误，此时循环会再次调用 Consume 以重新加入消费者组。
-			if err := c.cg.Consume(ctx, c.topics, c.handler); err != nil {
+			// 传入包装后的 handler，使暂停状态在重平衡后新分配的分区上依然生效。
+			if err := c.cg.Consume(ctx, c.topics, pauseAwareHandler{ConsumerGroupHandler: c.handler, group: c}); err != nil {
 				// 检查错误类型，以决定是正常退出还是记录错误并重试。
 				if errors.Is(err, sarama.ErrClosedConsumerGroup) || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 					// 这些是预期的错误，通常表示消费者组正在关闭或上下文已被取消。
@@ -143,18 +171,18 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 					)
 					return // 退出 goroutine
 				}
-				// 对于其他类型的错误，可能是暂时的网络问题或 Broker 问题。
-				// 记录错误并尝试在短暂延迟后重试。
-				c.logger.Error("消费者组 Consume 操作出错，将在短暂延迟后重试",
-					zap.String("group_id", c.groupID),
-					zap.Error(err),
-				)
+				// 对于其他类型的错误，可能是暂时的网络问题或 Broker 问题，也可能是不会自行恢复的问题 (例如主题被删除)。
+				// 记录错误并按指数退避等待后重试；连续失败达到退出阈值时停止循环，由 main 优雅关闭进程。
+				backoff, exit := c.recordConsumeFailure(err)
+				if exit {
+					return // 退出 goroutine
+				}
 
 				// 为什么要有重试延迟?
 				// 避免在发生持续性问题时进入快速失败的紧密循环 (tight loop)，这会消耗大量 CPU 和日志资源。
 				// 延迟给予系统恢复的时间。
 				select {
-				case <-time.After(5 * time.Second): // 等待 5 秒
+				case <-time.After(backoff):
 					c.logger.Info("延迟结束，尝试重新执行 Consume 操作", zap.String("group_id", c.groupID))
 				case <-ctx.Done(): // 在延迟期间，如果外部上下文被取消，也应立即退出。
 					c.logger.Info("消费者组在重试延迟期间，上下文被取消，将退出",
@@ -163,6 +191,7 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 					)
 					return // 退出 goroutine
 				}
+				continue // 失败后不清零连续失败次数，直接重新执行 Consume
 			}
 
 			// 为什么在这里再次检查 ctx.Err()?
@@ -177,6 +206,7 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 				return // 退出 goroutine
 			}
 			// 如果 Consume 正常返回 (通常是因为重平衡)，记录信息并继续循环，以便重新加入消费。
+			c.resetConsumeFailures()
 			c.logger.Info("Consume 调用正常结束 (可能发生重平衡)，将重新尝试加入消费", zap.String("group_id", c.groupID))
 		}
 	}()
@@ -213,6 +243,22 @@ func (c *ConsumerGroup) Start(ctx context.Context) {
 	)
 }
 
+// Status 返回消费者组及其 Handler 的运行时配置与状态快照，供诊断接口使用。
+// 如果 handler 提供了 Status() 方法 (例如 *Handler)，其中的重试、DLQ 与会话信息也会包含在内。
+func (c *ConsumerGroup) Status() models.KafkaStatus {
+	var status models.KafkaStatus
+	if provider, ok := c.handler.(interface{ Status() models.KafkaStatus }); ok {
+		status = provider.Status()
+	}
+	status.GroupID = c.groupID
+	status.SubscribedTopics = append([]string(nil), c.topics...)
+	status.Running = c.running.Load()
+	status.ConsecutiveConsumeErrors = c.consumeFailures.Load()
+	status.Paused = c.paused.Load()
+	status.PausedAt = c.pausedAt.Load()
+	return status
+}
+
 // Close 优雅地关闭消费者组。
 // 它会首先尝试关闭底层的 Sarama 消费者组客户端，然后等待所有内部的消费 goroutine 完成。
 // 返回值:
@@ -0,0 +1,111 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Xushengqwer/post_search/config"
+	"go.uber.org/zap"
+)
+
+// consumeRetry 的默认值，与 config.ConsumeRetryConfig 的 default 标签一致。
+const (
+	defaultConsumeInitialBackoff = 5 * time.Second
+	defaultConsumeMaxBackoff     = 5 * time.Minute
+	defaultConsumeAlertThreshold = 5
+)
+
+// ErrConsumeFailedRepeatedly 表示 Consume 连续失败次数达到 consumeRetry.exitAfter，消费循环已停止。
+var ErrConsumeFailedRepeatedly = errors.New("消费者组 Consume 连续失败次数达到退出阈值")
+
+// normalizeConsumeRetryConfig 为未配置或配置无效的字段填充默认值。
+func normalizeConsumeRetryConfig(cfg config.ConsumeRetryConfig) config.ConsumeRetryConfig {
+	if cfg.InitialBackoff <= 0 {
+		cfg.InitialBackoff = defaultConsumeInitialBackoff
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = defaultConsumeMaxBackoff
+	}
+	if cfg.MaxBackoff < cfg.InitialBackoff {
+		cfg.MaxBackoff = cfg.InitialBackoff
+	}
+	if cfg.AlertThreshold <= 0 {
+		cfg.AlertThreshold = defaultConsumeAlertThreshold
+	}
+	if cfg.ExitAfter < 0 {
+		cfg.ExitAfter = 0
+	}
+	return cfg
+}
+
+// consumeBackoff 返回第 failures 次连续失败后的等待时间：InitialBackoff * 2^(failures-1)，不超过 MaxBackoff。
+func consumeBackoff(cfg config.ConsumeRetryConfig, failures int) time.Duration {
+	backoff := cfg.InitialBackoff
+	for i := 1; i < failures && backoff < cfg.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > cfg.MaxBackoff {
+		backoff = cfg.MaxBackoff
+	}
+	return backoff
+}
+
+// Fatal 返回一个通道：Consume 连续失败次数达到 consumeRetry.exitAfter 时，消费循环停止并向其发送一个错误。
+// 调用方 (main) 应据此执行优雅关闭并以非零状态码退出，由 Kubernetes 重启进程。未配置 exitAfter 时永远不会有值。
+func (c *ConsumerGroup) Fatal() <-chan error {
+	return c.fatal
+}
+
+// recordConsumeFailure 记录一次 Consume 失败，返回重试前的等待时间；exit 为 true 表示已达到退出阈值，消费循环应停止。
+// 为什么不再固定等待 5 秒?
+// 有些错误 (例如订阅的主题被删除) 不会自行恢复，固定间隔的重试会无限循环且没有任何告警；
+// 指数退避减少无意义的重试，连续失败次数通过指标暴露，达到阈值时记录告警日志。
+func (c *ConsumerGroup) recordConsumeFailure(err error) (time.Duration, bool) {
+	failures := int(c.consumeFailures.Add(1))
+	consumeErrors.Inc(c.groupID)
+	consumeConsecutiveErrors.Set(int64(failures), c.groupID)
+
+	if c.retryCfg.ExitAfter > 0 && failures >= c.retryCfg.ExitAfter {
+		c.logger.Error("消费者组 Consume 连续失败次数达到退出阈值，停止消费并请求退出进程",
+			zap.String("group_id", c.groupID),
+			zap.Int("consecutive_failures", failures),
+			zap.Int("exit_after", c.retryCfg.ExitAfter),
+			zap.Error(err),
+		)
+		select {
+		case c.fatal <- fmt.Errorf("%w (连续 %d 次): %w", ErrConsumeFailedRepeatedly, failures, err):
+		default:
+		}
+		return 0, true
+	}
+
+	backoff := consumeBackoff(c.retryCfg, failures)
+	fields := []zap.Field{
+		zap.String("group_id", c.groupID),
+		zap.Int("consecutive_failures", failures),
+		zap.Duration("retry_in", backoff),
+		zap.Error(err),
+	}
+	if failures >= c.retryCfg.AlertThreshold {
+		consumeAlerting.Set(1, c.groupID)
+		c.logger.Error("告警：消费者组 Consume 持续失败，消息消费已停滞 (请检查订阅的主题是否存在以及 Broker 状态)",
+			append(fields, zap.Int("alert_threshold", c.retryCfg.AlertThreshold))...)
+	} else {
+		c.logger.Error("消费者组 Consume 操作出错，将在退避等待后重试", fields...)
+	}
+	return backoff, false
+}
+
+// resetConsumeFailures 在 Consume 正常返回后清零连续失败次数。
+func (c *ConsumerGroup) resetConsumeFailures() {
+	failures := c.consumeFailures.Swap(0)
+	if failures == 0 {
+		return
+	}
+	consumeConsecutiveErrors.Set(0, c.groupID)
+	consumeAlerting.Set(0, c.groupID)
+	c.logger.Info("消费者组 Consume 已恢复正常",
+		zap.String("group_id", c.groupID),
+		zap.Int64("previous_consecutive_failures", failures))
+}
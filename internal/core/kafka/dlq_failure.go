@@ -0,0 +1,116 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"go.uber.org/zap"
+)
+
+// defaultDLQFailureWebhookTimeout 是 DLQ 发送失败 webhook 未配置超时时使用的默认值。
+const defaultDLQFailureWebhookTimeout = 5 * time.Second
+
+// DLQFailure 描述一条处理失败且发送到 DLQ 也失败的消息。消息在此之后会被标记为已处理，
+// 因此这是数据丢失前的最后一道防线：凭 Topic/Partition/Offset 可以在 Broker 的保留期内从原主题重新读取该消息。
+type DLQFailure struct {
+	Topic           string    `json:"topic"`                      // 原消息所在主题
+	Partition       int32     `json:"partition"`                  // 原消息所在分区
+	Offset          int64     `json:"offset"`                     // 原消息的 offset
+	Key             string    `json:"key,omitempty"`              // 原消息的 Key
+	MessageTime     time.Time `json:"message_time"`               // 原消息的 Kafka 时间戳
+	ErrorStage      string    `json:"error_stage"`                // 失败阶段，与 DLQ 消息的 dlq_error_stage 头部取值一致
+	ProcessingError string    `json:"processing_error,omitempty"` // 原始处理错误
+	DLQError        string    `json:"dlq_error"`                  // 发送到 DLQ 的错误
+	OccurredAt      time.Time `json:"occurred_at"`                // 发生时间
+}
+
+// DLQFailureHook 在消息发送到 DLQ 失败时被同步调用，实现不应阻塞 (耗时操作应放到独立的 goroutine 中)。
+type DLQFailureHook func(failure DLQFailure)
+
+// SetDLQFailureHook 设置 DLQ 发送失败时的回调 (例如告警 webhook)，nil 表示不回调。
+// 与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetDLQFailureHook(hook DLQFailureHook) {
+	h.dlqFailureHook = hook
+}
+
+// reportDLQFailure 记录 DLQ 发送失败的指标并调用回调。cause 是导致消息需要进入 DLQ 的原始错误。
+func (h *Handler) reportDLQFailure(message *sarama.ConsumerMessage, cause, dlqErr error) {
+	stage := classifyErrorStage(cause)
+	dlqSendFailures.Inc(message.Topic, stage)
+	if h.dlqFailureHook == nil {
+		return
+	}
+	failure := DLQFailure{
+		Topic:       message.Topic,
+		Partition:   message.Partition,
+		Offset:      message.Offset,
+		Key:         string(message.Key),
+		MessageTime: message.Timestamp,
+		ErrorStage:  stage,
+		DLQError:    dlqErr.Error(),
+		OccurredAt:  time.Now(),
+	}
+	if cause != nil {
+		failure.ProcessingError = cause.Error()
+	}
+	h.dlqFailureHook(failure)
+}
+
+// NewDLQFailureWebhook 返回一个把 DLQFailure 以 JSON POST 到配置的 URL 的回调；URL 为空时返回 nil。
+// 请求在独立的 goroutine 中发送，不阻塞消费；发送失败只记录日志 (指标和 Error 日志已经由 Handler 记录)。
+func NewDLQFailureWebhook(cfg config.DLQFailureWebhookConfig, logger *core.ZapLogger) DLQFailureHook {
+	if cfg.URL == "" {
+		return nil
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = defaultDLQFailureWebhookTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	logger.Info("已启用 DLQ 发送失败告警 webhook", zap.Duration("timeout", timeout))
+
+	return func(failure DLQFailure) {
+		go func() {
+			if err := postDLQFailure(client, cfg.URL, failure); err != nil {
+				logger.Error("发送 DLQ 发送失败告警 webhook 失败",
+					zap.String("topic", failure.Topic),
+					zap.Int32("partition", failure.Partition),
+					zap.Int64("offset", failure.Offset),
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+}
+
+// postDLQFailure 发送一次 webhook 请求，非 2xx 响应视为失败。
+func postDLQFailure(client *http.Client, url string, failure DLQFailure) error {
+	body, err := json.Marshal(failure)
+	if err != nil {
+		return fmt.Errorf("序列化 DLQ 失败事件失败: %w", err)
+	}
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("创建 webhook 请求失败: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 webhook 失败: %w", err)
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return errors.New("webhook 返回非 2xx 状态码: " + res.Status)
+	}
+	return nil
+}
@@ -2,12 +2,16 @@ package kafka // 通常 EventService 应该在 service 包下，但根据你提
 
 import (
 	"context"
+	"encoding/json"
 	"errors" // 用于错误检查，例如 errors.Is
 	"fmt"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/Xushengqwer/go-common/models/kafkaevents" // <-- 新增导入
 
 	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
 	// "github.com/Xushengqwer/post_search/internal/models" // <-- 移除或修改，确保不引用旧的 Kafka DTOs
 	"github.com/Xushengqwer/post_search/internal/models" // <-- 仍然需要这个来引用 EsPostDocument
 	"github.com/Xushengqwer/post_search/internal/repositories"
@@ -22,24 +26,64 @@ var (
 	ErrMissingAuthorID    = errors.New("帖子作者ID不能为空") // 如果 AuthorID 是必需的，则定义此错误
 	ErrInvalidPostID      = errors.New("无效的帖子ID")
 	ErrEmptyTitle         = errors.New("帖子标题不能为空")
-	ErrInvalidEventFormat = errors.New("无效的事件格式或缺少关键数据") // 注意：此错误在当前代码片段中已定义但尚未使用，如果需要，请在适当的逻辑中加入。
+	ErrInvalidEventFormat = errors.New("无效的事件格式或缺少关键数据") // 消息体无法反序列化为预期的事件结构时返回 (由 Handler 包装)。
+	ErrFieldTooLong       = errors.New("帖子字段长度超过上限")     // 字段超过 IndexingConfig 中的长度上限且配置为 reject 时返回。
+	ErrUnknownTopic       = errors.New("消息所属主题没有注册处理函数") // Handler 收到未注册主题的消息并转发到 DLQ 时使用。
+	ErrMessageTimeout     = errors.New("消息处理超时")         // 单次处理尝试超过 messageTimeout 时返回 (可重试)。
+	ErrMessageTooLarge    = errors.New("消息体超过大小上限")      // 消息体超过 maxMessageBytes，未做反序列化直接转发到 DLQ 时使用。
 )
 
+// 错误阶段 (error stage) 标识消息在哪个处理步骤失败，写入 DLQ 消息的 dlq_error_stage 头部，
+// 便于排查时按失败类别筛选 DLQ 消息。
+const (
+	ErrorStageDeserialization = "deserialization" // 消息体无法反序列化为事件结构
+	ErrorStageValidation      = "validation"      // 事件数据未通过业务校验
+	ErrorStageIndexing        = "indexing"        // 写入或删除 Elasticsearch 文档失败
+	ErrorStageUnknownTopic    = "unknown_topic"   // 消息所属主题没有注册处理函数 (通常是订阅配置错误)
+	ErrorStageTimeout         = "timeout"         // 每次处理尝试都超过了 messageTimeout
+	ErrorStageOversize        = "oversize"        // 消息体超过 maxMessageBytes，未尝试处理
+)
+
+// classifyErrorStage 根据错误链中的哨兵错误判断消息处理失败的阶段。
+// 未匹配到反序列化或校验类错误时，视为在调用仓库层 (索引/删除) 时失败。
+func classifyErrorStage(err error) string {
+	var syntaxError *json.SyntaxError
+	var unmarshalTypeError *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, ErrUnknownTopic):
+		return ErrorStageUnknownTopic
+	case errors.Is(err, ErrMessageTimeout):
+		return ErrorStageTimeout
+	case errors.Is(err, ErrMessageTooLarge):
+		return ErrorStageOversize
+	case errors.Is(err, ErrInvalidEventFormat), errors.As(err, &syntaxError), errors.As(err, &unmarshalTypeError):
+		return ErrorStageDeserialization
+	case errors.Is(err, ErrInvalidPostID), errors.Is(err, ErrEmptyTitle), errors.Is(err, ErrMissingAuthorID), errors.Is(err, ErrFieldTooLong),
+		errors.Is(err, repositories.ErrUnpatchableField):
+		return ErrorStageValidation
+	default:
+		return ErrorStageIndexing
+	}
+}
+
 // EventService 封装了处理与帖子相关的 Kafka 事件的业务逻辑。
 // 它依赖于 PostRepository 与 Elasticsearch 进行交互。
 type EventService struct {
-	postRepo repositories.PostRepository // postRepo 存储了与帖子数据持久化相关的操作接口。
-	logger   *core.ZapLogger             // logger 用于结构化日志记录。
+	postRepo     repositories.PostRepository // postRepo 存储了与帖子数据持久化相关的操作接口。
+	indexingCfg  config.IndexingConfig       // indexingCfg 定义写入前对标题、正文长度的限制。
+	statusPolicy statusPolicy                // statusPolicy 决定各状态的帖子是写入还是从索引中删除 (见 status_policy.go)。
+	logger       *core.ZapLogger             // logger 用于结构化日志记录。
 }
 
 // NewEventService 创建 EventService 的新实例。
 // 参数:
 //   - postRepo: 实现了 PostRepository 接口的实例，用于与帖子数据存储交互。
+//   - indexingCfg: 索引写入配置，定义标题、正文的长度上限及超限处理方式。
 //   - logger: ZapLogger 实例，用于日志记录。
 //
 // 注意：如果关键依赖项 (postRepo, logger) 为 nil，此函数会 panic，
 // 因为服务在这种情况下无法正常运行。这是一种快速失败的策略，防止服务以损坏状态启动。
-func NewEventService(postRepo repositories.PostRepository, logger *core.ZapLogger) *EventService {
+func NewEventService(postRepo repositories.PostRepository, indexingCfg config.IndexingConfig, logger *core.ZapLogger) *EventService {
 	if postRepo == nil {
 		// 对于服务启动时的关键依赖，如果缺失，则 panic 以阻止服务以不正确状态运行。
 		panic("致命错误 [事件服务]: PostRepository 依赖注入失败，实例不能为 nil")
@@ -48,22 +92,56 @@ func NewEventService(postRepo repositories.PostRepository, logger *core.ZapLogge
 		panic("致命错误 [事件服务]: ZapLogger 依赖注入失败，实例不能为 nil")
 	}
 	return &EventService{
-		postRepo: postRepo,
-		logger:   logger,
+		postRepo:     postRepo,
+		indexingCfg:  indexingCfg,
+		statusPolicy: newStatusPolicy(indexingCfg.StatusPolicy, nil, logger),
+		logger:       logger,
 	}
 }
 
-// HandlePostApprovedEvent 处理帖子审核通过的 Kafka 事件 (替换 HandlePostAuditEvent)
+// HandlePostApprovedEvent 处理帖子审核通过的 Kafka 事件。
 // 它会验证事件数据，将其转换为 Elasticsearch 文档模型，然后调用仓库层进行索引。
 // 参数:
 //   - ctx: 上下文，用于控制超时和取消。
 //   - event: 从 Kafka 消费到的帖子审核通过事件数据 (类型已更新为 kafkaevents.PostApprovedEvent)。
+//   - tags: 帖子标签 (来自消息中的 post.tags，共享事件结构尚未包含该字段)，可为空。
+//   - expiresAt: 帖子过期时间 (来自消息中的 post.expires_at，秒或毫秒)，0 表示永不过期。
+//   - contactQRCode: 联系方式二维码 (来自消息中的 post.contact_qr_code)，可为空。
 //
 // 返回值:
 //   - error: 如果处理过程中发生错误（如验证失败、索引失败），则返回错误。
 //     返回的错误可能包装了预定义的哨兵错误（如 ErrInvalidPostID, ErrEmptyTitle），
 //     以便上层调用者可以进行类型检查。
-func (s *EventService) HandlePostApprovedEvent(ctx context.Context, event *kafkaevents.PostApprovedEvent) error {
+func (s *EventService) HandlePostApprovedEvent(ctx context.Context, event *kafkaevents.PostApprovedEvent, tags []string, expiresAt int64, contactQRCode string) error {
+	postDoc, err := s.preparePostApprovedDocument(ctx, event, tags, expiresAt, contactQRCode)
+	if err != nil || postDoc == nil {
+		return err
+	}
+
+	// --- 调用 Elasticsearch 仓库操作 ---
+	// 尝试将帖子文档索引到 Elasticsearch。
+	err = s.postRepo.IndexPost(ctx, *postDoc)
+	if err != nil {
+		s.logger.Error("调用 PostRepository 的 IndexPost 操作失败",
+			zap.String("event_id", event.EventID),
+			zap.Uint64("post_id", postDoc.ID),
+			// zap.Any("post_document", postDoc), // 记录尝试索引的文档内容，有助于调试 (可能含敏感信息，按需开启)
+			zap.Error(err), // 记录底层的具体错误信息
+		)
+		// 将底层错误包装后向上传递。
+		// 上层调用者（Kafka 消费者处理器）可以根据此错误决定是否重试或发送到 DLQ。
+		return fmt.Errorf("索引帖子 ID '%d' 到 Elasticsearch 失败: %w", postDoc.ID, err)
+	}
+
+	s.logger.Info("成功处理并索引帖子审核通过事件",
+		zap.String("event_id", event.EventID),
+		zap.Uint64("post_id", postDoc.ID))
+	return nil // 表示成功处理
+}
+
+// preparePostApprovedDocument 校验审核通过事件并生成待写入的帖子文档，单条处理与批量索引 (bulk_consumer.go) 共用。
+// 事件已在此处处理完毕 (状态策略跳过或删除、只更新了浏览量) 时返回 nil 文档与 nil 错误，调用方无需再写入。
+func (s *EventService) preparePostApprovedDocument(ctx context.Context, event *kafkaevents.PostApprovedEvent, tags []string, expiresAt int64, contactQRCode string) (*models.EsPostDocument, error) {
 	// 2. 从 event.Post 中获取核心数据
 	postData := event.Post
 	s.logger.Info("开始处理帖子审核通过事件 (PostApprovedEvent)",
@@ -81,7 +159,7 @@ func (s *EventService) HandlePostApprovedEvent(ctx context.Context, event *kafka
 			zap.String("校验规则", "ID 必须大于 0"),
 		)
 		// 返回包装后的哨兵错误，指明这是一个永久性错误。
-		return fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 无效: %w", postData.ID, ErrInvalidPostID)
+		return nil, fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 无效: %w", postData.ID, ErrInvalidPostID)
 	}
 	if postData.Title == "" {
 		s.logger.Error("处理 PostApprovedEvent 失败：事件中的帖子标题为空",
@@ -89,19 +167,54 @@ func (s *EventService) HandlePostApprovedEvent(ctx context.Context, event *kafka
 			zap.Uint64("post_id", postData.ID),
 		)
 		// 返回包装后的哨兵错误。
-		return fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 的标题为空: %w", postData.ID, ErrEmptyTitle)
+		return nil, fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 的标题为空: %w", postData.ID, ErrEmptyTitle)
 	}
 	// 可以在此处添加对 event.Post 其他关键字段的验证，例如 AuthorID 等。
 	// if postData.AuthorID == "" { ... return fmt.Errorf("...: %w", ErrMissingAuthorID) }
 
+	// --- HTML 标签清理 ---
+	// 在长度限制之前执行，使长度上限按清理后的纯文本计算。
+	title, content := postData.Title, postData.Content
+	var titleRaw, contentRaw string
+	if stripCfg := s.indexingCfg.StripHTML; stripCfg.Enabled {
+		content = stripHTML(content)
+		if stripCfg.PreserveOriginal && content != postData.Content {
+			contentRaw = postData.Content
+		}
+		if stripCfg.StripTitle {
+			title = stripHTML(title)
+			if stripCfg.PreserveOriginal && title != postData.Title {
+				titleRaw = postData.Title
+			}
+			if title == "" {
+				s.logger.Error("处理 PostApprovedEvent 失败：帖子标题去除 HTML 标签后为空",
+					zap.String("event_id", event.EventID),
+					zap.Uint64("post_id", postData.ID),
+				)
+				return nil, fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 的标题去除 HTML 标签后为空: %w", postData.ID, ErrEmptyTitle)
+			}
+		}
+	}
+
+	// --- 字段长度限制 ---
+	// 超长的标题或正文会撑大索引并拖慢高亮；按配置截断，或拒绝该事件 (进入 DLQ)。
+	title, err := s.applyFieldLengthLimit(event.EventID, postData.ID, "title", title, s.indexingCfg.Title)
+	if err != nil {
+		return nil, err
+	}
+	content, err = s.applyFieldLengthLimit(event.EventID, postData.ID, "content", content, s.indexingCfg.Content)
+	if err != nil {
+		return nil, err
+	}
+
 	// --- 数据转换/映射 ---
 	// 将从 Kafka 事件模型 (kafkaevents.PostData) 转换为 Elasticsearch 文档模型 (models.EsPostDocument)。
 	// 这样做可以解耦事件的格式和存储的格式。
 	postDoc := models.EsPostDocument{
 		ID:             postData.ID,
-		Title:          postData.Title,
-		Content:        postData.Content,
-		AuthorID:       postData.AuthorID,
+		Title:          title,
+		Content:        content,
+		AuthorID:       models.NormalizeAuthorID(postData.AuthorID, s.indexingCfg.LowercaseAuthorID), // 与搜索侧的 author_id 筛选使用相同的规范化
 		AuthorAvatar:   postData.AuthorAvatar,
 		AuthorUsername: postData.AuthorUsername,
 		Status:         postData.Status, // 直接使用 common/enums.Status 类型
@@ -109,33 +222,53 @@ func (s *EventService) HandlePostApprovedEvent(ctx context.Context, event *kafka
 		OfficialTag:    postData.OfficialTag, // 直接使用 common/enums.OfficialTag 类型
 		PricePerUnit:   postData.PricePerUnit,
 		ContactInfo:    postData.ContactInfo,
-		// UpdatedAt: time.Now(), // 通常 ES 会自动处理时间戳，或者从事件中获取。
-		// kafkaevents.PostData 包含 CreatedAt 和 UpdatedAt (int64)，可以按需映射到 EsPostDocument
-		// 例如: EsPostDocument 如果有 CreatedAt int64 字段，则： postDoc.CreatedAt = postData.CreatedAt
+		ContactQRCode:  strings.TrimSpace(contactQRCode),
+		CreatedAt:      normalizeEpochMillis(postData.CreatedAt), // 统一为毫秒，与索引映射中的 epoch_millis 格式一致
+		Tags:           normalizeTags(tags),
+		ExpiresAt:      normalizeEpochMillis(expiresAt),
+		TitleRaw:       titleRaw,
+		ContentRaw:     contentRaw,
+		// UpdatedAt 由 PostRepository.IndexPost 在写入时刷新，这里无需设置。
 	}
 	s.logger.Debug("已将 Kafka 事件数据映射到 EsPostDocument 模型",
 		zap.String("event_id", event.EventID),
 		zap.Uint64("post_id", postData.ID))
 
-	// --- 调用 Elasticsearch 仓库操作 ---
-	// 尝试将帖子文档索引到 Elasticsearch。
-	err := s.postRepo.IndexPost(ctx, postDoc)
+	// --- 状态索引策略 ---
+	// searchableOnly 策略下，不可搜索状态的帖子不写入索引；已在索引中的会被删除 (例如从审核通过退回待审核)。
+	// 两种情况都先暂存完整文档，之后的部分更新事件恢复可搜索状态时据此完整重新索引。
+	action, err := s.resolveStatusAction(ctx, event.EventID, postData.ID, postData.Status)
 	if err != nil {
-		s.logger.Error("调用 PostRepository 的 IndexPost 操作失败",
+		return nil, err
+	}
+	switch action {
+	case statusActionSkip:
+		return nil, s.parkPost(ctx, event.EventID, postDoc)
+	case statusActionDelete:
+		if err := s.parkPost(ctx, event.EventID, postDoc); err != nil {
+			return nil, err
+		}
+		if err := s.postRepo.DeletePost(ctx, postData.ID); err != nil {
+			return nil, fmt.Errorf("按状态策略从 Elasticsearch 删除帖子 ID '%d' 失败: %w", postData.ID, err)
+		}
+		s.logger.Info("帖子状态变为不可搜索，已从索引中删除",
 			zap.String("event_id", event.EventID),
-			zap.Uint64("post_id", postData.ID),
-			// zap.Any("post_document", postDoc), // 记录尝试索引的文档内容，有助于调试 (可能含敏感信息，按需开启)
-			zap.Error(err), // 记录底层的具体错误信息
-		)
-		// 将底层错误包装后向上传递。
-		// 上层调用者（Kafka 消费者处理器）可以根据此错误决定是否重试或发送到 DLQ。
-		return fmt.Errorf("索引帖子 ID '%d' 到 Elasticsearch 失败: %w", postData.ID, err)
+			zap.Uint64("post_id", postData.ID))
+		return nil, nil
 	}
 
-	s.logger.Info("成功处理并索引帖子审核通过事件",
-		zap.String("event_id", event.EventID),
-		zap.Uint64("post_id", postData.ID))
-	return nil // 表示成功处理
+	// --- 仅浏览量变化时部分更新 ---
+	// 审核服务会用完整的审核事件推送浏览量变化；此时只更新 view_count，避免整体重新索引与内容更新相互覆盖。
+	if s.indexingCfg.ViewCountOnlyPatch {
+		patched, err := s.tryPatchViewCount(ctx, event.EventID, postDoc)
+		if err != nil {
+			return nil, err
+		}
+		if patched {
+			return nil, nil
+		}
+	}
+	return &postDoc, nil
 }
 
 // HandlePostDeleteEvent 处理帖子删除的 Kafka 事件。
@@ -176,9 +309,205 @@ func (s *EventService) HandlePostDeleteEvent(ctx context.Context, event *kafkaev
 		)
 		return fmt.Errorf("从 Elasticsearch 删除帖子 ID '%d' 失败: %w", event.PostID, err)
 	}
+	// searchableOnly 策略下帖子可能有暂存副本，一并删除，避免之后的部分更新事件把已删除的帖子恢复到索引中。
+	if s.statusPolicy.searchableOnly {
+		if err := s.postRepo.DeleteParkedPost(ctx, event.PostID); err != nil {
+			s.logger.Error("删除帖子的暂存副本失败",
+				zap.String("event_id", event.EventID),
+				zap.Uint64("post_id", event.PostID),
+				zap.Error(err),
+			)
+			return fmt.Errorf("删除帖子 ID '%d' 的暂存副本失败: %w", event.PostID, err)
+		}
+	}
 
 	s.logger.Info("成功处理并删除帖子事件",
 		zap.String("event_id", event.EventID),
 		zap.Uint64("post_id", event.PostID))
 	return nil // 表示成功处理
 }
+
+// HandlePostPatchedEvent 处理帖子部分字段变更事件，只更新事件中携带的字段。
+// title/content 同样经过 HTML 标签清理并受字段长度限制约束；字段名不在白名单中或帖子不存在时返回永久性错误 (进入 DLQ)。
+// 携带 status 时与审核事件一样经过状态策略：searchableOnly 策略下新状态不可搜索时暂存并删除帖子，而不是只更新状态字段；
+// 帖子不在索引中但有暂存副本时 (例如恢复为可搜索状态)，合并变化的字段后完整重新索引。
+func (s *EventService) HandlePostPatchedEvent(ctx context.Context, event *models.PostPatchedEvent) error {
+	s.logger.Info("开始处理帖子部分更新事件 (PostPatchedEvent)",
+		zap.String("event_id", event.EventID),
+		zap.Uint64("post_id", event.PostID))
+
+	if event.PostID <= 0 {
+		s.logger.Error("处理 PostPatchedEvent 失败：事件中包含无效的帖子 ID",
+			zap.String("event_id", event.EventID),
+			zap.Uint64("post_id", event.PostID),
+		)
+		return fmt.Errorf("处理帖子部分更新事件失败，帖子 ID '%d' 无效: %w", event.PostID, ErrInvalidPostID)
+	}
+
+	fields := make(map[string]interface{}, len(event.Fields))
+	for name, value := range event.Fields {
+		// 字段名由仓库层按白名单校验；值的类型在这里检查，暂存/恢复等不经过 PatchPost 的路径同样不会写入错误类型的值。
+		if models.PatchableFields[name] {
+			if err := models.CheckPatchValue(name, value); err != nil {
+				return fmt.Errorf("处理帖子部分更新事件失败，帖子 ID '%d' 的%v: %w", event.PostID, err, ErrInvalidEventFormat)
+			}
+		}
+		fields[name] = value
+	}
+	if raw, ok := fields["tags"]; ok {
+		// 与审核事件一致：去除空白、丢弃空标签并去重。值已通过类型检查，必然是字符串数组。
+		var tags []string
+		switch v := raw.(type) {
+		case []string:
+			tags = v
+		case []interface{}:
+			for _, tag := range v {
+				text, _ := tag.(string)
+				tags = append(tags, text)
+			}
+		}
+		if tags = normalizeTags(tags); tags == nil {
+			tags = []string{} // 部分更新中 null 不会清空数组，显式写入空数组。
+		}
+		fields["tags"] = tags
+	}
+	for name, limit := range map[string]config.FieldLengthLimit{"title": s.indexingCfg.Title, "content": s.indexingCfg.Content} {
+		raw, ok := fields[name]
+		if !ok {
+			continue
+		}
+		text, isString := raw.(string)
+		if !isString {
+			return fmt.Errorf("处理帖子部分更新事件失败，字段 %s 必须是字符串: %w", name, ErrInvalidEventFormat)
+		}
+		// 与审核事件相同：先清理 HTML 标签，再检查空标题和长度上限。
+		if stripCfg := s.indexingCfg.StripHTML; stripCfg.Enabled && (name == "content" || stripCfg.StripTitle) {
+			stripped := stripHTML(text)
+			if stripCfg.PreserveOriginal {
+				// 部分更新不会替换整个文档，原文未变化时显式清空 *_raw，避免留下旧原文。
+				if stripped != text {
+					fields[name+"_raw"] = text
+				} else {
+					fields[name+"_raw"] = nil
+				}
+			}
+			text = stripped
+		}
+		if name == "title" && text == "" {
+			return fmt.Errorf("处理帖子部分更新事件失败，帖子 ID '%d' 的标题为空: %w", event.PostID, ErrEmptyTitle)
+		}
+		limited, err := s.applyFieldLengthLimit(event.EventID, event.PostID, name, text, limit)
+		if err != nil {
+			return err
+		}
+		fields[name] = limited
+	}
+
+	if raw, ok := fields["status"]; ok {
+		status, err := patchedStatus(raw)
+		if err != nil {
+			return fmt.Errorf("处理帖子部分更新事件失败，帖子 ID '%d' 的 %v: %w", event.PostID, err, ErrInvalidEventFormat)
+		}
+		if action := s.statusPolicy.decidePatch(status); action == statusActionDelete {
+			s.logger.Info("帖子部分更新后的状态不可搜索，按状态策略从索引中删除",
+				zap.String("event_id", event.EventID),
+				zap.Uint64("post_id", event.PostID),
+				zap.Int("incoming_status", int(status)),
+				zap.String("action", action.String()),
+			)
+			return s.parkPatchedPost(ctx, event.EventID, event.PostID, fields)
+		}
+	}
+
+	if err := s.postRepo.PatchPost(ctx, event.PostID, fields); err != nil {
+		// searchableOnly 策略下帖子不在索引中可能是因为此前被移到了暂存索引 (例如恢复为可搜索状态)。
+		if s.statusPolicy.searchableOnly && errors.Is(err, repositories.ErrPostNotFound) {
+			restored, restoreErr := s.restoreParkedPost(ctx, event.EventID, event.PostID, fields)
+			if restoreErr != nil {
+				return restoreErr
+			}
+			if restored {
+				return nil
+			}
+		}
+		s.logger.Error("调用 PostRepository 的 PatchPost 操作失败",
+			zap.String("event_id", event.EventID),
+			zap.Uint64("post_id", event.PostID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("部分更新帖子 ID '%d' 失败: %w", event.PostID, err)
+	}
+
+	s.logger.Info("成功处理帖子部分更新事件",
+		zap.String("event_id", event.EventID),
+		zap.Uint64("post_id", event.PostID))
+	return nil
+}
+
+// applyFieldLengthLimit 检查字段是否超过长度上限 (按 Unicode 字符计算)。
+// 未超限或未配置上限时原样返回；超限且 OnExceed 为 "reject" 时返回包装了 ErrFieldTooLong 的错误，
+// 否则截断到 MaxLength 个字符并记录警告。
+func (s *EventService) applyFieldLengthLimit(eventID string, postID uint64, field, value string, limit config.FieldLengthLimit) (string, error) {
+	if limit.MaxLength <= 0 {
+		return value, nil
+	}
+	length := utf8.RuneCountInString(value)
+	if length <= limit.MaxLength {
+		return value, nil
+	}
+
+	if strings.EqualFold(limit.OnExceed, "reject") {
+		s.logger.Error("处理 PostApprovedEvent 失败：帖子字段长度超过上限",
+			zap.String("event_id", eventID),
+			zap.Uint64("post_id", postID),
+			zap.String("field", field),
+			zap.Int("length", length),
+			zap.Int("max_length", limit.MaxLength),
+		)
+		return "", fmt.Errorf("处理帖子审核通过事件失败，帖子 ID '%d' 的字段 %s 长度 %d 超过上限 %d: %w",
+			postID, field, length, limit.MaxLength, ErrFieldTooLong)
+	}
+
+	s.logger.Warn("帖子字段长度超过上限，已截断后索引",
+		zap.String("event_id", eventID),
+		zap.Uint64("post_id", postID),
+		zap.String("field", field),
+		zap.Int("length", length),
+		zap.Int("max_length", limit.MaxLength),
+	)
+	return string([]rune(value)[:limit.MaxLength]), nil
+}
+
+// normalizeTags 去除标签两端的空白，丢弃空标签并去重 (保持首次出现的顺序)。
+func normalizeTags(tags []string) []string {
+	if len(tags) == 0 {
+		return nil
+	}
+	seen := make(map[string]struct{}, len(tags))
+	normalized := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		if _, dup := seen[tag]; dup {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// epochMillisThreshold 用于区分秒级与毫秒级的 Unix 时间戳。
+// 1e12 毫秒约为 2001-09-09，而 1e12 秒远在未来，因此小于此值的时间戳可以安全地视为秒级。
+const epochMillisThreshold = 1_000_000_000_000
+
+// normalizeEpochMillis 将上游事件中的时间戳统一转换为 Unix 毫秒。
+// kafkaevents.PostData 未约定时间戳单位 (秒或毫秒)，这里根据数量级自动判断；非正数原样返回 (视为未设置)。
+func normalizeEpochMillis(ts int64) int64 {
+	if ts > 0 && ts < epochMillisThreshold {
+		return ts * 1000
+	}
+	return ts
+}
@@ -0,0 +1,271 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/models/kafkaevents"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+// indexApprovedPost 用 indexingCfg 处理一条帖子数据为 post (状态为审核通过) 的审核通过事件，返回写入索引的文档。
+func indexApprovedPost(t *testing.T, indexingCfg config.IndexingConfig, post kafkaevents.PostData) models.EsPostDocument {
+	t.Helper()
+	repo := &fakePostRepo{events: &eventLog{}}
+	svc := NewEventService(repo, indexingCfg, newTestLogger(t))
+	post.Status = enums.Approved
+	event := &kafkaevents.PostApprovedEvent{EventID: "event-1", Post: post}
+	if err := svc.HandlePostApprovedEvent(context.Background(), event, nil, 0, ""); err != nil {
+		t.Fatalf("HandlePostApprovedEvent 返回错误: %v", err)
+	}
+	if len(repo.indexed) != 1 {
+		t.Fatalf("写入了 %d 个文档, want 1", len(repo.indexed))
+	}
+	return repo.indexed[0]
+}
+
+func TestHandlePostApprovedEventNormalizesAuthorID(t *testing.T) {
+	tests := []struct {
+		name      string
+		authorID  string
+		lowercase bool
+		want      string
+	}{
+		{name: "去除两端空白", authorID: "  Author1\t", want: "Author1"},
+		{name: "未开启小写时保留大小写", authorID: "Author1", want: "Author1"},
+		{name: "开启小写", authorID: " Author1 ", lowercase: true, want: "author1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := indexApprovedPost(t, config.IndexingConfig{LowercaseAuthorID: tt.lowercase}, kafkaevents.PostData{ID: 1, Title: "标题", AuthorID: tt.authorID})
+			if doc.AuthorID != tt.want {
+				t.Errorf("AuthorID = %q, want %q", doc.AuthorID, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePostApprovedEventStripsHTML(t *testing.T) {
+	const (
+		htmlTitle   = "<b>出售</b> Kafka 书籍"
+		htmlContent = "<p>九成新</p><p>可小刀 &amp; 包邮</p><script>track()</script>"
+	)
+	tests := []struct {
+		name           string
+		strip          config.StripHTMLConfig
+		title, content string
+		want           models.EsPostDocument
+	}{
+		{
+			name:    "未开启时原样写入",
+			title:   htmlTitle,
+			content: htmlContent,
+			want:    models.EsPostDocument{Title: htmlTitle, Content: htmlContent},
+		},
+		{
+			name:    "默认只清理正文",
+			strip:   config.StripHTMLConfig{Enabled: true},
+			title:   htmlTitle,
+			content: htmlContent,
+			want:    models.EsPostDocument{Title: htmlTitle, Content: "九成新 可小刀 &amp; 包邮"},
+		},
+		{
+			name:    "同时清理标题",
+			strip:   config.StripHTMLConfig{Enabled: true, StripTitle: true},
+			title:   htmlTitle,
+			content: htmlContent,
+			want:    models.EsPostDocument{Title: "出售 Kafka 书籍", Content: "九成新 可小刀 &amp; 包邮"},
+		},
+		{
+			name:    "保留原文",
+			strip:   config.StripHTMLConfig{Enabled: true, StripTitle: true, PreserveOriginal: true},
+			title:   htmlTitle,
+			content: htmlContent,
+			want: models.EsPostDocument{
+				Title: "出售 Kafka 书籍", Content: "九成新 可小刀 &amp; 包邮",
+				TitleRaw: htmlTitle, ContentRaw: htmlContent,
+			},
+		},
+		{
+			name:    "原文没有变化时不写入 raw 字段",
+			strip:   config.StripHTMLConfig{Enabled: true, StripTitle: true, PreserveOriginal: true},
+			title:   "出售 Kafka 书籍",
+			content: "九成新",
+			want:    models.EsPostDocument{Title: "出售 Kafka 书籍", Content: "九成新"},
+		},
+		{
+			name:    "未开启 StripTitle 时不保留标题原文",
+			strip:   config.StripHTMLConfig{Enabled: true, PreserveOriginal: true},
+			title:   htmlTitle,
+			content: htmlContent,
+			want:    models.EsPostDocument{Title: htmlTitle, Content: "九成新 可小刀 &amp; 包邮", ContentRaw: htmlContent},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := indexApprovedPost(t, config.IndexingConfig{StripHTML: tt.strip}, kafkaevents.PostData{ID: 1, Title: tt.title, Content: tt.content, AuthorID: "author1"})
+			got := models.EsPostDocument{Title: doc.Title, Content: doc.Content, TitleRaw: doc.TitleRaw, ContentRaw: doc.ContentRaw}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("文档文本字段 = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePostApprovedEventRejectsTitleEmptyAfterStrip(t *testing.T) {
+	repo := &fakePostRepo{events: &eventLog{}}
+	svc := NewEventService(repo, config.IndexingConfig{StripHTML: config.StripHTMLConfig{Enabled: true, StripTitle: true}}, newTestLogger(t))
+	event := &kafkaevents.PostApprovedEvent{EventID: "event-1", Post: kafkaevents.PostData{
+		ID: 1, Title: "<script>x</script><br>", Content: "正文", AuthorID: "author1", Status: enums.Approved,
+	}}
+
+	err := svc.HandlePostApprovedEvent(context.Background(), event, nil, 0, "")
+	if !errors.Is(err, ErrEmptyTitle) {
+		t.Fatalf("error = %v, want 包装 ErrEmptyTitle", err)
+	}
+	if !isPermanentError(err) {
+		t.Errorf("标题为空应为永久性错误: %v", err)
+	}
+	if len(repo.indexed) != 0 {
+		t.Errorf("不应写入索引: %+v", repo.indexed)
+	}
+}
+
+func TestHandlePostPatchedEventStripsHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		strip   config.StripHTMLConfig
+		fields  map[string]interface{}
+		want    map[string]interface{}
+		wantErr error
+	}{
+		{
+			name:   "未开启时原样更新",
+			fields: map[string]interface{}{"title": "<b>标题</b>", "content": "<p>正文</p>"},
+			want:   map[string]interface{}{"title": "<b>标题</b>", "content": "<p>正文</p>"},
+		},
+		{
+			name:   "默认只清理正文",
+			strip:  config.StripHTMLConfig{Enabled: true},
+			fields: map[string]interface{}{"title": "<b>标题</b>", "content": "<p>正文</p>"},
+			want:   map[string]interface{}{"title": "<b>标题</b>", "content": "正文"},
+		},
+		{
+			name:   "保留原文",
+			strip:  config.StripHTMLConfig{Enabled: true, StripTitle: true, PreserveOriginal: true},
+			fields: map[string]interface{}{"title": "<b>标题</b>", "content": "<p>正文</p>"},
+			want: map[string]interface{}{
+				"title": "标题", "title_raw": "<b>标题</b>",
+				"content": "正文", "content_raw": "<p>正文</p>",
+			},
+		},
+		{
+			name:   "原文没有变化时清空旧的 raw 字段",
+			strip:  config.StripHTMLConfig{Enabled: true, PreserveOriginal: true},
+			fields: map[string]interface{}{"content": "正文", "view_count": 3},
+			want:   map[string]interface{}{"content": "正文", "content_raw": nil, "view_count": 3},
+		},
+		{
+			name:    "清理后标题为空",
+			strip:   config.StripHTMLConfig{Enabled: true, StripTitle: true},
+			fields:  map[string]interface{}{"title": "<style>p{}</style>"},
+			wantErr: ErrEmptyTitle,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePostRepo{events: &eventLog{}}
+			svc := NewEventService(repo, config.IndexingConfig{StripHTML: tt.strip}, newTestLogger(t))
+
+			err := svc.HandlePostPatchedEvent(context.Background(), &models.PostPatchedEvent{EventID: "event-1", PostID: 1, Fields: tt.fields})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want 包装 %v", err, tt.wantErr)
+				}
+				if len(repo.patched) != 0 {
+					t.Errorf("不应发送部分更新: %+v", repo.patched)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HandlePostPatchedEvent 返回错误: %v", err)
+			}
+			if len(repo.patched) != 1 {
+				t.Fatalf("PatchPost 调用了 %d 次, want 1", len(repo.patched))
+			}
+			if !reflect.DeepEqual(repo.patched[0], tt.want) {
+				t.Errorf("部分更新字段 = %#v, want %#v", repo.patched[0], tt.want)
+			}
+			for name := range repo.patched[0] {
+				if !models.PatchableFields[name] {
+					t.Errorf("字段 %q 不在 PatchableFields 白名单中", name)
+				}
+			}
+		})
+	}
+}
+
+func TestHandlePostPatchedEventChecksFieldValues(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  map[string]interface{}
+		want    map[string]interface{}
+		wantErr error
+	}{
+		{
+			name:   "标签与审核事件一样规范化",
+			fields: map[string]interface{}{"tags": []interface{}{" go ", "", "kafka", "go"}},
+			want:   map[string]interface{}{"tags": []string{"go", "kafka"}},
+		},
+		{
+			name:   "规范化后没有标签时写入空数组",
+			fields: map[string]interface{}{"tags": []interface{}{" ", ""}},
+			want:   map[string]interface{}{"tags": []string{}},
+		},
+		{
+			name:    "字符串浏览量",
+			fields:  map[string]interface{}{"view_count": "12"},
+			wantErr: ErrInvalidEventFormat,
+		},
+		{
+			name:    "非整数状态",
+			fields:  map[string]interface{}{"status": "approved"},
+			wantErr: ErrInvalidEventFormat,
+		},
+		{
+			name:    "标签不是数组",
+			fields:  map[string]interface{}{"tags": "go"},
+			wantErr: ErrInvalidEventFormat,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePostRepo{events: &eventLog{}}
+			svc := NewEventService(repo, config.IndexingConfig{}, newTestLogger(t))
+
+			err := svc.HandlePostPatchedEvent(context.Background(), &models.PostPatchedEvent{EventID: "event-1", PostID: 1, Fields: tt.fields})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want 包装 %v", err, tt.wantErr)
+				}
+				if !isPermanentError(err) {
+					t.Errorf("类型错误应为永久性错误: %v", err)
+				}
+				if len(repo.patched) != 0 {
+					t.Errorf("不应发送部分更新: %+v", repo.patched)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("HandlePostPatchedEvent 返回错误: %v", err)
+			}
+			if len(repo.patched) != 1 || !reflect.DeepEqual(repo.patched[0], tt.want) {
+				t.Errorf("部分更新字段 = %#v, want %#v", repo.patched, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,358 @@
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/models/kafkaevents"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+// eventLog 按发生顺序记录测试中各个假依赖被调用的情况，用于断言调用顺序 (例如标记发生在写入之后)。
+type eventLog struct {
+	mu      sync.Mutex
+	entries []string
+}
+
+func (l *eventLog) add(format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.entries = append(l.entries, fmt.Sprintf(format, args...))
+}
+
+func (l *eventLog) all() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.entries...)
+}
+
+// fakePostRepo 记录写入操作的 PostRepository，未覆盖的方法会因嵌入的 nil 接口而 panic。
+type fakePostRepo struct {
+	repositories.PostRepository
+
+	events *eventLog
+	// bulkRespond 决定第 call 次 (从 0 开始) BulkIndexPosts 调用的结果，为 nil 时全部成功。
+	bulkRespond func(call int, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error)
+
+	mu        sync.Mutex
+	indexed   []models.EsPostDocument
+	deleted   []uint64
+	patched   []map[string]interface{}
+	bulkCalls [][]models.EsPostDocument
+}
+
+func (r *fakePostRepo) IndexPost(_ context.Context, doc models.EsPostDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexed = append(r.indexed, doc)
+	if r.events != nil {
+		r.events.add("index %d", doc.ID)
+	}
+	return nil
+}
+
+func (r *fakePostRepo) DeletePost(_ context.Context, postID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.deleted = append(r.deleted, postID)
+	if r.events != nil {
+		r.events.add("delete %d", postID)
+	}
+	return nil
+}
+
+func (r *fakePostRepo) PatchPost(_ context.Context, postID uint64, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.patched = append(r.patched, fields)
+	if r.events != nil {
+		r.events.add("patch %d", postID)
+	}
+	return nil
+}
+
+func (r *fakePostRepo) BulkIndexPosts(_ context.Context, docs []models.EsPostDocument) ([]repositories.BulkItemFailure, error) {
+	r.mu.Lock()
+	call := len(r.bulkCalls)
+	r.bulkCalls = append(r.bulkCalls, append([]models.EsPostDocument(nil), docs...))
+	r.mu.Unlock()
+
+	ids := make([]uint64, len(docs))
+	for i, doc := range docs {
+		ids[i] = doc.ID
+	}
+	if r.events != nil {
+		r.events.add("bulk %v", ids)
+	}
+	if r.bulkRespond == nil {
+		return nil, nil
+	}
+	return r.bulkRespond(call, docs)
+}
+
+// bulkCallIDs 返回每次 BulkIndexPosts 调用中文档的帖子 ID。
+func (r *fakePostRepo) bulkCallIDs() [][]uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	calls := make([][]uint64, len(r.bulkCalls))
+	for i, docs := range r.bulkCalls {
+		for _, doc := range docs {
+			calls[i] = append(calls[i], doc.ID)
+		}
+	}
+	return calls
+}
+
+// memoryPostRepo 是在内存中保存帖子索引与暂存索引的 PostRepository，用于测试状态策略的移出与恢复。
+// 未覆盖的方法会因嵌入的 nil 接口而 panic。
+type memoryPostRepo struct {
+	repositories.PostRepository
+
+	// getErr 不为 nil 时，GetPostsByIDs 与 GetPost 返回该错误 (例如 _mget 中单个文档读取失败)。
+	getErr error
+
+	// beforeConditionalPatch 在每次 PatchPostIfUnchanged 之前调用 (不持有锁)，用于模拟读取之后的并发写入。
+	beforeConditionalPatch func()
+
+	mu                 sync.Mutex
+	indexed            map[uint64]models.EsPostDocument
+	parked             map[uint64]models.EsPostDocument
+	seqNo              map[uint64]int64 // 每次写入索引中的文档时递增，模拟 _seq_no
+	fullIndexes        int
+	conditionalPatches int
+}
+
+func newMemoryPostRepo() *memoryPostRepo {
+	return &memoryPostRepo{
+		indexed: map[uint64]models.EsPostDocument{},
+		parked:  map[uint64]models.EsPostDocument{},
+		seqNo:   map[uint64]int64{},
+	}
+}
+
+func (r *memoryPostRepo) IndexPost(_ context.Context, doc models.EsPostDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.indexed[doc.ID] = doc
+	r.seqNo[doc.ID]++
+	r.fullIndexes++
+	return nil
+}
+
+func (r *memoryPostRepo) DeletePost(_ context.Context, postID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.indexed, postID)
+	return nil
+}
+
+func (r *memoryPostRepo) PatchPost(_ context.Context, postID uint64, fields map[string]interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.indexed[postID]
+	if !ok {
+		return fmt.Errorf("%w: ID %d", repositories.ErrPostNotFound, postID)
+	}
+	merged, err := applyPatchFields(doc, fields)
+	if err != nil {
+		return err
+	}
+	r.indexed[postID] = merged
+	r.seqNo[postID]++
+	return nil
+}
+
+func (r *memoryPostRepo) GetPostsByIDs(_ context.Context, ids []uint64) ([]models.EsPostDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	var docs []models.EsPostDocument
+	for _, id := range ids {
+		if doc, ok := r.indexed[id]; ok {
+			docs = append(docs, doc)
+		}
+	}
+	return docs, nil
+}
+
+func (r *memoryPostRepo) GetPost(_ context.Context, postID uint64) (*repositories.StoredPost, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.getErr != nil {
+		return nil, r.getErr
+	}
+	if doc, ok := r.indexed[postID]; ok {
+		return &repositories.StoredPost{EsPostDocument: doc, SeqNo: r.seqNo[postID], PrimaryTerm: 1}, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryPostRepo) PatchPostIfUnchanged(_ context.Context, current *repositories.StoredPost, fields map[string]interface{}) error {
+	if r.beforeConditionalPatch != nil {
+		r.beforeConditionalPatch()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.conditionalPatches++
+	doc, ok := r.indexed[current.ID]
+	if !ok {
+		return fmt.Errorf("%w: ID %d", repositories.ErrPostNotFound, current.ID)
+	}
+	if r.seqNo[current.ID] != current.SeqNo {
+		return fmt.Errorf("%w: ID %d", repositories.ErrVersionConflict, current.ID)
+	}
+	merged, err := applyPatchFields(doc, fields)
+	if err != nil {
+		return err
+	}
+	r.indexed[current.ID] = merged
+	r.seqNo[current.ID]++
+	return nil
+}
+
+func (r *memoryPostRepo) ParkPost(_ context.Context, doc models.EsPostDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.parked[doc.ID] = doc
+	return nil
+}
+
+func (r *memoryPostRepo) GetParkedPost(_ context.Context, postID uint64) (*models.EsPostDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc, ok := r.parked[postID]; ok {
+		return &doc, nil
+	}
+	return nil, nil
+}
+
+func (r *memoryPostRepo) DeleteParkedPost(_ context.Context, postID uint64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.parked, postID)
+	return nil
+}
+
+// state 返回帖子在索引和暂存索引中的文档 (不存在时为 nil)。
+func (r *memoryPostRepo) state(postID uint64) (indexed, parked *models.EsPostDocument) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc, ok := r.indexed[postID]; ok {
+		indexed = &doc
+	}
+	if doc, ok := r.parked[postID]; ok {
+		parked = &doc
+	}
+	return indexed, parked
+}
+
+// fakeSession 是只实现 Context 与 MarkMessage 的 ConsumerGroupSession。
+type fakeSession struct {
+	sarama.ConsumerGroupSession
+
+	ctx    context.Context
+	events *eventLog
+
+	mu     sync.Mutex
+	marked []int64
+}
+
+func (s *fakeSession) Context() context.Context { return s.ctx }
+
+func (s *fakeSession) MarkMessage(message *sarama.ConsumerMessage, _ string) {
+	s.mu.Lock()
+	s.marked = append(s.marked, message.Offset)
+	s.mu.Unlock()
+	s.events.add("mark %d", message.Offset)
+}
+
+func (s *fakeSession) markedOffsets() []int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int64(nil), s.marked...)
+}
+
+// fakeClaim 是一个分区的 ConsumerGroupClaim，消息来自 messages 通道。
+type fakeClaim struct {
+	sarama.ConsumerGroupClaim
+
+	topic    string
+	messages chan *sarama.ConsumerMessage
+}
+
+func (c *fakeClaim) Topic() string                            { return c.topic }
+func (c *fakeClaim) Partition() int32                         { return 0 }
+func (c *fakeClaim) InitialOffset() int64                     { return 0 }
+func (c *fakeClaim) Messages() <-chan *sarama.ConsumerMessage { return c.messages }
+
+// fakeDLQProducer 记录发送到 DLQ 的消息。
+type fakeDLQProducer struct {
+	sarama.SyncProducer
+
+	events *eventLog
+
+	mu   sync.Mutex
+	sent []*sarama.ProducerMessage
+}
+
+func (p *fakeDLQProducer) SendMessage(message *sarama.ProducerMessage) (int32, int64, error) {
+	p.mu.Lock()
+	p.sent = append(p.sent, message)
+	offset := int64(len(p.sent) - 1)
+	p.mu.Unlock()
+	p.events.add("dlq %s", dlqHeader(message, "dlq_original_offset"))
+	return 0, offset, nil
+}
+
+// sentOffsets 返回发送到 DLQ 的消息对应的原始偏移量。
+func (p *fakeDLQProducer) sentOffsets() []int64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	offsets := make([]int64, 0, len(p.sent))
+	for _, message := range p.sent {
+		offset, _ := strconv.ParseInt(dlqHeader(message, "dlq_original_offset"), 10, 64)
+		offsets = append(offsets, offset)
+	}
+	return offsets
+}
+
+// dlqHeader 返回 DLQ 消息中指定头部的值，不存在时返回空字符串。
+func dlqHeader(message *sarama.ProducerMessage, key string) string {
+	for _, header := range message.Headers {
+		if string(header.Key) == key {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
+
+// approvedMessage 返回一条审核通过事件消息，帖子 ID 为 postID。
+func approvedMessage(t *testing.T, topic string, offset int64, postID uint64) *sarama.ConsumerMessage {
+	t.Helper()
+	event := kafkaevents.PostApprovedEvent{
+		EventID:   fmt.Sprintf("event-%d", offset),
+		Timestamp: time.Now().UTC(),
+		Post: kafkaevents.PostData{
+			ID:       postID,
+			Title:    fmt.Sprintf("帖子 %d", postID),
+			Content:  "正文",
+			AuthorID: "author-1",
+			Status:   enums.Approved,
+		},
+	}
+	value, err := json.Marshal(event)
+	if err != nil {
+		t.Fatalf("序列化审核通过事件失败: %v", err)
+	}
+	return &sarama.ConsumerMessage{Topic: topic, Partition: 0, Offset: offset, Value: value}
+}
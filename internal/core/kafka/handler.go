@@ -5,10 +5,15 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/IBM/sarama" // 或 Shopify/sarama
 	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
 	"github.com/cenkalti/backoff/v4"
 	"go.uber.org/zap"
 
@@ -27,24 +32,51 @@ type Handler struct {
 	dlqProducer    sarama.SyncProducer           // 用于发送消息到死信队列 (DLQ) 的同步生产者。
 	dlqTopic       string                        // 死信队列 (DLQ) 的主题名称。
 	maxRetry       uint64                        // 消息处理的最大重试次数。
+	messageTimeout time.Duration                 // 单条消息每次处理尝试的超时时间。
+	maxMessageSize int                           // 消息体的最大字节数，超出时不做处理直接发送到 DLQ。
+	dlqSendCfg     config.DLQSendConfig          // 发送到 DLQ 的超时与重试设置 (已填充默认值)。
+	schemaPolicy   schemaPolicy                  // 事件缺少预期字段时的处理策略 (见 schema_compat.go)。
+	auditTopic     string                        // 审核通过事件主题，开启批量索引时按批次处理 (见 bulk_consumer.go)。
+	bulk           *config.BulkIndexingConfig    // 批量索引配置 (已填充默认值)，为 nil 时逐条处理。
+	dlqFailureHook DLQFailureHook                // DLQ 发送失败时的回调，可为 nil (见 dlq_failure.go)。
+	tenancy        *config.TenancyConfig         // 多租户配置，为 nil 时不读取租户消息头 (见 tenant.go)。
 	topicToHandler map[string]MessageHandlerFunc // 将主题名称映射到具体的处理函数。
 	ready          chan bool                     // 用于发出 handler 已准备好消费信号的通道。此通道由 Setup 方法关闭。
 	logger         *core.ZapLogger               // 结构化日志记录器。
+
+	// 会话状态，由 Setup/Cleanup 更新，供 Status 诊断接口读取。
+	sessionMu       sync.Mutex
+	inSession       bool
+	memberID        string
+	lastRebalanceAt time.Time
 }
 
+// DLQ 发送设置的默认值，在配置缺失或无效时使用。
+const (
+	defaultDLQSendTimeout       = 10 * time.Second
+	defaultDLQSendRetryInterval = 500 * time.Millisecond
+	defaultMessageTimeout       = 30 * time.Second
+	defaultMaxMessageBytes      = 1024 * 1024
+	// 低于 Broker 默认的 message.max.bytes (约 1MB)，为 DLQ 头部信息预留空间。
+	defaultDLQMaxPayloadBytes = 900 * 1024
+)
+
 // MessageHandlerFunc 定义了处理特定 Kafka 消息的函数的签名。
 // 每个主题的消息处理器都应符合此函数原型。
 type MessageHandlerFunc func(ctx context.Context, message *sarama.ConsumerMessage) error
 
-// NewHandler 创建并初始化一个新的 Kafka 消息处理程序 (Handler) 实例。
+// NewHandler 创建并初始化一个新的 Kafka 消息处理程序 (Handler) 实例，并注册帖子审核通过与帖子删除两个主题。
+// 这是现有部署使用的便捷构造函数；需要处理更多主题时，可以在返回的 Handler 上调用 RegisterTopicHandler，
+// 或者使用 NewCustomHandler 从零开始注册。
 // 参数:
 //   - eventSvc: 业务事件服务 (*EventService) 的实例。
 //   - producer: 用于发送到 DLQ 的 sarama.SyncProducer 实例。
 //   - dlqTopic: 死信队列的主题名称。
-//   - auditTopic: 帖子审计事件的主题名称。 (现在对应 kafkaevents.PostApprovedEvent)
-//   - deleteTopic: 帖子删除事件的主题名称。 (现在对应 kafkaevents.PostDeletedEvent)
+//   - auditTopic: 帖子审核通过事件 (kafkaevents.PostApprovedEvent) 的主题名称。
+//   - deleteTopic: 帖子删除事件 (kafkaevents.PostDeletedEvent) 的主题名称。
 //   - logger: *core.ZapLogger 实例。
 //   - maxRetries: 消息处理的最大重试次数。
+//   - dlqSendCfg: 发送到 DLQ 的超时与重试设置，无效值会被替换为默认值。
 //
 // 返回值:
 //   - *Handler: 初始化完成的消息处理程序实例。
@@ -56,6 +88,25 @@ func NewHandler(
 	deleteTopic string, // 这个 Topic 对应 PostDeletedEvent
 	logger *core.ZapLogger,
 	maxRetries uint64,
+	dlqSendCfg config.DLQSendConfig,
+) *Handler {
+	h := NewCustomHandler(eventSvc, producer, dlqTopic, logger, maxRetries, dlqSendCfg)
+	h.auditTopic = auditTopic
+	h.RegisterTopicHandler(auditTopic, h.handlePostApprovedEvent) // "帖子审计事件" 主题的消息将由 h.handlePostApprovedEvent 方法处理。
+	h.RegisterTopicHandler(deleteTopic, h.handlePostDeleteEvent)  // "帖子删除事件" 主题的消息将由 h.handlePostDeleteEvent 方法处理。
+	return h
+}
+
+// NewCustomHandler 创建一个尚未注册任何主题的 Handler。
+// 调用方通过 RegisterTopicHandler 为每个主题注册处理函数，新增事件类型时无需修改构造函数的参数列表。
+// 参数含义与 NewHandler 相同。
+func NewCustomHandler(
+	eventSvc *EventService,
+	producer sarama.SyncProducer,
+	dlqTopic string,
+	logger *core.ZapLogger,
+	maxRetries uint64,
+	dlqSendCfg config.DLQSendConfig,
 ) *Handler {
 	// 为什么进行这些检查?
 	// 确保核心依赖项已正确提供，否则 Handler 无法正常工作。
@@ -79,31 +130,123 @@ func NewHandler(
 		logger.Warn("DLQ 生产者已提供，但 DLQ 主题未配置。DLQ 功能可能无法正常工作。")
 	}
 
-	h := &Handler{
-		eventService: eventSvc,
-		dlqProducer:  producer,
-		dlqTopic:     dlqTopic,
-		maxRetry:     maxRetries,      // 从参数获取最大重试次数，增强了可配置性。
-		ready:        make(chan bool), // 初始化 ready 通道，用于 Setup 完成的信号。
-		logger:       logger,
+	if dlqSendCfg.Timeout <= 0 {
+		dlqSendCfg.Timeout = defaultDLQSendTimeout
+	}
+	if dlqSendCfg.RetryInterval <= 0 {
+		dlqSendCfg.RetryInterval = defaultDLQSendRetryInterval
+	}
+	if dlqSendCfg.MaxPayloadBytes <= 0 {
+		dlqSendCfg.MaxPayloadBytes = defaultDLQMaxPayloadBytes
 	}
 
-	// 初始化主题到处理函数的映射。
-	// 这种映射方式使得 Handler 能够根据消息来源的主题动态选择正确的处理逻辑，
-	// 方便未来扩展新的主题和对应的处理器。
-	h.topicToHandler = map[string]MessageHandlerFunc{
-		auditTopic:  h.handlePostApprovedEvent, // "帖子审计事件" 主题的消息将由 h.handlePostApprovedEvent 方法处理。
-		deleteTopic: h.handlePostDeleteEvent,   // "帖子删除事件" 主题的消息将由 h.handlePostDeleteEvent 方法处理。
+	h := &Handler{
+		eventService:   eventSvc,
+		dlqProducer:    producer,
+		dlqTopic:       dlqTopic,
+		maxRetry:       maxRetries, // 从参数获取最大重试次数，增强了可配置性。
+		messageTimeout: defaultMessageTimeout,
+		maxMessageSize: defaultMaxMessageBytes,
+		dlqSendCfg:     dlqSendCfg,
+		schemaPolicy:   newSchemaPolicy(config.SchemaCompatibilityConfig{}),
+		// 主题到处理函数的映射，由 RegisterTopicHandler 填充。
+		// 这种映射方式使得 Handler 能够根据消息来源的主题动态选择正确的处理逻辑，
+		// 方便未来扩展新的主题和对应的处理器。
+		topicToHandler: make(map[string]MessageHandlerFunc),
+		ready:          make(chan bool), // 初始化 ready 通道，用于 Setup 完成的信号。
+		logger:         logger,
 	}
+
 	logger.Info("Kafka Handler 初始化完成",
-		zap.Strings("subscribed_topics_for_handler", []string{auditTopic, deleteTopic}), // 记录 Handler 实际配置处理的主题
-		zap.Uint64("max_processing_retries", maxRetries),                                // 记录配置的最大重试次数
-		zap.Bool("dlq_producer_configured", producer != nil),                            // 记录 DLQ 生产者是否配置
-		zap.String("dlq_topic_configured", dlqTopic),                                    // 记录 DLQ 主题是否配置
+		zap.Uint64("max_processing_retries", maxRetries),     // 记录配置的最大重试次数
+		zap.Bool("dlq_producer_configured", producer != nil), // 记录 DLQ 生产者是否配置
+		zap.String("dlq_topic_configured", dlqTopic),         // 记录 DLQ 主题是否配置
+		zap.Duration("dlq_send_timeout", dlqSendCfg.Timeout),
+		zap.Uint64("dlq_send_max_retries", dlqSendCfg.MaxRetries),
+		zap.Int("dlq_max_payload_bytes", dlqSendCfg.MaxPayloadBytes),
+		zap.Bool("dlq_unknown_topics", dlqSendCfg.UnknownTopics),
 	)
 	return h
 }
 
+// RegisterTopicHandler 为指定主题注册消息处理函数；同一主题重复注册时，后注册的函数会覆盖之前的。
+// 注册必须在消费开始 (ConsumerGroup.Start) 之前完成：topicToHandler 在消费期间只读，未加锁保护。
+func (h *Handler) RegisterTopicHandler(topic string, fn MessageHandlerFunc) {
+	if topic == "" || fn == nil {
+		h.logger.Warn("忽略无效的主题处理函数注册：主题名称为空或处理函数为 nil", zap.String("topic", topic))
+		return
+	}
+	if _, exists := h.topicToHandler[topic]; exists {
+		h.logger.Warn("主题已注册过处理函数，将被覆盖", zap.String("topic", topic))
+	}
+	h.topicToHandler[topic] = fn
+	h.logger.Info("已为 Kafka 主题注册消息处理函数", zap.String("topic", topic))
+}
+
+// SetMessageTimeout 设置单条消息每次处理尝试的超时时间，<= 0 时使用默认值 (30s)。
+// 与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetMessageTimeout(timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultMessageTimeout
+	}
+	h.messageTimeout = timeout
+	h.logger.Info("Kafka 消息处理超时时间已设置", zap.Duration("message_timeout", timeout))
+}
+
+// SetMaxMessageBytes 设置消息体的最大字节数，<= 0 时使用默认值 (1MiB)。
+// 与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetMaxMessageBytes(maxBytes int) {
+	if maxBytes <= 0 {
+		maxBytes = defaultMaxMessageBytes
+	}
+	h.maxMessageSize = maxBytes
+	h.logger.Info("Kafka 消息体大小上限已设置", zap.Int("max_message_bytes", maxBytes))
+}
+
+// Topics 返回已注册处理函数的主题列表 (顺序不固定)。
+func (h *Handler) Topics() []string {
+	topics := make([]string, 0, len(h.topicToHandler))
+	for topic := range h.topicToHandler {
+		topics = append(topics, topic)
+	}
+	return topics
+}
+
+// Status 返回 Handler 的运行时配置与会话状态 (消费者组相关字段由 ConsumerGroup.Status 补充)。
+func (h *Handler) Status() models.KafkaStatus {
+	handledTopics := h.Topics()
+	sort.Strings(handledTopics)
+
+	status := models.KafkaStatus{
+		HandledTopics:    handledTopics,
+		MaxRetries:       h.maxRetry,
+		MessageTimeoutMs: h.messageTimeout.Milliseconds(),
+		DLQ: models.KafkaDLQStatus{
+			Topic:              h.dlqTopic,
+			ProducerConfigured: h.dlqProducer != nil,
+			SendTimeoutMs:      h.dlqSendCfg.Timeout.Milliseconds(),
+			SendMaxRetries:     h.dlqSendCfg.MaxRetries,
+			MaxPayloadBytes:    h.dlqSendCfg.MaxPayloadBytes,
+			UnknownTopics:      h.dlqSendCfg.UnknownTopics,
+		},
+	}
+	select {
+	case <-h.ready:
+		status.Ready = true
+	default:
+	}
+
+	h.sessionMu.Lock()
+	status.InSession = h.inSession
+	status.MemberID = h.memberID
+	if !h.lastRebalanceAt.IsZero() {
+		lastRebalanceAt := h.lastRebalanceAt
+		status.LastRebalanceAt = &lastRebalanceAt
+	}
+	h.sessionMu.Unlock()
+	return status
+}
+
 // Ready 返回一个只读通道，用于外部（例如 ConsumerGroup）等待此 Handler 准备就绪。
 // 当 Handler 的 Setup 方法成功完成时，此通道将被关闭，任何监听此通道的 goroutine 将会解除阻塞。
 // 这是实现 ConsumerGroup 等待 Handler 初始化完成的同步机制。
@@ -130,6 +273,11 @@ func (h *Handler) Setup(session sarama.ConsumerGroupSession) error {
 		close(h.ready)
 		h.logger.Info("Kafka Handler 的 ready 通道已成功关闭。", zap.String("member_id", session.MemberID()))
 	}
+	h.sessionMu.Lock()
+	h.inSession = true
+	h.memberID = session.MemberID()
+	h.lastRebalanceAt = time.Now()
+	h.sessionMu.Unlock()
 	h.logger.Info("Kafka Handler Setup 完成，已准备好消费消息。", zap.String("member_id", session.MemberID()))
 	return nil // 返回 nil 表示 Setup 成功。
 }
@@ -142,7 +290,10 @@ func (h *Handler) Cleanup(session sarama.ConsumerGroupSession) error {
 	// 当前设计中，通常 ConsumerGroup 会为每个 Start 调用创建一个新的 Handler 实例，
 	// 或者 ConsumerGroup 的 Start/Close 周期对应 Handler 的完整生命周期。
 	// 如果 Handler 实例在多次重平衡中被 Sarama 内部复用（不常见），则 ready 信号机制可能需要更复杂的处理。
-	// 对于本示例，Cleanup 中没有特别的资源需要释放。
+	// 对于本示例，Cleanup 中没有特别的资源需要释放，只需记录会话已结束。
+	h.sessionMu.Lock()
+	h.inSession = false
+	h.sessionMu.Unlock()
 	h.logger.Info("Kafka Handler Cleanup 完成。", zap.String("member_id", session.MemberID()))
 	return nil // 返回 nil 表示 Cleanup 成功。
 }
@@ -161,6 +312,13 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 		zap.Int64("initial_offset", initialOffset),
 	)
 
+	// 开启批量索引时，审核通过事件按批次通过 _bulk 写入 (见 bulk_consumer.go)。
+	if h.bulk != nil && topic == h.auditTopic {
+		if _, ok := h.topicToHandler[topic]; ok {
+			return h.consumeClaimBulk(session, claim)
+		}
+	}
+
 	// 为什么使用 for-range 循环 claim.Messages()?
 	// `claim.Messages()` 返回一个 `<-chan *sarama.ConsumerMessage`。
 	// for-range 会持续从这个通道接收消息，直到通道被关闭。
@@ -183,14 +341,20 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 		handlerFunc, ok := h.topicToHandler[message.Topic]
 		if !ok {
 			// 如果没有为该主题注册处理函数，这通常表示配置错误或接收到了非预期的消息。
-			// 记录警告并跳过该消息，同时确保标记消息以避免重复消费。
-			h.logger.Warn("未找到针对该主题注册的消息处理函数，将跳过此消息",
-				zap.String("topic", message.Topic),
-				zap.Int64("offset", offset),
-				zap.Int32("partition", message.Partition),
-			)
+			// 根据配置跳过或转发到 DLQ，并且都要标记消息以避免重复消费。
+			h.handleUnknownTopicMessage(message)
 			session.MarkMessage(message, "") // 必须标记，否则 Sarama 会认为此消息未处理。
-			continue                         // 继续处理来自该分区的下一条消息。
+			recordPartitionProgress(message, "skipped")
+			continue // 继续处理来自该分区的下一条消息。
+		}
+
+		// 超大消息在反序列化之前拦截：json.Unmarshal 一个巨大的消息体可能耗尽内存，
+		// 而这类消息重试也不会成功，因此不经过 processWithRetry，直接转发到 DLQ。
+		if len(message.Value) > h.maxMessageSize {
+			h.handleOversizeMessage(message)
+			session.MarkMessage(message, "")
+			recordPartitionProgress(message, "skipped")
+			continue
 		}
 
 		// 使用 processWithRetry 方法处理消息，该方法封装了重试逻辑。
@@ -199,57 +363,8 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 		processingCtx := session.Context()
 		processErr := h.processWithRetry(processingCtx, message, handlerFunc)
 
-		// 根据消息处理的最终结果进行后续操作。
-		if processErr != nil {
-			// 如果在所有重试尝试后消息仍然处理失败，记录错误。
-			h.logger.Error("消息在所有重试尝试后处理失败，准备发送到死信队列 (DLQ)",
-				zap.String("topic", message.Topic),
-				zap.Int64("offset", offset),
-				zap.Int32("partition", message.Partition),
-				zap.Error(processErr), // 记录导致处理失败的根本原因
-			)
-
-			// 尝试将处理失败的消息发送到 DLQ。
-			// 为 DLQ 发送操作创建一个独立的、带超时的上下文，
-			// 避免因 DLQ 生产者阻塞而导致整个消费者卡住。
-			dlqCtx, dlqCancel := context.WithTimeout(context.Background(), 10*time.Second) // 例如，10秒超时
-			dlqErr := SendToDLQ(dlqCtx, h.dlqProducer, h.dlqTopic, message, processErr, h.logger)
-			dlqCancel() // 及时释放 dlqCtx 的资源，无论 SendToDLQ 成功与否。
-
-			if dlqErr != nil {
-				// 如果发送到 DLQ 也失败，这是一个严重问题，可能表示 DLQ 系统本身不可用。
-				// 记录更高级别的错误，并强调需要人工介入。
-				h.logger.Error("发送消息到死信队列 (DLQ) 失败，可能导致消息丢失，需要人工关注！",
-					zap.String("topic", message.Topic),
-					zap.Int64("offset", offset),
-					zap.Int32("partition", message.Partition),
-					zap.NamedError("original_processing_error", processErr), // 记录原始处理错误，便于关联
-					zap.NamedError("dlq_send_error", dlqErr),                // 记录 DLQ 发送错误
-				)
-				// 决策点：即使发送 DLQ 失败，是否仍标记原消息为已处理？
-				// - 标记为已处理：优点是避免阻塞后续消息的处理，保证消费流的继续；缺点是当前消息可能永久丢失。
-				// - 不标记：优点是尝试保留消息（如果错误是暂时的）；缺点是可能导致消息在后续被重复处理（如果消费者重启），或者如果问题持续，消费者会卡在这个消息上。
-				// 通常选择标记并发出严重告警，以保证整体流程的可用性，同时依赖监控和告警来处理丢失的消息。
-				session.MarkMessage(message, "")
-			} else {
-				// 消息成功发送到 DLQ。
-				h.logger.Info("消息已成功发送到死信队列 (DLQ)",
-					zap.String("original_topic", message.Topic),
-					zap.Int64("original_offset", offset),
-					zap.Int32("original_partition", message.Partition),
-					zap.String("dlq_topic", h.dlqTopic),
-				)
-				session.MarkMessage(message, "") // 成功发送到 DLQ 后，标记原始消息为已处理。
-			}
-		} else {
-			// 消息处理成功（可能在某次重试后成功）。
-			session.MarkMessage(message, "") // 标记消息为已处理。
-			h.logger.Debug("消息处理成功",         // 成功处理的日志通常使用 Debug 级别，以减少生产环境日志量
-				zap.String("topic", message.Topic),
-				zap.Int64("offset", offset),
-				zap.Int32("partition", message.Partition),
-			)
-		}
+		// 根据消息处理的最终结果标记消息：失败时先发送到 DLQ。
+		h.finishMessage(session, message, processErr)
 
 		// 在每次消息处理（无论成功、失败或发送到 DLQ）后，检查会话上下文是否已被取消。
 		// 这允许消费者在处理长时间运行的任务时（虽然 Kafka 消息处理通常应设计为快速的）也能及时响应外部的关闭信号。
@@ -273,9 +388,161 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 	return nil // 正常退出 ConsumeClaim 方法，表示此 claim 的处理已完成。
 }
 
+// finishMessage 根据消息处理的最终结果标记消息：processErr 为 nil 时直接标记；
+// 否则先将消息发送到 DLQ，无论发送是否成功都会标记，以免阻塞分区的后续消息。
+// 单条处理与批量索引 (bulk_consumer.go) 共用，保证两条路径的 DLQ 与标记语义一致。
+func (h *Handler) finishMessage(session sarama.ConsumerGroupSession, message *sarama.ConsumerMessage, processErr error) {
+	if processErr != nil {
+		// 如果在所有重试尝试后消息仍然处理失败，记录错误。
+		h.logger.Error("消息在所有重试尝试后处理失败，准备发送到死信队列 (DLQ)",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.Error(processErr), // 记录导致处理失败的根本原因
+		)
+
+		// 尝试将处理失败的消息发送到 DLQ (带有限次数的重试)。
+		dlqErr := h.sendToDLQWithRetry(message, processErr)
+
+		if dlqErr != nil {
+			// 如果发送到 DLQ 也失败，这是一个严重问题，可能表示 DLQ 系统本身不可用。
+			// 记录更高级别的错误，并强调需要人工介入。
+			h.logger.Error("发送消息到死信队列 (DLQ) 失败，可能导致消息丢失，需要人工关注！",
+				zap.String("topic", message.Topic),
+				zap.Int64("offset", message.Offset),
+				zap.Int32("partition", message.Partition),
+				zap.NamedError("original_processing_error", processErr), // 记录原始处理错误，便于关联
+				zap.NamedError("dlq_send_error", dlqErr),                // 记录 DLQ 发送错误
+			)
+			h.reportDLQFailure(message, processErr, dlqErr)
+			// 决策点：即使发送 DLQ 失败，是否仍标记原消息为已处理？
+			// - 标记为已处理：优点是避免阻塞后续消息的处理，保证消费流的继续；缺点是当前消息可能永久丢失。
+			// - 不标记：优点是尝试保留消息（如果错误是暂时的）；缺点是可能导致消息在后续被重复处理（如果消费者重启），或者如果问题持续，消费者会卡在这个消息上。
+			// 通常选择标记并发出严重告警，以保证整体流程的可用性，同时依赖监控和告警来处理丢失的消息。
+			session.MarkMessage(message, "")
+			recordPartitionProgress(message, "dlq_failed")
+		} else {
+			// 消息成功发送到 DLQ。
+			h.logger.Info("消息已成功发送到死信队列 (DLQ)",
+				zap.String("original_topic", message.Topic),
+				zap.Int64("original_offset", message.Offset),
+				zap.Int32("original_partition", message.Partition),
+				zap.String("dlq_topic", h.dlqTopic),
+			)
+			session.MarkMessage(message, "") // 成功发送到 DLQ 后，标记原始消息为已处理。
+			recordPartitionProgress(message, "dlq")
+		}
+	} else {
+		// 消息处理成功（可能在某次重试后成功）。
+		session.MarkMessage(message, "") // 标记消息为已处理。
+		recordPartitionProgress(message, "success")
+		h.logger.Debug("消息处理成功", // 成功处理的日志通常使用 Debug 级别，以减少生产环境日志量
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+		)
+	}
+}
+
+// handleUnknownTopicMessage 处理没有注册处理函数的主题消息。
+// 默认只记录日志并跳过；开启 dlqSend.unknownTopics 后转发到 DLQ (dlq_error_stage=unknown_topic)，避免因订阅配置错误而静默丢失数据。
+// 两种情况都会增加 unknownTopicMessages 计数，便于对配置错误设置告警。
+func (h *Handler) handleUnknownTopicMessage(message *sarama.ConsumerMessage) {
+	if !h.dlqSendCfg.UnknownTopics {
+		unknownTopicMessages.Inc(message.Topic, "skipped")
+		h.logger.Warn("未找到针对该主题注册的消息处理函数，将跳过此消息",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+		)
+		return
+	}
+
+	unknownTopicMessages.Inc(message.Topic, "dlq")
+	unknownErr := fmt.Errorf("%w: '%s'", ErrUnknownTopic, message.Topic)
+	if dlqErr := h.sendToDLQWithRetry(message, unknownErr); dlqErr != nil {
+		h.logger.Error("未注册主题的消息发送到死信队列 (DLQ) 失败，消息将被跳过，需要人工关注！",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.NamedError("dlq_send_error", dlqErr),
+		)
+		h.reportDLQFailure(message, unknownErr, dlqErr)
+		return
+	}
+	h.logger.Warn("未找到针对该主题注册的消息处理函数，消息已转发到死信队列 (DLQ)",
+		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
+		zap.Int32("partition", message.Partition),
+		zap.String("dlq_topic", h.dlqTopic),
+	)
+}
+
+// handleOversizeMessage 将超过大小上限的消息直接转发到 DLQ (dlq_error_stage=oversize)。
+// DLQ 中的消息体会按 dlqSend.maxPayloadBytes 截断，原始大小记录在 dlq_original_payload_bytes 头部。
+func (h *Handler) handleOversizeMessage(message *sarama.ConsumerMessage) {
+	oversizeMessages.Inc(message.Topic)
+	oversizeErr := fmt.Errorf("%w: %d 字节，上限 %d 字节", ErrMessageTooLarge, len(message.Value), h.maxMessageSize)
+	if dlqErr := h.sendToDLQWithRetry(message, oversizeErr); dlqErr != nil {
+		h.logger.Error("超大消息发送到死信队列 (DLQ) 失败，消息将被跳过，需要人工关注！",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.Int("value_length", len(message.Value)),
+			zap.NamedError("dlq_send_error", dlqErr),
+		)
+		h.reportDLQFailure(message, oversizeErr, dlqErr)
+		return
+	}
+	h.logger.Warn("消息体超过大小上限，未做处理，已转发到死信队列 (DLQ)",
+		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
+		zap.Int32("partition", message.Partition),
+		zap.Int("value_length", len(message.Value)),
+		zap.Int("max_message_bytes", h.maxMessageSize),
+		zap.String("dlq_topic", h.dlqTopic),
+	)
+}
+
+// sendToDLQWithRetry 将处理失败的消息发送到 DLQ，并对暂时性的生产者错误进行有限次数的重试。
+// 为什么需要重试?
+// Broker 的短暂抖动 (例如 Leader 切换) 会让单次发送失败，如果不重试，消息会直接落入
+// "需要人工关注" 的路径。重试次数和间隔都较小，避免长时间阻塞当前分区的消费。
+// 每次尝试都使用独立的、带超时的上下文 (基于 context.Background())，
+// 避免因 DLQ 生产者阻塞而导致整个消费者卡住；SendToDLQ 对上下文取消的处理保持不变。
+func (h *Handler) sendToDLQWithRetry(message *sarama.ConsumerMessage, processErr error) error {
+	bo := backoff.NewExponentialBackOff()
+	bo.InitialInterval = h.dlqSendCfg.RetryInterval
+	bo.MaxElapsedTime = 0 // 重试次数由 WithMaxRetries 控制
+
+	operation := func() error {
+		dlqCtx, dlqCancel := context.WithTimeout(context.Background(), h.dlqSendCfg.Timeout)
+		defer dlqCancel() // 及时释放 dlqCtx 的资源，无论 SendToDLQ 成功与否。
+
+		err := SendToDLQ(dlqCtx, h.dlqProducer, h.dlqTopic, message, processErr, h.dlqSendCfg.MaxPayloadBytes, h.logger)
+		if err != nil && errors.Is(err, ErrDLQNotConfigured) {
+			// DLQ 缺少生产者或主题属于配置问题，重试无法恢复。
+			return backoff.Permanent(err)
+		}
+		return err
+	}
+
+	notify := func(err error, next time.Duration) {
+		h.logger.Warn("发送消息到 DLQ 失败，准备重试",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.Duration("next_retry_in", next),
+			zap.Error(err),
+		)
+	}
+
+	return backoff.RetryNotify(operation, backoff.WithMaxRetries(bo, h.dlqSendCfg.MaxRetries), notify)
+}
+
 // processWithRetry 使用指数退避策略执行消息处理函数，并在发生可重试错误时进行重试。
 // 参数:
-//   - ctx: 上下文对象，传递给实际的消息处理函数，用于控制其执行（例如超时或取消）。
+//   - ctx: 上下文对象 (通常是会话上下文)，每次处理尝试都会在其基础上附加 messageTimeout 超时后传递给处理函数。
 //   - message: 当前正在处理的 Kafka 消息。
 //   - handlerFunc: 实际执行消息处理逻辑的函数。
 //
@@ -283,6 +550,18 @@ func (h *Handler) ConsumeClaim(session sarama.ConsumerGroupSession, claim sarama
 //   - error: 如果在所有配置的重试次数后消息处理仍然失败，则返回最后一次遇到的错误。
 //     如果消息处理成功（可能在某次重试后），则返回 nil。
 func (h *Handler) processWithRetry(ctx context.Context, message *sarama.ConsumerMessage, handlerFunc MessageHandlerFunc) error {
+	// 多租户：按消息头选择目标租户索引。租户标识无效时重试没有意义，直接返回 (发送到 DLQ)。
+	ctx, err := h.withMessageTenant(ctx, message)
+	if err != nil {
+		h.logger.Error("消息携带的租户标识无效，不做处理",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.Error(err),
+		)
+		return err
+	}
+
 	// 配置指数退避策略。
 	// NewExponentialBackOff() 创建一个具有默认参数的策略（例如，初始间隔500ms，乘数1.5，随机因子0.5等）。
 	bo := backoff.NewExponentialBackOff()
@@ -301,8 +580,17 @@ func (h *Handler) processWithRetry(ctx context.Context, message *sarama.Consumer
 	// 或者达到最大重试次数/时间。
 	retryableOperation := func() error {
 		// 调用注入的 MessageHandlerFunc 来处理消息。
-		// 将上下文传递给处理函数，使其能够响应外部的取消或超时。
-		err := handlerFunc(ctx, message)
+		// 为什么每次尝试都附加超时?
+		// 会话上下文只有在重平衡或关闭时才会取消，ES 卡住时单条消息可能耗尽整个会话的处理时间，
+		// 阻塞同一分区的后续消息。超时只作用于本次尝试，因此仍会按退避策略重试。
+		attemptCtx, cancel := context.WithTimeout(ctx, h.messageTimeout)
+		err := handlerFunc(attemptCtx, message)
+		cancel()
+		if err != nil && errors.Is(err, context.DeadlineExceeded) && ctx.Err() == nil {
+			// 只有本次尝试的超时触发，会话上下文仍然有效：转换为可重试的 ErrMessageTimeout，
+			// 避免被 isPermanentError 当作会话取消而立即放弃。
+			err = fmt.Errorf("%w (超过 %s): %v", ErrMessageTimeout, h.messageTimeout, err)
+		}
 		if err != nil {
 			// 如果处理函数返回错误，判断该错误是否为永久性错误。
 			// 永久性错误（如数据验证失败、反序列化失败）不应重试，因为重试不太可能成功。
@@ -344,7 +632,7 @@ func (h *Handler) processWithRetry(ctx context.Context, message *sarama.Consumer
 	// 使用配置的退避策略和最大重试次数来执行操作。
 	// backoff.WithMaxRetries 将指数退避与固定的最大重试次数 (h.maxRetry) 结合起来。
 	// RetryNotify 会在每次重试前调用 notifyFunc。
-	err := backoff.RetryNotify(retryableOperation, backoff.WithMaxRetries(bo, h.maxRetry), notifyFunc)
+	err = backoff.RetryNotify(retryableOperation, backoff.WithMaxRetries(bo, h.maxRetry), notifyFunc)
 
 	// 返回重试过程后最终的错误状态。如果所有重试都失败，err 将是最后一次尝试的错误。
 	// 如果某次尝试成功，err 将为 nil。
@@ -353,10 +641,23 @@ func (h *Handler) processWithRetry(ctx context.Context, message *sarama.Consumer
 
 // --- 特定主题的消息处理函数实现 ---
 
-// handlePostApprovedEvent (之前是 handlePostAuditEvent) 是处理 "帖子审计事件" (现在是 "帖子审核通过事件") 主题消息的具体实现。
+// handlePostApprovedEvent 是处理 "帖子审核通过事件" 主题消息的具体实现。
 // 它负责反序列化消息内容为 kafkaevents.PostApprovedEvent，然后调用 EventService 进行处理。
 func (h *Handler) handlePostApprovedEvent(ctx context.Context, message *sarama.ConsumerMessage) error {
-	// 2. 使用从 common 包导入的 kafkaevents.PostApprovedEvent
+	event, ext, err := h.decodePostApprovedEvent(message)
+	if err != nil {
+		return err
+	}
+
+	// 调用 EventService 的方法来处理已反序列化的审核通过事件。
+	// EventService 内部会包含具体的业务逻辑，如数据验证、与 Elasticsearch 交互等。
+	// EventService 返回的错误将被 processWithRetry 进一步判断是否为永久性错误。
+	return h.eventService.HandlePostApprovedEvent(ctx, event, ext.Post.Tags, ext.Post.ExpiresAt, ext.Post.ContactQRCode)
+}
+
+// decodePostApprovedEvent 将消息体反序列化为 kafkaevents.PostApprovedEvent 及其扩展字段。
+// 返回的错误均为永久性错误 (backoff.Permanent)，单条处理与批量索引 (bulk_consumer.go) 共用。
+func (h *Handler) decodePostApprovedEvent(message *sarama.ConsumerMessage) (*kafkaevents.PostApprovedEvent, *postEventExtensions, error) {
 	var event kafkaevents.PostApprovedEvent // 准备用于反序列化的事件结构体
 
 	// 尝试将消息的 Value (字节流) 反序列化为 PostApprovedEvent 结构体。
@@ -371,10 +672,25 @@ func (h *Handler) handlePostApprovedEvent(ctx context.Context, message *sarama.C
 			zap.Error(err),
 		)
 		// 使用 backoff.Permanent 包装错误，以避免不必要的重试。
-		return backoff.Permanent(fmt.Errorf("反序列化 PostApprovedEvent 失败 (主题: %s, 偏移量: %d): %w", message.Topic, message.Offset, err))
+		return nil, nil, backoff.Permanent(fmt.Errorf("反序列化 PostApprovedEvent 失败 (主题: %s, 偏移量: %d): %w: %w", message.Topic, message.Offset, ErrInvalidEventFormat, err))
+	}
+
+	// 旧版生产者发送的是扁平结构 (帖子字段位于顶层，没有 post 对象)，json.Unmarshal 不会报错，
+	// 只会得到空的 event.Post，最终表现为令人困惑的 "无效的帖子ID"。这里显式识别并按格式错误处理。
+	if event.Post.ID == 0 && isLegacyFlatPostPayload(message.Value) {
+		h.logger.Error("收到旧版扁平结构的帖子事件，请升级生产者以发送 kafkaevents.PostApprovedEvent",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+		)
+		return nil, nil, backoff.Permanent(fmt.Errorf("帖子事件使用了旧版扁平结构，缺少 post 对象 (主题: %s, 偏移量: %d): %w", message.Topic, message.Offset, ErrInvalidEventFormat))
+	}
+
+	// 上游重命名字段时反序列化不会失败，只会得到零值；按 schema 兼容策略决定是告警后继续还是发送到 DLQ。
+	if err := h.checkPostApprovedSchema(message); err != nil {
+		return nil, nil, err
 	}
 
-	// 日志记录更新以反映新的事件结构
 	h.logger.Debug("成功反序列化 PostApprovedEvent，准备交由 EventService 处理",
 		zap.String("event_id", event.EventID),        // 使用 kafkaevents.PostApprovedEvent 的 EventID
 		zap.Uint64("event_post_id", event.Post.ID),   // 从 event.Post.ID 获取帖子 ID
@@ -383,17 +699,43 @@ func (h *Handler) handlePostApprovedEvent(ctx context.Context, message *sarama.C
 		zap.Int64("offset", message.Offset),
 	)
 
-	// 调用 EventService 的方法来处理已反序列化的审计事件。
-	// EventService 内部会包含具体的业务逻辑，如数据验证、与 Elasticsearch 交互等。
-	// EventService 返回的错误将被 processWithRetry 进一步判断是否为永久性错误。
-	// 注意：eventService.HandlePostApprovedEvent 的签名需要接受 *kafkaevents.PostApprovedEvent
-	return h.eventService.HandlePostApprovedEvent(ctx, &event)
+	// 共享模块的 kafkaevents.PostData 尚未包含 tags、expires_at 字段，这里从同一消息体中单独解析。
+	// 旧版 schema 的消息没有 post.tags，解析结果为空，不影响处理。
+	var ext postEventExtensions
+	if err := json.Unmarshal(message.Value, &ext); err != nil {
+		// 主结构已解析成功，扩展字段格式不符时只记录警告，按无标签、永不过期处理。
+		h.logger.Warn("解析 PostApprovedEvent 的扩展字段 (tags、expires_at) 失败，将忽略这些字段",
+			zap.String("event_id", event.EventID),
+			zap.Error(err),
+		)
+	}
+	return &event, &ext, nil
+}
+
+// isLegacyFlatPostPayload 判断消息体是否为旧版扁平结构的帖子事件：顶层直接包含帖子的 id 和 title，而没有 post 对象。
+func isLegacyFlatPostPayload(value []byte) bool {
+	var probe map[string]json.RawMessage
+	if err := json.Unmarshal(value, &probe); err != nil {
+		return false
+	}
+	_, hasPost := probe["post"]
+	_, hasID := probe["id"]
+	_, hasTitle := probe["title"]
+	return !hasPost && hasID && hasTitle
+}
+
+// postEventExtensions 描述新版帖子事件 schema 中、共享模块 kafkaevents.PostData 尚未定义的字段。
+type postEventExtensions struct {
+	Post struct {
+		Tags          []string `json:"tags"`
+		ExpiresAt     int64    `json:"expires_at"`      // 过期时间 (Unix 秒或毫秒)，0 或缺失表示永不过期。
+		ContactQRCode string   `json:"contact_qr_code"` // 联系方式二维码，缺失表示没有。
+	} `json:"post"`
 }
 
 // handlePostDeleteEvent 是处理 "帖子删除事件" 主题消息的具体实现。
 // 它负责反序列化消息内容为 kafkaevents.PostDeletedEvent，然后调用 EventService 进行处理。
 func (h *Handler) handlePostDeleteEvent(ctx context.Context, message *sarama.ConsumerMessage) error {
-	// 4. 使用从 common 包导入的 kafkaevents.PostDeletedEvent
 	var event kafkaevents.PostDeletedEvent // 准备用于反序列化的事件结构体
 
 	if err := json.Unmarshal(message.Value, &event); err != nil {
@@ -404,13 +746,11 @@ func (h *Handler) handlePostDeleteEvent(ctx context.Context, message *sarama.Con
 			zap.ByteString("raw_value_snippet", message.Value[:min(1024, len(message.Value))]), // 记录片段
 			zap.Error(err),
 		)
-		return backoff.Permanent(fmt.Errorf("反序列化 PostDeleteEvent 失败 (主题: %s, 偏移量: %d): %w", message.Topic, message.Offset, err))
+		return backoff.Permanent(fmt.Errorf("反序列化 PostDeleteEvent 失败 (主题: %s, 偏移量: %d): %w: %w", message.Topic, message.Offset, ErrInvalidEventFormat, err))
 	}
 
-	// 我们统一的 PostDeletedEvent 不再有 Operation 字段，该信息由 Topic 本身承载。
-	// 此处移除了对 event.Operation 的检查。
-
-	// 日志记录更新以反映新的事件结构
+	// PostDeletedEvent 没有 Operation 字段，操作类型由主题本身承载。
+	// 旧版删除事件 ({"operation":"delete","post_id":...}) 的 post_id 字段名相同，仍可正常处理。
 	h.logger.Debug("成功反序列化 PostDeleteEvent，准备交由 EventService 处理",
 		zap.String("event_id", event.EventID),        // 使用 kafkaevents.PostDeletedEvent 的 EventID
 		zap.Uint64("event_post_id", event.PostID),    // 使用 kafkaevents.PostDeletedEvent 的 PostID
@@ -420,28 +760,65 @@ func (h *Handler) handlePostDeleteEvent(ctx context.Context, message *sarama.Con
 	)
 
 	// 调用 EventService 的方法来处理已反序列化的删除事件。
-	// 注意：eventService.HandlePostDeleteEvent 的签名需要接受 *kafkaevents.PostDeletedEvent
 	return h.eventService.HandlePostDeleteEvent(ctx, &event)
 }
 
+// handlePostPatchedEvent 是处理 "帖子部分更新事件" 主题消息的具体实现。
+// 消息体为 models.PostPatchedEvent，只携带需要更新的字段。
+func (h *Handler) handlePostPatchedEvent(ctx context.Context, message *sarama.ConsumerMessage) error {
+	var event models.PostPatchedEvent
+	if err := json.Unmarshal(message.Value, &event); err != nil {
+		h.logger.Error("反序列化 'PostPatchedEvent' 消息失败，数据格式可能不正确或与模型不匹配",
+			zap.String("topic", message.Topic),
+			zap.Int64("offset", message.Offset),
+			zap.Int32("partition", message.Partition),
+			zap.ByteString("raw_value_snippet", message.Value[:min(1024, len(message.Value))]),
+			zap.Error(err),
+		)
+		return backoff.Permanent(fmt.Errorf("反序列化 PostPatchedEvent 失败 (主题: %s, 偏移量: %d): %w: %w", message.Topic, message.Offset, ErrInvalidEventFormat, err))
+	}
+
+	h.logger.Debug("成功反序列化 PostPatchedEvent，准备交由 EventService 处理",
+		zap.String("event_id", event.EventID),
+		zap.Uint64("event_post_id", event.PostID),
+		zap.Int("field_count", len(event.Fields)),
+		zap.String("topic", message.Topic),
+		zap.Int64("offset", message.Offset),
+	)
+	return h.eventService.HandlePostPatchedEvent(ctx, &event)
+}
+
+// PostPatchedHandler 返回处理帖子部分更新事件的函数，供调用方通过 RegisterTopicHandler 注册到对应主题。
+func (h *Handler) PostPatchedHandler() MessageHandlerFunc {
+	return h.handlePostPatchedEvent
+}
+
 // isPermanentError 判断给定的错误是否为永久性错误，即不应进行重试的错误。
-// (注释和逻辑保持不变，但其引用的哨兵错误需要确认来源)
+// 校验类哨兵错误 (ErrInvalidPostID 等) 由 EventService 返回，部分更新相关的错误由 repositories 包返回。
 func isPermanentError(err error) bool {
 	if err == nil {
 		return false // 没有错误，自然不是永久性错误。
 	}
 
 	// 1. 检查上下文相关的错误。
+	// 单次处理尝试超时 (ErrMessageTimeout) 是可重试的；其余的上下文错误来自会话取消，重试没有意义。
+	if errors.Is(err, ErrMessageTimeout) {
+		return false
+	}
 	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
 		return true
 	}
 
-	// 2. 检查由 EventService（或 kafka 包内定义的，如 ErrInvalidPostID 等）产生的已知永久性业务/验证错误。
-	// 这些哨兵错误应该在你的项目中合适的位置定义 (例如，公共错误包或 EventService 包)
+	// 2. 检查由 EventService (event_service.go) 和 repositories 包返回的已知永久性业务/验证错误。
 	if errors.Is(err, ErrInvalidPostID) ||
 		errors.Is(err, ErrEmptyTitle) ||
 		errors.Is(err, ErrMissingAuthorID) ||
-		errors.Is(err, ErrInvalidEventFormat) {
+		errors.Is(err, ErrFieldTooLong) ||
+		errors.Is(err, ErrInvalidEventFormat) ||
+		errors.Is(err, repositories.ErrInvalidTenant) ||
+		errors.Is(err, repositories.ErrUnpatchableField) ||
+		errors.Is(err, repositories.ErrInvalidPatchValue) ||
+		errors.Is(err, repositories.ErrPostNotFound) {
 		return true
 	}
 
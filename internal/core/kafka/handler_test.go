@@ -0,0 +1,188 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"github.com/cenkalti/backoff/v4"
+)
+
+// newTestHandler 返回使用假仓库的 Handler (不发送 DLQ)。
+func newTestHandler(t *testing.T, indexingCfg config.IndexingConfig) (*Handler, *fakePostRepo) {
+	t.Helper()
+	logger := newTestLogger(t)
+	repo := &fakePostRepo{events: &eventLog{}}
+	return NewHandler(NewEventService(repo, indexingCfg, logger), nil, "", testApprovedTopic, testDeletedTopic, logger, 0, config.DLQSendConfig{}), repo
+}
+
+// approvedEventPayload 是帖子服务实际发送的审核通过事件消息体 (kafkaevents.PostApprovedEvent 加扩展字段)。
+const approvedEventPayload = `{
+	"event_id": "4c1f6f0e-0d5c-4a43-9a0c-2b7d1f0e9a11",
+	"timestamp": "2025-06-01T08:00:00+08:00",
+	"post": {
+		"id": 42,
+		"title": "出售二手 Kafka 书籍",
+		"content": "九成新，可小刀",
+		"author_id": "author42",
+		"author_avatar": "https://cdn.example.com/a/42.png",
+		"author_username": "xushen",
+		"status": 1,
+		"view_count": 17,
+		"official_tag": 0,
+		"price_per_unit": 25.5,
+		"contact_info": "wx:xushen",
+		"created_at": 1748736000,
+		"updated_at": 1748736000000,
+		"images": [{"image_url": "https://cdn.example.com/p/42-1.png", "display_order": 1}],
+		"tags": [" kafka", "books", "kafka"],
+		"expires_at": 1767225600,
+		"contact_qr_code": " https://cdn.example.com/qr/42.png "
+	}
+}`
+
+func TestHandlePostApprovedEventDecodesPayload(t *testing.T) {
+	h, repo := newTestHandler(t, config.IndexingConfig{})
+
+	message := &sarama.ConsumerMessage{Topic: testApprovedTopic, Offset: 3, Value: []byte(approvedEventPayload)}
+	if err := h.handlePostApprovedEvent(context.Background(), message); err != nil {
+		t.Fatalf("handlePostApprovedEvent 返回错误: %v", err)
+	}
+	if len(repo.indexed) != 1 {
+		t.Fatalf("写入了 %d 个文档, want 1", len(repo.indexed))
+	}
+	doc := repo.indexed[0]
+	checks := []struct {
+		field     string
+		got, want interface{}
+	}{
+		{"ID", doc.ID, uint64(42)},
+		{"Title", doc.Title, "出售二手 Kafka 书籍"},
+		{"Content", doc.Content, "九成新，可小刀"},
+		{"AuthorID", doc.AuthorID, "author42"},
+		{"AuthorUsername", doc.AuthorUsername, "xushen"},
+		{"Status", doc.Status, enums.Approved},
+		{"ViewCount", doc.ViewCount, int64(17)},
+		{"PricePerUnit", doc.PricePerUnit, 25.5},
+		{"ContactInfo", doc.ContactInfo, "wx:xushen"},
+		{"CreatedAt", doc.CreatedAt, int64(1748736000000)}, // 秒级时间戳统一为毫秒
+		{"Tags", doc.Tags, []string{"kafka", "books"}},
+		{"ExpiresAt", doc.ExpiresAt, int64(1767225600000)},
+		{"ContactQRCode", doc.ContactQRCode, "https://cdn.example.com/qr/42.png"},
+	}
+	for _, c := range checks {
+		if !reflect.DeepEqual(c.got, c.want) {
+			t.Errorf("%s = %#v, want %#v", c.field, c.got, c.want)
+		}
+	}
+}
+
+func TestHandlePostApprovedEventRejectsInvalidPayloads(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "旧版扁平结构", payload: `{"id": 42, "title": "旧版事件", "content": "正文", "author_id": "author42", "status": 1}`},
+		{name: "JSON 语法错误", payload: `{"event_id": "e1", "post": {`},
+		{name: "字段类型不匹配", payload: `{"event_id": "e1", "post": {"id": "forty-two", "title": "标题"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo := newTestHandler(t, config.IndexingConfig{})
+
+			err := h.handlePostApprovedEvent(context.Background(), &sarama.ConsumerMessage{Topic: testApprovedTopic, Value: []byte(tt.payload)})
+			if !errors.Is(err, ErrInvalidEventFormat) {
+				t.Fatalf("error = %v, want 包装 ErrInvalidEventFormat", err)
+			}
+			var permanent *backoff.PermanentError
+			if !errors.As(err, &permanent) {
+				t.Errorf("error = %v, want backoff.Permanent 包装 (不重试)", err)
+			}
+			if got := classifyErrorStage(err); got != ErrorStageDeserialization {
+				t.Errorf("classifyErrorStage = %q, want %q", got, ErrorStageDeserialization)
+			}
+			if len(repo.indexed) != 0 {
+				t.Errorf("格式错误的事件不应写入索引: %+v", repo.indexed)
+			}
+		})
+	}
+}
+
+func TestIsLegacyFlatPostPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    bool
+	}{
+		{name: "旧版扁平结构", payload: `{"id": 1, "title": "标题", "content": "正文"}`, want: true},
+		{name: "新版结构", payload: `{"event_id": "e1", "post": {"id": 1, "title": "标题"}}`},
+		{name: "顶层和 post 同时存在", payload: `{"id": 1, "title": "标题", "post": {"id": 1}}`},
+		{name: "缺少 title", payload: `{"id": 1, "content": "正文"}`},
+		{name: "删除事件", payload: `{"event_id": "e1", "post_id": 1}`},
+		{name: "不是 JSON 对象", payload: `[1, 2]`},
+		{name: "无效 JSON", payload: `{"id": 1,`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isLegacyFlatPostPayload([]byte(tt.payload)); got != tt.want {
+				t.Errorf("isLegacyFlatPostPayload(%s) = %v, want %v", tt.payload, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlePostDeleteEventAcceptsLegacyPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+	}{
+		{name: "kafkaevents.PostDeletedEvent", payload: `{"event_id": "e1", "timestamp": "2025-06-01T00:00:00Z", "post_id": 7}`},
+		{name: "旧版删除事件", payload: `{"operation": "delete", "post_id": 7}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo := newTestHandler(t, config.IndexingConfig{})
+			if err := h.handlePostDeleteEvent(context.Background(), &sarama.ConsumerMessage{Topic: testDeletedTopic, Value: []byte(tt.payload)}); err != nil {
+				t.Fatalf("handlePostDeleteEvent 返回错误: %v", err)
+			}
+			if want := []uint64{7}; !reflect.DeepEqual(repo.deleted, want) {
+				t.Errorf("删除的帖子 = %v, want %v", repo.deleted, want)
+			}
+		})
+	}
+}
+
+func TestIsPermanentError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil},
+		{name: "未知错误可重试", err: errors.New("connection refused")},
+		{name: "单次处理超时可重试", err: fmt.Errorf("处理失败: %w", ErrMessageTimeout)},
+		{name: "会话取消", err: context.Canceled, want: true},
+		{name: "上下文超时", err: fmt.Errorf("索引失败: %w", context.DeadlineExceeded), want: true},
+		{name: "无效的帖子 ID", err: fmt.Errorf("校验失败: %w", ErrInvalidPostID), want: true},
+		{name: "标题为空", err: fmt.Errorf("校验失败: %w", ErrEmptyTitle), want: true},
+		{name: "缺少作者 ID", err: fmt.Errorf("校验失败: %w", ErrMissingAuthorID), want: true},
+		{name: "字段超长", err: fmt.Errorf("校验失败: %w", ErrFieldTooLong), want: true},
+		{name: "事件格式无效", err: fmt.Errorf("反序列化失败: %w", ErrInvalidEventFormat), want: true},
+		{name: "租户无效", err: fmt.Errorf("解析租户失败: %w", repositories.ErrInvalidTenant), want: true},
+		{name: "字段不允许部分更新", err: fmt.Errorf("部分更新失败: %w", repositories.ErrUnpatchableField), want: true},
+		{name: "部分更新的帖子不存在", err: fmt.Errorf("部分更新失败: %w", repositories.ErrPostNotFound), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isPermanentError(tt.err); got != tt.want {
+				t.Errorf("isPermanentError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package kafka
+
+import (
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// blockElements 是会在文本之间产生换行的块级元素。去掉这些标签时补一个空格，
+// 避免 "<p>foo</p><p>bar</p>" 变成 "foobar" 导致分词错误；行内元素 (b、a、span 等) 直接去掉，不插入空白。
+var blockElements = map[atom.Atom]bool{
+	atom.Address: true, atom.Article: true, atom.Aside: true, atom.Blockquote: true, atom.Br: true,
+	atom.Dd: true, atom.Div: true, atom.Dl: true, atom.Dt: true, atom.Figcaption: true, atom.Figure: true,
+	atom.Footer: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true, atom.H5: true, atom.H6: true,
+	atom.Header: true, atom.Hr: true, atom.Li: true, atom.Main: true, atom.Nav: true, atom.Ol: true, atom.P: true,
+	atom.Pre: true, atom.Section: true, atom.Table: true, atom.Td: true, atom.Th: true, atom.Tr: true, atom.Ul: true,
+}
+
+// droppedElements 的内容不是帖子文本 (脚本、样式、内嵌页面等)，连同内容一起去掉。
+// 这是 "只去掉标签、保留文本" 的唯一例外：这些元素里的文本对搜索没有意义，保留反而会把代码分词写入索引。
+var droppedElements = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true, atom.Iframe: true,
+}
+
+// textEscaper 对输出文本中的 '&'、'<'、'>' 重新转义。分词器会解码实体，
+// 不重新转义的话 "&lt;script&gt;" 会变成字面上的 "<script>"，清理反而制造出标签。
+var textEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;")
+
+// stripHTML 去除 s 中的 HTML 标签、注释和 doctype，返回去掉首尾空白的文本。
+// 使用 HTML5 分词器而非正则解析，因此嵌套、未闭合或不合法的标签都能安全处理。
+// 文本中的实体先解码，再由 textEscaper 重新转义 '&'、'<'、'>'：例如 "&lt;" 和 "&amp;" 保持不变，
+// "&quot;" 解码为 '"'，不构成标签的 '<' (例如 "a < b") 转义为 "&lt;"，因此输出中不会出现标签。
+// droppedElements 中的元素连同其中的文本一起去掉。
+// 不包含 '<'、'>'、'&' 的输入不需要解析，只去掉首尾空白。
+func stripHTML(s string) string {
+	if !strings.ContainsAny(s, "<>&") {
+		return strings.TrimSpace(s)
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	// separate 在写入下一段文本前补一个空格 (前面是块级元素且输出末尾不是空白时)。
+	separate := false
+	dropDepth := 0
+
+	z := html.NewTokenizer(strings.NewReader(s))
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			// 输入结束 (io.EOF)；strings.Reader 不会返回其他错误。
+			return strings.TrimSpace(b.String())
+		case html.TextToken:
+			if dropDepth > 0 {
+				continue
+			}
+			text := z.Text()
+			if len(text) == 0 {
+				continue
+			}
+			if separate && b.Len() > 0 && !endsWithSpace(b.String()) && !isSpace(text[0]) {
+				b.WriteByte(' ')
+			}
+			separate = false
+			textEscaper.WriteString(&b, string(text))
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			name, _ := z.TagName()
+			tag := atom.Lookup(name)
+			if droppedElements[tag] {
+				switch tt {
+				case html.StartTagToken:
+					dropDepth++
+				case html.EndTagToken:
+					if dropDepth > 0 {
+						dropDepth--
+					}
+				}
+				continue
+			}
+			if blockElements[tag] {
+				separate = true
+			}
+		}
+		// 注释、doctype 直接丢弃。
+	}
+}
+
+func endsWithSpace(s string) bool {
+	return s != "" && isSpace(s[len(s)-1])
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,34 @@
+package kafka
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "纯文本只去掉首尾空白", input: "  九成新，可小刀  ", want: "九成新，可小刀"},
+		{name: "带标签的文本同样去掉首尾空白", input: "  <b>九成新</b>，可小刀  ", want: "九成新，可小刀"},
+		{name: "行内标签直接去掉", input: "<b>Go</b>语言<a href=\"/x\">入门</a>", want: "Go语言入门"},
+		{name: "块级标签之间补空格", input: "<p>第一段</p><p>第二段</p>", want: "第一段 第二段"},
+		{name: "br 换行", input: "正文<br/>第二行", want: "正文 第二行"},
+		{name: "已有空白时不重复补空格", input: "<li>一</li>\n<li>二</li>", want: "一\n二"},
+		{name: "实体解码后重新转义", input: "Tom &amp; Jerry &lt;3 &quot;ok&quot; &#60;", want: "Tom &amp; Jerry &lt;3 \"ok\" &lt;"},
+		{name: "转义的标签不会被还原为标签", input: "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>", want: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{name: "只有大于号时同样转义", input: "a > b", want: "a &gt; b"},
+		{name: "script 和 style 连同内容去掉", input: "价格 <script>alert('x')</script>面议<style>p{color:red}</style>", want: "价格 面议"},
+		{name: "注释和 doctype 去掉", input: "<!DOCTYPE html><!-- 注释 -->正文", want: "正文"},
+		{name: "不构成标签的小于号转义后保留", input: "a < b", want: "a &lt; b"},
+		{name: "title 中的标签文本转义后保留", input: "<title><b>标题</b></title>", want: "&lt;b&gt;标题&lt;/b&gt;"},
+		{name: "未闭合的标签", input: "<div>正文<span>未闭合", want: "正文未闭合"},
+		{name: "只有标签时为空", input: "<script>x</script><p></p>", want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripHTML(tt.input); got != tt.want {
+				t.Errorf("stripHTML(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
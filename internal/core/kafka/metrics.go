@@ -0,0 +1,85 @@
+package kafka
+
+import (
+	"strconv"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/post_search/internal/metrics"
+)
+
+// unknownTopicMessages 统计收到的、没有注册处理函数的主题消息数量。
+// 非零值通常意味着订阅的主题列表与 Handler 注册的主题不一致，应配置告警。
+// action 标签取值: skipped (标记后跳过) 或 dlq (已转发到死信队列)。
+var unknownTopicMessages = metrics.NewCounterVec(
+	"post_search_kafka_unknown_topic_messages_total",
+	"Kafka messages received from topics without a registered handler.",
+	"topic", "action",
+)
+
+// oversizeMessages 统计因消息体超过 maxMessageBytes 而未做处理、直接转发到 DLQ 的消息数量。
+var oversizeMessages = metrics.NewCounterVec(
+	"post_search_kafka_oversize_messages_total",
+	"Kafka messages larger than maxMessageBytes, sent to the DLQ without processing.",
+	"topic",
+)
+
+// dlqSendFailures 统计处理失败后发送到 DLQ 也失败的消息数量。这些消息会被标记为已处理，
+// 任何非零增长都意味着潜在的数据丢失，应配置告警。stage 标签与 DLQ 消息的 dlq_error_stage 取值一致。
+var dlqSendFailures = metrics.NewCounterVec(
+	"post_search_kafka_dlq_send_failed_total",
+	"Kafka messages that could not be processed and could not be sent to the DLQ either (potential data loss).",
+	"topic", "stage",
+)
+
+// partitionMessagesProcessed 统计每个分区处理完成 (成功、发送到 DLQ 或跳过后标记) 的消息数量。
+// 按分区计算速率可以发现单个过热的分区；某个分区的速率降为 0 而其他分区正常时，通常是该分区卡住了。
+// result 标签取值: success、dlq (已发送到 DLQ)、dlq_failed (发送 DLQ 也失败)、skipped (未知主题或超大消息)。
+var partitionMessagesProcessed = metrics.NewCounterVec(
+	"post_search_kafka_partition_messages_processed_total",
+	"Kafka messages marked as processed, per topic/partition and result.",
+	"topic", "partition", "result",
+)
+
+// partitionLastMarkedOffset 记录每个分区最后标记 (MarkMessage) 的消息偏移量。
+// 与 Broker 侧的最新偏移量对比可以得到消费延迟；长时间不变则说明该分区的处理卡住了。
+var partitionLastMarkedOffset = metrics.NewGaugeVec(
+	"post_search_kafka_partition_last_marked_offset",
+	"Offset of the last message marked as processed, per topic/partition.",
+	"topic", "partition",
+)
+
+// recordPartitionProgress 在消息被标记后更新分区级指标。
+func recordPartitionProgress(message *sarama.ConsumerMessage, result string) {
+	partition := strconv.FormatInt(int64(message.Partition), 10)
+	partitionMessagesProcessed.Inc(message.Topic, partition, result)
+	partitionLastMarkedOffset.Set(message.Offset, message.Topic, partition)
+}
+
+// bulkItemFailures 统计批量索引 (bulkIndexing) 中写入失败的条目数，同一条目每次重试失败都会计数。
+// kind 标签取值: permanent (不重试，发送到 DLQ) 或 transient (429/5xx，将重试)。
+var bulkItemFailures = metrics.NewCounterVec(
+	"post_search_kafka_bulk_item_failures_total",
+	"Failed items in bulk index requests for post approved events.",
+	"topic", "kind",
+)
+
+// consumeErrors 统计消费者组 Consume 调用返回错误 (不含关闭与上下文取消) 的次数。
+var consumeErrors = metrics.NewCounterVec(
+	"post_search_kafka_consume_errors_total",
+	"Consumer group Consume calls that returned an error and were retried.",
+	"group",
+)
+
+// consumeConsecutiveErrors 记录 Consume 当前的连续失败次数，一次会话正常结束后归零。
+var consumeConsecutiveErrors = metrics.NewGaugeVec(
+	"post_search_kafka_consume_consecutive_errors",
+	"Current number of consecutive failed Consume calls.",
+	"group",
+)
+
+// consumeAlerting 在连续失败次数达到 consumeRetry.alertThreshold 时为 1，恢复后归零，可直接用于告警规则。
+var consumeAlerting = metrics.NewGaugeVec(
+	"post_search_kafka_consume_alerting",
+	"1 while consecutive Consume failures are at or above the alert threshold, 0 otherwise.",
+	"group",
+)
@@ -14,9 +14,14 @@ import (
 	// "log" // 建议移除标准 log 包，统一使用 zap
 )
 
+// ErrDLQNotConfigured 表示 DLQ 发送所需的组件 (生产者、主题或原始消息) 缺失。
+// 这类错误是配置问题而不是暂时性故障，重试无法恢复。
+var ErrDLQNotConfigured = errors.New("DLQ 未正确配置")
+
 // NewSyncProducer 初始化一个 Kafka 同步生产者。
 // 同步生产者在发送消息后会阻塞，直到收到 Broker 的确认（确认级别取决于 Sarama 配置中的 Producer.RequiredAcks）。
 // 这种类型的生产者通常用于发送那些需要确保已成功写入 Kafka 的重要消息，例如发送到 DLQ 的消息。
+// 返回的生产者在底层连接不可恢复地失效后会在下一次发送时自动重建，进程无需重启。
 // 参数:
 //   - cfg: 应用程序的 KafkaConfig 配置，主要用于获取 Broker 地址列表。
 //   - clientConfig: 预先配置好的 Sarama 客户端通用配置对象，它会应用于此生产者。
@@ -54,7 +59,8 @@ func NewSyncProducer(cfg config.KafkaConfig, clientConfig *sarama.Config, logger
 	}
 
 	logger.Info("Kafka 同步生产者初始化成功", zap.Strings("brokers", cfg.Brokers))
-	return producer, nil
+	// 包装为可重建的生产者：底层连接失效后由 SendToDLQ 触发延迟重连 (见 producer_reconnect.go)。
+	return newReconnectingSyncProducer(producer, cfg.Brokers, clientConfig, logger), nil
 }
 
 // SendToDLQ 将处理失败的消息发送到死信队列 (DLQ)。
@@ -66,6 +72,7 @@ func NewSyncProducer(cfg config.KafkaConfig, clientConfig *sarama.Config, logger
 //   - dlqTopic: 死信队列的主题名称。
 //   - originalMessage: 从 Kafka 消费的原始消息，在处理过程中失败。
 //   - processingError: 导致原始消息处理失败的具体错误。
+//   - maxPayloadBytes: DLQ 消息体的最大字节数，超出时截断消息体而不是让发送失败；<= 0 表示不限制。
 //   - logger: 用于结构化日志记录的 ZapLogger 实例。
 //
 // 返回值:
@@ -75,6 +82,7 @@ func SendToDLQ(ctx context.Context,
 	dlqTopic string,
 	originalMessage *sarama.ConsumerMessage,
 	processingError error,
+	maxPayloadBytes int,
 	logger *core.ZapLogger) error {
 
 	// --- 输入参数有效性检查 ---
@@ -86,24 +94,24 @@ func SendToDLQ(ctx context.Context,
 		// 这是一个严重问题，理论上不应该发生如果上游正确注入了 logger。
 		// 此处打印到标准错误输出作为最后的手段，并返回错误。
 		fmt.Println("严重错误: SendToDLQ 函数接收到的 logger 实例为 nil")
-		return errors.New("发送到 DLQ 失败：logger 实例不能为空")
+		return fmt.Errorf("%w: 发送到 DLQ 失败：logger 实例不能为空", ErrDLQNotConfigured)
 	}
 	if producer == nil {
 		logger.Error("发送消息到 DLQ 失败：DLQ 生产者实例 (producer) 为空",
 			zap.String("original_topic", originalMessage.Topic), // originalMessage 可能为 nil，需要检查
 			zap.String("dlq_topic", dlqTopic),
 		)
-		return errors.New("发送到 DLQ 失败：DLQ 生产者实例 (producer) 未配置")
+		return fmt.Errorf("%w: 发送到 DLQ 失败：DLQ 生产者实例 (producer) 未配置", ErrDLQNotConfigured)
 	}
 	if dlqTopic == "" {
 		logger.Error("发送消息到 DLQ 失败：DLQ 主题名称 (dlqTopic) 为空",
 			zap.String("original_topic", originalMessage.Topic), // originalMessage 可能为 nil
 		)
-		return errors.New("发送到 DLQ 失败：DLQ 主题名称 (dlqTopic) 未配置")
+		return fmt.Errorf("%w: 发送到 DLQ 失败：DLQ 主题名称 (dlqTopic) 未配置", ErrDLQNotConfigured)
 	}
 	if originalMessage == nil {
 		logger.Error("发送消息到 DLQ 失败：原始消息 (originalMessage) 为空")
-		return errors.New("发送到 DLQ 失败：原始消息 (originalMessage) 不能为空")
+		return fmt.Errorf("%w: 发送到 DLQ 失败：原始消息 (originalMessage) 不能为空", ErrDLQNotConfigured)
 	}
 
 	// --- 构建消息头部 ---
@@ -117,7 +125,11 @@ func SendToDLQ(ctx context.Context,
 		{Key: []byte("dlq_timestamp_utc"), Value: []byte(time.Now().UTC().Format(time.RFC3339Nano))}, // 强调是 UTC 时间
 	}
 	if processingError != nil {
-		headers = append(headers, sarama.RecordHeader{Key: []byte("dlq_processing_error"), Value: []byte(processingError.Error())})
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte("dlq_processing_error"), Value: []byte(processingError.Error())},
+			// dlq_error_stage 标识失败发生在反序列化、校验、索引阶段，或是主题未注册、处理超时、消息超大，便于按类别筛选 DLQ 消息。
+			sarama.RecordHeader{Key: []byte("dlq_error_stage"), Value: []byte(classifyErrorStage(processingError))},
+		)
 	}
 	if originalMessage.Key != nil {
 		// 保留原始消息的 Key，有助于在 DLQ 中追踪或按 Key 进行特定处理。
@@ -129,12 +141,32 @@ func SendToDLQ(ctx context.Context,
 		headers = append(headers, sarama.RecordHeader{Key: []byte("dlq_original_message_timestamp_utc"), Value: []byte(originalMessage.Timestamp.UTC().Format(time.RFC3339Nano))})
 	}
 
+	// --- 检查消息体大小 ---
+	// 为什么截断而不是直接发送?
+	// 超过 Broker message.max.bytes 的消息会被拒绝，DLQ 发送失败会让原始消息彻底丢失。
+	// 截断后保留头部信息和消息体前缀，至少能让排查人员定位到原始消息 (主题/分区/偏移量)。
+	payload := originalMessage.Value
+	if maxPayloadBytes > 0 && len(payload) > maxPayloadBytes {
+		payload = payload[:maxPayloadBytes]
+		headers = append(headers,
+			sarama.RecordHeader{Key: []byte("dlq_payload_truncated"), Value: []byte("true")},
+			sarama.RecordHeader{Key: []byte("dlq_original_payload_bytes"), Value: []byte(strconv.Itoa(len(originalMessage.Value)))},
+		)
+		logger.Warn("原始消息体超过 DLQ 消息大小上限，DLQ 中保存的是截断后的部分内容，完整消息需从原始主题获取",
+			zap.String("original_topic", originalMessage.Topic),
+			zap.Int32("original_partition", originalMessage.Partition),
+			zap.Int64("original_offset", originalMessage.Offset),
+			zap.Int("original_payload_bytes", len(originalMessage.Value)),
+			zap.Int("truncated_payload_bytes", maxPayloadBytes),
+		)
+	}
+
 	// --- 创建生产者消息 ---
 	dlqMessage := &sarama.ProducerMessage{
-		Topic:   dlqTopic,                                  // 目标是 DLQ 主题。
-		Value:   sarama.ByteEncoder(originalMessage.Value), // 消息体使用原始消息的 Payload。
-		Headers: headers,                                   // 附加上下文头部信息。
-		Key:     sarama.ByteEncoder(originalMessage.Key),   // 保留原始消息的 Key。
+		Topic:   dlqTopic,                                // 目标是 DLQ 主题。
+		Value:   sarama.ByteEncoder(payload),             // 消息体使用原始消息的 Payload (超出上限时为截断后的前缀)。
+		Headers: headers,                                 // 附加上下文头部信息。
+		Key:     sarama.ByteEncoder(originalMessage.Key), // 保留原始消息的 Key。
 		// Timestamp 字段可以由 Sarama 自动设置，或者如果需要精确控制，可以设置为 time.Now()。
 		// 如果原始消息的 Timestamp 很重要，也可以考虑将其作为 DLQ 消息的 Timestamp，但这取决于业务需求。
 		// Timestamp: originalMessage.Timestamp, // 例如，如果想保留原始消息的时间戳
@@ -169,6 +201,11 @@ func SendToDLQ(ctx context.Context,
 				zap.Int64("original_offset", originalMessage.Offset),
 				zap.Error(res.err),
 			)
+			// 底层生产者已失效 (例如 Broker 重启后客户端被关闭)：标记失效，
+			// 由下一次发送 (通常是 sendToDLQWithRetry 的重试) 重新建立连接。
+			if rp, ok := producer.(*reconnectingSyncProducer); ok && isUnrecoverableProducerError(res.err) {
+				rp.invalidate(res.err)
+			}
 			return fmt.Errorf("发送消息到 DLQ 失败 (原始消息偏移量 %d，主题 '%s'): %w", originalMessage.Offset, originalMessage.Topic, res.err)
 		}
 		logger.Info("消息成功发送到 DLQ",
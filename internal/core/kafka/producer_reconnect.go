@@ -0,0 +1,168 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/core"
+	"go.uber.org/zap"
+)
+
+// reconnectingSyncProducer 包装 sarama.SyncProducer，在底层生产者不可恢复地失效后延迟重建。
+// DLQ 生产者只在启动时创建一次；Broker 重启后底层客户端可能进入 "已关闭" 或 "没有可用 Broker" 的状态，
+// 此后每次发送都会失败，DLQ 在进程的整个生命周期内都无法使用。
+// SendToDLQ 遇到这类错误时调用 invalidate 标记生产者失效，下一次发送前在互斥锁保护下重新创建生产者。
+type reconnectingSyncProducer struct {
+	brokers     []string
+	newProducer func() (sarama.SyncProducer, error) // 创建底层生产者，测试中可替换为假的工厂函数。
+	logger      *core.ZapLogger
+
+	mu       sync.Mutex
+	producer sarama.SyncProducer // 当前使用的生产者；重建失败时为 nil，下一次发送时再次尝试。
+	closed   bool                // Close 之后不再重建。
+}
+
+// newReconnectingSyncProducer 使用已创建成功的生产者初始化包装器。
+func newReconnectingSyncProducer(producer sarama.SyncProducer, brokers []string, config *sarama.Config, logger *core.ZapLogger) *reconnectingSyncProducer {
+	return &reconnectingSyncProducer{
+		brokers: brokers,
+		newProducer: func() (sarama.SyncProducer, error) {
+			return sarama.NewSyncProducer(brokers, config)
+		},
+		logger:   logger,
+		producer: producer,
+	}
+}
+
+// isUnrecoverableProducerError 判断发送错误是否意味着底层生产者已失效，只能通过重建恢复。
+// 暂时性的 Broker 错误 (如 Leader 切换) 由 Sarama 内部重试 (Producer.Retry) 处理，不在此列。
+func isUnrecoverableProducerError(err error) bool {
+	return errors.Is(err, sarama.ErrClosedClient) ||
+		errors.Is(err, sarama.ErrOutOfBrokers) ||
+		errors.Is(err, sarama.ErrNotConnected) ||
+		errors.Is(err, sarama.ErrShuttingDown)
+}
+
+// invalidate 标记当前生产者失效并在后台关闭它，下一次发送时会重新创建。
+func (p *reconnectingSyncProducer) invalidate(cause error) {
+	p.mu.Lock()
+	old := p.producer
+	p.producer = nil
+	p.mu.Unlock()
+	if old == nil {
+		return
+	}
+	p.logger.Warn("DLQ 生产者已失效，将在下一次发送时重新连接", zap.Error(cause))
+	// 关闭可能阻塞 (等待进行中的请求)，不应拖慢当前的 DLQ 重试。
+	go func() {
+		if err := old.Close(); err != nil {
+			p.logger.Debug("关闭失效的 DLQ 生产者时出错", zap.Error(err))
+		}
+	}()
+}
+
+// current 返回可用的生产者，必要时重新创建。
+func (p *reconnectingSyncProducer) current() (sarama.SyncProducer, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, sarama.ErrClosedClient
+	}
+	if p.producer != nil {
+		return p.producer, nil
+	}
+	producer, err := p.newProducer()
+	if err != nil {
+		p.logger.Error("重新连接 DLQ 生产者失败", zap.Strings("brokers", p.brokers), zap.Error(err))
+		return nil, fmt.Errorf("重新连接 DLQ 生产者失败: %w", err)
+	}
+	p.logger.Info("DLQ 生产者已重新连接", zap.Strings("brokers", p.brokers))
+	p.producer = producer
+	return producer, nil
+}
+
+func (p *reconnectingSyncProducer) SendMessage(msg *sarama.ProducerMessage) (int32, int64, error) {
+	producer, err := p.current()
+	if err != nil {
+		return -1, -1, err
+	}
+	return producer.SendMessage(msg)
+}
+
+func (p *reconnectingSyncProducer) SendMessages(msgs []*sarama.ProducerMessage) error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.SendMessages(msgs)
+}
+
+// Close 关闭当前的生产者，之后不再重建。
+func (p *reconnectingSyncProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	if p.producer == nil {
+		return nil
+	}
+	err := p.producer.Close()
+	p.producer = nil
+	return err
+}
+
+// 以下事务相关方法直接委托给当前的生产者；DLQ 生产者不使用事务。
+
+func (p *reconnectingSyncProducer) TxnStatus() sarama.ProducerTxnStatusFlag {
+	producer, err := p.current()
+	if err != nil {
+		return sarama.ProducerTxnFlagFatalError
+	}
+	return producer.TxnStatus()
+}
+
+func (p *reconnectingSyncProducer) IsTransactional() bool {
+	producer, err := p.current()
+	return err == nil && producer.IsTransactional()
+}
+
+func (p *reconnectingSyncProducer) BeginTxn() error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.BeginTxn()
+}
+
+func (p *reconnectingSyncProducer) CommitTxn() error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.CommitTxn()
+}
+
+func (p *reconnectingSyncProducer) AbortTxn() error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.AbortTxn()
+}
+
+func (p *reconnectingSyncProducer) AddOffsetsToTxn(offsets map[string][]*sarama.PartitionOffsetMetadata, groupId string) error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.AddOffsetsToTxn(offsets, groupId)
+}
+
+func (p *reconnectingSyncProducer) AddMessageToTxn(msg *sarama.ConsumerMessage, groupId string, metadata *string) error {
+	producer, err := p.current()
+	if err != nil {
+		return err
+	}
+	return producer.AddMessageToTxn(msg, groupId, metadata)
+}
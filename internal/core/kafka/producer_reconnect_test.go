@@ -0,0 +1,235 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/IBM/sarama"
+)
+
+// fakeSyncProducer 是底层生产者的替身：SendMessage 返回 sendErr，成功时偏移量为 id，便于区分是哪一个生产者发送的。
+type fakeSyncProducer struct {
+	sarama.SyncProducer
+
+	id      int64
+	sendErr error
+
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+func newFakeSyncProducer(id int64) *fakeSyncProducer {
+	return &fakeSyncProducer{id: id, closed: make(chan struct{})}
+}
+
+func (p *fakeSyncProducer) SendMessage(*sarama.ProducerMessage) (int32, int64, error) {
+	if p.sendErr != nil {
+		return -1, -1, p.sendErr
+	}
+	return 0, p.id, nil
+}
+
+func (p *fakeSyncProducer) Close() error {
+	p.closeOnce.Do(func() { close(p.closed) })
+	return nil
+}
+
+// waitClosed 等待生产者被关闭 (invalidate 在后台关闭旧的生产者)。
+func (p *fakeSyncProducer) waitClosed(t *testing.T) {
+	t.Helper()
+	select {
+	case <-p.closed:
+	case <-time.After(time.Second):
+		t.Fatalf("生产者 %d 没有被关闭", p.id)
+	}
+}
+
+// fakeProducerFactory 代替 sarama.NewSyncProducer 创建生产者，依次分配 ID 1、2、3……
+type fakeProducerFactory struct {
+	mu      sync.Mutex
+	created []*fakeSyncProducer
+	err     error // 非 nil 时创建失败
+
+	started chan struct{} // 非 nil 时每次创建开始时发送信号
+	release chan struct{} // 非 nil 时创建前等待它关闭，模拟耗时的重新连接
+}
+
+func (f *fakeProducerFactory) newProducer() (sarama.SyncProducer, error) {
+	if f.started != nil {
+		f.started <- struct{}{}
+	}
+	if f.release != nil {
+		<-f.release
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err != nil {
+		return nil, f.err
+	}
+	producer := newFakeSyncProducer(int64(len(f.created) + 1))
+	f.created = append(f.created, producer)
+	return producer, nil
+}
+
+func (f *fakeProducerFactory) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.created)
+}
+
+// newTestReconnectingProducer 返回以 ID 为 0 的生产者初始化、使用 factory 重建的包装器。
+func newTestReconnectingProducer(t *testing.T, factory *fakeProducerFactory) (*reconnectingSyncProducer, *fakeSyncProducer) {
+	t.Helper()
+	initial := newFakeSyncProducer(0)
+	p := newReconnectingSyncProducer(initial, []string{"kafka:9092"}, sarama.NewConfig(), newTestLogger(t))
+	p.newProducer = factory.newProducer
+	return p, initial
+}
+
+func sendTestMessage(t *testing.T, p *reconnectingSyncProducer) (int64, error) {
+	t.Helper()
+	_, offset, err := p.SendMessage(&sarama.ProducerMessage{Topic: "dlq", Value: sarama.StringEncoder("payload")})
+	return offset, err
+}
+
+func TestReconnectingSyncProducerReconnectsAfterInvalidate(t *testing.T) {
+	factory := &fakeProducerFactory{}
+	p, initial := newTestReconnectingProducer(t, factory)
+
+	if offset, err := sendTestMessage(t, p); err != nil || offset != 0 {
+		t.Fatalf("初始发送 = (%d, %v), want 由初始生产者发送", offset, err)
+	}
+	if factory.count() != 0 {
+		t.Fatalf("未失效时不应重建生产者，已创建 %d 个", factory.count())
+	}
+
+	p.invalidate(sarama.ErrOutOfBrokers)
+	p.invalidate(sarama.ErrOutOfBrokers) // 重复标记失效不应再关闭或重建。
+	initial.waitClosed(t)
+
+	for i := 0; i < 2; i++ {
+		if offset, err := sendTestMessage(t, p); err != nil || offset != 1 {
+			t.Fatalf("失效后第 %d 次发送 = (%d, %v), want 由重建的生产者 1 发送", i+1, offset, err)
+		}
+	}
+	if factory.count() != 1 {
+		t.Errorf("重建了 %d 个生产者, want 1", factory.count())
+	}
+}
+
+func TestReconnectingSyncProducerRetriesFailedReconnect(t *testing.T) {
+	reconnectErr := errors.New("dial tcp: connection refused")
+	factory := &fakeProducerFactory{err: reconnectErr}
+	p, _ := newTestReconnectingProducer(t, factory)
+	p.invalidate(sarama.ErrClosedClient)
+
+	if _, err := sendTestMessage(t, p); !errors.Is(err, reconnectErr) {
+		t.Fatalf("重建失败时 err = %v, want 包装 %v", err, reconnectErr)
+	}
+
+	// 重建失败不应留下不可用的生产者：下一次发送再次尝试。
+	factory.mu.Lock()
+	factory.err = nil
+	factory.mu.Unlock()
+	if offset, err := sendTestMessage(t, p); err != nil || offset != 1 {
+		t.Fatalf("恢复后发送 = (%d, %v), want 由重建的生产者 1 发送", offset, err)
+	}
+}
+
+func TestReconnectingSyncProducerClose(t *testing.T) {
+	factory := &fakeProducerFactory{}
+	p, initial := newTestReconnectingProducer(t, factory)
+
+	if err := p.Close(); err != nil {
+		t.Fatalf("Close 返回错误: %v", err)
+	}
+	initial.waitClosed(t)
+
+	if _, err := sendTestMessage(t, p); !errors.Is(err, sarama.ErrClosedClient) {
+		t.Errorf("Close 之后发送 err = %v, want ErrClosedClient", err)
+	}
+	p.invalidate(sarama.ErrClosedClient)
+	if _, err := sendTestMessage(t, p); !errors.Is(err, sarama.ErrClosedClient) {
+		t.Errorf("Close 之后标记失效再发送 err = %v, want ErrClosedClient", err)
+	}
+	if factory.count() != 0 {
+		t.Errorf("Close 之后不应重建生产者，已创建 %d 个", factory.count())
+	}
+}
+
+func TestReconnectingSyncProducerConcurrentReconnect(t *testing.T) {
+	factory := &fakeProducerFactory{started: make(chan struct{}, 1), release: make(chan struct{})}
+	p, _ := newTestReconnectingProducer(t, factory)
+	p.invalidate(sarama.ErrNotConnected)
+
+	const callers = 16
+	var wg sync.WaitGroup
+	producers := make([]sarama.SyncProducer, callers)
+	errs := make([]error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			producers[i], errs[i] = p.current()
+		}(i)
+	}
+
+	// 第一个调用方开始重建后，其余调用方在互斥锁上等待；放行后它们应复用同一个新生产者。
+	select {
+	case <-factory.started:
+	case <-time.After(time.Second):
+		t.Fatal("没有调用方开始重建生产者")
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(factory.release)
+	wg.Wait()
+
+	if factory.count() != 1 {
+		t.Fatalf("并发调用期间重建了 %d 个生产者, want 1", factory.count())
+	}
+	for i := range producers {
+		if errs[i] != nil {
+			t.Errorf("调用方 %d 返回错误: %v", i, errs[i])
+		} else if producers[i] != factory.created[0] {
+			t.Errorf("调用方 %d 得到的生产者不是重建的生产者", i)
+		}
+	}
+}
+
+func TestSendToDLQInvalidatesOnUnrecoverableError(t *testing.T) {
+	tests := []struct {
+		name           string
+		sendErr        error
+		wantReconnects int
+	}{
+		{name: "客户端已关闭", sendErr: sarama.ErrClosedClient, wantReconnects: 1},
+		{name: "没有可用的 Broker", sendErr: sarama.ErrOutOfBrokers, wantReconnects: 1},
+		{name: "暂时性错误由 Sarama 内部重试", sendErr: sarama.ErrNotLeaderForPartition, wantReconnects: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			factory := &fakeProducerFactory{}
+			p, initial := newTestReconnectingProducer(t, factory)
+			initial.sendErr = tt.sendErr
+			message := &sarama.ConsumerMessage{Topic: "post_approved", Offset: 7, Value: []byte(`{}`)}
+			logger := newTestLogger(t)
+
+			if err := SendToDLQ(context.Background(), p, "dlq", message, errors.New("处理失败"), 0, logger); !errors.Is(err, tt.sendErr) {
+				t.Fatalf("第一次发送 err = %v, want 包装 %v", err, tt.sendErr)
+			}
+			err := SendToDLQ(context.Background(), p, "dlq", message, errors.New("处理失败"), 0, logger)
+			if factory.count() != tt.wantReconnects {
+				t.Fatalf("重建了 %d 个生产者, want %d", factory.count(), tt.wantReconnects)
+			}
+			if tt.wantReconnects > 0 && err != nil {
+				t.Errorf("重建后的发送返回错误: %v", err)
+			}
+			if tt.wantReconnects == 0 && !errors.Is(err, tt.sendErr) {
+				t.Errorf("未重建时应继续使用原生产者, err = %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package kafka
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/cenkalti/backoff/v4"
+	"go.uber.org/zap"
+)
+
+// 事件 schema 兼容模式，见 config.SchemaCompatibilityConfig。
+const (
+	SchemaModeStrict  = "strict"  // 缺少预期字段的消息按格式错误处理，发送到 DLQ
+	SchemaModeLenient = "lenient" // 已知版本的消息缺少预期字段时只记录警告，尽可能索引其余字段
+)
+
+// schemaVersionField 是事件顶层携带 schema 版本号的字段名，可以是字符串或数字。
+const schemaVersionField = "schemaVersion"
+
+// postApprovedExpectedFields 是 PostApprovedEvent 中预期一定出现的字段 (kafkaevents 中没有 omitempty 的字段)。
+// 上游重命名字段时 json.Unmarshal 不会报错，只会得到零值，因此需要显式检查这些键是否存在。
+var postApprovedExpectedFields = struct {
+	top  []string
+	post []string
+}{
+	top: []string{"event_id", "timestamp", "post"},
+	post: []string{
+		"id", "title", "content", "author_id", "author_avatar", "author_username",
+		"status", "view_count", "official_tag", "price_per_unit", "created_at", "updated_at",
+	},
+}
+
+// schemaPolicy 是 SchemaCompatibilityConfig 填充默认值后的运行时形式。
+type schemaPolicy struct {
+	mode          string
+	knownVersions map[string]bool
+}
+
+// newSchemaPolicy 根据配置创建 schemaPolicy；未知的模式回退到 strict。
+func newSchemaPolicy(cfg config.SchemaCompatibilityConfig) schemaPolicy {
+	mode := strings.ToLower(strings.TrimSpace(cfg.Mode))
+	if mode != SchemaModeLenient {
+		mode = SchemaModeStrict
+	}
+	known := make(map[string]bool, len(cfg.KnownVersions))
+	for _, v := range cfg.KnownVersions {
+		if v = strings.TrimSpace(v); v != "" {
+			known[v] = true
+		}
+	}
+	return schemaPolicy{mode: mode, knownVersions: known}
+}
+
+// lenientFor 判断给定 schema 版本的消息是否按宽松模式处理。
+// 没有携带版本号的消息视为当前已知的 schema；携带了未知版本号的消息即使配置了 lenient 也按 strict 处理，
+// 因为无法确定缺失的字段是被重命名还是语义发生了变化。
+func (p schemaPolicy) lenientFor(version string) bool {
+	if p.mode != SchemaModeLenient {
+		return false
+	}
+	return version == "" || p.knownVersions[version]
+}
+
+// SetSchemaCompatibility 设置事件 schema 兼容策略。与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetSchemaCompatibility(cfg config.SchemaCompatibilityConfig) {
+	h.schemaPolicy = newSchemaPolicy(cfg)
+	h.logger.Info("Kafka 事件 schema 兼容策略已设置",
+		zap.String("mode", h.schemaPolicy.mode),
+		zap.Strings("known_versions", cfg.KnownVersions),
+	)
+}
+
+// checkPostApprovedSchema 检查 PostApprovedEvent 消息体是否包含全部预期字段。
+// 缺少字段时：宽松模式下记录警告并返回 nil，让后续流程索引能解析出的字段；
+// 严格模式 (或未知版本) 下返回包装 ErrInvalidEventFormat 的永久性错误，消息最终进入 DLQ。
+// 消息体本身无法解析为 JSON 对象时返回 nil，交由后续的反序列化步骤报告错误。
+func (h *Handler) checkPostApprovedSchema(message *sarama.ConsumerMessage) error {
+	var top map[string]json.RawMessage
+	if err := json.Unmarshal(message.Value, &top); err != nil {
+		return nil
+	}
+	version := schemaVersionOf(top)
+
+	var missing []string
+	for _, f := range postApprovedExpectedFields.top {
+		if _, ok := top[f]; !ok {
+			missing = append(missing, f)
+		}
+	}
+	var post map[string]json.RawMessage
+	if raw, ok := top["post"]; ok && json.Unmarshal(raw, &post) == nil {
+		for _, f := range postApprovedExpectedFields.post {
+			if _, ok := post[f]; !ok {
+				missing = append(missing, "post."+f)
+			}
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	fields := []zap.Field{
+		zap.String("topic", message.Topic),
+		zap.Int32("partition", message.Partition),
+		zap.Int64("offset", message.Offset),
+		zap.String("schema_version", version),
+		zap.Strings("missing_fields", missing),
+		zap.String("schema_mode", h.schemaPolicy.mode),
+	}
+	if h.schemaPolicy.lenientFor(version) {
+		h.logger.Warn("PostApprovedEvent 缺少预期字段 (宽松模式)，将索引其余字段，缺失字段按零值处理", fields...)
+		return nil
+	}
+	h.logger.Error("PostApprovedEvent 缺少预期字段，上游 schema 可能已变更，消息将发送到 DLQ", fields...)
+	return backoff.Permanent(fmt.Errorf("PostApprovedEvent 缺少预期字段 %v (schema 版本: %q, 主题: %s, 偏移量: %d): %w",
+		missing, version, message.Topic, message.Offset, ErrInvalidEventFormat))
+}
+
+// schemaVersionOf 读取事件顶层的 schemaVersion，字符串与数字形式都按文本返回；不存在时返回空字符串。
+func schemaVersionOf(top map[string]json.RawMessage) string {
+	raw, ok := top[schemaVersionField]
+	if !ok {
+		return ""
+	}
+	var s string
+	if err := json.Unmarshal(raw, &s); err == nil {
+		return strings.TrimSpace(s)
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,59 @@
+package kafka
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// scramClient 将 xdg-go/scram 的客户端会话适配为 sarama.SCRAMClient 接口。
+// Sarama 只定义了 SCRAM 交互的接口而不提供实现，这里使用其官方示例同样采用的 xdg-go/scram。
+type scramClient struct {
+	*scram.Client
+	*scram.ClientConversation
+	hashGen scram.HashGeneratorFcn
+}
+
+// newSCRAMClientGenerator 返回可用于 sarama.Config.Net.SASL.SCRAMClientGeneratorFunc 的构造函数。
+func newSCRAMClientGenerator(hashGen func() hash.Hash) func() sarama.SCRAMClient {
+	return func() sarama.SCRAMClient {
+		return &scramClient{hashGen: hashGen}
+	}
+}
+
+// scramHashFor 返回指定 SCRAM 机制对应的哈希函数。
+func scramHashFor(mechanism sarama.SASLMechanism) (func() hash.Hash, bool) {
+	switch mechanism {
+	case sarama.SASLTypeSCRAMSHA256:
+		return sha256.New, true
+	case sarama.SASLTypeSCRAMSHA512:
+		return sha512.New, true
+	default:
+		return nil, false
+	}
+}
+
+// Begin 为新的 SCRAM 交互准备凭据 (按 SASLprep 规范化用户名和密码) 并开始新的会话。
+func (c *scramClient) Begin(userName, password, authzID string) error {
+	client, err := c.hashGen.NewClient(userName, password, authzID)
+	if err != nil {
+		return fmt.Errorf("创建 SCRAM 客户端失败: %w", err)
+	}
+	c.Client = client
+	c.ClientConversation = client.NewConversation()
+	return nil
+}
+
+// Step 根据服务端的质询推进 SCRAM 交互，返回需要发送给服务端的消息；服务端签名校验失败时返回错误。
+func (c *scramClient) Step(challenge string) (string, error) {
+	return c.ClientConversation.Step(challenge)
+}
+
+// Done 报告 SCRAM 交互是否已结束。
+func (c *scramClient) Done() bool {
+	return c.ClientConversation.Done()
+}
@@ -0,0 +1,120 @@
+package kafka
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/xdg-go/scram"
+)
+
+// newTestSCRAMServer 创建只认识 username/password 这一个用户的 SCRAM 服务端 (模拟 Kafka broker)。
+func newTestSCRAMServer(t *testing.T, mechanism sarama.SASLMechanism, username, password string) *scram.Server {
+	t.Helper()
+	hashGen, ok := scramHashFor(mechanism)
+	if !ok {
+		t.Fatalf("不支持的机制 %s", mechanism)
+	}
+	fcn := scram.HashGeneratorFcn(hashGen)
+	user, err := fcn.NewClient(username, password, "")
+	if err != nil {
+		t.Fatalf("创建服务端凭据失败: %v", err)
+	}
+	creds := user.GetStoredCredentials(scram.KeyFactors{Salt: "kafka-salt", Iters: 4096})
+	server, err := fcn.NewServer(func(name string) (scram.StoredCredentials, error) {
+		if name != username {
+			return scram.StoredCredentials{}, sarama.ErrSASLAuthenticationFailed
+		}
+		return creds, nil
+	})
+	if err != nil {
+		t.Fatalf("创建 SCRAM 服务端失败: %v", err)
+	}
+	return server
+}
+
+// runSCRAMExchange 按 Sarama 的方式驱动客户端完成 SCRAM 交互：
+// 客户端 Step 的输出发给服务端，服务端的回复作为下一次 Step 的质询，直到客户端 Done。
+// tamper 可在服务端回复到达客户端前修改它，用于模拟伪造的服务端。
+func runSCRAMExchange(client sarama.SCRAMClient, server *scram.ServerConversation, tamper func(string) string) error {
+	challenge := ""
+	for !client.Done() {
+		msg, err := client.Step(challenge)
+		if err != nil {
+			return err
+		}
+		if client.Done() {
+			break
+		}
+		// 服务端校验失败时同样返回 "e=..." 消息交给客户端处理，与 broker 的行为一致。
+		challenge, _ = server.Step(msg)
+		if tamper != nil {
+			challenge = tamper(challenge)
+		}
+	}
+	return nil
+}
+
+func TestSCRAMClient(t *testing.T) {
+	tamperSignature := func(msg string) string {
+		if strings.HasPrefix(msg, "v=") {
+			return "v=" + strings.Repeat("A", len(msg)-3) + "="
+		}
+		return msg
+	}
+	tests := []struct {
+		name      string
+		mechanism sarama.SASLMechanism
+		password  string
+		tamper    func(string) string
+		wantErr   bool
+	}{
+		{name: "SHA-256 凭据正确", mechanism: sarama.SASLTypeSCRAMSHA256, password: "pencil"},
+		{name: "SHA-512 凭据正确", mechanism: sarama.SASLTypeSCRAMSHA512, password: "pencil"},
+		{name: "密码错误", mechanism: sarama.SASLTypeSCRAMSHA256, password: "wrong", wantErr: true},
+		{name: "服务端签名不匹配", mechanism: sarama.SASLTypeSCRAMSHA512, password: "pencil", tamper: tamperSignature, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestSCRAMServer(t, tt.mechanism, "user", "pencil")
+			hashGen, _ := scramHashFor(tt.mechanism)
+			client := newSCRAMClientGenerator(hashGen)()
+
+			if err := client.Begin("user", tt.password, ""); err != nil {
+				t.Fatalf("Begin 返回错误: %v", err)
+			}
+			conv := server.NewConversation()
+			err := runSCRAMExchange(client, conv, tt.tamper)
+			if tt.wantErr {
+				if err == nil {
+					t.Error("认证应失败，但客户端没有返回错误")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("SCRAM 交互失败: %v", err)
+			}
+			if !conv.Valid() {
+				t.Error("服务端未认可客户端证明")
+			}
+		})
+	}
+}
+
+func TestSCRAMClientBeginResetsConversation(t *testing.T) {
+	// Sarama 每次连接都会对同一个客户端重新调用 Begin，上一次交互的状态不应影响新的交互。
+	hashGen, _ := scramHashFor(sarama.SASLTypeSCRAMSHA256)
+	client := newSCRAMClientGenerator(hashGen)()
+	for i := 0; i < 2; i++ {
+		server := newTestSCRAMServer(t, sarama.SASLTypeSCRAMSHA256, "user", "pencil")
+		if err := client.Begin("user", "pencil", ""); err != nil {
+			t.Fatalf("第 %d 次 Begin 返回错误: %v", i+1, err)
+		}
+		if client.Done() {
+			t.Fatalf("第 %d 次 Begin 后 Done 应为 false", i+1)
+		}
+		if err := runSCRAMExchange(client, server.NewConversation(), nil); err != nil {
+			t.Fatalf("第 %d 次 SCRAM 交互失败: %v", i+1, err)
+		}
+	}
+}
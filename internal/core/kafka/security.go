@@ -0,0 +1,112 @@
+package kafka
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"go.uber.org/zap"
+)
+
+// configureSecurity 将应用的 Kafka 安全配置 (SASL 认证和 TLS 加密) 应用到 Sarama 配置上。
+// SASL 与 TLS 可以独立启用：例如仅使用 TLS 加密而不认证，或在 TLS 之上使用 SCRAM 认证。
+// 参数:
+//   - saramaCfg: 待修改的 Sarama 配置对象。
+//   - secCfg: 应用程序的 Kafka 安全配置。
+//   - logger: 用于记录配置结果的 ZapLogger 实例。
+//
+// 返回值:
+//   - error: 如果配置不完整 (例如启用了 SASL 但缺少凭据) 或证书文件无法加载，则返回错误。
+func configureSecurity(saramaCfg *sarama.Config, secCfg config.KafkaSecurityConfig, logger *core.ZapLogger) error {
+	if secCfg.Enabled {
+		mechanism := sarama.SASLMechanism(strings.ToUpper(strings.TrimSpace(secCfg.Mechanism)))
+		switch mechanism {
+		case sarama.SASLTypePlaintext:
+		case sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512:
+			hashGen, _ := scramHashFor(mechanism)
+			saramaCfg.Net.SASL.SCRAMClientGeneratorFunc = newSCRAMClientGenerator(hashGen)
+		default:
+			logger.Error("不支持的 Kafka SASL 认证机制",
+				zap.String("configured_mechanism", secCfg.Mechanism),
+				zap.Strings("supported_mechanisms", []string{sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512}),
+			)
+			return fmt.Errorf("不支持的 Kafka SASL 认证机制 '%s'，可选值: %s, %s, %s",
+				secCfg.Mechanism, sarama.SASLTypePlaintext, sarama.SASLTypeSCRAMSHA256, sarama.SASLTypeSCRAMSHA512)
+		}
+		if secCfg.Username == "" || secCfg.Password == "" {
+			logger.Error("Kafka SASL 已启用，但未配置用户名或密码", zap.String("sasl_mechanism", string(mechanism)))
+			return errors.New("kafka SASL 已启用，但用户名 (username) 或密码 (password) 为空")
+		}
+
+		saramaCfg.Net.SASL.Enable = true
+		saramaCfg.Net.SASL.Handshake = true
+		saramaCfg.Net.SASL.Mechanism = mechanism
+		saramaCfg.Net.SASL.User = secCfg.Username
+		saramaCfg.Net.SASL.Password = secCfg.Password
+		logger.Info("Kafka SASL 认证已启用",
+			zap.String("sasl_mechanism", string(mechanism)),
+			zap.String("sasl_username", secCfg.Username),
+		)
+		if !secCfg.TLSEnabled {
+			// SASL/PLAIN 会以明文传输密码，提醒运维确认网络环境。
+			logger.Warn("Kafka SASL 已启用但未启用 TLS，凭据将以未加密的方式在网络上传输")
+		}
+	}
+
+	if secCfg.TLSEnabled {
+		tlsCfg, err := buildTLSConfig(secCfg)
+		if err != nil {
+			logger.Error("加载 Kafka TLS 配置失败", zap.Error(err))
+			return err
+		}
+		saramaCfg.Net.TLS.Enable = true
+		saramaCfg.Net.TLS.Config = tlsCfg
+		logger.Info("Kafka TLS 已启用",
+			zap.Bool("custom_ca", secCfg.CAFile != ""),
+			zap.Bool("client_certificate", secCfg.CertFile != ""),
+			zap.Bool("insecure_skip_verify", secCfg.InsecureSkipVerify),
+		)
+	}
+
+	return nil
+}
+
+// buildTLSConfig 根据配置的 CA 证书和客户端证书路径构建 tls.Config。
+// 未配置 CAFile 时使用系统根证书；CertFile 和 KeyFile 必须同时配置 (双向 TLS) 或同时为空。
+func buildTLSConfig(secCfg config.KafkaSecurityConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		InsecureSkipVerify: secCfg.InsecureSkipVerify, // 跳过服务端证书校验，仅应在测试环境中显式开启
+	}
+
+	if secCfg.CAFile != "" {
+		caPEM, err := os.ReadFile(secCfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("读取 Kafka CA 证书文件 '%s' 失败: %w", secCfg.CAFile, err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("kafka CA 证书文件 '%s' 中没有有效的 PEM 证书", secCfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	if (secCfg.CertFile == "") != (secCfg.KeyFile == "") {
+		return nil, errors.New("kafka TLS 客户端证书 (certFile) 和私钥 (keyFile) 必须同时配置")
+	}
+	if secCfg.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(secCfg.CertFile, secCfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("加载 Kafka TLS 客户端证书失败 (cert: '%s', key: '%s'): %w", secCfg.CertFile, secCfg.KeyFile, err)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsCfg, nil
+}
@@ -0,0 +1,298 @@
+package kafka
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"go.uber.org/zap"
+)
+
+// 帖子状态索引策略，见 config.IndexingConfig.StatusPolicy。
+const (
+	StatusPolicyAll            = "all"            // 任何状态的帖子都写入索引 (默认，与旧版本行为一致)
+	StatusPolicySearchableOnly = "searchableOnly" // 只索引可搜索状态的帖子，变为其他状态时从索引中删除
+)
+
+// statusAction 是状态策略对一条审核事件给出的处理方式。
+type statusAction int
+
+const (
+	statusActionIndex  statusAction = iota // 写入 (创建或覆盖) 索引
+	statusActionDelete                     // 帖子已在索引中，但新状态不可搜索：删除
+	statusActionSkip                       // 帖子不在索引中，且新状态不可搜索：不做任何操作
+)
+
+func (a statusAction) String() string {
+	switch a {
+	case statusActionIndex:
+		return "index"
+	case statusActionDelete:
+		return "delete"
+	case statusActionSkip:
+		return "skip"
+	default:
+		return "unknown"
+	}
+}
+
+// defaultIndexedSearchableStatuses 是未设置可搜索状态时 searchableOnly 策略保留的状态，与公开搜索的默认值一致。
+var defaultIndexedSearchableStatuses = []enums.Status{enums.Approved}
+
+// statusPolicy 是状态索引策略的运行时形式。
+type statusPolicy struct {
+	searchableOnly bool
+	searchable     map[enums.Status]bool
+}
+
+// newStatusPolicy 根据策略模式与可搜索状态创建 statusPolicy。
+// 无法识别的模式回退到 all (不删除任何文档)；无法识别的状态值会被忽略，全部无效时使用默认值。
+func newStatusPolicy(mode string, searchableStatuses []int, logger *core.ZapLogger) statusPolicy {
+	policy := statusPolicy{searchable: make(map[enums.Status]bool)}
+	switch strings.TrimSpace(mode) {
+	case "", StatusPolicyAll:
+	case StatusPolicySearchableOnly:
+		policy.searchableOnly = true
+	default:
+		logger.Warn("无法识别的帖子状态索引策略 (indexingConfig.statusPolicy)，将索引所有状态",
+			zap.String("configured_policy", mode),
+			zap.Strings("supported_policies", []string{StatusPolicyAll, StatusPolicySearchableOnly}),
+		)
+	}
+	for _, v := range searchableStatuses {
+		status := enums.Status(v)
+		if status != enums.Pending && status != enums.Approved && status != enums.Rejected {
+			logger.Warn("可搜索状态中包含无法识别的值，状态索引策略将忽略它", zap.Int("status", v))
+			continue
+		}
+		policy.searchable[status] = true
+	}
+	if len(policy.searchable) == 0 {
+		for _, status := range defaultIndexedSearchableStatuses {
+			policy.searchable[status] = true
+		}
+	}
+	return policy
+}
+
+// needsPriorLookup 判断决定处理方式前是否需要读取帖子在索引中的当前状态。
+// 只有 searchableOnly 策略下新状态不可搜索时才需要：此时要区分 "从可搜索变为不可搜索" (删除) 与 "从未被索引" (跳过)。
+func (p statusPolicy) needsPriorLookup(incoming enums.Status) bool {
+	return p.searchableOnly && !p.searchable[incoming]
+}
+
+// decide 根据事件中的新状态与帖子在索引中的当前状态 (previous 为 nil 表示不在索引中) 给出处理方式。
+//   - all 策略，或新状态可搜索：写入索引 (包括从不可搜索恢复为可搜索)。
+//   - 新状态不可搜索且帖子在索引中：删除。
+//   - 新状态不可搜索且帖子不在索引中：跳过。
+func (p statusPolicy) decide(incoming enums.Status, previous *enums.Status) statusAction {
+	if !p.searchableOnly || p.searchable[incoming] {
+		return statusActionIndex
+	}
+	if previous != nil {
+		return statusActionDelete
+	}
+	return statusActionSkip
+}
+
+// decidePatch 给出部分更新事件中新状态的处理方式：部分更新只作用于已在索引中的帖子，
+// 因此 searchableOnly 策略下新状态不可搜索时删除，其他情况照常更新。
+func (p statusPolicy) decidePatch(incoming enums.Status) statusAction {
+	return p.decide(incoming, &incoming)
+}
+
+// patchedStatus 将部分更新事件中的 status 字段 (JSON 数字，反序列化为 float64) 转换为 enums.Status。
+func patchedStatus(raw interface{}) (enums.Status, error) {
+	switch v := raw.(type) {
+	case float64:
+		if v != float64(int(v)) {
+			return 0, fmt.Errorf("status 必须是整数，实际为 %v", v)
+		}
+		return enums.Status(int(v)), nil
+	case int:
+		return enums.Status(v), nil
+	case enums.Status:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("status 必须是整数，实际类型为 %T", raw)
+	}
+}
+
+// SetSearchableStatuses 设置 searchableOnly 策略保留的帖子状态，应与公开搜索的 searchConfig.searchableStatuses 一致。
+// 必须在开始消费之前调用。
+func (s *EventService) SetSearchableStatuses(statuses []int) {
+	s.statusPolicy = newStatusPolicy(s.indexingCfg.StatusPolicy, statuses, s.logger)
+}
+
+// resolveStatusAction 按状态策略决定审核事件的处理方式。
+// 需要时通过 GetPostsByIDs 读取帖子的当前状态：这是每条不可搜索状态事件额外的一次 ES 读取，
+// 读取失败 (包括 _mget 中该文档的单独错误) 时返回错误 (可重试)，而不是当作不在索引中、冒险跳过一次应有的删除。
+func (s *EventService) resolveStatusAction(ctx context.Context, eventID string, postID uint64, incoming enums.Status) (statusAction, error) {
+	if !s.statusPolicy.needsPriorLookup(incoming) {
+		return s.statusPolicy.decide(incoming, nil), nil
+	}
+	docs, err := s.postRepo.GetPostsByIDs(ctx, []uint64{postID})
+	if err != nil {
+		s.logger.Error("读取帖子当前状态失败，无法按状态策略决定处理方式",
+			zap.String("event_id", eventID),
+			zap.Uint64("post_id", postID),
+			zap.Error(err),
+		)
+		return statusActionSkip, fmt.Errorf("读取帖子 ID '%d' 的当前状态失败: %w", postID, err)
+	}
+	var previous *enums.Status
+	if len(docs) > 0 {
+		previous = &docs[0].Status
+	}
+	action := s.statusPolicy.decide(incoming, previous)
+	fields := []zap.Field{
+		zap.String("event_id", eventID),
+		zap.Uint64("post_id", postID),
+		zap.Int("incoming_status", int(incoming)),
+		zap.String("action", action.String()),
+	}
+	if previous != nil {
+		fields = append(fields, zap.Int("previous_status", int(*previous)))
+	}
+	s.logger.Info("帖子新状态不可搜索，按状态策略处理", fields...)
+	return action, nil
+}
+
+// parkPost 在 searchableOnly 策略把帖子移出 (或不写入) 索引时暂存完整文档 (见 repositories 的 es_post_parked.go)，
+// 之后的部分更新事件恢复可搜索状态时据此完整重新索引。
+func (s *EventService) parkPost(ctx context.Context, eventID string, doc models.EsPostDocument) error {
+	if err := s.postRepo.ParkPost(ctx, doc); err != nil {
+		s.logger.Error("暂存帖子完整文档失败",
+			zap.String("event_id", eventID),
+			zap.Uint64("post_id", doc.ID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("暂存帖子 ID '%d' 的完整文档失败: %w", doc.ID, err)
+	}
+	return nil
+}
+
+// parkPatchedPost 处理部分更新后状态不可搜索的帖子：读取索引中的完整文档，合并变化的字段后暂存，再从索引中删除。
+// 帖子不在索引中时更新已有的暂存副本；两者都不存在时无事可做。
+func (s *EventService) parkPatchedPost(ctx context.Context, eventID string, postID uint64, fields map[string]interface{}) error {
+	stored, err := s.postRepo.GetPost(ctx, postID)
+	if err != nil {
+		s.logger.Error("读取帖子当前文档失败，无法暂存", zap.String("event_id", eventID), zap.Uint64("post_id", postID), zap.Error(err))
+		return fmt.Errorf("读取帖子 ID '%d' 的当前文档失败: %w", postID, err)
+	}
+	indexed := stored != nil
+	var current *models.EsPostDocument
+	if indexed {
+		current = &stored.EsPostDocument
+	} else {
+		if current, err = s.postRepo.GetParkedPost(ctx, postID); err != nil {
+			s.logger.Error("读取帖子暂存副本失败", zap.String("event_id", eventID), zap.Uint64("post_id", postID), zap.Error(err))
+			return fmt.Errorf("读取帖子 ID '%d' 的暂存副本失败: %w", postID, err)
+		}
+		if current == nil {
+			s.logger.Warn("帖子既不在索引中也没有暂存副本，忽略部分更新",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", postID))
+			return nil
+		}
+	}
+
+	merged, err := applyPatchFields(*current, fields)
+	if err != nil {
+		return fmt.Errorf("合并帖子 ID '%d' 的部分更新字段失败: %v: %w", postID, err, ErrInvalidEventFormat)
+	}
+	// 先暂存再删除：两步之间失败时重试仍然幂等，不会丢失文档。
+	if err := s.parkPost(ctx, eventID, merged); err != nil {
+		return err
+	}
+	if !indexed {
+		return nil
+	}
+	if err := s.postRepo.DeletePost(ctx, postID); err != nil {
+		s.logger.Error("按状态策略删除帖子失败",
+			zap.String("event_id", eventID),
+			zap.Uint64("post_id", postID),
+			zap.Error(err),
+		)
+		return fmt.Errorf("按状态策略删除帖子 ID '%d' 失败: %w", postID, err)
+	}
+	return nil
+}
+
+// restoreParkedPost 在部分更新的目标帖子不在索引中时，尝试从暂存副本恢复：合并变化的字段后，
+// 新状态可搜索则完整重新索引并删除暂存副本，否则只更新暂存副本。
+// 没有暂存副本时返回 false，调用方按帖子不存在处理。
+func (s *EventService) restoreParkedPost(ctx context.Context, eventID string, postID uint64, fields map[string]interface{}) (bool, error) {
+	parked, err := s.postRepo.GetParkedPost(ctx, postID)
+	if err != nil {
+		s.logger.Error("读取帖子暂存副本失败", zap.String("event_id", eventID), zap.Uint64("post_id", postID), zap.Error(err))
+		return false, fmt.Errorf("读取帖子 ID '%d' 的暂存副本失败: %w", postID, err)
+	}
+	if parked == nil {
+		return false, nil
+	}
+	merged, err := applyPatchFields(*parked, fields)
+	if err != nil {
+		return false, fmt.Errorf("合并帖子 ID '%d' 的部分更新字段失败: %v: %w", postID, err, ErrInvalidEventFormat)
+	}
+	if !s.statusPolicy.searchable[merged.Status] {
+		return true, s.parkPost(ctx, eventID, merged)
+	}
+
+	if err := s.postRepo.IndexPost(ctx, merged); err != nil {
+		s.logger.Error("从暂存副本重新索引帖子失败", zap.String("event_id", eventID), zap.Uint64("post_id", postID), zap.Error(err))
+		return false, fmt.Errorf("从暂存副本重新索引帖子 ID '%d' 失败: %w", postID, err)
+	}
+	if err := s.postRepo.DeleteParkedPost(ctx, postID); err != nil {
+		// 帖子已恢复；残留的暂存副本只会在帖子再次被移出索引时被覆盖，不影响正确性。
+		s.logger.Warn("帖子已从暂存副本恢复，但删除暂存副本失败", zap.String("event_id", eventID), zap.Uint64("post_id", postID), zap.Error(err))
+	}
+	s.logger.Info("帖子恢复为可搜索状态，已从暂存副本完整重新索引",
+		zap.String("event_id", eventID),
+		zap.Uint64("post_id", postID),
+		zap.Int("status", int(merged.Status)))
+	return true, nil
+}
+
+// applyPatchFields 将部分更新的字段合并到完整文档中，字段值按 JSON 映射到 EsPostDocument 的对应字段。
+// title_raw/content_raw 不参与 EsPostDocument 的 JSON 序列化，单独处理 (nil 表示清空)。
+func applyPatchFields(doc models.EsPostDocument, fields map[string]interface{}) (models.EsPostDocument, error) {
+	encoded, err := json.Marshal(doc)
+	if err != nil {
+		return doc, err
+	}
+	// UseNumber 避免超过 2^53 的帖子 ID 在合并过程中损失精度。
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.UseNumber()
+	var merged map[string]interface{}
+	if err := decoder.Decode(&merged); err != nil {
+		return doc, err
+	}
+	for name, value := range fields {
+		if name == "title_raw" || name == "content_raw" {
+			continue
+		}
+		merged[name] = value
+	}
+	if encoded, err = json.Marshal(merged); err != nil {
+		return doc, err
+	}
+	var result models.EsPostDocument
+	if err := json.Unmarshal(encoded, &result); err != nil {
+		return doc, err
+	}
+
+	result.TitleRaw, result.ContentRaw = doc.TitleRaw, doc.ContentRaw
+	if raw, ok := fields["title_raw"]; ok {
+		result.TitleRaw, _ = raw.(string)
+	}
+	if raw, ok := fields["content_raw"]; ok {
+		result.ContentRaw, _ = raw.(string)
+	}
+	return result, nil
+}
@@ -0,0 +1,281 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	commonconfig "github.com/Xushengqwer/go-common/config"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/go-common/models/kafkaevents"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+// newTestLogger 返回只输出 error 及以上级别的 logger，避免测试输出被日志淹没。
+func newTestLogger(t *testing.T) *core.ZapLogger {
+	t.Helper()
+	logger, err := core.NewZapLogger(commonconfig.ZapConfig{Level: "error", Encoding: "console"})
+	if err != nil {
+		t.Fatalf("创建测试 logger 失败: %v", err)
+	}
+	return logger
+}
+
+func TestNewStatusPolicy(t *testing.T) {
+	tests := []struct {
+		name               string
+		mode               string
+		searchableStatuses []int
+		wantSearchableOnly bool
+		wantSearchable     []enums.Status
+	}{
+		{name: "空模式视为 all", mode: "", wantSearchable: []enums.Status{enums.Approved}},
+		{name: "all", mode: StatusPolicyAll, wantSearchable: []enums.Status{enums.Approved}},
+		{name: "searchableOnly 使用默认状态", mode: StatusPolicySearchableOnly, wantSearchableOnly: true, wantSearchable: []enums.Status{enums.Approved}},
+		{name: "模式两端的空白", mode: " searchableOnly ", wantSearchableOnly: true, wantSearchable: []enums.Status{enums.Approved}},
+		{name: "无法识别的模式回退到 all", mode: "deleteAll", wantSearchable: []enums.Status{enums.Approved}},
+		{
+			name:               "配置的可搜索状态",
+			mode:               StatusPolicySearchableOnly,
+			searchableStatuses: []int{int(enums.Pending), int(enums.Approved)},
+			wantSearchableOnly: true,
+			wantSearchable:     []enums.Status{enums.Pending, enums.Approved},
+		},
+		{
+			name:               "忽略无法识别的状态",
+			mode:               StatusPolicySearchableOnly,
+			searchableStatuses: []int{int(enums.Rejected), 99},
+			wantSearchableOnly: true,
+			wantSearchable:     []enums.Status{enums.Rejected},
+		},
+		{
+			name:               "全部无效时使用默认状态",
+			mode:               StatusPolicySearchableOnly,
+			searchableStatuses: []int{-1, 99},
+			wantSearchableOnly: true,
+			wantSearchable:     []enums.Status{enums.Approved},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := newStatusPolicy(tt.mode, tt.searchableStatuses, newTestLogger(t))
+			if policy.searchableOnly != tt.wantSearchableOnly {
+				t.Errorf("searchableOnly = %v, want %v", policy.searchableOnly, tt.wantSearchableOnly)
+			}
+			if len(policy.searchable) != len(tt.wantSearchable) {
+				t.Fatalf("searchable = %v, want %v", policy.searchable, tt.wantSearchable)
+			}
+			for _, status := range tt.wantSearchable {
+				if !policy.searchable[status] {
+					t.Errorf("searchable 缺少状态 %d: %v", status, policy.searchable)
+				}
+			}
+		})
+	}
+}
+
+func TestStatusPolicyDecide(t *testing.T) {
+	approved, pending := enums.Approved, enums.Pending
+	all := newStatusPolicy(StatusPolicyAll, nil, newTestLogger(t))
+	searchableOnly := newStatusPolicy(StatusPolicySearchableOnly, nil, newTestLogger(t))
+
+	tests := []struct {
+		name     string
+		policy   statusPolicy
+		incoming enums.Status
+		previous *enums.Status
+		want     statusAction
+	}{
+		{name: "all 策略索引不可搜索状态", policy: all, incoming: enums.Rejected, want: statusActionIndex},
+		{name: "all 策略覆盖已索引的帖子", policy: all, incoming: enums.Pending, previous: &approved, want: statusActionIndex},
+		{name: "可搜索状态写入索引", policy: searchableOnly, incoming: enums.Approved, want: statusActionIndex},
+		{name: "从不可搜索恢复为可搜索", policy: searchableOnly, incoming: enums.Approved, previous: &pending, want: statusActionIndex},
+		{name: "已索引的帖子变为不可搜索时删除", policy: searchableOnly, incoming: enums.Rejected, previous: &approved, want: statusActionDelete},
+		{name: "未索引的帖子不可搜索时跳过", policy: searchableOnly, incoming: enums.Rejected, want: statusActionSkip},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.decide(tt.incoming, tt.previous); got != tt.want {
+				t.Errorf("decide(%d, %v) = %s, want %s", tt.incoming, tt.previous, got, tt.want)
+			}
+			if got, want := tt.policy.needsPriorLookup(tt.incoming), tt.want != statusActionIndex; got != want {
+				t.Errorf("needsPriorLookup(%d) = %v, want %v", tt.incoming, got, want)
+			}
+		})
+	}
+}
+
+func TestStatusPolicyDecidePatch(t *testing.T) {
+	searchableOnly := newStatusPolicy(StatusPolicySearchableOnly, nil, newTestLogger(t))
+	all := newStatusPolicy(StatusPolicyAll, nil, newTestLogger(t))
+
+	if got := searchableOnly.decidePatch(enums.Rejected); got != statusActionDelete {
+		t.Errorf("searchableOnly.decidePatch(Rejected) = %s, want delete", got)
+	}
+	if got := searchableOnly.decidePatch(enums.Approved); got != statusActionIndex {
+		t.Errorf("searchableOnly.decidePatch(Approved) = %s, want index", got)
+	}
+	if got := all.decidePatch(enums.Rejected); got != statusActionIndex {
+		t.Errorf("all.decidePatch(Rejected) = %s, want index", got)
+	}
+}
+
+func TestPatchedStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     interface{}
+		want    enums.Status
+		wantErr bool
+	}{
+		{name: "JSON 数字", raw: float64(2), want: enums.Status(2)},
+		{name: "int", raw: 1, want: enums.Status(1)},
+		{name: "小数", raw: 1.5, wantErr: true},
+		{name: "字符串", raw: "1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patchedStatus(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("patchedStatus(%v) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("patchedStatus(%v) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+// newSearchableOnlyService 返回使用 searchableOnly 状态策略 (只有 Approved 可搜索) 与内存仓库的 EventService。
+func newSearchableOnlyService(t *testing.T, repo *memoryPostRepo) *EventService {
+	t.Helper()
+	return NewEventService(repo, config.IndexingConfig{StatusPolicy: StatusPolicySearchableOnly}, newTestLogger(t))
+}
+
+func approvedEvent(postID uint64, status enums.Status, title string) *kafkaevents.PostApprovedEvent {
+	return &kafkaevents.PostApprovedEvent{
+		EventID:   "event-approved",
+		Timestamp: time.Now().UTC(),
+		Post: kafkaevents.PostData{
+			ID:          postID,
+			Title:       title,
+			Content:     "正文",
+			AuthorID:    "author-1",
+			Status:      status,
+			ContactInfo: "13800000000",
+		},
+	}
+}
+
+func patchedEvent(postID uint64, fields map[string]interface{}) *models.PostPatchedEvent {
+	return &models.PostPatchedEvent{EventID: "event-patched", Timestamp: time.Now().UTC(), PostID: postID, Fields: fields}
+}
+
+func TestPatchRestoresPostSkippedByStatusPolicy(t *testing.T) {
+	repo := newMemoryPostRepo()
+	svc := newSearchableOnlyService(t, repo)
+	ctx := context.Background()
+
+	// 待审核的帖子不写入索引，但完整文档被暂存。
+	if err := svc.HandlePostApprovedEvent(ctx, approvedEvent(7, enums.Pending, "标题"), []string{"kafka"}, 0, ""); err != nil {
+		t.Fatalf("HandlePostApprovedEvent 返回错误: %v", err)
+	}
+	if indexed, parked := repo.state(7); indexed != nil || parked == nil {
+		t.Fatalf("indexed = %v, parked = %v, want 只有暂存副本", indexed, parked)
+	}
+
+	// 部分更新把状态改为可搜索：从暂存副本完整重新索引，而不是返回 ErrPostNotFound (进入 DLQ)。
+	fields := map[string]interface{}{"status": float64(enums.Approved), "title": "新标题"}
+	if err := svc.HandlePostPatchedEvent(ctx, patchedEvent(7, fields)); err != nil {
+		t.Fatalf("HandlePostPatchedEvent 返回错误: %v", err)
+	}
+	indexed, parked := repo.state(7)
+	if indexed == nil {
+		t.Fatal("帖子应从暂存副本恢复到索引中")
+	}
+	if parked != nil {
+		t.Errorf("恢复后应删除暂存副本, parked = %+v", parked)
+	}
+	if indexed.Status != enums.Approved || indexed.Title != "新标题" || indexed.ContactInfo != "13800000000" ||
+		len(indexed.Tags) != 1 || indexed.Tags[0] != "kafka" {
+		t.Errorf("恢复的文档 = %+v, 应包含暂存的完整字段与部分更新的字段", *indexed)
+	}
+}
+
+func TestPatchMovesPostToParkedAndBack(t *testing.T) {
+	repo := newMemoryPostRepo()
+	svc := newSearchableOnlyService(t, repo)
+	ctx := context.Background()
+	repo.indexed[9] = models.EsPostDocument{ID: 9, Title: "标题", Content: "正文", ContentRaw: "<p>正文</p>", Status: enums.Approved, ViewCount: 5}
+
+	// 变为不可搜索：合并字段后暂存，并从索引中删除。
+	if err := svc.HandlePostPatchedEvent(ctx, patchedEvent(9, map[string]interface{}{"status": float64(enums.Rejected)})); err != nil {
+		t.Fatalf("HandlePostPatchedEvent(Rejected) 返回错误: %v", err)
+	}
+	indexed, parked := repo.state(9)
+	if indexed != nil || parked == nil || parked.Status != enums.Rejected {
+		t.Fatalf("indexed = %v, parked = %v, want 只有状态为 Rejected 的暂存副本", indexed, parked)
+	}
+
+	// 暂存期间的其他字段变化更新暂存副本。
+	if err := svc.HandlePostPatchedEvent(ctx, patchedEvent(9, map[string]interface{}{"view_count": float64(8)})); err != nil {
+		t.Fatalf("HandlePostPatchedEvent(view_count) 返回错误: %v", err)
+	}
+	if indexed, parked = repo.state(9); indexed != nil || parked == nil || parked.ViewCount != 8 {
+		t.Fatalf("indexed = %v, parked = %v, want 暂存副本的 view_count 为 8", indexed, parked)
+	}
+
+	if err := svc.HandlePostPatchedEvent(ctx, patchedEvent(9, map[string]interface{}{"status": float64(enums.Approved)})); err != nil {
+		t.Fatalf("HandlePostPatchedEvent(Approved) 返回错误: %v", err)
+	}
+	indexed, parked = repo.state(9)
+	if indexed == nil || parked != nil {
+		t.Fatalf("indexed = %v, parked = %v, want 帖子恢复到索引中", indexed, parked)
+	}
+	want := models.EsPostDocument{ID: 9, Title: "标题", Content: "正文", ContentRaw: "<p>正文</p>", Status: enums.Approved, ViewCount: 8}
+	if !onlyViewCountChanged(*indexed, want) || indexed.ViewCount != 8 {
+		t.Errorf("恢复的文档 = %+v, want %+v", *indexed, want)
+	}
+}
+
+func TestPatchWithoutParkedPostIsNotFound(t *testing.T) {
+	svc := newSearchableOnlyService(t, newMemoryPostRepo())
+
+	err := svc.HandlePostPatchedEvent(context.Background(), patchedEvent(3, map[string]interface{}{"status": float64(enums.Approved)}))
+	if !errors.Is(err, repositories.ErrPostNotFound) {
+		t.Errorf("err = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestDeleteEventRemovesParkedPost(t *testing.T) {
+	repo := newMemoryPostRepo()
+	svc := newSearchableOnlyService(t, repo)
+	repo.parked[4] = models.EsPostDocument{ID: 4, Title: "标题", Status: enums.Pending}
+
+	if err := svc.HandlePostDeleteEvent(context.Background(), &kafkaevents.PostDeletedEvent{EventID: "event-deleted", PostID: 4}); err != nil {
+		t.Fatalf("HandlePostDeleteEvent 返回错误: %v", err)
+	}
+	// 已删除的帖子不应被之后的部分更新事件恢复。
+	if _, parked := repo.state(4); parked != nil {
+		t.Errorf("删除事件后暂存副本 = %+v, want nil", parked)
+	}
+}
+
+func TestResolveStatusActionSurfacesLookupErrors(t *testing.T) {
+	repo := newMemoryPostRepo()
+	repo.indexed[5] = models.EsPostDocument{ID: 5, Title: "标题", Status: enums.Approved}
+	// 例如 _mget 中该文档所在分片不可用：不能当作不在索引中而跳过应有的删除。
+	repo.getErr = repositories.ErrESUnavailable
+	svc := newSearchableOnlyService(t, repo)
+
+	err := svc.HandlePostApprovedEvent(context.Background(), approvedEvent(5, enums.Rejected, "标题"), nil, 0, "")
+	if !errors.Is(err, repositories.ErrESUnavailable) {
+		t.Fatalf("err = %v, want ErrESUnavailable", err)
+	}
+	if indexed, parked := repo.state(5); indexed == nil || parked != nil {
+		t.Errorf("读取失败时不应删除或暂存帖子: indexed = %v, parked = %v", indexed, parked)
+	}
+}
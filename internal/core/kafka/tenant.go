@@ -0,0 +1,46 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/IBM/sarama"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// defaultTenantHeader 是未配置 TenancyConfig.HeaderName 时携带租户标识的消息头，与 HTTP 请求头同名。
+const defaultTenantHeader = "X-Tenant-ID"
+
+// SetTenancy 启用按消息头选择租户索引：携带租户消息头的事件写入该租户的帖子索引，未携带的写入主索引。
+// cfg.Enabled 为 false 时不做任何处理。与 RegisterTopicHandler 一样，必须在消费开始之前调用。
+func (h *Handler) SetTenancy(cfg config.TenancyConfig) {
+	if !cfg.Enabled {
+		return
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = defaultTenantHeader
+	}
+	h.tenancy = &cfg
+	h.logger.Info("Kafka 消息将按消息头选择租户索引", zap.String("header_name", cfg.HeaderName))
+}
+
+// withMessageTenant 读取消息头中的租户标识并写入上下文。
+// 未启用多租户或消息未携带租户头时原样返回 ctx；租户标识无效时返回包装 ErrInvalidTenant 的错误 (永久性错误)。
+func (h *Handler) withMessageTenant(ctx context.Context, message *sarama.ConsumerMessage) (context.Context, error) {
+	if h.tenancy == nil {
+		return ctx, nil
+	}
+	for _, header := range message.Headers {
+		if header == nil || string(header.Key) != h.tenancy.HeaderName {
+			continue
+		}
+		tenant, err := repositories.ParseTenant(string(header.Value), h.tenancy.AllowedTenants)
+		if err != nil {
+			return ctx, fmt.Errorf("消息头 %s 中的租户标识无效 (主题: %s, 偏移量: %d): %w", h.tenancy.HeaderName, message.Topic, message.Offset, err)
+		}
+		return repositories.WithTenant(ctx, tenant), nil
+	}
+	return ctx, nil
+}
@@ -0,0 +1,67 @@
+package kafka
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"go.uber.org/zap"
+)
+
+// Topics 是按用途区分的订阅主题。
+type Topics struct {
+	Audit  string // 审核通过事件 (PostApprovedEvent)，必填。
+	Delete string // 帖子删除事件 (PostDeletedEvent)，必填。
+	Patch  string // 帖子部分更新事件 (PostPatchedEvent)，可选。
+}
+
+// ResolveTopics 从 KafkaConfig 中解析各用途的主题，并将 cfg.SubscribedTopics 改写为由这些主题推导出的列表。
+//
+// 优先使用具名字段 auditTopic / deleteTopic / patchTopic。只有在具名字段全部为空时，
+// 才回退到已弃用的按位置解释 subscribedTopics ([0] 审核、[1] 删除、[2] 部分更新) 的方式并记录警告：
+// 配置中主题顺序一旦被调整，删除事件就会被静默地交给审核处理器。
+// 审核主题和删除主题缺失时返回错误，调用方应拒绝启动。
+func ResolveTopics(cfg *config.KafkaConfig, logger *core.ZapLogger) (Topics, error) {
+	topics := Topics{Audit: cfg.AuditTopic, Delete: cfg.DeleteTopic, Patch: cfg.PatchTopic}
+
+	if topics == (Topics{}) {
+		if len(cfg.SubscribedTopics) > 0 {
+			logger.Warn("Kafka 配置使用了已弃用的按位置解释 subscribedTopics 的方式，请改为配置 auditTopic / deleteTopic / patchTopic",
+				zap.Strings("subscribed_topics", cfg.SubscribedTopics))
+		}
+		positional := make([]string, 3)
+		copy(positional, cfg.SubscribedTopics)
+		topics = Topics{Audit: positional[0], Delete: positional[1], Patch: positional[2]}
+	} else if len(cfg.SubscribedTopics) > 0 {
+		logger.Warn("已配置具名主题，subscribedTopics 将被忽略并由具名主题推导",
+			zap.Strings("ignored_subscribed_topics", cfg.SubscribedTopics))
+	}
+
+	var missing []string
+	if topics.Audit == "" {
+		missing = append(missing, "auditTopic")
+	}
+	if topics.Delete == "" {
+		missing = append(missing, "deleteTopic")
+	}
+	if len(missing) > 0 {
+		logger.Error("Kafka 主题配置不完整", zap.Strings("missing_topics", missing))
+		return Topics{}, fmt.Errorf("Kafka 主题配置不完整，缺少 %s", strings.Join(missing, ", "))
+	}
+	if topics.Audit == topics.Delete {
+		return Topics{}, errors.New("Kafka 主题配置错误：auditTopic 与 deleteTopic 不能相同")
+	}
+
+	cfg.SubscribedTopics = []string{topics.Audit, topics.Delete}
+	if topics.Patch != "" {
+		cfg.SubscribedTopics = append(cfg.SubscribedTopics, topics.Patch)
+	}
+	logger.Info("Kafka 订阅主题解析完成",
+		zap.String("audit_topic", topics.Audit),
+		zap.String("delete_topic", topics.Delete),
+		zap.String("patch_topic", topics.Patch),
+	)
+	return topics, nil
+}
@@ -0,0 +1,96 @@
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// viewCountPatchAttempts 是条件部分更新遇到版本冲突 (读取之后文档被其他写入修改) 时重新读取并重试的总次数，
+// 用尽后回退为完整重新索引。
+const viewCountPatchAttempts = 3
+
+// tryPatchViewCount 在开启 indexingConfig.viewCountOnlyPatch 时，判断审核事件相对于索引中的文档是否只改变了 view_count。
+// 是则只对 view_count 执行部分更新 (_update) 并返回 true，调用方不再整体重新索引；
+// 索引中不存在该帖子、有其他字段变化或读取失败时返回 false，由调用方走完整的 IndexPost。
+// 读取失败不返回错误：完整重新索引始终是正确的，只是代价更高。
+// 部分更新带有读取时的 _seq_no/_primary_term：比较与写入之间文档被其他事件修改 (ES 返回 409) 时重新读取并比较，
+// 多次冲突后回退为完整重新索引，避免基于过期的比较结果只更新 view_count。
+func (s *EventService) tryPatchViewCount(ctx context.Context, eventID string, doc models.EsPostDocument) (bool, error) {
+	for attempt := 1; attempt <= viewCountPatchAttempts; attempt++ {
+		current, err := s.postRepo.GetPost(ctx, doc.ID)
+		if err != nil {
+			s.logger.Warn("读取帖子当前文档失败，回退为完整重新索引",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", doc.ID),
+				zap.Error(err),
+			)
+			return false, nil
+		}
+		if current == nil || !onlyViewCountChanged(current.EsPostDocument, doc) {
+			return false, nil
+		}
+		if current.ViewCount == doc.ViewCount {
+			s.logger.Info("帖子内容与索引中的文档一致，跳过写入",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", doc.ID))
+			return true, nil
+		}
+
+		err = s.postRepo.PatchPostIfUnchanged(ctx, current, map[string]interface{}{"view_count": doc.ViewCount})
+		switch {
+		case err == nil:
+			s.logger.Info("帖子仅浏览量变化，已部分更新 view_count",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", doc.ID),
+				zap.Int64("previous_view_count", current.ViewCount),
+				zap.Int64("view_count", doc.ViewCount))
+			return true, nil
+		case errors.Is(err, repositories.ErrVersionConflict):
+			s.logger.Info("帖子在读取后被修改，重新读取后再比较",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", doc.ID),
+				zap.Int("attempt", attempt))
+		case errors.Is(err, repositories.ErrPostNotFound):
+			// 读取之后帖子被删除：与未开启 viewCountOnlyPatch 时一致，完整写入事件中的文档。
+			return false, nil
+		default:
+			s.logger.Error("部分更新帖子浏览量失败",
+				zap.String("event_id", eventID),
+				zap.Uint64("post_id", doc.ID),
+				zap.Error(err),
+			)
+			return false, fmt.Errorf("更新帖子 ID '%d' 的浏览量失败: %w", doc.ID, err)
+		}
+	}
+
+	s.logger.Warn("帖子多次在读取后被修改，回退为完整重新索引",
+		zap.String("event_id", eventID),
+		zap.Uint64("post_id", doc.ID),
+		zap.Int("attempts", viewCountPatchAttempts))
+	return false, nil
+}
+
+// onlyViewCountChanged 比较索引中的文档与新文档除 view_count 以外的所有存储字段。
+// updated_at 由写入时刷新、highlights 不存在于 _source 中，两者不参与比较；
+// 空切片与 nil 视为相同 (omitempty 字段读回时为 nil)。
+func onlyViewCountChanged(existing, incoming models.EsPostDocument) bool {
+	normalize := func(d models.EsPostDocument) models.EsPostDocument {
+		d.ViewCount = 0
+		d.UpdatedAt = incoming.UpdatedAt
+		d.Highlights = nil
+		if len(d.Images) == 0 {
+			d.Images = nil
+		}
+		if len(d.Tags) == 0 {
+			d.Tags = nil
+		}
+		return d
+	}
+	return reflect.DeepEqual(normalize(existing), normalize(incoming))
+}
@@ -0,0 +1,99 @@
+package kafka
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+func TestTryPatchViewCount(t *testing.T) {
+	// 原文字段只存储在 _source 中，比较时同样参与：索引中的原文与事件一致才算只有浏览量变化。
+	stored := models.EsPostDocument{ID: 1, Title: "标题", Content: "正文", ContentRaw: "<p>正文</p>", Status: enums.Approved, ViewCount: 10}
+	incoming := stored
+	incoming.ViewCount = 12
+
+	tests := []struct {
+		name string
+		// concurrentWrite 在第 call 次 (从 1 开始) 条件更新之前修改索引中的文档，模拟读取之后的并发写入。
+		concurrentWrite        func(repo *memoryPostRepo, call int)
+		wantPatched            bool
+		wantConditionalPatches int
+		wantViewCount          int64
+	}{
+		{
+			name:                   "只有浏览量变化时按读取的版本条件更新",
+			wantPatched:            true,
+			wantConditionalPatches: 1,
+			wantViewCount:          12,
+		},
+		{
+			name: "读取后浏览量被并发修改：版本冲突后重新读取并重试",
+			concurrentWrite: func(repo *memoryPostRepo, call int) {
+				if call == 1 {
+					doc := repo.indexed[1]
+					doc.ViewCount = 11
+					repo.indexed[1] = doc
+					repo.seqNo[1]++
+				}
+			},
+			wantPatched:            true,
+			wantConditionalPatches: 2,
+			wantViewCount:          12,
+		},
+		{
+			name: "读取后内容被并发修改：重新比较后回退为完整重新索引",
+			concurrentWrite: func(repo *memoryPostRepo, call int) {
+				doc := repo.indexed[1]
+				doc.Title = "并发修改的标题"
+				repo.indexed[1] = doc
+				repo.seqNo[1]++
+			},
+			wantPatched:            false,
+			wantConditionalPatches: 1,
+			wantViewCount:          10,
+		},
+		{
+			name: "持续冲突时回退为完整重新索引",
+			concurrentWrite: func(repo *memoryPostRepo, call int) {
+				repo.seqNo[1]++
+			},
+			wantPatched:            false,
+			wantConditionalPatches: viewCountPatchAttempts,
+			wantViewCount:          10,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newMemoryPostRepo()
+			repo.indexed[1] = stored
+			repo.seqNo[1] = 5
+			if tt.concurrentWrite != nil {
+				call := 0
+				repo.beforeConditionalPatch = func() {
+					call++
+					repo.mu.Lock()
+					defer repo.mu.Unlock()
+					tt.concurrentWrite(repo, call)
+				}
+			}
+			svc := NewEventService(repo, config.IndexingConfig{ViewCountOnlyPatch: true}, newTestLogger(t))
+
+			patched, err := svc.tryPatchViewCount(context.Background(), "event-1", incoming)
+			if err != nil {
+				t.Fatalf("tryPatchViewCount 返回错误: %v", err)
+			}
+			if patched != tt.wantPatched {
+				t.Errorf("patched = %v, want %v", patched, tt.wantPatched)
+			}
+			if repo.conditionalPatches != tt.wantConditionalPatches {
+				t.Errorf("条件更新次数 = %d, want %d", repo.conditionalPatches, tt.wantConditionalPatches)
+			}
+			if got := repo.indexed[1].ViewCount; got != tt.wantViewCount {
+				t.Errorf("view_count = %d, want %d", got, tt.wantViewCount)
+			}
+		})
+	}
+}
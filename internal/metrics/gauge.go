@@ -0,0 +1,21 @@
+package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+// GaugeVec 是一组按标签值区分、可以任意设置的整数仪表 (例如最后处理的偏移量)。
+type GaugeVec struct {
+	vec *prometheus.GaugeVec
+}
+
+// NewGaugeVec 创建一个仪表并注册到包级别的注册表中，用法与 NewCounterVec 相同。
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	vec := prometheus.NewGaugeVec(prometheus.GaugeOpts{Name: name, Help: help}, labelNames)
+	registry.MustRegister(vec)
+	return &GaugeVec{vec: vec}
+}
+
+// Set 将指定标签值对应的仪表设置为 value。
+// 标签值数量与 labelNames 不一致属于编程错误，直接 panic 以便在开发阶段暴露。
+func (g *GaugeVec) Set(value int64, labelValues ...string) {
+	g.vec.WithLabelValues(labelValues...).Set(float64(value))
+}
@@ -0,0 +1,43 @@
+// Package metrics 提供进程内的计数器与仪表 (gauge)，基于 prometheus/client_golang 注册并通过 /metrics 对外暴露。
+// 指标统一注册到包级别的注册表，而不是 client_golang 的全局默认注册表，Handler 只输出本服务声明的指标。
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// registry 是本服务所有指标的注册表。
+var registry = prometheus.NewRegistry()
+
+// CounterVec 是一组按标签值区分的单调递增计数器。
+type CounterVec struct {
+	vec *prometheus.CounterVec
+}
+
+// NewCounterVec 创建一个计数器并注册到包级别的注册表中，Handler 会输出所有已注册的计数器。
+// 通常在包级别的 var 声明中调用；labelNames 的顺序即 Inc/Add 时标签值的顺序。
+// 指标名重复或不合法属于编程错误，注册时直接 panic。
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	vec := prometheus.NewCounterVec(prometheus.CounterOpts{Name: name, Help: help}, labelNames)
+	registry.MustRegister(vec)
+	return &CounterVec{vec: vec}
+}
+
+// Inc 将指定标签值对应的计数器加 1。
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add 将指定标签值对应的计数器增加 delta。
+// 标签值数量与 labelNames 不一致属于编程错误，直接 panic 以便在开发阶段暴露。
+func (c *CounterVec) Add(delta uint64, labelValues ...string) {
+	c.vec.WithLabelValues(labelValues...).Add(float64(delta))
+}
+
+// Handler 返回供 Prometheus 抓取的 HTTP 处理器。
+func Handler() http.Handler {
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
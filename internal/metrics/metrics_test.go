@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestHandlerExposesRegisteredMetrics(t *testing.T) {
+	counter := NewCounterVec("post_search_test_events_total", "Events seen by the test.", "topic")
+	gauge := NewGaugeVec("post_search_test_offset", "Offset set by the test.", "topic", "partition")
+	counter.Inc(`post."events"`)
+	counter.Add(2, `post."events"`)
+	gauge.Set(42, "post", "0")
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE post_search_test_events_total counter",
+		`post_search_test_events_total{topic="post.\"events\""} 3`,
+		"# TYPE post_search_test_offset gauge",
+		`post_search_test_offset{partition="0",topic="post"} 42`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("输出中缺少 %q:\n%s", want, body)
+		}
+	}
+}
+
+func TestLabelCountMismatchPanics(t *testing.T) {
+	counter := NewCounterVec("post_search_test_mismatch_total", "Counter used for the label mismatch test.", "topic", "action")
+	defer func() {
+		if recover() == nil {
+			t.Error("标签值数量与标签名不一致时应 panic")
+		}
+	}()
+	counter.Inc("only-topic")
+}
@@ -0,0 +1,113 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// SearchRequest.QueryMode 的可选值。
+const (
+	QueryModeDefault  = "default"  // 关键词按 multi_match 匹配，支持以 "-" 开头的排除词
+	QueryModeAdvanced = "advanced" // Lucene 风格的 query_string 语法，支持 title:kafka 这样的字段限定词
+)
+
+// AdvancedQueryFields 是高级查询中允许使用的字段限定词白名单。
+// 只开放文本字段和标签：其他字段 (例如 contact_info) 不应允许被任意检索，数值/日期字段应使用筛选参数。
+var AdvancedQueryFields = map[string]bool{
+	"title":           true,
+	"content":         true,
+	"author_username": true,
+	"tags":            true,
+}
+
+// quotedPhrasePattern 匹配双引号包裹的短语 (支持 \" 转义)，短语内的冒号、斜杠等字符不是语法。
+var quotedPhrasePattern = regexp.MustCompile(`"(?:[^"\\]|\\.)*"`)
+
+// leadingWildcardPattern 匹配以 * 或 ? 开头的词 (可带字段限定词或前缀运算符)。
+// 前导通配符需要遍历整个词典，代价很高，因此在发送给 ES 之前拒绝 (ES 侧同样设置 allow_leading_wildcard=false)。
+var leadingWildcardPattern = regexp.MustCompile(`(?:^|[\s(:+\-!&|"])[*?]`)
+
+// ValidateAdvancedQuery 校验高级查询语法中可能危害 ES 的用法，返回第一个问题的描述；合法时返回 nil。
+//   - 字段限定词必须在 AdvancedQueryFields 白名单中 (不允许 _exists_、字段通配符等)。
+//   - 不允许前导通配符 (*foo、?oo) 与正则表达式 (/.../)。
+//   - 双引号必须成对出现。
+func ValidateAdvancedQuery(query string) error {
+	fields, err := advancedQueryFields(query)
+	if err != nil {
+		return err
+	}
+	for _, field := range fields {
+		if !AdvancedQueryFields[field] {
+			return fmt.Errorf("不支持按字段 '%s' 检索，可用字段: title, content, author_username, tags", field)
+		}
+	}
+	// 去掉短语内容后再检查语法，短语中的字符按原文匹配。
+	stripped := quotedPhrasePattern.ReplaceAllString(strings.ReplaceAll(query, `\\`, ""), `""`)
+	if leadingWildcardPattern.MatchString(stripped) {
+		return errors.New("不支持以 * 或 ? 开头的通配符")
+	}
+	if strings.Contains(strings.ReplaceAll(stripped, `\/`, ""), "/") {
+		return errors.New("不支持正则表达式 (/.../)，如需搜索斜杠请使用 \\/ 转义")
+	}
+	return nil
+}
+
+// advancedQueryFields 按 query_string 的词法扫描查询，返回每个字段限定词 (双引号外、未转义的冒号之前的词) 的字段名。
+// 为什么不用正则匹配 "字段名:"?
+// query_string 中几乎任何未转义的分隔符 (&&、||、引号、括号等) 之后都可以紧跟字段限定词，
+// 字段名本身还可以包含转义字符 (contact\_info 等价于 contact_info)；只检查部分前缀字符的正则很容易被绕过。
+// 这里逐字符扫描：反斜杠转义的字符按字面值计入当前词，分隔符结束当前词，遇到冒号时当前词即为字段名 (已去除转义)。
+// 冒号前没有词 (例如 "x":y 或 :y) 同样返回错误。双引号未成对出现时返回错误。
+func advancedQueryFields(query string) ([]string, error) {
+	var (
+		fields  []string
+		term    strings.Builder
+		inQuote bool
+	)
+	runes := []rune(query)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		if r == '\\' {
+			if i+1 < len(runes) {
+				i++
+				if !inQuote {
+					term.WriteRune(runes[i])
+				}
+			}
+			continue
+		}
+		if inQuote {
+			if r == '"' {
+				inQuote = false
+			}
+			continue
+		}
+		switch {
+		case r == '"':
+			inQuote = true
+			term.Reset()
+		case r == ':':
+			if term.Len() == 0 {
+				return nil, errors.New("冒号前缺少字段名，如需搜索冒号请使用 \\: 转义")
+			}
+			fields = append(fields, term.String())
+			term.Reset()
+		case r == '-' && term.Len() == 0:
+			// 词首的 - 是排除运算符，词中的 - 属于词本身 (例如 e-mail)。
+		case unicode.IsSpace(r) || strings.ContainsRune(advancedQuerySeparators, r):
+			term.Reset()
+		default:
+			term.WriteRune(r)
+		}
+	}
+	if inQuote {
+		return nil, errors.New("双引号必须成对出现")
+	}
+	return fields, nil
+}
+
+// advancedQuerySeparators 是 query_string 语法中结束一个词的运算符字符 (空白另行判断)。
+const advancedQuerySeparators = "()[]{}+!&|^~<>="
@@ -0,0 +1,41 @@
+package models
+
+import "testing"
+
+func TestValidateAdvancedQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		wantErr bool
+	}{
+		{name: "普通关键词", query: "kafka 教程"},
+		{name: "白名单字段", query: "title:kafka AND tags:go"},
+		{name: "前缀运算符与分组", query: "+title:go -(content:java OR tags:java)"},
+		{name: "词中的连字符", query: "title:e-mail"},
+		{name: "短语中的冒号", query: `title:"a:b" content:"contact_info:1"`},
+		{name: "转义的冒号", query: `title:10\:30`},
+		{name: "范围语法", query: "title:[a TO c]"},
+
+		{name: "非白名单字段", query: "contact_info:1380", wantErr: true},
+		{name: "&& 之后的字段", query: "&&contact_info:1380", wantErr: true},
+		{name: "|| 之后的字段", query: "title:go||author_id:abc", wantErr: true},
+		{name: "短语之后紧跟字段", query: `"x"contact_info:1380`, wantErr: true},
+		{name: "字段名中的转义字符", query: `contact\_info:1380`, wantErr: true},
+		{name: "括号内的字段", query: "(title:go)(contact_info:1)", wantErr: true},
+		{name: "字段通配符", query: "*:kafka", wantErr: true},
+		{name: "_exists_", query: "_exists_:contact_info", wantErr: true},
+		{name: "冒号前没有字段名", query: ":kafka", wantErr: true},
+		{name: "双引号未成对", query: `title:"kafka`, wantErr: true},
+		{name: "前导通配符", query: "title:*fka", wantErr: true},
+		{name: "&& 之后的前导通配符", query: "go&&*fka", wantErr: true},
+		{name: "正则表达式", query: "title:/ka.*/", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateAdvancedQuery(tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidateAdvancedQuery(%q) error = %v, wantErr %v", tt.query, err, tt.wantErr)
+			}
+		})
+	}
+}
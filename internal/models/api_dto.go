@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+
 	"github.com/Xushengqwer/go-common/models/enums" // 确保 enums 包路径正确
 )
 
@@ -8,23 +10,100 @@ import (
 
 // SearchRequest 定义搜索 API 请求的参数及验证规则.
 type SearchRequest struct {
-	Query     string `form:"q"`                                                          // 搜索关键词，非必需
-	Page      int    `form:"page,default=1" binding:"omitempty,min=1"`                   // 页码，可选，默认为1，最小为1
-	Size      int    `form:"size,default=10" binding:"omitempty,min=1,max=100"`          // 每页大小，可选，默认10，范围1-100
-	SortBy    string `form:"sort_by,default=updated_at" binding:"omitempty"`             // 排序字段，可选，默认 updated_at
-	SortOrder string `form:"sort_order,default=desc" binding:"omitempty,oneof=asc desc"` // 排序顺序，可选，默认 desc，必须是 asc 或 desc
+	Query     string `form:"q"`                                                     // 搜索关键词，非必需
+	QueryMode string `form:"query_mode" binding:"omitempty,oneof=default advanced"` // 关键词语法，可选：default (multi_match) 或 advanced (query_string，支持 title:kafka)
+	Page      int    `form:"page,default=1" binding:"omitempty,min=1"`              // 页码，可选，默认为1，最小为1
+	Size      int    `form:"size,default=10" binding:"omitempty,min=1,max=100"`     // 每页大小，可选，默认10，范围1-100
+	SortBy    string `form:"sort_by" binding:"omitempty"`                           // 排序字段，可选，必须在 SortableFields 白名单中；未传递时由服务端按是否有关键词选择默认排序
+	SortOrder string `form:"sort_order" binding:"omitempty,oneof=asc desc"`         // 排序顺序，可选，必须是 asc 或 desc；未传递时使用默认排序的顺序
 
 	// --- 过滤器字段 ---
 	// 这些字段用于根据精确条件筛选结果，不影响相关性评分。
 	// 确保这些字段的名称和类型与前端请求参数一致，并且后端有相应的处理逻辑。
-	AuthorID string        `form:"author_id" binding:"omitempty,uuid|alphanum"` // 可选，按作者ID筛选。binding 标签用于输入验证。
+	AuthorID string        `form:"author_id"` // 可选，按作者ID筛选。去除两端空白后必须是 UUID 或字母数字 (在 validateSearchRequest 中校验)。
 	Status   *enums.Status `form:"status" binding:"omitempty,min=0,max=2" swaggertype:"primitive,integer" example:"1"`
-	// 你可以根据需要添加更多过滤字段，例如：
-	// Tags     []string `form:"tags" binding:"omitempty"` // 按标签筛选 (如果帖子有标签字段)
-	// StartDate *time.Time `form:"start_date" binding:"omitempty,datetime"` // 按起始日期筛选
-	// EndDate   *time.Time `form:"end_date" binding:"omitempty,datetime"`   // 按结束日期筛选
+
+	// Statuses 是服务端施加的可搜索状态限制 (SearchConfig.SearchableStatuses)，不能由客户端传入。
+	// 仅在客户端未指定 Status 时由 SearchService 填充；JSON 表示参与结果缓存键的计算。
+	Statuses []enums.Status `form:"-" json:"statuses,omitempty" swaggerignore:"true"`
+
+	// CreatedFrom / CreatedTo 按帖子创建时间筛选 (Unix 毫秒时间戳，闭区间)。
+	CreatedFrom *int64 `form:"created_from" binding:"omitempty,min=0" example:"1717171200000"` // 可选，创建时间下限 (含)
+	CreatedTo   *int64 `form:"created_to" binding:"omitempty,min=0" example:"1719763200000"`   // 可选，创建时间上限 (含)
+
+	// UpdatedFrom / UpdatedTo 按帖子更新时间筛选 (Unix 毫秒时间戳，闭区间)。
+	UpdatedFrom *int64 `form:"updated_from" binding:"omitempty,min=0" example:"1717171200000"` // 可选，更新时间下限 (含)
+	UpdatedTo   *int64 `form:"updated_to" binding:"omitempty,min=0" example:"1719763200000"`   // 可选，更新时间上限 (含)
+
+	// Freshness 是 "最近一天/一周/一个月内更新" 的快捷筛选 (day/week/month)，由 SearchService 转换为 updated_at 下限。
+	// 与 updated_from 同时传递时取更窄的窗口。
+	Freshness string `form:"freshness" binding:"omitempty,oneof=day week month" example:"week"`
+
+	// ExcludeIDs 是需要从结果中排除的帖子 ID 列表 (例如推荐页已展示的帖子)。
+	// 以重复的查询参数传递：exclude_ids=1&exclude_ids=2。数量上限由 SearchConfig.MaxExcludeIDs 控制。
+	ExcludeIDs []uint64 `form:"exclude_ids" binding:"omitempty,dive,min=1"`
+
+	// BoostRecency 是否在相关性评分中提升较新的帖子。未传递时使用服务端配置 (searchConfig.recencyBoost.enabled)。
+	BoostRecency *bool `form:"boost_recency" example:"true"`
+
+	// HighlightFields 限制需要高亮的字段 (title/content/author_username)。
+	// 未传递时高亮 title 和 content；传递了空值 (highlight_fields=) 时关闭高亮，搜索本身不受影响。
+	HighlightFields []string `form:"highlight_fields"`
+
+	// HighlightRequireFieldMatch 是否只高亮实际匹配了查询的字段。未传递时使用服务端配置 (默认 true)。
+	HighlightRequireFieldMatch *bool `form:"highlight_require_field_match" example:"true"`
+
+	// HighlightEncoder 是高亮片段的编码方式 (html/default)。未传递时使用服务端配置 (默认 html，转义字段内容中的 HTML)。
+	HighlightEncoder string `form:"highlight_encoder" binding:"omitempty,oneof=default html" example:"html"`
+
+	// SourceFields 限制响应中每个帖子返回的字段 (ES _source 过滤)，例如列表页不需要 content。
+	// 支持重复参数或逗号分隔；id 始终会被返回。未传递时返回全部字段。高亮不受影响 (来自 highlight 部分)。
+	SourceFields []string `form:"source_fields"`
+
+	// Tags 按标签筛选，以重复的查询参数传递：tags=二手&tags=数码。
+	// 默认命中任意一个标签即可 (OR)；MatchAllTags 为 true 时要求同时包含所有标签 (AND)。
+	Tags         []string `form:"tags" binding:"omitempty,dive,min=1,max=64"`
+	MatchAllTags bool     `form:"match_all_tags" example:"false"`
+
+	// HasContactQRCode 按是否有联系方式二维码筛选：true 只返回有二维码的帖子，false 只返回没有的 (字段缺失或为空字符串)。
+	// 未传递时不筛选。依赖 contact_qr_code 被索引，旧索引 (映射为 index: false) 需要重建后才能使用。
+	HasContactQRCode *bool `form:"has_contact_qr_code" example:"true"`
+
+	// MinScore 是相关性得分下限 (ES min_score)，得分低于它的文档不会出现在结果中，用于过滤模糊/宽泛搜索的弱匹配。
+	// 只在有正向关键词时生效：没有关键词时查询为 match_all，所有文档得分相同，阈值没有意义。
+	MinScore *float64 `form:"min_score" binding:"omitempty,min=0" example:"1.5"`
+
+	// IncludeFacets 为 true 时在结果的 facets 中返回分面统计 (目前为 price_per_unit 区间)，
+	// 统计范围是满足全部查询与筛选条件的帖子，不受分页影响。
+	IncludeFacets bool `form:"include_facets" example:"false"`
+
+	// IncludeArchived 为 true 时同时搜索配置的归档索引 (searchConfig.archiveIndices)，排序与分页在合并后的结果上进行。
+	// 未配置归档索引时忽略。
+	IncludeArchived bool `form:"include_archived" example:"false"`
+
+	// FilterGroups 是以 JSON 数组形式传递的过滤条件组 (查询参数 filter_groups)，由 API 层解析。
+	// 每组内的条件为 OR，组与组之间以及与上面的普通筛选参数之间为 AND。未传递时只使用普通筛选参数。
+	FilterGroups []FilterGroup `form:"-" json:"filter_groups,omitempty"`
+
+	// Debug 为 true 时在响应的 _debug.dsl 中返回实际发送给 ES 的查询 DSL，用于排查相关性问题。
+	// 仅对 admin 请求生效，非 admin 请求携带时由 API 层忽略。Pretty 为 true 时 DSL 以缩进格式返回。
+	Debug  bool `form:"debug" example:"false"`
+	Pretty bool `form:"pretty" example:"false"`
 }
 
+// SearchRequest.HighlightEncoder 的可选值，对应 ES 高亮的 encoder 参数。
+const (
+	HighlightEncoderHTML    = "html"    // 转义字段内容中的 HTML 字符后再插入高亮标签
+	HighlightEncoderDefault = "default" // 不转义，原样返回字段内容
+)
+
+// SearchRequest.Freshness 的可选值。
+const (
+	FreshnessDay   = "day"   // 最近 24 小时内更新
+	FreshnessWeek  = "week"  // 最近 7 天内更新
+	FreshnessMonth = "month" // 最近 30 天内更新
+)
+
 // SearchResult 定义搜索 API 的响应数据结构.
 type SearchResult struct {
 	Hits  []EsPostDocument `json:"hits"`                           // 命中的帖子列表
@@ -33,4 +112,86 @@ type SearchResult struct {
 	Size  int              `json:"size"`                           // 当前页大小
 	Took  int64            `json:"took_ms,omitempty" example:"50"` // UPRAVENO: Doba trvání dotazu v milisekundách (typ int64)
 	// json:"took_ms,omitempty" 表示在序列化为JSON时，字段名为 "took_ms"，如果值为零值则忽略。
+
+	// 关键词搜索没有命中时，按配置 (searchConfig.zeroResultFallback) 附带的热门帖子，与 Hits 分开返回。
+	Fallbacks    []EsPostDocument `json:"fallbacks,omitempty"`     // 兜底推荐的热门帖子 (按 view_count 倒序)，并非搜索命中
+	FallbackUsed bool             `json:"fallback_used,omitempty"` // 为 true 时 Fallbacks 中的帖子是兜底推荐
+
+	Facets *SearchFacets `json:"facets,omitempty"` // 分面统计，仅请求携带 include_facets=true 时返回
+
+	Debug *SearchDebugInfo `json:"_debug,omitempty"` // 调试信息，仅 admin 请求携带 debug=true 时返回
+
+	// 请求携带 source_fields 时由仓库层填充，序列化时代替 Hits 作为 "hits" 返回：
+	// 只包含 _source 中实际返回的字段 (以及高亮片段)，未请求的字段不会以零值出现在响应中。
+	// Hits 仍然填充，供服务内部使用。
+	ProjectedHits []map[string]interface{} `json:"-"`
+}
+
+// MarshalJSON 在存在 ProjectedHits 时用它代替 Hits 输出 "hits"。
+func (r SearchResult) MarshalJSON() ([]byte, error) {
+	type plainSearchResult SearchResult // 去掉 MarshalJSON 方法，避免递归
+	if r.ProjectedHits == nil {
+		return json.Marshal(plainSearchResult(r))
+	}
+	return json.Marshal(struct {
+		plainSearchResult
+		Hits []map[string]interface{} `json:"hits"`
+	}{plainSearchResult(r), r.ProjectedHits})
+}
+
+// SearchFacets 是搜索结果的分面统计。
+type SearchFacets struct {
+	PriceRanges []RangeFacetBucket `json:"price_ranges"` // price_per_unit 各区间的帖子数，按区间升序
+}
+
+// RangeFacetBucket 是数值区间分面中的一个区间，下限含、上限不含。
+type RangeFacetBucket struct {
+	Key      string   `json:"key" example:"10-50"`         // 区间标识，形如 "*-10"、"10-50"、"50-*"
+	From     *float64 `json:"from,omitempty" example:"10"` // 区间下限 (含)，第一个区间没有下限
+	To       *float64 `json:"to,omitempty" example:"50"`   // 区间上限 (不含)，最后一个区间没有上限
+	DocCount int64    `json:"doc_count" example:"42"`      // 落在该区间的帖子数
+}
+
+// SearchDebugInfo 是搜索响应中的调试信息。
+type SearchDebugInfo struct {
+	DSL string `json:"dsl"` // 实际发送给 Elasticsearch 的查询 DSL (JSON 字符串)
+}
+
+// UnifiedSearchResult 是统一搜索的响应：帖子结果与热门搜索词联想分开返回。
+type UnifiedSearchResult struct {
+	Posts                   *SearchResult   `json:"posts"`                               // 帖子搜索结果
+	TermSuggestions         []HotSearchTerm `json:"term_suggestions"`                    // 以关键词开头的热门搜索词，按搜索次数倒序
+	TermSuggestionsDegraded bool            `json:"term_suggestions_degraded,omitempty"` // 为 true 时热门搜索词查询失败或超时，term_suggestions 为空
+}
+
+// MultiSearchRequest 是批量搜索 (POST /msearch) 的请求体。
+// Requests 的每个元素是一个 JSON 对象，键与 GET /search 的查询参数相同 (例如 {"q":"kafka","tags":["go"],"size":5})，
+// 数组表示重复传递的参数，filter_groups 可直接传 JSON 数组。
+type MultiSearchRequest struct {
+	Requests []map[string]json.RawMessage `json:"requests" binding:"required,min=1" swaggertype:"array,object"` // 子搜索列表，数量受服务端配置限制 (默认 10 个)
+}
+
+// FieldValidationError 描述单个请求参数的校验失败详情。
+type FieldValidationError struct {
+	Field   string `json:"field" example:"size"`               // 校验失败的参数名 (与查询参数名一致，例如 "size")
+	Rule    string `json:"rule" example:"max"`                 // 未通过的校验规则 (例如 "max", "min", "oneof")
+	Param   string `json:"param,omitempty" example:"100"`      // 校验规则的参数 (例如 max=100 中的 "100")
+	Value   string `json:"value,omitempty" example:"500"`      // 客户端提交的原始值
+	Message string `json:"message" example:"参数 size 不能大于 100"` // 面向人类的错误描述
+}
+
+// ValidationErrorData 是参数校验失败时响应 data 字段的负载。
+type ValidationErrorData struct {
+	Errors  []FieldValidationError `json:"errors"`             // 所有校验失败的字段列表
+	TraceID string                 `json:"trace_id,omitempty"` // 当前请求的 trace ID，便于反馈问题时关联服务端日志
+}
+
+// ErrorData 是错误响应 data 字段的负载，目前只携带 trace ID。
+type ErrorData struct {
+	TraceID string `json:"trace_id,omitempty" example:"4bf92f3577b34da6a3ce929d0e0e4736"` // 当前请求的 trace ID
+}
+
+// MgetRequest 定义批量获取帖子接口 (POST /posts/mget) 的请求体。
+type MgetRequest struct {
+	IDs []uint64 `json:"ids" binding:"required,min=1,dive,min=1" example:"401,402"` // 需要获取的帖子 ID 列表，返回结果保持此顺序
 }
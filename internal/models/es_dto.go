@@ -25,9 +25,14 @@ type EsPostDocument struct {
 	OfficialTag    enums.OfficialTag `json:"official_tag" swaggertype:"primitive,integer" example:"0"` // 官方标签，直接使用导入的枚举类型（建议在 ES 中存储为整数或映射为 keyword）。
 	PricePerUnit   float64           `json:"price_per_unit"`                                           // 每单位价格（如果适用）。
 	ContactInfo    string            `json:"contact_info"`                                             // 联系方式
-	CreatedAt      int64             `json:"created_at"`
-	UpdatedAt      time.Time         `json:"updated_at"`       // 文档在 Elasticsearch 中最后更新的时间戳。
-	Images         []ImageEventData  `json:"images,omitempty"` // 图片列表
+	ContactQRCode  string            `json:"contact_qr_code,omitempty"`                                // 联系方式二维码 (URL 或对象存储 key)，为空表示没有二维码；可按是否存在筛选。
+	CreatedAt      int64             `json:"created_at"`                                               // 帖子创建时间 (Unix 毫秒时间戳)，在 ES 中映射为 date 类型。
+	UpdatedAt      time.Time         `json:"updated_at"`                                               // 文档在 Elasticsearch 中最后更新的时间戳。
+	Images         []ImageEventData  `json:"images,omitempty"`                                         // 图片列表
+	Tags           []string          `json:"tags,omitempty"`                                           // 帖子标签，在 ES 中映射为 keyword，用于精确筛选。
+	ExpiresAt      int64             `json:"expires_at,omitempty"`                                     // 帖子过期时间 (Unix 毫秒时间戳)，0 表示永不过期；过期后由清理任务删除。
+	TitleRaw       string            `json:"-"`                                                        // 去除 HTML 标签前的原始标题 (开启 stripHTML.preserveOriginal 时写入 title_raw，不索引，也不随 API 返回)。
+	ContentRaw     string            `json:"-"`                                                        // 去除 HTML 标签前的原始正文 (开启 stripHTML.preserveOriginal 时写入 content_raw，不索引，也不随 API 返回)。
 
 	// 新增：用于存储高亮片段的字段
 	// 键是字段名 (如 "title", "content")，值是包含高亮HTML片段的字符串切片。
@@ -0,0 +1,81 @@
+package models
+
+// FilterGroup 是一组以 OR (should) 组合的过滤条件，用于分面导航中的 "或" 条件，
+// 例如 (official_tag=1 OR view_count>1000)。多个 FilterGroup 之间、以及与 author_id/tags 等普通筛选参数之间均为 AND。
+// 过滤条件不参与相关性评分。
+type FilterGroup struct {
+	Should []FilterCondition `json:"should"`
+	// MinimumShouldMatch 是组内至少需要满足的条件数，0 或未传递时为 1。
+	MinimumShouldMatch int `json:"minimum_should_match,omitempty"`
+}
+
+// FilterCondition 是单个过滤条件。
+type FilterCondition struct {
+	Field string `json:"field"` // 字段名，必须在 FilterableFields 白名单中
+	// Op 是比较方式：eq (等于)、in (等于其中任意一个)、gt/gte/lt/lte (范围，只适用于数值和日期字段)。
+	Op string `json:"op"`
+	// Value 是比较值：in 时为数组，其余为单个值。日期字段使用 Unix 毫秒时间戳。
+	Value interface{} `json:"value" swaggertype:"object"`
+}
+
+// 过滤条件组的数量限制，防止客户端构造过于庞大的 bool 查询。
+const (
+	MaxFilterGroups            = 5
+	MaxFilterGroupConditions   = 10
+	MaxFilterConditionInValues = 100
+)
+
+// 过滤条件支持的比较方式。
+const (
+	FilterOpEq  = "eq"
+	FilterOpIn  = "in"
+	FilterOpGt  = "gt"
+	FilterOpGte = "gte"
+	FilterOpLt  = "lt"
+	FilterOpLte = "lte"
+)
+
+// IsRangeFilterOp 判断比较方式是否为范围比较 (gt/gte/lt/lte)。
+func IsRangeFilterOp(op string) bool {
+	switch op {
+	case FilterOpGt, FilterOpGte, FilterOpLt, FilterOpLte:
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizeFilterGroupAuthorIDs 返回 author_id 条件的值经过 NormalizeAuthorID 规范化后的过滤条件组副本，
+// 使其与普通的 author_id 参数以及索引侧的处理方式一致。
+func NormalizeFilterGroupAuthorIDs(groups []FilterGroup, lowercase bool) []FilterGroup {
+	if len(groups) == 0 {
+		return groups
+	}
+	normalized := make([]FilterGroup, len(groups))
+	for i, group := range groups {
+		should := make([]FilterCondition, len(group.Should))
+		for j, cond := range group.Should {
+			if cond.Field == "author_id" {
+				cond.Value = normalizeAuthorIDValue(cond.Value, lowercase)
+			}
+			should[j] = cond
+		}
+		normalized[i] = FilterGroup{Should: should, MinimumShouldMatch: group.MinimumShouldMatch}
+	}
+	return normalized
+}
+
+func normalizeAuthorIDValue(v interface{}, lowercase bool) interface{} {
+	switch val := v.(type) {
+	case string:
+		return NormalizeAuthorID(val, lowercase)
+	case []interface{}:
+		out := make([]interface{}, len(val))
+		for i, item := range val {
+			out[i] = normalizeAuthorIDValue(item, lowercase)
+		}
+		return out
+	default:
+		return v
+	}
+}
@@ -9,6 +9,41 @@ type HotSearchTerm struct {
 	Count int64  `json:"count,omitempty"` // 搜索词的频率计数，omitempty表示如果为0则不在JSON中显示，可选
 }
 
+// GET /hot-terms 的 sort 参数可选值。
+const (
+	HotTermsSortCount   = "count"   // 按搜索次数倒序 (默认)
+	HotTermsSortRecent  = "recent"  // 按最后一次被搜索的时间倒序
+	HotTermsSortBlended = "blended" // 按综合得分倒序：搜索次数 (取对数) 乘以随最后搜索时间衰减的系数
+)
+
+// IsValidHotTermsSort 判断 sort 参数是否为支持的排序方式。
+func IsValidHotTermsSort(sort string) bool {
+	switch sort {
+	case HotTermsSortCount, HotTermsSortRecent, HotTermsSortBlended:
+		return true
+	default:
+		return false
+	}
+}
+
+// HotSearchTermPage 是分页浏览全部热门搜索词 (admin) 时返回的一页结果。
+type HotSearchTermPage struct {
+	Terms []HotSearchTerm `json:"terms"` // 当前页的搜索词，按计数倒序
+	Total int64           `json:"total"` // 计数为正的搜索词总数
+	From  int             `json:"from"`  // 当前页起始偏移量
+	Size  int             `json:"size"`  // 当前页大小
+}
+
+// SearchVolume 是一段时间内的搜索量统计 (GET /_search-volume)。
+// 热门搜索词索引只保存每个词的累计计数，带时间窗口时 TotalSearches 为窗口内被搜索过的词的累计计数之和，
+// 即窗口内搜索量的上限估计；采样记录和计数衰减也会使其与实际搜索次数存在偏差。
+type SearchVolume struct {
+	Window        string     `json:"window" example:"24h"`           // 统计窗口，"all" 表示不限时间
+	Since         *time.Time `json:"since,omitempty"`                // 窗口起点 (UTC)，不限时间时省略
+	TotalSearches int64      `json:"total_searches" example:"12840"` // 搜索次数 (计数之和)
+	DistinctTerms int64      `json:"distinct_terms" example:"1375"`  // 窗口内被搜索过的不同搜索词数量
+}
+
 // HotSearchTermES 定义在 Elasticsearch 中存储热门搜索词统计数据的结构。
 // 这个结构体用于在Elasticsearch中存储和聚合搜索词的频率。
 type HotSearchTermES struct {
@@ -16,3 +51,10 @@ type HotSearchTermES struct {
 	Count          int64     `json:"count"`            // 该搜索词被搜索的总次数
 	LastSearchedAt time.Time `json:"last_searched_at"` // 该搜索词最后一次被搜索的时间，UTC格式
 }
+
+// HotTermsIndexResetResult 是重置 (删除并重建) 热门搜索词索引的结果。
+type HotTermsIndexResetResult struct {
+	Index            string    `json:"index" example:"hot_search_terms_stats"` // 被重置的索引名称
+	PreviousDocCount int64     `json:"previous_doc_count" example:"1250"`      // 重置前的文档数 (被清除的搜索词数量)
+	ResetAt          time.Time `json:"reset_at"`                               // 重置完成的时间 (UTC)
+}
@@ -0,0 +1,22 @@
+package models
+
+import "testing"
+
+func TestIsValidHotTermsSort(t *testing.T) {
+	tests := []struct {
+		sort string
+		want bool
+	}{
+		{sort: HotTermsSortCount, want: true},
+		{sort: HotTermsSortRecent, want: true},
+		{sort: HotTermsSortBlended, want: true},
+		{sort: ""},
+		{sort: "Count"},
+		{sort: "score"},
+	}
+	for _, tt := range tests {
+		if got := IsValidHotTermsSort(tt.sort); got != tt.want {
+			t.Errorf("IsValidHotTermsSort(%q) = %v, want %v", tt.sort, got, tt.want)
+		}
+	}
+}
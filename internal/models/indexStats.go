@@ -0,0 +1,17 @@
+package models
+
+// IndexStats 汇总服务所管理的 Elasticsearch 索引的统计信息，供运维观察索引增长情况。
+type IndexStats struct {
+	Posts    IndexStatsEntry `json:"posts"`     // 主帖子索引
+	HotTerms IndexStatsEntry `json:"hot_terms"` // 热门搜索词索引
+}
+
+// IndexStatsEntry 描述单个索引的文档数量、存储大小和分片情况。
+type IndexStatsEntry struct {
+	Index            string `json:"index" example:"posts_index"`          // 索引名称 (或别名)
+	DocCount         int64  `json:"doc_count" example:"12345"`            // 文档数量 (来自 _count API，不含已删除文档)
+	StoreSizeBytes   int64  `json:"store_size_bytes" example:"10485760"`  // 所有分片副本占用的存储大小 (字节)
+	PrimarySizeBytes int64  `json:"primary_size_bytes" example:"5242880"` // 仅主分片占用的存储大小 (字节)
+	ShardsTotal      int    `json:"shards_total" example:"2"`             // 分片副本总数 (主分片 + 副本分片)
+	ShardsSuccessful int    `json:"shards_successful" example:"2"`        // 成功返回统计信息的分片数，小于总数时说明有分片未分配
+}
@@ -0,0 +1,21 @@
+package models
+
+// IndexRefreshResult 是手动刷新索引 (_refresh) 的结果。
+type IndexRefreshResult struct {
+	Index            string `json:"index" example:"posts_index"`   // 被刷新的索引名称 (或别名)
+	ShardsTotal      int    `json:"shards_total" example:"6"`      // 需要刷新的分片副本总数
+	ShardsSuccessful int    `json:"shards_successful" example:"6"` // 刷新成功的分片数
+	ShardsFailed     int    `json:"shards_failed" example:"0"`     // 刷新失败的分片数
+}
+
+// IndexRefreshIntervalRequest 是动态调整帖子索引 refresh_interval 的请求体。
+// 批量回填前可设置为 "-1" 关闭自动刷新，回填结束后传空字符串恢复为配置值。
+type IndexRefreshIntervalRequest struct {
+	RefreshInterval string `json:"refresh_interval" example:"-1"` // 新的刷新间隔 (例如 "30s"，"-1" 关闭自动刷新)，为空表示恢复为配置值
+}
+
+// IndexRefreshIntervalResult 是调整 refresh_interval 后的结果。
+type IndexRefreshIntervalResult struct {
+	Index           string `json:"index" example:"posts_index"`   // 被调整的索引名称 (或别名)
+	RefreshInterval string `json:"refresh_interval" example:"-1"` // 生效的刷新间隔，为空表示已恢复为 ES 默认值
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// PostPatchedEvent 是帖子部分字段变更事件：上游只修改了少量字段 (例如 status、official_tag) 时发送，
+// 本服务使用 ES 的 _update (partial doc) 只更新这些字段，避免携带完整帖子数据重新索引。
+// 共享模块 kafkaevents 中尚无对应的事件结构，因此在本服务内定义，字段命名与 kafkaevents 保持一致。
+type PostPatchedEvent struct {
+	EventID   string                 `json:"event_id"`  // 事件唯一ID
+	Timestamp time.Time              `json:"timestamp"` // 事件发生时间
+	PostID    uint64                 `json:"post_id"`   // 帖子ID
+	Fields    map[string]interface{} `json:"fields"`    // 需要更新的字段，键必须在 PatchableFields 白名单中
+}
@@ -0,0 +1,35 @@
+package models
+
+import "time"
+
+// KafkaStatus 是 Kafka 消费者运行时配置与状态的快照，用于 GET /_kafka-status 诊断接口。
+type KafkaStatus struct {
+	GroupID          string   `json:"group_id"`          // 消费者组 ID
+	SubscribedTopics []string `json:"subscribed_topics"` // 消费者组订阅的主题
+	HandledTopics    []string `json:"handled_topics"`    // Handler 注册了处理函数的主题 (按名称排序)
+	Running          bool     `json:"running"`           // 消费循环 goroutine 是否在运行
+
+	ConsecutiveConsumeErrors int64 `json:"consecutive_consume_errors"` // Consume 当前的连续失败次数，一次会话正常结束后归零
+
+	Paused   bool       `json:"paused"`              // 是否已通过 admin 接口暂停消费
+	PausedAt *time.Time `json:"paused_at,omitempty"` // 暂停开始的时间
+
+	Ready           bool       `json:"ready"`                       // Handler 是否已完成过至少一次 Setup
+	InSession       bool       `json:"in_session"`                  // 当前是否处于消费者组会话中 (Setup 之后、Cleanup 之前)
+	MemberID        string     `json:"member_id,omitempty"`         // 当前会话的成员 ID
+	LastRebalanceAt *time.Time `json:"last_rebalance_at,omitempty"` // 最近一次重平衡 (Setup) 的时间
+
+	MaxRetries       uint64         `json:"max_retries"`        // 消息处理的最大重试次数
+	MessageTimeoutMs int64          `json:"message_timeout_ms"` // 单次处理尝试的超时时间 (毫秒)
+	DLQ              KafkaDLQStatus `json:"dlq"`                // 死信队列配置
+}
+
+// KafkaDLQStatus 描述死信队列 (DLQ) 的配置。
+type KafkaDLQStatus struct {
+	Topic              string `json:"topic"`               // DLQ 主题
+	ProducerConfigured bool   `json:"producer_configured"` // DLQ 生产者是否可用
+	SendTimeoutMs      int64  `json:"send_timeout_ms"`     // 单次发送超时 (毫秒)
+	SendMaxRetries     uint64 `json:"send_max_retries"`    // 发送失败时的最大重试次数
+	MaxPayloadBytes    int    `json:"max_payload_bytes"`   // 消息体上限，超出时截断
+	UnknownTopics      bool   `json:"unknown_topics"`      // 未注册主题的消息是否转发到 DLQ
+}
@@ -0,0 +1,19 @@
+package models
+
+// ReadinessReport 是就绪检查接口 (/_ready) 的响应负载，逐项列出服务依赖的状态。
+type ReadinessReport struct {
+	Ready        bool               `json:"ready" example:"true"` // 所有依赖都健康时为 true
+	Dependencies []DependencyStatus `json:"dependencies"`         // 各依赖的检查结果
+
+	// ConsumerPaused 表示 Kafka 消费已被 admin 暂停。暂停不影响 Ready：搜索接口照常可用，只是索引更新会延迟到恢复之后。
+	ConsumerPaused bool `json:"consumer_paused,omitempty" example:"false"`
+}
+
+// DependencyStatus 描述单个依赖 (例如某个 Elasticsearch 索引) 的检查结果。
+type DependencyStatus struct {
+	Name    string `json:"name" example:"posts_index"`                     // 依赖的逻辑名称，例如 "posts_index"、"hot_terms_index"
+	Index   string `json:"index,omitempty" example:"posts_index"`          // 对应的 Elasticsearch 索引名称
+	Healthy bool   `json:"healthy" example:"true"`                         // 是否满足就绪要求 (索引存在且健康状态至少为 yellow)
+	Status  string `json:"status" example:"green"`                         // 索引健康状态：green/yellow/red，索引不存在时为 missing，无法检查时为 unknown
+	Error   string `json:"error,omitempty" example:"索引 'posts_index' 不存在"` // 不健康时的原因
+}
@@ -0,0 +1,210 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SearchableFields 是关键词查询 (multi_match 及排除词) 可以匹配的字段白名单，值为字段在索引映射中的类型。
+// searchConfig.fieldBoosts 中只能配置这些字段 (以及开启后的 .en 英文子字段)。
+var SearchableFields = map[string]string{
+	"title":           "text",
+	"content":         "text",
+	"author_username": "text",
+}
+
+// SortableFields 是搜索 API 允许的排序字段白名单 (sort_by 参数)。
+// 键为 ES 字段名，值为该字段在索引映射中的类型，便于后续扩展 (例如为不同类型设置不同的排序选项)。
+// 新增可排序字段时，需要确保索引映射中存在对应的字段且为可排序类型 (keyword/数值/date)。
+var SortableFields = map[string]string{
+	"_score":         "score",
+	"id":             "unsigned_long",
+	"updated_at":     "date",
+	"created_at":     "date",
+	"view_count":     "long",
+	"price_per_unit": "double",
+}
+
+// IsSortableField 判断给定的字段名是否在排序白名单中。
+func IsSortableField(field string) bool {
+	_, ok := SortableFields[field]
+	return ok
+}
+
+// SplitExclusionTerms 将查询字符串拆分为正向关键词和排除词。
+// 以 "-" 开头且长度大于 1 的词视为排除词 (去掉前缀 "-")；单独的 "-" 被忽略。
+// 例如 "go -kafka -docker" 返回 ("go", ["kafka", "docker"])。
+func SplitExclusionTerms(query string) (string, []string) {
+	var positive, excluded []string
+	for _, token := range strings.Fields(query) {
+		switch {
+		case token == "-":
+			continue
+		case strings.HasPrefix(token, "-"):
+			excluded = append(excluded, strings.TrimPrefix(token, "-"))
+		default:
+			positive = append(positive, token)
+		}
+	}
+	return strings.Join(positive, " "), excluded
+}
+
+// NormalizeAuthorID 规范化作者 ID：去除两端空白，lowercase 为 true 时转为小写。
+// 索引侧 (EventService) 与搜索侧 (SearchService) 必须使用相同的参数调用，
+// 否则 keyword 类型的 author_id 精确匹配会失败。
+func NormalizeAuthorID(authorID string, lowercase bool) string {
+	authorID = strings.TrimSpace(authorID)
+	if lowercase {
+		authorID = strings.ToLower(authorID)
+	}
+	return authorID
+}
+
+// FilterableFields 是过滤条件组 (filter_groups) 允许使用的字段白名单，值为字段在索引映射中的类型分类：
+// "keyword" 只支持 eq/in，"number" 与 "date" 还支持范围比较。
+// status 不在白名单中：可见状态由 status 参数和服务端的可搜索状态限制统一控制。
+var FilterableFields = map[string]string{
+	"author_id":      "keyword",
+	"tags":           "keyword",
+	"official_tag":   "number",
+	"view_count":     "number",
+	"price_per_unit": "number",
+	"created_at":     "date",
+	"updated_at":     "date",
+}
+
+// SourceFields 是 source_fields 参数允许请求的 _source 字段白名单，与 EsPostDocument 的 JSON 字段名一致。
+var SourceFields = map[string]bool{
+	"id":              true,
+	"title":           true,
+	"content":         true,
+	"author_id":       true,
+	"author_avatar":   true,
+	"author_username": true,
+	"status":          true,
+	"view_count":      true,
+	"official_tag":    true,
+	"price_per_unit":  true,
+	"contact_info":    true,
+	"contact_qr_code": true,
+	"created_at":      true,
+	"updated_at":      true,
+	"images":          true,
+	"tags":            true,
+}
+
+// StoredOnlyFields 是只保存在 _source 中、不通过 API 返回的字段：开启 stripHTML.preserveOriginal 时保存的未清理原文。
+// 原文可能包含任意 HTML，读取帖子的查询都会从 _source 中排除它们。
+var StoredOnlyFields = []string{"title_raw", "content_raw"}
+
+// PatchableFields 是部分更新 (PatchPost) 允许修改的字段白名单，均为索引映射中已定义的字段。
+// 为什么需要白名单?
+// 索引映射使用动态映射时，拼写错误的字段名会被当作新字段写入并污染映射。
+// id 与 author_id 不在白名单中：前者是文档标识，后者在按作者路由时决定文档所在分片，修改它们需要完整重新索引。
+// updated_at 由仓库层在更新时自动刷新。
+var PatchableFields = map[string]bool{
+	"title":           true,
+	"content":         true,
+	"author_avatar":   true,
+	"author_username": true,
+	"status":          true,
+	"view_count":      true,
+	"official_tag":    true,
+	"price_per_unit":  true,
+	"contact_info":    true,
+	"contact_qr_code": true,
+	"created_at":      true,
+	"images":          true,
+	"tags":            true,
+	// title_raw / content_raw 由本服务在开启 stripHTML.preserveOriginal 时随 title/content 一起写入。
+	"title_raw":   true,
+	"content_raw": true,
+}
+
+// patchFieldTypes 是 PatchableFields 中每个字段值的 Go 类型，取自 EsPostDocument 中 json 标签同名的字段 (与索引映射一致)。
+// title_raw / content_raw 在 EsPostDocument 中不参与 JSON 序列化，单独声明为字符串。
+var patchFieldTypes = func() map[string]reflect.Type {
+	types := map[string]reflect.Type{"title_raw": reflect.TypeOf(""), "content_raw": reflect.TypeOf("")}
+	docType := reflect.TypeOf(EsPostDocument{})
+	for i := 0; i < docType.NumField(); i++ {
+		name, _, _ := strings.Cut(docType.Field(i).Tag.Get("json"), ",")
+		if PatchableFields[name] {
+			types[name] = docType.Field(i).Type
+		}
+	}
+	return types
+}()
+
+// CheckPatchValue 检查部分更新字段的值是否符合该字段在索引映射中的类型，
+// 例如 view_count/status 必须是整数、tags 必须是字符串数组，images 中的对象不能带有映射之外的键。
+// 为什么需要检查?
+// ES 会把 "12" 这样的字符串强制转换后写入数值字段，_source 中却保留字符串，之后读取或合并文档时会失败。
+// 只有 title_raw / content_raw 可以为 null (表示清除保存的原文)。
+func CheckPatchValue(name string, value interface{}) error {
+	typ, ok := patchFieldTypes[name]
+	if !ok {
+		return fmt.Errorf("字段 %s 不允许部分更新", name)
+	}
+	if value == nil {
+		if name == "title_raw" || name == "content_raw" {
+			return nil
+		}
+		return fmt.Errorf("字段 %s 的值不能为 null", name)
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("字段 %s 的值无法序列化: %w", name, err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(reflect.New(typ).Interface()); err != nil {
+		return fmt.Errorf("字段 %s 的值 %s 与索引映射的类型不匹配: %w", name, encoded, err)
+	}
+	return nil
+}
+
+// NormalizeSourceFields 规范化 source_fields 参数 (支持重复参数和逗号分隔)，并确保始终包含 id。
+// 未传递或全部为空白时返回 nil，表示返回完整的 _source。
+func NormalizeSourceFields(raw []string) []string {
+	fields := NormalizeHighlightFields(raw)
+	if len(fields) == 0 {
+		return nil
+	}
+	for _, f := range fields {
+		if f == "id" {
+			return fields
+		}
+	}
+	return append([]string{"id"}, fields...)
+}
+
+// HighlightableFields 是搜索 API 允许高亮的字段白名单 (highlight_fields 参数)。
+var HighlightableFields = map[string]bool{
+	"title":           true,
+	"content":         true,
+	"author_username": true,
+}
+
+// DefaultHighlightFields 是客户端未指定 highlight_fields 时高亮的字段。
+var DefaultHighlightFields = []string{"title", "content"}
+
+// NormalizeHighlightFields 规范化 highlight_fields 参数：支持重复参数和逗号分隔两种写法，并去除空白项。
+// 未传递该参数 (nil) 时返回 nil，表示使用默认高亮字段；
+// 传递了但为空 (例如 "highlight_fields=") 时返回非 nil 的空切片，表示关闭高亮。
+func NormalizeHighlightFields(raw []string) []string {
+	if raw == nil {
+		return nil
+	}
+	fields := make([]string, 0, len(raw))
+	for _, item := range raw {
+		for _, f := range strings.Split(item, ",") {
+			if f = strings.TrimSpace(f); f != "" {
+				fields = append(fields, f)
+			}
+		}
+	}
+	return fields
+}
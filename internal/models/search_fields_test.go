@@ -0,0 +1,61 @@
+package models
+
+import "testing"
+
+func TestNormalizeAuthorID(t *testing.T) {
+	tests := []struct {
+		name      string
+		authorID  string
+		lowercase bool
+		want      string
+	}{
+		{name: "空字符串", authorID: "", want: ""},
+		{name: "只有空白", authorID: " \t\n", want: ""},
+		{name: "去除两端空白", authorID: "  Author1 \t", want: "Author1"},
+		{name: "保留大小写", authorID: "AbC123", want: "AbC123"},
+		{name: "转为小写", authorID: "AbC123", lowercase: true, want: "abc123"},
+		{name: "去除空白并转为小写", authorID: " 3F2504E0-4F89-11D3-9A0C-0305E82C3301\n", lowercase: true, want: "3f2504e0-4f89-11d3-9a0c-0305e82c3301"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeAuthorID(tt.authorID, tt.lowercase); got != tt.want {
+				t.Errorf("NormalizeAuthorID(%q, %v) = %q, want %q", tt.authorID, tt.lowercase, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckPatchValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		field   string
+		value   interface{}
+		wantErr bool
+	}{
+		{name: "整数浏览量 (JSON 数字)", field: "view_count", value: float64(12)},
+		{name: "整数浏览量 (Go 整数)", field: "view_count", value: int64(12)},
+		{name: "字符串浏览量", field: "view_count", value: "12", wantErr: true},
+		{name: "小数浏览量", field: "view_count", value: 1.5, wantErr: true},
+		{name: "整数状态", field: "status", value: float64(1)},
+		{name: "字符串状态", field: "status", value: "approved", wantErr: true},
+		{name: "状态为 null", field: "status", value: nil, wantErr: true},
+		{name: "小数价格", field: "price_per_unit", value: 9.9},
+		{name: "字符串标题", field: "title", value: "标题"},
+		{name: "数字标题", field: "title", value: float64(1), wantErr: true},
+		{name: "字符串数组标签", field: "tags", value: []interface{}{"go", "kafka"}},
+		{name: "单个字符串标签", field: "tags", value: "go", wantErr: true},
+		{name: "包含数字的标签", field: "tags", value: []interface{}{"go", float64(1)}, wantErr: true},
+		{name: "图片", field: "images", value: []interface{}{map[string]interface{}{"image_url": "https://img/1.png", "display_order": float64(1)}}},
+		{name: "图片包含映射之外的键", field: "images", value: []interface{}{map[string]interface{}{"image_url": "https://img/1.png", "width": float64(100)}}, wantErr: true},
+		{name: "清除原文", field: "content_raw", value: nil},
+		{name: "不在白名单中的字段", field: "author_id", value: "a1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := CheckPatchValue(tt.field, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckPatchValue(%q, %#v) = %v, wantErr %v", tt.field, tt.value, err, tt.wantErr)
+			}
+		})
+	}
+}
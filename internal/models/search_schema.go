@@ -0,0 +1,62 @@
+package models
+
+import "sort"
+
+// SearchSchema 描述搜索 API 可用的字段，由 GET /api/v1/search/_schema 返回，供前端动态生成排序、筛选控件。
+// 内容完全由本包中的白名单 (SearchableFields、SortableFields、FilterableFields 等) 生成，
+// 这些白名单同时用于请求校验和查询构建，因此接口返回的字段与后端实际接受的字段始终一致。
+type SearchSchema struct {
+	Searchable          []SchemaField `json:"searchable"`            // 关键词 (q) 可以匹配的字段；实际参与匹配的字段及权重由 searchConfig.fieldBoosts 决定
+	Sortable            []SchemaField `json:"sortable"`              // sort_by 可用的字段
+	Filterable          []SchemaField `json:"filterable"`            // filter_groups 中可用的字段及比较方式
+	Highlightable       []string      `json:"highlightable"`         // highlight_fields 可用的字段
+	AdvancedQueryFields []string      `json:"advanced_query_fields"` // query_mode=advanced 时允许的字段限定词
+}
+
+// SchemaField 是 SearchSchema 中的单个字段。
+type SchemaField struct {
+	Name      string   `json:"name" example:"view_count"`
+	Type      string   `json:"type" example:"number"`            // 可排序字段为索引映射中的类型 (score 表示相关性得分)；可筛选字段为 keyword/number/date
+	Operators []string `json:"operators,omitempty" example:"eq"` // 仅可筛选字段：支持的比较方式
+}
+
+// FilterOpsForKind 返回某类可筛选字段支持的比较方式：keyword 只支持 eq/in，number 与 date 还支持范围比较。
+func FilterOpsForKind(kind string) []string {
+	if kind == "keyword" {
+		return []string{FilterOpEq, FilterOpIn}
+	}
+	return []string{FilterOpEq, FilterOpIn, FilterOpGt, FilterOpGte, FilterOpLt, FilterOpLte}
+}
+
+// BuildSearchSchema 根据字段白名单生成 SearchSchema，各列表按字段名排序以保证输出稳定。
+func BuildSearchSchema() SearchSchema {
+	schema := SearchSchema{
+		Searchable:          schemaFields(SearchableFields),
+		Sortable:            schemaFields(SortableFields),
+		Filterable:          schemaFields(FilterableFields),
+		Highlightable:       sortedKeys(HighlightableFields),
+		AdvancedQueryFields: sortedKeys(AdvancedQueryFields),
+	}
+	for i := range schema.Filterable {
+		schema.Filterable[i].Operators = FilterOpsForKind(schema.Filterable[i].Type)
+	}
+	return schema
+}
+
+func schemaFields(fields map[string]string) []SchemaField {
+	result := make([]SchemaField, 0, len(fields))
+	for name, typ := range fields {
+		result = append(result, SchemaField{Name: name, Type: typ})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Name < result[j].Name })
+	return result
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -10,12 +10,19 @@ type SwaggerSearchResultResponse struct {
 	Data    SearchResult `json:"data,omitempty"` // 具体的搜索结果数据负载。使用 omitempty 可以在 Data 为空时不显示该字段。
 }
 
+// SwaggerMultiSearchResponse 是批量搜索接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerMultiSearchResponse struct {
+	Code    int            `json:"code"`           // 业务自定义状态码。
+	Message string         `json:"message"`        // 操作结果的文字描述。
+	Data    []SearchResult `json:"data,omitempty"` // 与请求中 requests 顺序一致的搜索结果。
+}
+
 // SwaggerErrorResponse 是一个专门为 Swagger 文档生成的辅助结构体，用于表示错误响应。
 // 它解决了 swag 工具无法正确解析泛型类型 response.APIResponse[any] 或 response.APIResponse[nil] 的问题。
 type SwaggerErrorResponse struct {
-	Code    int         `json:"code"`           // 业务自定义错误码。
-	Message string      `json:"message"`        // 错误的文字描述。
-	Data    interface{} `json:"data,omitempty"` // 错误响应中 data 字段通常为 null 或不包含有效业务数据，这里使用 interface{}。
+	Code    int        `json:"code"`           // 业务自定义错误码。
+	Message string     `json:"message"`        // 错误的文字描述。
+	Data    *ErrorData `json:"data,omitempty"` // 启用链路追踪时携带 trace_id，否则省略。
 }
 
 // SwaggerHealthCheckResponse 是一个专门为 Swagger 文档生成的辅助结构体，用于健康检查响应。
@@ -35,3 +42,87 @@ type SwaggerHotSearchTermsResponse struct {
 	Message string        `json:"message"`        // 操作结果的文字描述，例如 "搜索成功" 或具体的错误信息。
 	Data    HotSearchTerm `json:"data,omitempty"` // 告诉前端哪些词是热门的。
 }
+
+// SwaggerHotSearchTermPageResponse 是分页浏览热门搜索词接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerHotSearchTermPageResponse struct {
+	Code    int               `json:"code"`           // 业务自定义状态码。
+	Message string            `json:"message"`        // 操作结果的文字描述。
+	Data    HotSearchTermPage `json:"data,omitempty"` // 当前页的搜索词及总数。
+}
+
+// SwaggerUnifiedSearchResultResponse 是统一搜索接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerUnifiedSearchResultResponse struct {
+	Code    int                 `json:"code"`           // 业务自定义状态码。
+	Message string              `json:"message"`        // 操作结果的文字描述。
+	Data    UnifiedSearchResult `json:"data,omitempty"` // 帖子结果与热门搜索词联想。
+}
+
+// SwaggerValidationErrorResponse 是参数校验失败 (HTTP 400) 时的响应结构，仅用于 Swagger 文档生成。
+type SwaggerValidationErrorResponse struct {
+	Code    int                 `json:"code"`           // 业务自定义错误码。
+	Message string              `json:"message"`        // 错误的文字描述。
+	Data    ValidationErrorData `json:"data,omitempty"` // 字段级别的校验失败详情。
+}
+
+// SwaggerPostListResponse 是返回帖子文档列表的响应结构，仅用于 Swagger 文档生成。
+type SwaggerPostListResponse struct {
+	Code    int              `json:"code"`           // 业务自定义状态码。
+	Message string           `json:"message"`        // 操作结果的文字描述。
+	Data    []EsPostDocument `json:"data,omitempty"` // 帖子文档列表。
+}
+
+// SwaggerSearchSchemaResponse 是搜索字段信息接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerSearchSchemaResponse struct {
+	Code    int          `json:"code"`           // 业务自定义状态码。
+	Message string       `json:"message"`        // 操作结果的文字描述。
+	Data    SearchSchema `json:"data,omitempty"` // 可搜索、排序、筛选、高亮的字段。
+}
+
+// SwaggerSearchVolumeResponse 是搜索量统计接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerSearchVolumeResponse struct {
+	Code    int          `json:"code"`           // 业务自定义状态码。
+	Message string       `json:"message"`        // 操作结果的文字描述。
+	Data    SearchVolume `json:"data,omitempty"` // 搜索量统计。
+}
+
+// SwaggerIndexStatsResponse 是索引统计信息接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerIndexStatsResponse struct {
+	Code    int        `json:"code"`           // 业务自定义状态码。
+	Message string     `json:"message"`        // 操作结果的文字描述。
+	Data    IndexStats `json:"data,omitempty"` // 各索引的统计信息。
+}
+
+// SwaggerIndexRefreshResponse 是刷新索引接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerIndexRefreshResponse struct {
+	Code    int                `json:"code"`           // 业务自定义状态码。
+	Message string             `json:"message"`        // 操作结果的文字描述。
+	Data    IndexRefreshResult `json:"data,omitempty"` // 分片刷新结果。
+}
+
+// SwaggerIndexRefreshIntervalResponse 是调整 refresh_interval 接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerIndexRefreshIntervalResponse struct {
+	Code    int                        `json:"code"`           // 业务自定义状态码。
+	Message string                     `json:"message"`        // 操作结果的文字描述。
+	Data    IndexRefreshIntervalResult `json:"data,omitempty"` // 生效的刷新间隔。
+}
+
+// SwaggerHotTermsIndexResetResponse 是重置热门搜索词索引接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerHotTermsIndexResetResponse struct {
+	Code    int                      `json:"code"`           // 业务自定义状态码。
+	Message string                   `json:"message"`        // 操作结果的文字描述。
+	Data    HotTermsIndexResetResult `json:"data,omitempty"` // 重置结果。
+}
+
+// SwaggerReadinessResponse 是就绪检查接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerReadinessResponse struct {
+	Code    int             `json:"code"`           // 业务自定义状态码。
+	Message string          `json:"message"`        // 操作结果的文字描述。
+	Data    ReadinessReport `json:"data,omitempty"` // 各依赖的就绪状态。
+}
+
+// SwaggerKafkaStatusResponse 是 Kafka 消费者状态接口的响应结构，仅用于 Swagger 文档生成。
+type SwaggerKafkaStatusResponse struct {
+	Code    int         `json:"code"`           // 业务自定义状态码。
+	Message string      `json:"message"`        // 操作结果的文字描述。
+	Data    KafkaStatus `json:"data,omitempty"` // Kafka 消费者的运行时配置与状态。
+}
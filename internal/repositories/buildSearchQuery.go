@@ -4,36 +4,421 @@ package repositories
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
 	"github.com/Xushengqwer/post_search/internal/models"
+	"go.uber.org/zap"
 )
 
+// defaultFieldBoosts 是未配置 SearchConfig.FieldBoosts 时关键词查询 (以及排除词查询) 匹配的字段及权重。
+var defaultFieldBoosts = map[string]float64{"title": 3, "content": 1, "author_username": 1}
+
+// defaultEnglishFieldBoosts 是开启英文子字段且未配置 FieldBoosts 时追加的 .en 子字段权重，
+// 低于对应的原生分析器字段，使中文查询优先由 ik 分词的字段决定得分。
+var defaultEnglishFieldBoosts = map[string]float64{"title.en": 1, "content.en": 0.5}
+
+// englishSubfields 是依赖 primaryIndex.englishSubfields 映射的子字段。
+var englishSubfields = map[string]bool{"title.en": true, "content.en": true}
+
+// searchQueryOptions 是构建搜索 DSL 时使用的服务端选项，由 SearchConfig 在仓库初始化时生成。
+type searchQueryOptions struct {
+	recencyBoost      config.RecencyBoostConfig         // 新帖加权 (function_score) 参数，已填充默认值
+	highlightFields   map[string]map[string]interface{} // 每个可高亮字段的高亮参数，已合并默认值与配置
+	requireFieldMatch bool                              // 请求未指定 highlight_require_field_match 时是否只高亮匹配了查询的字段
+	highlightEncoder  string                            // 请求未指定 highlight_encoder 时的高亮编码方式 (html/default)
+	multiMatchFields  []string                          // 关键词查询匹配的字段及权重，形如 "title^3"，按字段名排序
+	sortMissing       map[string]string                 // 每个可排序字段缺值文档的位置 (_last/_first)，已填充默认值
+	priceRanges       []map[string]interface{}          // price_per_unit 区间分面的 ranges 参数，由分界点生成
+	hasArchiveIndices bool                              // 是否配置了归档索引 (include_archived 时追加 _index 次级排序)
+}
+
+// priceRangesAggName 是 price_per_unit 区间分面在 ES 请求与响应中的聚合名称。
+const priceRangesAggName = "price_ranges"
+
+// defaultPriceRangeBoundaries 是未配置 FacetsConfig.PriceRangeBoundaries 时使用的分界点。
+var defaultPriceRangeBoundaries = []float64{10, 50}
+
+// buildPriceRanges 将分界点转换为 range 聚合的 ranges 参数：n 个分界点生成 n+1 个区间，每个区间带有固定的 key。
+// 分界点会排序并去重。
+func buildPriceRanges(boundaries []float64) []map[string]interface{} {
+	if len(boundaries) == 0 {
+		boundaries = defaultPriceRangeBoundaries
+	}
+	sorted := append([]float64(nil), boundaries...)
+	sort.Float64s(sorted)
+	uniq := sorted[:0]
+	for i, b := range sorted {
+		if i == 0 || b != sorted[i-1] {
+			uniq = append(uniq, b)
+		}
+	}
+
+	format := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	ranges := make([]map[string]interface{}, 0, len(uniq)+1)
+	ranges = append(ranges, map[string]interface{}{"key": "*-" + format(uniq[0]), "to": uniq[0]})
+	for i := 1; i < len(uniq); i++ {
+		ranges = append(ranges, map[string]interface{}{
+			"key":  format(uniq[i-1]) + "-" + format(uniq[i]),
+			"from": uniq[i-1],
+			"to":   uniq[i],
+		})
+	}
+	ranges = append(ranges, map[string]interface{}{"key": format(uniq[len(uniq)-1]) + "-*", "from": uniq[len(uniq)-1]})
+	return ranges
+}
+
+// defaultContentFragmentSize / defaultContentFragments 是 content 字段默认的高亮片段大小与数量。
+const (
+	defaultContentFragmentSize = 150
+	defaultContentFragments    = 3
+
+	// unified 高亮器支持的片段边界方式 (boundary_scanner)。
+	highlightBoundarySentence = "sentence"
+	highlightBoundaryWord     = "word"
+)
+
+// buildHighlightFieldOptions 为每个可高亮字段生成高亮参数：content 默认分片段，其余字段使用 ES 默认设置，
+// 再用配置中的按字段设置覆盖。配置了不可高亮的字段时记录警告并忽略。
+func buildHighlightFieldOptions(configured map[string]config.HighlightFieldConfig, logger *core.ZapLogger) map[string]map[string]interface{} {
+	fields := make(map[string]map[string]interface{}, len(models.HighlightableFields))
+	for f := range models.HighlightableFields {
+		fields[f] = map[string]interface{}{}
+	}
+	fields["content"]["fragment_size"] = defaultContentFragmentSize
+	fields["content"]["number_of_fragments"] = defaultContentFragments
+
+	for f, fc := range configured {
+		opts, ok := fields[f]
+		if !ok {
+			logger.Warn("高亮配置 (searchConfig.highlight) 中的字段不可高亮，已忽略", zap.String("field", f))
+			continue
+		}
+		if fc.FragmentSize > 0 {
+			opts["fragment_size"] = fc.FragmentSize
+		}
+		if fc.NumberOfFragments != nil {
+			if *fc.NumberOfFragments < 0 {
+				logger.Warn("高亮片段数量配置无效，已忽略", zap.String("field", f), zap.Int("number_of_fragments", *fc.NumberOfFragments))
+			} else {
+				// number_of_fragments 为 0 时 ES 忽略 fragment_size，返回整个字段内容并高亮匹配词。
+				opts["number_of_fragments"] = *fc.NumberOfFragments
+			}
+		}
+		switch fc.BoundaryScanner {
+		case "":
+		case highlightBoundarySentence, highlightBoundaryWord:
+			opts["boundary_scanner"] = fc.BoundaryScanner
+		default:
+			logger.Warn("高亮边界方式配置无效 (可选 sentence、word)，已忽略", zap.String("field", f), zap.String("boundary_scanner", fc.BoundaryScanner))
+		}
+		if fc.BoundaryScannerLocale != "" {
+			opts["boundary_scanner_locale"] = fc.BoundaryScannerLocale
+		}
+	}
+	return fields
+}
+
+// buildMultiMatchFields 根据字段权重配置生成 multi_match 的 fields 参数。
+// 未开启英文子字段时忽略 .en 字段 (映射中不存在)；不可搜索的字段和非正数权重会被忽略并记录警告。
+func buildMultiMatchFields(configured map[string]float64, englishEnabled bool, logger *core.ZapLogger) []string {
+	boosts := configured
+	if len(boosts) == 0 {
+		boosts = make(map[string]float64, len(defaultFieldBoosts)+len(defaultEnglishFieldBoosts))
+		for f, b := range defaultFieldBoosts {
+			boosts[f] = b
+		}
+		if englishEnabled {
+			for f, b := range defaultEnglishFieldBoosts {
+				boosts[f] = b
+			}
+		}
+	}
+
+	fields := make([]string, 0, len(boosts))
+	for f, boost := range boosts {
+		switch {
+		case englishSubfields[f] && !englishEnabled:
+			logger.Warn("字段权重配置 (searchConfig.fieldBoosts) 中的英文子字段未在映射中启用 (primaryIndex.englishSubfields)，已忽略", zap.String("field", f))
+			continue
+		case !englishSubfields[f] && models.SearchableFields[f] == "":
+			logger.Warn("字段权重配置 (searchConfig.fieldBoosts) 中的字段不可搜索，已忽略", zap.String("field", f))
+			continue
+		case boost <= 0:
+			logger.Warn("字段权重必须为正数，已忽略该字段", zap.String("field", f), zap.Float64("boost", boost))
+			continue
+		}
+		fields = append(fields, f+"^"+strconv.FormatFloat(boost, 'f', -1, 64))
+	}
+	if len(fields) == 0 {
+		logger.Warn("字段权重配置中没有可用的字段，将使用默认字段权重")
+		return buildMultiMatchFields(nil, englishEnabled, logger)
+	}
+	sort.Strings(fields) // map 遍历顺序随机，排序使生成的 DSL 稳定 (便于日志比对和结果缓存)
+	return fields
+}
+
+// defaultSortMissing 是未配置 SearchConfig.SortMissing 的字段缺值文档的位置。
+const defaultSortMissing = "_last"
+
+// buildSortMissing 为每个可排序字段 (_score 除外) 确定缺值文档的位置，配置中无效的字段或取值会被忽略并记录警告。
+func buildSortMissing(configured map[string]string, logger *core.ZapLogger) map[string]string {
+	missing := make(map[string]string, len(models.SortableFields))
+	for f := range models.SortableFields {
+		if f != "_score" {
+			missing[f] = defaultSortMissing
+		}
+	}
+	for f, v := range configured {
+		if _, ok := missing[f]; !ok {
+			logger.Warn("排序缺值配置 (searchConfig.sortMissing) 中的字段不可排序，已忽略", zap.String("field", f))
+			continue
+		}
+		if v != "_last" && v != "_first" {
+			logger.Warn("排序缺值配置的取值无效 (只支持 _last 或 _first)，将使用 _last", zap.String("field", f), zap.String("missing", v))
+			continue
+		}
+		missing[f] = v
+	}
+	return missing
+}
+
+// sortEntry 生成单个字段的排序子句。除 _score 外都设置 missing 与 unmapped_type：
+// missing 让缺少该字段的文档固定排在一端，unmapped_type 让索引映射中尚未出现该字段时 (例如新建的空索引) 不会报错。
+func sortEntry(field, order string, opts searchQueryOptions) map[string]map[string]interface{} {
+	entry := map[string]interface{}{"order": order}
+	if missing, ok := opts.sortMissing[field]; ok {
+		entry["missing"] = missing
+		entry["unmapped_type"] = models.SortableFields[field]
+	}
+	return map[string]map[string]interface{}{field: entry}
+}
+
+// newSearchQueryOptions 校验 SearchConfig 中与查询构建相关的参数，并为无效值填充默认值。
+// englishEnabled 表示主帖子索引是否启用了 .en 子字段 (IndexSpecificConfig.EnglishSubfields)。
+func newSearchQueryOptions(cfg config.SearchConfig, englishEnabled bool, logger *core.ZapLogger) searchQueryOptions {
+	rb := cfg.RecencyBoost
+	if rb.DecayFunction != "gauss" && rb.DecayFunction != "exp" {
+		if rb.DecayFunction != "" {
+			logger.Warn("新帖加权的衰减函数配置无效，将使用 gauss", zap.String("configured_decay_function", rb.DecayFunction))
+		}
+		rb.DecayFunction = "gauss"
+	}
+	if rb.Scale == "" {
+		rb.Scale = "7d"
+	}
+	if rb.Decay <= 0 || rb.Decay >= 1 {
+		rb.Decay = 0.5
+	}
+	if rb.Weight <= 0 {
+		rb.Weight = 1
+	}
+	if rb.ViewCountModifier == "" {
+		rb.ViewCountModifier = "log1p"
+	}
+	if rb.BoostMode == "" {
+		rb.BoostMode = "multiply"
+	}
+	requireFieldMatch := true
+	if cfg.HighlightRequireFieldMatch != nil {
+		requireFieldMatch = *cfg.HighlightRequireFieldMatch
+	}
+	highlightEncoder := models.HighlightEncoderHTML
+	switch cfg.HighlightEncoder {
+	case "", models.HighlightEncoderHTML:
+	case models.HighlightEncoderDefault:
+		highlightEncoder = models.HighlightEncoderDefault
+	default:
+		logger.Warn("无法识别的高亮编码方式 (searchConfig.highlightEncoder)，使用 html", zap.String("configured_encoder", cfg.HighlightEncoder))
+	}
+	return searchQueryOptions{
+		recencyBoost:      rb,
+		highlightFields:   buildHighlightFieldOptions(cfg.Highlight, logger),
+		requireFieldMatch: requireFieldMatch,
+		highlightEncoder:  highlightEncoder,
+		multiMatchFields:  buildMultiMatchFields(cfg.FieldBoosts, englishEnabled, logger),
+		sortMissing:       buildSortMissing(cfg.SortMissing, logger),
+		priceRanges:       buildPriceRanges(cfg.Facets.PriceRangeBoundaries),
+		hasArchiveIndices: len(cfg.ArchiveIndices) > 0,
+	}
+}
+
+// buildRecencyFunctionScore 将查询包裹在 function_score 中，对 updated_at 施加衰减，
+// 并在配置了 ViewCountFactor 时按 view_count 加权。
+func buildRecencyFunctionScore(query map[string]interface{}, rb config.RecencyBoostConfig) map[string]interface{} {
+	decayParams := map[string]interface{}{
+		"origin": "now",
+		"scale":  rb.Scale,
+		"decay":  rb.Decay,
+	}
+	if rb.Offset != "" {
+		decayParams["offset"] = rb.Offset
+	}
+	functions := []map[string]interface{}{
+		{
+			rb.DecayFunction: map[string]interface{}{"updated_at": decayParams},
+			"weight":         rb.Weight,
+		},
+	}
+	if rb.ViewCountFactor > 0 {
+		functions = append(functions, map[string]interface{}{
+			"field_value_factor": map[string]interface{}{
+				"field":    "view_count",
+				"factor":   rb.ViewCountFactor,
+				"modifier": rb.ViewCountModifier,
+				"missing":  0,
+			},
+		})
+	}
+	return map[string]interface{}{
+		"function_score": map[string]interface{}{
+			"query":      query,
+			"functions":  functions,
+			"score_mode": "sum",
+			"boost_mode": rb.BoostMode,
+		},
+	}
+}
+
 // buildSearchQuery 根据提供的搜索请求构建 Elasticsearch 查询的 JSON 体。
 // 这个函数封装了分页、排序、主查询逻辑（match_all 或 multi_match）、可选的过滤逻辑以及高亮逻辑。
-func buildSearchQuery(req models.SearchRequest) ([]byte, error) {
+// opts 提供服务端配置的查询选项，例如新帖加权。
+func buildSearchQuery(req models.SearchRequest, opts searchQueryOptions) ([]byte, error) {
 	from := (req.Page - 1) * req.Size
 	if from < 0 {
 		from = 0
 	}
 
-	sortClause := []map[string]map[string]string{
-		{req.SortBy: {"order": req.SortOrder}},
+	// 除按 id 排序外，始终追加 id 作为次级排序，保证排序值相同时分页结果稳定。
+	// 按 _score 排序时同样需要：没有正向关键词 (match_all，例如只按 author_id 筛选) 时所有文档得分相同，
+	// 缺少次级排序会导致同一请求多次执行返回的顺序不一致。
+	sortClause := []map[string]map[string]interface{}{
+		sortEntry(req.SortBy, req.SortOrder, opts),
+	}
+	if req.SortBy != "id" {
+		sortClause = append(sortClause, sortEntry("id", "asc", opts))
+	}
+	// 同时搜索归档索引时，同一帖子可能同时存在于主索引和归档索引 (归档过程中)，id 不再唯一；
+	// 再以 _index 排序，保证跨索引分页的顺序确定。
+	if req.IncludeArchived && opts.hasArchiveIndices {
+		sortClause = append(sortClause, map[string]map[string]interface{}{"_index": {"order": "asc"}})
+	}
+
+	finalQueryDSL, positiveQuery := buildQueryClause(req, opts)
+
+	// --- 新增：高亮 (Highlighting) 配置 ---
+	// 客户端未指定 highlight_fields (nil) 时使用默认字段；指定了空列表时关闭高亮。
+	highlightFields := req.HighlightFields
+	if highlightFields == nil {
+		highlightFields = models.DefaultHighlightFields
+	}
+	var highlightClause map[string]interface{}
+	if positiveQuery != "" && len(highlightFields) > 0 { // 只有当有 (非排除的) 搜索关键词时才添加高亮
+		fieldsClause := make(map[string]interface{}, len(highlightFields))
+		for _, f := range highlightFields {
+			// 每个字段的参数 (片段大小、片段数量) 在初始化时由 buildHighlightFieldOptions 合并默认值与配置得到。
+			fieldOpts, ok := opts.highlightFields[f]
+			if !ok {
+				fieldOpts = map[string]interface{}{}
+			}
+			fieldsClause[f] = fieldOpts
+		}
+		requireFieldMatch := opts.requireFieldMatch
+		if req.HighlightRequireFieldMatch != nil {
+			requireFieldMatch = *req.HighlightRequireFieldMatch
+		}
+		encoder := opts.highlightEncoder
+		if req.HighlightEncoder != "" {
+			encoder = req.HighlightEncoder
+		}
+		highlightClause = map[string]interface{}{
+			"pre_tags":  []string{"<strong>"},  // 定义包裹匹配词的前置标签 (HTML加粗)
+			"post_tags": []string{"</strong>"}, // 定义包裹匹配词的后置标签
+			"fields":    fieldsClause,          // 指定要在哪些字段上进行高亮
+			// require_field_match 为 true 时只有匹配了查询的字段才会高亮；
+			// 为 false 时，只要字段中出现了关键词就会高亮 (即使该字段并未参与匹配)，容易让用户误解命中原因。
+			"require_field_match": requireFieldMatch,
+			// html 编码先转义字段内容中的 HTML 字符，再插入 pre_tags/post_tags，<strong> 本身不会被转义。
+			"encoder": encoder,
+		}
+	}
+	// --- 结束新增部分 ---
+
+	esQueryRequest := map[string]interface{}{
+		"from":             from,
+		"size":             req.Size,
+		"sort":             sortClause,
+		"query":            finalQueryDSL,
+		"track_total_hits": true,
+	}
+
+	// min_score 只在有正向关键词时添加：match_all 下所有文档得分都是 1.0，
+	// 阈值要么不起作用，要么 (大于 1 时) 把所有文档都过滤掉，两者都不是调用方想要的。
+	if req.MinScore != nil && positiveQuery != "" {
+		esQueryRequest["min_score"] = *req.MinScore
+	}
+
+	// 分面：聚合统计的是满足查询与全部筛选条件的文档，与分页无关。
+	if req.IncludeFacets {
+		esQueryRequest["aggs"] = map[string]interface{}{
+			priceRangesAggName: map[string]interface{}{
+				"range": map[string]interface{}{
+					"field":  "price_per_unit",
+					"ranges": opts.priceRanges,
+				},
+			},
+		}
 	}
-	if req.SortBy != "id" && req.SortBy != "_score" {
-		sortClause = append(sortClause, map[string]map[string]string{"id": {"order": "asc"}})
+
+	// _source 过滤：只返回客户端请求的字段，减少响应体积；未指定时返回除只存储字段 (未清理的 HTML 原文) 外的全部字段。
+	// 高亮片段来自 highlight 部分而非 _source，因此即使排除了 content 也能正常返回 content 的高亮。
+	if len(req.SourceFields) > 0 {
+		esQueryRequest["_source"] = map[string]interface{}{"includes": req.SourceFields}
+	} else {
+		esQueryRequest["_source"] = map[string]interface{}{"excludes": models.StoredOnlyFields}
+	}
+
+	// 只有当 highlightClause 被创建时（即有搜索关键词时），才将其添加到请求中
+	if highlightClause != nil {
+		esQueryRequest["highlight"] = highlightClause
+	}
+
+	queryJSON, err := json.Marshal(esQueryRequest)
+	if err != nil {
+		return nil, fmt.Errorf("序列化 Elasticsearch 查询对象为 JSON 失败: %w", err)
+	}
+
+	return queryJSON, nil
+}
+
+// buildQueryClause 生成搜索请求体中的 query 部分：关键词主查询、筛选条件、排除条件以及可选的新帖加权，
+// 同时返回去掉排除词后的正向关键词 (为空表示没有参与评分的关键词)，供高亮与 min_score 判断使用。
+// _explain 等只接受查询的 API 通过 SearchQueryBuilder.Query 复用这部分逻辑。
+func buildQueryClause(req models.SearchRequest, opts searchQueryOptions) (map[string]interface{}, string) {
+	// 拆分关键词中的排除词 (以 "-" 开头的词，例如 "go -kafka")。
+	// 只剩排除词时，主查询退化为 match_all，再由 must_not 排除匹配的文档。
+	// 高级查询模式下 "-" 是 query_string 自身的语法，不做拆分。
+	advanced := req.QueryMode == models.QueryModeAdvanced && strings.TrimSpace(req.Query) != ""
+	positiveQuery, excludedTerms := models.SplitExclusionTerms(req.Query)
+	if advanced {
+		positiveQuery, excludedTerms = strings.TrimSpace(req.Query), nil
 	}
 
 	var mainQueryDSL map[string]interface{}
-	if strings.TrimSpace(req.Query) == "" {
+	if advanced {
+		mainQueryDSL = buildAdvancedQuery(positiveQuery, opts)
+	} else if positiveQuery == "" {
 		mainQueryDSL = map[string]interface{}{
 			"match_all": map[string]interface{}{},
 		}
 	} else {
 		mainQueryDSL = map[string]interface{}{
 			"multi_match": map[string]interface{}{
-				"query":  req.Query,
-				"fields": []string{"title^3", "content", "author_username"}, // 您希望在高亮中也考虑这些字段
+				"query":  positiveQuery,
+				"fields": opts.multiMatchFields, // 字段及权重来自 searchConfig.fieldBoosts
 				"type":   "best_fields",
 			},
 		}
@@ -49,58 +434,168 @@ func buildSearchQuery(req models.SearchRequest) ([]byte, error) {
 		filters = append(filters, map[string]interface{}{
 			"term": map[string]interface{}{"status": *req.Status},
 		})
+	} else if len(req.Statuses) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"terms": map[string]interface{}{"status": req.Statuses},
+		})
 	}
-
-	var finalQueryDSL map[string]interface{}
-	if len(filters) > 0 {
-		finalQueryDSL = map[string]interface{}{
+	if len(req.Tags) > 0 {
+		if req.MatchAllTags {
+			// AND 语义：每个标签一个 term 过滤条件，文档必须同时包含所有标签。
+			for _, tag := range req.Tags {
+				filters = append(filters, map[string]interface{}{
+					"term": map[string]interface{}{"tags": tag},
+				})
+			}
+		} else {
+			// OR 语义：terms 过滤命中任意一个标签即可。
+			filters = append(filters, map[string]interface{}{
+				"terms": map[string]interface{}{"tags": req.Tags},
+			})
+		}
+	}
+	if req.HasContactQRCode != nil {
+		hasQRCode := map[string]interface{}{
 			"bool": map[string]interface{}{
-				"must":   mainQueryDSL,
-				"filter": filters,
+				"filter":   map[string]interface{}{"exists": map[string]interface{}{"field": "contact_qr_code"}},
+				"must_not": map[string]interface{}{"term": map[string]interface{}{"contact_qr_code": ""}},
 			},
 		}
-	} else {
-		finalQueryDSL = mainQueryDSL
+		if !*req.HasContactQRCode {
+			// 没有二维码 = 字段缺失或为空字符串，即 "有二维码" 条件取反。
+			hasQRCode = map[string]interface{}{
+				"bool": map[string]interface{}{"must_not": hasQRCode},
+			}
+		}
+		filters = append(filters, hasQRCode)
+	}
+	if req.CreatedFrom != nil || req.CreatedTo != nil {
+		createdRange := map[string]interface{}{}
+		if req.CreatedFrom != nil {
+			createdRange["gte"] = *req.CreatedFrom
+		}
+		if req.CreatedTo != nil {
+			createdRange["lte"] = *req.CreatedTo
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"created_at": createdRange},
+		})
+	}
+	if req.UpdatedFrom != nil || req.UpdatedTo != nil {
+		updatedRange := map[string]interface{}{}
+		if req.UpdatedFrom != nil {
+			updatedRange["gte"] = *req.UpdatedFrom
+		}
+		if req.UpdatedTo != nil {
+			updatedRange["lte"] = *req.UpdatedTo
+		}
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"updated_at": updatedRange},
+		})
 	}
 
-	// --- 新增：高亮 (Highlighting) 配置 ---
-	var highlightClause map[string]interface{}
-	if strings.TrimSpace(req.Query) != "" { // 只有当有搜索关键词时才添加高亮
-		highlightClause = map[string]interface{}{
-			"pre_tags":  []string{"<strong>"},  // 定义包裹匹配词的前置标签 (HTML加粗)
-			"post_tags": []string{"</strong>"}, // 定义包裹匹配词的后置标签
-			"fields": map[string]interface{}{ // 指定要在哪些字段上进行高亮
-				"title": map[string]interface{}{}, // 对 title 字段进行高亮，使用默认设置
-				"content": map[string]interface{}{ // 对 content 字段进行高亮
-					"fragment_size":       150, // 每个高亮片段的最大字符数 (大致)
-					"number_of_fragments": 3,   // 最多返回多少个高亮片段
-					// "no_match_size": 150, // 如果没有匹配的片段，但字段本身需要返回一部分内容时，可以指定长度
-				},
-				// "author_username": map[string]interface{}{}, // 如果也想高亮作者名
+	// 过滤条件组：每组生成一个 should 子查询，组与组之间以及与上面的条件之间通过 filter 取 AND。
+	for _, group := range req.FilterGroups {
+		filters = append(filters, buildFilterGroup(group))
+	}
+
+	// 排除指定的帖子 ID (例如推荐页已展示的帖子)。must_not 与 filter 一样不参与评分。
+	var mustNot []map[string]interface{}
+	if len(req.ExcludeIDs) > 0 {
+		mustNot = append(mustNot, map[string]interface{}{
+			"terms": map[string]interface{}{"id": req.ExcludeIDs},
+		})
+	}
+	// 排除词：命中任意一个排除词的文档都会被排除 (multi_match 默认使用 OR 组合分词结果)。
+	if len(excludedTerms) > 0 {
+		mustNot = append(mustNot, map[string]interface{}{
+			"multi_match": map[string]interface{}{
+				"query":  strings.Join(excludedTerms, " "),
+				"fields": opts.multiMatchFields,
+				"type":   "best_fields",
 			},
-			// "encoder": "html", // 确保特殊HTML字符被正确编码 (通常是默认行为)
-			// "require_field_match": false, // 如果为true，则只有查询匹配的字段才会高亮。默认为false，可能会高亮其他字段（如果使用通配符字段名）
-		}
+		})
 	}
-	// --- 结束新增部分 ---
 
-	esQueryRequest := map[string]interface{}{
-		"from":             from,
-		"size":             req.Size,
-		"sort":             sortClause,
-		"query":            finalQueryDSL,
-		"track_total_hits": true,
+	var finalQueryDSL map[string]interface{}
+	if len(filters) > 0 || len(mustNot) > 0 {
+		boolQuery := map[string]interface{}{
+			"must": mainQueryDSL,
+		}
+		if len(filters) > 0 {
+			boolQuery["filter"] = filters
+		}
+		if len(mustNot) > 0 {
+			boolQuery["must_not"] = mustNot
+		}
+		finalQueryDSL = map[string]interface{}{
+			"bool": boolQuery,
+		}
+	} else {
+		finalQueryDSL = mainQueryDSL
 	}
 
-	// 只有当 highlightClause 被创建时（即有搜索关键词时），才将其添加到请求中
-	if highlightClause != nil {
-		esQueryRequest["highlight"] = highlightClause
+	// 新帖加权：请求参数优先，未指定时使用服务端配置。关闭时查询保持原样。
+	boostRecency := opts.recencyBoost.Enabled
+	if req.BoostRecency != nil {
+		boostRecency = *req.BoostRecency
 	}
+	if boostRecency {
+		finalQueryDSL = buildRecencyFunctionScore(finalQueryDSL, opts.recencyBoost)
+	}
+	return finalQueryDSL, positiveQuery
+}
 
-	queryJSON, err := json.Marshal(esQueryRequest)
-	if err != nil {
-		return nil, fmt.Errorf("序列化 Elasticsearch 查询对象为 JSON 失败: %w", err)
+// buildFilterGroup 将一个过滤条件组转换为 bool.should 查询，minimum_should_match 未指定时为 1。
+// 条件已在 API 层按 models.FilterableFields 校验过字段、比较方式和值类型。
+func buildFilterGroup(group models.FilterGroup) map[string]interface{} {
+	should := make([]map[string]interface{}, 0, len(group.Should))
+	for _, cond := range group.Should {
+		switch {
+		case cond.Op == models.FilterOpIn:
+			should = append(should, map[string]interface{}{
+				"terms": map[string]interface{}{cond.Field: cond.Value},
+			})
+		case models.IsRangeFilterOp(cond.Op):
+			should = append(should, map[string]interface{}{
+				"range": map[string]interface{}{cond.Field: map[string]interface{}{cond.Op: cond.Value}},
+			})
+		default:
+			should = append(should, map[string]interface{}{
+				"term": map[string]interface{}{cond.Field: cond.Value},
+			})
+		}
 	}
+	minimumShouldMatch := group.MinimumShouldMatch
+	if minimumShouldMatch <= 0 {
+		minimumShouldMatch = 1
+	}
+	return map[string]interface{}{
+		"bool": map[string]interface{}{
+			"should":               should,
+			"minimum_should_match": minimumShouldMatch,
+		},
+	}
+}
 
-	return queryJSON, nil
+// buildAdvancedQuery 为高级查询模式 (query_mode=advanced) 构建 query_string 查询。
+// 查询字符串已由 API 层的 models.ValidateAdvancedQuery 校验过字段白名单、前导通配符与正则；
+// 这里再通过 ES 参数关闭同样的危险特性，作为第二道防线：
+//   - allow_leading_wildcard=false：禁止 *foo 这类需要遍历整个词典的查询。
+//   - 未限定字段的词在 fields (与 multi_match 相同的字段及权重) 中匹配；限定了字段的词只能使用白名单中的字段。
+//   - max_determinized_states 限制通配符自动机的规模，fuzzy_max_expansions 限制模糊查询展开的词数。
+//   - default_operator=AND：高级用户书写的多个条件 (例如 title:kafka author_username:xushen) 需要同时满足。
+func buildAdvancedQuery(query string, opts searchQueryOptions) map[string]interface{} {
+	return map[string]interface{}{
+		"query_string": map[string]interface{}{
+			"query":                   query,
+			"fields":                  opts.multiMatchFields,
+			"default_operator":        "AND",
+			"allow_leading_wildcard":  false,
+			"analyze_wildcard":        false,
+			"max_determinized_states": 1000,
+			"fuzzy_max_expansions":    10,
+			"lenient":                 true,
+		},
+	}
 }
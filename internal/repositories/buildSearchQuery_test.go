@@ -0,0 +1,353 @@
+package repositories
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+// newTestSearchQueryOptions 返回按 cfg 生成的查询选项 (未启用 .en 子字段)。
+func newTestSearchQueryOptions(t *testing.T, cfg config.SearchConfig) searchQueryOptions {
+	t.Helper()
+	return newSearchQueryOptions(cfg, false, newTestLogger(t))
+}
+
+// buildTestSearchBody 使用 cfg 对应的选项为 req 构建搜索请求体。
+func buildTestSearchBody(t *testing.T, cfg config.SearchConfig, req models.SearchRequest) []byte {
+	t.Helper()
+	body, err := buildSearchQuery(req, newTestSearchQueryOptions(t, cfg))
+	if err != nil {
+		t.Fatalf("buildSearchQuery 返回错误: %v", err)
+	}
+	return body
+}
+
+func TestBuildSearchQueryAuthorFilterAndSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		req      models.SearchRequest
+		wantBody string
+	}{
+		{
+			name: "关键词加作者筛选",
+			req:  models.SearchRequest{Query: "kafka", AuthorID: "author-1", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc"},
+			wantBody: `{
+				"from": 0,
+				"size": 10,
+				"track_total_hits": true,
+				"_source": {"excludes": ["title_raw", "content_raw"]},
+				"sort": [
+					{"_score": {"order": "desc"}},
+					{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+				],
+				"query": {"bool": {
+					"must": {"multi_match": {"query": "kafka", "fields": ["author_username^1", "content^1", "title^3"], "type": "best_fields"}},
+					"filter": [{"term": {"author_id": "author-1"}}]
+				}},
+				"highlight": {
+					"pre_tags": ["<strong>"],
+					"post_tags": ["</strong>"],
+					"fields": {"title": {}, "content": {"fragment_size": 150, "number_of_fragments": 3}},
+					"require_field_match": true,
+					"encoder": "html"
+				}
+			}`,
+		},
+		{
+			name: "只按作者筛选时为 match_all 并使用浏览模式默认排序",
+			req:  models.SearchRequest{AuthorID: "author-1", Page: 2, Size: 5, SortBy: "updated_at", SortOrder: "desc"},
+			wantBody: `{
+				"from": 5,
+				"size": 5,
+				"track_total_hits": true,
+				"_source": {"excludes": ["title_raw", "content_raw"]},
+				"sort": [
+					{"updated_at": {"order": "desc", "missing": "_last", "unmapped_type": "date"}},
+					{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+				],
+				"query": {"bool": {
+					"must": {"match_all": {}},
+					"filter": [{"term": {"author_id": "author-1"}}]
+				}}
+			}`,
+		},
+		{
+			name: "match_all 按 _score 排序时仍追加 id 次级排序",
+			req:  models.SearchRequest{Query: "-kafka", AuthorID: "author-1", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc"},
+			wantBody: `{
+				"from": 0,
+				"size": 10,
+				"track_total_hits": true,
+				"_source": {"excludes": ["title_raw", "content_raw"]},
+				"sort": [
+					{"_score": {"order": "desc"}},
+					{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+				],
+				"query": {"bool": {
+					"must": {"match_all": {}},
+					"filter": [{"term": {"author_id": "author-1"}}],
+					"must_not": [{"multi_match": {"query": "kafka", "fields": ["author_username^1", "content^1", "title^3"], "type": "best_fields"}}]
+				}}
+			}`,
+		},
+		{
+			name: "按 id 排序时不重复追加 id",
+			req:  models.SearchRequest{AuthorID: "author-1", Page: 1, Size: 10, SortBy: "id", SortOrder: "desc"},
+			wantBody: `{
+				"from": 0,
+				"size": 10,
+				"track_total_hits": true,
+				"_source": {"excludes": ["title_raw", "content_raw"]},
+				"sort": [
+					{"id": {"order": "desc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+				],
+				"query": {"bool": {
+					"must": {"match_all": {}},
+					"filter": [{"term": {"author_id": "author-1"}}]
+				}}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assertJSONEqual(t, buildTestSearchBody(t, config.SearchConfig{}, tt.req), tt.wantBody)
+		})
+	}
+}
+
+// highlightOf 返回请求体中的 highlight 部分，不存在时返回 nil。
+func highlightOf(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	highlight, _ := decodeJSONMap(t, body)["highlight"].(map[string]interface{})
+	return highlight
+}
+
+func TestBuildSearchQueryHighlightRequireFieldMatch(t *testing.T) {
+	boolPtr := func(v bool) *bool { return &v }
+	tests := []struct {
+		name       string
+		configured *bool
+		requested  *bool
+		want       bool
+	}{
+		{name: "默认只高亮匹配的字段", want: true},
+		{name: "配置关闭", configured: boolPtr(false), want: false},
+		{name: "配置开启", configured: boolPtr(true), want: true},
+		{name: "请求覆盖配置 (关闭)", configured: boolPtr(true), requested: boolPtr(false), want: false},
+		{name: "请求覆盖配置 (开启)", configured: boolPtr(false), requested: boolPtr(true), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.SearchRequest{Query: "kafka", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc", HighlightRequireFieldMatch: tt.requested}
+			body := buildTestSearchBody(t, config.SearchConfig{HighlightRequireFieldMatch: tt.configured}, req)
+			highlight := highlightOf(t, body)
+			if highlight == nil {
+				t.Fatalf("请求体缺少 highlight: %s", body)
+			}
+			if got := highlight["require_field_match"]; got != tt.want {
+				t.Errorf("require_field_match = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSearchQueryNoHighlightWithoutKeyword(t *testing.T) {
+	requireFieldMatch := false
+	for _, query := range []string{"", "-kafka"} {
+		req := models.SearchRequest{Query: query, Page: 1, Size: 10, SortBy: "updated_at", SortOrder: "desc", HighlightRequireFieldMatch: &requireFieldMatch}
+		if highlight := highlightOf(t, buildTestSearchBody(t, config.SearchConfig{}, req)); highlight != nil {
+			t.Errorf("query=%q 时不应包含 highlight: %v", query, highlight)
+		}
+	}
+}
+
+func TestBuildMultiMatchFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		configured     map[string]float64
+		englishEnabled bool
+		want           []string
+	}{
+		{
+			name: "默认权重",
+			want: []string{"author_username^1", "content^1", "title^3"},
+		},
+		{
+			name:           "默认权重附加英文子字段",
+			englishEnabled: true,
+			want:           []string{"author_username^1", "content.en^0.5", "content^1", "title.en^1", "title^3"},
+		},
+		{
+			name:       "配置的权重",
+			configured: map[string]float64{"title": 5, "content": 1.5},
+			want:       []string{"content^1.5", "title^5"},
+		},
+		{
+			name:           "配置英文子字段",
+			configured:     map[string]float64{"title": 3, "title.en": 2},
+			englishEnabled: true,
+			want:           []string{"title.en^2", "title^3"},
+		},
+		{
+			name:       "未开启英文子字段时忽略 .en 字段",
+			configured: map[string]float64{"title": 3, "title.en": 2},
+			want:       []string{"title^3"},
+		},
+		{
+			name:       "忽略不可搜索的字段与非正数权重",
+			configured: map[string]float64{"title": 2, "contact_info": 5, "content": 0, "author_username": -1},
+			want:       []string{"title^2"},
+		},
+		{
+			name:       "没有可用字段时使用默认权重",
+			configured: map[string]float64{"contact_info": 5},
+			want:       []string{"author_username^1", "content^1", "title^3"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildMultiMatchFields(tt.configured, tt.englishEnabled, newTestLogger(t))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildMultiMatchFields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSearchQueryFieldBoosts(t *testing.T) {
+	opts := newSearchQueryOptions(config.SearchConfig{FieldBoosts: map[string]float64{"title": 4, "title.en": 2, "content": 1}}, true, newTestLogger(t))
+	body, err := buildSearchQuery(models.SearchRequest{Query: "running -java", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc"}, opts)
+	if err != nil {
+		t.Fatalf("buildSearchQuery 返回错误: %v", err)
+	}
+	// 正向关键词与排除词使用相同的字段及权重。
+	assertJSONEqual(t, decodeJSONMap(t, body)["query"], `{"bool": {
+		"must": {"multi_match": {"query": "running", "fields": ["content^1", "title.en^2", "title^4"], "type": "best_fields"}},
+		"must_not": [{"multi_match": {"query": "java", "fields": ["content^1", "title.en^2", "title^4"], "type": "best_fields"}}]
+	}}`)
+}
+
+func TestBuildSearchQueryMinScore(t *testing.T) {
+	floatPtr := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name      string
+		req       models.SearchRequest
+		wantScore interface{} // nil 表示请求体中不应包含 min_score
+	}{
+		{name: "未指定", req: models.SearchRequest{Query: "kafka"}},
+		{name: "关键词查询", req: models.SearchRequest{Query: "kafka", MinScore: floatPtr(1.5)}, wantScore: 1.5},
+		{name: "阈值为 0", req: models.SearchRequest{Query: "kafka", MinScore: floatPtr(0)}, wantScore: 0.0},
+		{name: "高级查询", req: models.SearchRequest{Query: "title:kafka", QueryMode: models.QueryModeAdvanced, MinScore: floatPtr(2)}, wantScore: 2.0},
+		{name: "没有关键词时忽略", req: models.SearchRequest{MinScore: floatPtr(1.5)}},
+		{name: "只有排除词时忽略", req: models.SearchRequest{Query: "-kafka", MinScore: floatPtr(1.5)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.Page, tt.req.Size, tt.req.SortBy, tt.req.SortOrder = 1, 10, "_score", "desc"
+			body := decodeJSONMap(t, buildTestSearchBody(t, config.SearchConfig{}, tt.req))
+			got, ok := body["min_score"]
+			if tt.wantScore == nil {
+				if ok {
+					t.Errorf("min_score = %v, want 不包含", got)
+				}
+				return
+			}
+			if got != tt.wantScore {
+				t.Errorf("min_score = %v, want %v", got, tt.wantScore)
+			}
+		})
+	}
+}
+
+func TestBuildSearchQueryHighlightEncoder(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured string
+		requested  string
+		want       string
+	}{
+		{name: "默认转义 HTML", want: models.HighlightEncoderHTML},
+		{name: "配置 html", configured: "html", want: models.HighlightEncoderHTML},
+		{name: "配置 default", configured: "default", want: models.HighlightEncoderDefault},
+		{name: "无法识别的配置回退为 html", configured: "base64", want: models.HighlightEncoderHTML},
+		{name: "请求覆盖配置 (default)", configured: "html", requested: "default", want: models.HighlightEncoderDefault},
+		{name: "请求覆盖配置 (html)", configured: "default", requested: "html", want: models.HighlightEncoderHTML},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.SearchRequest{Query: "kafka", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc", HighlightEncoder: tt.requested}
+			body := buildTestSearchBody(t, config.SearchConfig{HighlightEncoder: tt.configured}, req)
+			highlight := highlightOf(t, body)
+			if highlight == nil {
+				t.Fatalf("请求体缺少 highlight: %s", body)
+			}
+			if got := highlight["encoder"]; got != tt.want {
+				t.Errorf("encoder = %v, want %v", got, tt.want)
+			}
+			// 无论哪种编码，高亮标签本身都保持不变。
+			assertJSONEqual(t, highlight["pre_tags"], `["<strong>"]`)
+			assertJSONEqual(t, highlight["post_tags"], `["</strong>"]`)
+		})
+	}
+}
+
+func TestBuildSearchQueryHighlightBoundaryScanner(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured map[string]config.HighlightFieldConfig
+		wantFields string
+	}{
+		{
+			name:       "未配置时使用 ES 默认边界",
+			wantFields: `{"title": {}, "content": {"fragment_size": 150, "number_of_fragments": 3}}`,
+		},
+		{
+			name: "正文按中文句子切分",
+			configured: map[string]config.HighlightFieldConfig{
+				"content": {BoundaryScanner: "sentence", BoundaryScannerLocale: "zh-CN"},
+			},
+			wantFields: `{"title": {}, "content": {
+				"fragment_size": 150, "number_of_fragments": 3,
+				"boundary_scanner": "sentence", "boundary_scanner_locale": "zh-CN"
+			}}`,
+		},
+		{
+			name: "按字段分别配置",
+			configured: map[string]config.HighlightFieldConfig{
+				"title":   {BoundaryScanner: "word"},
+				"content": {BoundaryScannerLocale: "en-US"},
+			},
+			wantFields: `{
+				"title": {"boundary_scanner": "word"},
+				"content": {"fragment_size": 150, "number_of_fragments": 3, "boundary_scanner_locale": "en-US"}
+			}`,
+		},
+		{
+			name: "无效的边界方式被忽略",
+			configured: map[string]config.HighlightFieldConfig{
+				"content": {BoundaryScanner: "chars"},
+			},
+			wantFields: `{"title": {}, "content": {"fragment_size": 150, "number_of_fragments": 3}}`,
+		},
+		{
+			name: "不可高亮的字段被忽略",
+			configured: map[string]config.HighlightFieldConfig{
+				"contact_info": {BoundaryScanner: "sentence"},
+			},
+			wantFields: `{"title": {}, "content": {"fragment_size": 150, "number_of_fragments": 3}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.SearchRequest{Query: "kafka", Page: 1, Size: 10, SortBy: "_score", SortOrder: "desc"}
+			body := buildTestSearchBody(t, config.SearchConfig{Highlight: tt.configured}, req)
+			highlight := highlightOf(t, body)
+			if highlight == nil {
+				t.Fatalf("请求体缺少 highlight: %s", body)
+			}
+			assertJSONEqual(t, highlight["fields"], tt.wantFields)
+		})
+	}
+}
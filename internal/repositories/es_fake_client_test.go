@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	commonconfig "github.com/Xushengqwer/go-common/config"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/elastic/go-elasticsearch/v8"
+)
+
+// recordedESRequest 是 fakeESTransport 收到的一个请求。
+type recordedESRequest struct {
+	Method string
+	Path   string
+	Query  string
+	Body   []byte
+}
+
+// fakeESTransport 记录发往 Elasticsearch 的请求，并用 respond 返回的状态码与响应体作答，
+// 使仓库层测试无需真实的 ES 集群即可检查生成的请求体和响应解码。
+type fakeESTransport struct {
+	respond func(req recordedESRequest) (int, string)
+
+	mu       sync.Mutex
+	requests []recordedESRequest
+}
+
+func (tr *fakeESTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	recorded := recordedESRequest{Method: req.Method, Path: req.URL.Path, Query: req.URL.RawQuery}
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+		recorded.Body = body
+	}
+	tr.mu.Lock()
+	tr.requests = append(tr.requests, recorded)
+	tr.mu.Unlock()
+
+	status, body := tr.respond(recorded)
+	return &http.Response{
+		StatusCode: status,
+		Status:     http.StatusText(status),
+		Header: http.Header{
+			"Content-Type":      []string{"application/json"},
+			"X-Elastic-Product": []string{"Elasticsearch"}, // 客户端的产品检查要求此响应头
+		},
+		Body:    io.NopCloser(strings.NewReader(body)),
+		Request: req,
+	}, nil
+}
+
+// recorded 返回迄今收到的全部请求。
+func (tr *fakeESTransport) recorded() []recordedESRequest {
+	tr.mu.Lock()
+	defer tr.mu.Unlock()
+	return append([]recordedESRequest(nil), tr.requests...)
+}
+
+// newFakeESClient 返回一个所有请求都由 respond 作答的 ES 客户端。
+func newFakeESClient(t *testing.T, respond func(req recordedESRequest) (int, string)) (*elasticsearch.Client, *fakeESTransport) {
+	t.Helper()
+	transport := &fakeESTransport{respond: respond}
+	client, err := elasticsearch.NewClient(elasticsearch.Config{
+		Addresses:    []string{"http://es.test:9200"},
+		Transport:    transport,
+		DisableRetry: true,
+	})
+	if err != nil {
+		t.Fatalf("创建 ES 客户端失败: %v", err)
+	}
+	return client, transport
+}
+
+// newTestLogger 返回只输出 error 及以上级别的 logger，避免测试输出被日志淹没。
+func newTestLogger(t *testing.T) *core.ZapLogger {
+	t.Helper()
+	logger, err := core.NewZapLogger(commonconfig.ZapConfig{Level: "error", Encoding: "console"})
+	if err != nil {
+		t.Fatalf("创建测试 logger 失败: %v", err)
+	}
+	return logger
+}
+
+// assertJSONEqual 比较 got 序列化后的 JSON 与 want 是否语义相同 (忽略键顺序与空白)。
+func assertJSONEqual(t *testing.T, got interface{}, want string) {
+	t.Helper()
+	gotJSON, ok := got.([]byte)
+	if !ok {
+		var err error
+		if gotJSON, err = json.Marshal(got); err != nil {
+			t.Fatalf("序列化失败: %v", err)
+		}
+	}
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(gotJSON, &gotValue); err != nil {
+		t.Fatalf("解析实际 JSON 失败: %v\n%s", err, gotJSON)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("解析期望 JSON 失败: %v\n%s", err, want)
+	}
+	if !reflect.DeepEqual(gotValue, wantValue) {
+		t.Errorf("JSON 不一致\n got: %s\nwant: %s", gotJSON, want)
+	}
+}
+
+// decodeJSONMap 将请求体解析为 map，供只关心部分字段的断言使用。
+func decodeJSONMap(t *testing.T, body []byte) map[string]interface{} {
+	t.Helper()
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		t.Fatalf("解析请求体失败: %v\n%s", err, body)
+	}
+	return decoded
+}
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
 	"github.com/Xushengqwer/post_search/internal/models"
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -21,8 +22,26 @@ import (
 
 // HotSearchTermRepository 定义了与热门搜索词统计数据在 Elasticsearch 中交互的操作接口。
 type HotSearchTermRepository interface {
-	IncrementSearchTermCount(ctx context.Context, term string) error
-	GetHotSearchTerms(ctx context.Context, limit int) ([]models.HotSearchTerm, error)
+	// IncrementSearchTermCount 将搜索词的计数增加 increment (采样时大于 1)，词不存在时以 increment 为初始计数创建。
+	IncrementSearchTermCount(ctx context.Context, term string, increment int64) error
+	// GetHotSearchTerms 返回最多 limit 个热门搜索词，sort 为 models.HotTermsSort* 之一，为空时按计数排序。
+	// limit <= 0 时使用 searchConfig.hotTerms.defaultLimit，超过 maxLimit 时截断。
+	GetHotSearchTerms(ctx context.Context, limit int, sort string) ([]models.HotSearchTerm, error)
+
+	// ListHotSearchTerms 按计数倒序分页返回热门搜索词，并返回计数为正的搜索词总数。
+	ListHotSearchTerms(ctx context.Context, from, size int) (*models.HotSearchTermPage, error)
+
+	// SuggestHotTerms 返回以 prefix 开头的热门搜索词，按搜索次数倒序，最多 limit 个。
+	SuggestHotTerms(ctx context.Context, prefix string, limit int) ([]models.HotSearchTerm, error)
+
+	// GetSearchVolume 统计 since 之后被搜索过的词数及其计数之和；since 为零值时统计全部搜索词。
+	GetSearchVolume(ctx context.Context, since time.Time) (*models.SearchVolume, error)
+
+	// IndexStats 返回热门搜索词索引的文档数量、存储大小和分片信息。
+	IndexStats(ctx context.Context) (*models.IndexStatsEntry, error)
+
+	// IndexHealth 检查热门搜索词索引是否存在且健康状态至少为 yellow，用于就绪检查。
+	IndexHealth(ctx context.Context) models.DependencyStatus
 }
 
 // esHotSearchTermRepository 是 HotSearchTermRepository 接口针对 Elasticsearch 的具体实现。
@@ -30,17 +49,25 @@ type esHotSearchTermRepository struct {
 	client    *elasticsearch.Client // 注入的 Elasticsearch Go 客户端实例。
 	logger    *core.ZapLogger       // 注入的 Logger 实例，用于结构化日志记录。
 	indexName string                // 新增：此仓库操作的目标 Elasticsearch 索引名称。
+	limits    config.HotTermsConfig // GetHotSearchTerms 的默认返回数量与上限 (已填充默认值)。
 }
 
+// 未配置 searchConfig.hotTerms 时使用的默认返回数量与上限。
+const (
+	defaultHotTermsLimit    = 10
+	defaultHotTermsMaxLimit = 50
+)
+
 // NewESHotSearchTermRepository 创建一个新的 esHotSearchTermRepository 实例。
 // 参数:
 //   - client: 一个初始化完成且可用的 *elasticsearch.Client 实例。
 //   - logger: 一个 *core.ZapLogger 实例，用于日志记录。
 //   - indexName: 此仓库将要操作的 Elasticsearch 索引的名称。
+//   - limits: GetHotSearchTerms 的默认返回数量与上限，未配置的字段使用默认值。
 //
 // 返回值:
 //   - HotSearchTermRepository: 返回一个符合 HotSearchTermRepository 接口的 esHotSearchTermRepository 实例。
-func NewESHotSearchTermRepository(client *elasticsearch.Client, logger *core.ZapLogger, indexName string) HotSearchTermRepository {
+func NewESHotSearchTermRepository(client *elasticsearch.Client, logger *core.ZapLogger, indexName string, limits config.HotTermsConfig) HotSearchTermRepository {
 	if logger == nil {
 		panic("创建 esHotSearchTermRepository 失败：Logger 实例不能为 nil")
 	}
@@ -50,13 +77,25 @@ func NewESHotSearchTermRepository(client *elasticsearch.Client, logger *core.Zap
 	if indexName == "" { // 新增：检查 indexName 是否为空
 		logger.Fatal("创建 esHotSearchTermRepository 失败：热门搜索词索引名称 (indexName) 不能为空。")
 	}
+	if limits.DefaultLimit <= 0 {
+		limits.DefaultLimit = defaultHotTermsLimit
+	}
+	if limits.MaxLimit <= 0 {
+		limits.MaxLimit = defaultHotTermsMaxLimit
+	}
+	if limits.DefaultLimit > limits.MaxLimit {
+		limits.DefaultLimit = limits.MaxLimit
+	}
 	logger.Info("Elasticsearch HotSearchTermRepository 初始化成功",
 		zap.String("target_index_for_hot_terms", indexName), // 使用传入的 indexName
+		zap.Int("default_limit", limits.DefaultLimit),
+		zap.Int("max_limit", limits.MaxLimit),
 	)
 	return &esHotSearchTermRepository{
 		client:    client,
 		logger:    logger,
 		indexName: indexName, // 存储传入的 indexName
+		limits:    limits,
 	}
 }
 
@@ -87,19 +126,19 @@ func (repo *esHotSearchTermRepository) logAndWrapESErrorForHotTerms(res *esapi.R
 	return fmt.Errorf("Elasticsearch 热门搜索词操作 '%s' 失败，状态码: %s", operationDesc, res.Status())
 }
 
-// IncrementSearchTermCount 递增给定搜索词在 Elasticsearch 中的计数。
-func (repo *esHotSearchTermRepository) IncrementSearchTermCount(ctx context.Context, term string) error {
+// IncrementSearchTermCount 将给定搜索词在 Elasticsearch 中的计数增加 increment。
+func (repo *esHotSearchTermRepository) IncrementSearchTermCount(ctx context.Context, term string, increment int64) error {
 	docID := term
 
 	scriptSource := "ctx._source.count += params.count_val; ctx._source.last_searched_at = params.now; ctx._source.term = params.term_val;"
 	scriptParams := map[string]interface{}{
-		"count_val": 1,
+		"count_val": increment,
 		"now":       time.Now().UTC(),
 		"term_val":  term,
 	}
 	upsertDoc := models.HotSearchTermES{
 		Term:           term,
-		Count:          1,
+		Count:          increment,
 		LastSearchedAt: time.Now().UTC(),
 	}
 	updateBody := map[string]interface{}{
@@ -140,24 +179,125 @@ func (repo *esHotSearchTermRepository) IncrementSearchTermCount(ctx context.Cont
 	return nil
 }
 
-// GetHotSearchTerms 从 Elasticsearch 中检索最热门的 N 个搜索词。
-func (repo *esHotSearchTermRepository) GetHotSearchTerms(ctx context.Context, limit int) ([]models.HotSearchTerm, error) {
+// GetHotSearchTerms 从 Elasticsearch 中检索最热门的 N 个搜索词，按 sort 指定的方式排序。
+// limit <= 0 时使用配置的默认数量，超过配置的上限时截断。
+func (repo *esHotSearchTermRepository) GetHotSearchTerms(ctx context.Context, limit int, sort string) ([]models.HotSearchTerm, error) {
 	if limit <= 0 {
-		limit = 10
+		limit = repo.limits.DefaultLimit
+	} else if limit > repo.limits.MaxLimit {
+		limit = repo.limits.MaxLimit
+	}
+	if sort == "" {
+		sort = models.HotTermsSortCount
 	}
-	repo.logger.Info("准备从 Elasticsearch 检索热门搜索词", zap.Int("limit", limit), zap.String("index_name", repo.indexName))
+	repo.logger.Info("准备从 Elasticsearch 检索热门搜索词", zap.Int("limit", limit), zap.String("sort", sort), zap.String("index_name", repo.indexName))
 
-	query := map[string]interface{}{
-		"size": limit,
-		"sort": []map[string]interface{}{
-			{"count": map[string]string{"order": "desc"}},
+	terms, total, err := repo.searchHotTerms(ctx, 0, limit, sort, false)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.logger.Info("成功从 Elasticsearch 检索热门搜索词",
+		zap.Int("retrieved_count", len(terms)),
+		zap.Int64("total_stats_docs_in_es", total),
+		zap.String("index_name", repo.indexName),
+	)
+	return terms, nil
+}
+
+// ListHotSearchTerms 按计数倒序返回从 from 开始的 size 个热门搜索词，供 admin 分页浏览全部词条。
+// 与 GetHotSearchTerms 不同，这里要求 ES 精确统计总数 (track_total_hits)，词条超过 10000 个时总数也准确。
+func (repo *esHotSearchTermRepository) ListHotSearchTerms(ctx context.Context, from, size int) (*models.HotSearchTermPage, error) {
+	terms, total, err := repo.searchHotTerms(ctx, from, size, models.HotTermsSortCount, true)
+	if err != nil {
+		return nil, err
+	}
+
+	repo.logger.Debug("成功从 Elasticsearch 分页检索热门搜索词",
+		zap.Int("from", from),
+		zap.Int("size", size),
+		zap.Int("retrieved_count", len(terms)),
+		zap.Int64("total", total),
+	)
+	return &models.HotSearchTermPage{
+		Terms: terms,
+		Total: total,
+		From:  from,
+		Size:  size,
+	}, nil
+}
+
+// blendedRecencyScale 是综合排序 (blended) 中最后搜索时间的衰减尺度：
+// 最后一次被搜索的时间距今 blendedRecencyScale 时，时间系数降为 blendedRecencyDecay。
+const (
+	blendedRecencyScale = "7d"
+	blendedRecencyDecay = 0.5
+)
+
+// buildHotTermsQuery 生成热门搜索词查询的 query 与 sort 部分。
+// 只返回计数为正的词：衰减后计数可能降到 0 或以下，而清理任务删除这些文档之前它们仍在索引中。
+//   - count: 按 count 倒序。
+//   - recent: 按 last_searched_at 倒序，相同时按 count 倒序。
+//   - blended: 使用 function_score，得分 = ln(1 + count) × gauss(last_searched_at)，
+//     既不会让很久以前的高频词一直霸榜，也不会让只搜过一次的新词排到前面。
+func buildHotTermsQuery(sort string) (query map[string]interface{}, sortClause []map[string]interface{}) {
+	positiveCount := map[string]interface{}{
+		"range": map[string]interface{}{
+			"count": map[string]interface{}{"gt": 0},
 		},
 	}
+	byCount := map[string]interface{}{"count": map[string]string{"order": "desc"}}
+
+	switch sort {
+	case models.HotTermsSortRecent:
+		return positiveCount, []map[string]interface{}{
+			{"last_searched_at": map[string]string{"order": "desc"}},
+			byCount,
+		}
+	case models.HotTermsSortBlended:
+		return map[string]interface{}{
+			"function_score": map[string]interface{}{
+				"query": positiveCount,
+				"functions": []map[string]interface{}{
+					{"field_value_factor": map[string]interface{}{"field": "count", "modifier": "ln1p", "missing": 0}},
+					{"gauss": map[string]interface{}{
+						"last_searched_at": map[string]interface{}{
+							"origin": "now",
+							"scale":  blendedRecencyScale,
+							"decay":  blendedRecencyDecay,
+						},
+					}},
+				},
+				"score_mode": "multiply",
+				"boost_mode": "replace", // 忽略 range 查询本身的得分 (恒为 1)
+			},
+		}, []map[string]interface{}{
+			{"_score": map[string]string{"order": "desc"}},
+			byCount,
+		}
+	default:
+		return positiveCount, []map[string]interface{}{byCount}
+	}
+}
+
+// searchHotTerms 执行热门搜索词查询，按 sort (models.HotTermsSort*) 排序，返回 [from, from+size) 范围内的词和命中总数。
+// trackTotal 为 false 时总数沿用 ES 默认的统计上限 (10000)。
+func (repo *esHotSearchTermRepository) searchHotTerms(ctx context.Context, from, size int, sort string, trackTotal bool) ([]models.HotSearchTerm, int64, error) {
+	queryClause, sortClause := buildHotTermsQuery(sort)
+	query := map[string]interface{}{
+		"from":  from,
+		"size":  size,
+		"query": queryClause,
+		"sort":  sortClause,
+	}
+	if trackTotal {
+		query["track_total_hits"] = true
+	}
 
 	queryJSON, err := json.Marshal(query)
 	if err != nil {
 		repo.logger.Error("序列化热门搜索词查询 DSL 失败", zap.Error(err))
-		return nil, fmt.Errorf("序列化热门搜索词查询 DSL 失败: %w", err)
+		return nil, 0, fmt.Errorf("序列化热门搜索词查询 DSL 失败: %w", err)
 	}
 	repo.logger.Debug("构建的热门搜索词查询 DSL", zap.String("dsl_query", string(queryJSON)))
 
@@ -169,12 +309,12 @@ func (repo *esHotSearchTermRepository) GetHotSearchTerms(ctx context.Context, li
 	res, err := searchReq.Do(ctx, repo.client)
 	if err != nil {
 		repo.logger.Error("执行 Elasticsearch 热门搜索词搜索请求时发生连接或客户端错误", zap.Error(err))
-		return nil, fmt.Errorf("Elasticsearch 热门搜索词搜索请求失败: %w", err)
+		return nil, 0, fmt.Errorf("Elasticsearch 热门搜索词搜索请求失败: %w", err)
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, repo.logAndWrapESErrorForHotTerms(res, "检索热门搜索词", fmt.Sprintf("limit: %d on index %s", limit, repo.indexName))
+		return nil, 0, repo.logAndWrapESErrorForHotTerms(res, "检索热门搜索词", fmt.Sprintf("from: %d, size: %d on index %s", from, size, repo.indexName))
 	}
 
 	var esResponse struct {
@@ -190,7 +330,7 @@ func (repo *esHotSearchTermRepository) GetHotSearchTerms(ctx context.Context, li
 
 	if err := json.NewDecoder(res.Body).Decode(&esResponse); err != nil {
 		repo.logger.Error("解码 Elasticsearch 热门搜索词响应体失败", zap.Error(err))
-		return nil, fmt.Errorf("解码 Elasticsearch 热门搜索词响应失败: %w", err)
+		return nil, 0, fmt.Errorf("解码 Elasticsearch 热门搜索词响应失败: %w", err)
 	}
 
 	hotTermsAPI := make([]models.HotSearchTerm, 0, len(esResponse.Hits.Hits))
@@ -200,12 +340,168 @@ func (repo *esHotSearchTermRepository) GetHotSearchTerms(ctx context.Context, li
 			Count: hit.Source.Count,
 		})
 	}
+	return hotTermsAPI, esResponse.Hits.Total.Value, nil
+}
 
-	repo.logger.Info("成功从 Elasticsearch 检索热门搜索词",
-		zap.Int("retrieved_count", len(hotTermsAPI)),
-		zap.Int64("total_stats_docs_in_es", esResponse.Hits.Total.Value),
-		zap.String("index_name", repo.indexName),
+// SuggestHotTerms 在热门搜索词索引中按前缀匹配 term 字段 (keyword)，按 count 倒序返回最多 limit 个词。
+// prefix 应已与记录时一样规范化 (小写、去除首尾空白)，否则大小写不同的前缀无法命中。
+func (repo *esHotSearchTermRepository) SuggestHotTerms(ctx context.Context, prefix string, limit int) ([]models.HotSearchTerm, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	query := map[string]interface{}{
+		"size":    limit,
+		"_source": []string{"term", "count"},
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"filter": []map[string]interface{}{
+					{"prefix": map[string]interface{}{"term": map[string]interface{}{"value": prefix}}},
+					// 与 GetHotSearchTerms 一致，排除衰减后计数已降到 0 或以下、尚未被清理的词。
+					{"range": map[string]interface{}{"count": map[string]interface{}{"gt": 0}}},
+				},
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"count": map[string]string{"order": "desc"}},
+		},
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		repo.logger.Error("序列化热门搜索词联想查询 DSL 失败", zap.String("prefix", prefix), zap.Error(err))
+		return nil, fmt.Errorf("序列化热门搜索词联想查询 DSL 失败: %w", err)
+	}
+	repo.logger.Debug("构建的热门搜索词联想查询 DSL", zap.String("dsl_query", string(queryJSON)))
+
+	searchReq := esapi.SearchRequest{
+		Index: []string{repo.indexName},
+		Body:  bytes.NewReader(queryJSON),
+	}
+
+	res, err := searchReq.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 热门搜索词联想请求时发生连接或客户端错误", zap.String("prefix", prefix), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 热门搜索词联想请求失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, repo.logAndWrapESErrorForHotTerms(res, "联想热门搜索词", fmt.Sprintf("prefix: %s on index %s", prefix, repo.indexName))
+	}
+
+	var esResponse struct {
+		Hits struct {
+			Hits []struct {
+				Source models.HotSearchTermES `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&esResponse); err != nil {
+		repo.logger.Error("解码 Elasticsearch 热门搜索词联想响应体失败", zap.Error(err))
+		return nil, fmt.Errorf("解码 Elasticsearch 热门搜索词联想响应失败: %w", err)
+	}
+
+	terms := make([]models.HotSearchTerm, 0, len(esResponse.Hits.Hits))
+	for _, hit := range esResponse.Hits.Hits {
+		terms = append(terms, models.HotSearchTerm{
+			Term:  hit.Source.Term,
+			Count: hit.Source.Count,
+		})
+	}
+
+	repo.logger.Debug("成功从 Elasticsearch 检索热门搜索词联想",
+		zap.String("prefix", prefix),
+		zap.Int("retrieved_count", len(terms)),
 	)
+	return terms, nil
+}
+
+// GetSearchVolume 用 sum 聚合累加 count，并精确统计计数为正的搜索词数量 (track_total_hits)。
+// 索引只保存每个词的累计计数和最后搜索时间，没有逐次搜索的时间戳，因此指定 since 时
+// 统计的是 since 之后被搜索过的词的全部累计计数，是该时间窗口内搜索量的上限估计。
+func (repo *esHotSearchTermRepository) GetSearchVolume(ctx context.Context, since time.Time) (*models.SearchVolume, error) {
+	filters := []map[string]interface{}{
+		// 与 GetHotSearchTerms 一致，排除衰减后计数已降到 0 或以下、尚未被清理的词。
+		{"range": map[string]interface{}{"count": map[string]interface{}{"gt": 0}}},
+	}
+	if !since.IsZero() {
+		filters = append(filters, map[string]interface{}{
+			"range": map[string]interface{}{"last_searched_at": map[string]interface{}{"gte": since.UTC().Format(time.RFC3339)}},
+		})
+	}
+	query := map[string]interface{}{
+		"size":             0,
+		"track_total_hits": true,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{"filter": filters},
+		},
+		"aggs": map[string]interface{}{
+			"total_searches": map[string]interface{}{"sum": map[string]interface{}{"field": "count"}},
+		},
+	}
+
+	queryJSON, err := json.Marshal(query)
+	if err != nil {
+		repo.logger.Error("序列化搜索量统计查询 DSL 失败", zap.Error(err))
+		return nil, fmt.Errorf("序列化搜索量统计查询 DSL 失败: %w", err)
+	}
+	repo.logger.Debug("构建的搜索量统计查询 DSL", zap.String("dsl_query", string(queryJSON)))
+
+	searchReq := esapi.SearchRequest{
+		Index: []string{repo.indexName},
+		Body:  bytes.NewReader(queryJSON),
+	}
+
+	res, err := searchReq.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 搜索量统计请求时发生连接或客户端错误", zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 搜索量统计请求失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, repo.logAndWrapESErrorForHotTerms(res, "统计搜索量", fmt.Sprintf("since: %s on index %s", since.Format(time.RFC3339), repo.indexName))
+	}
+
+	var esResponse struct {
+		Hits struct {
+			Total struct {
+				Value int64 `json:"value"`
+			} `json:"total"`
+		} `json:"hits"`
+		Aggregations struct {
+			TotalSearches struct {
+				Value float64 `json:"value"`
+			} `json:"total_searches"`
+		} `json:"aggregations"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&esResponse); err != nil {
+		repo.logger.Error("解码 Elasticsearch 搜索量统计响应体失败", zap.Error(err))
+		return nil, fmt.Errorf("解码 Elasticsearch 搜索量统计响应失败: %w", err)
+	}
+
+	return &models.SearchVolume{
+		TotalSearches: int64(esResponse.Aggregations.TotalSearches.Value),
+		DistinctTerms: esResponse.Hits.Total.Value,
+	}, nil
+}
+
+// IndexStats 返回热门搜索词索引的文档数量、存储大小和分片信息。
+func (repo *esHotSearchTermRepository) IndexStats(ctx context.Context) (*models.IndexStatsEntry, error) {
+	stats, err := fetchIndexStats(ctx, repo.client, repo.indexName)
+	if err != nil {
+		repo.logger.Error("获取热门搜索词索引统计信息失败", zap.String("index_name", repo.indexName), zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
 
-	return hotTermsAPI, nil
+// IndexHealth 检查热门搜索词索引是否存在且健康状态至少为 yellow。
+func (repo *esHotSearchTermRepository) IndexHealth(ctx context.Context) models.DependencyStatus {
+	dep := checkIndexReadiness(ctx, repo.client, repo.indexName)
+	if !dep.Healthy {
+		repo.logger.Warn("热门搜索词索引未就绪", zap.String("index_name", repo.indexName), zap.String("status", dep.Status), zap.String("reason", dep.Error))
+	}
+	return dep
 }
@@ -0,0 +1,282 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+const testHotTermsIndex = "hot_search_terms_test"
+
+// countLowerBound 在查询 DSL 中查找 count 字段的 range 过滤条件，返回其 gt 下界。
+func countLowerBound(node interface{}) (float64, bool) {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if rng, ok := v["range"].(map[string]interface{}); ok {
+			if count, ok := rng["count"].(map[string]interface{}); ok {
+				if gt, ok := count["gt"].(float64); ok {
+					return gt, true
+				}
+			}
+		}
+		for _, child := range v {
+			if gt, ok := countLowerBound(child); ok {
+				return gt, true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if gt, ok := countLowerBound(child); ok {
+				return gt, true
+			}
+		}
+	}
+	return 0, false
+}
+
+// hotTermsSearchResponder 模拟热门搜索词索引：按请求中的 count range 过滤条件筛选 seeded 中的文档，
+// 再按请求的 size 截断 (文档已按计数倒序排列)。
+func hotTermsSearchResponder(t *testing.T, seeded []models.HotSearchTermES) func(req recordedESRequest) (int, string) {
+	return func(req recordedESRequest) (int, string) {
+		body := decodeJSONMap(t, req.Body)
+		gt, hasFilter := countLowerBound(body["query"])
+		var hits []string
+		for _, doc := range seeded {
+			if hasFilter && float64(doc.Count) <= gt {
+				continue
+			}
+			source, err := json.Marshal(doc)
+			if err != nil {
+				t.Fatalf("序列化文档失败: %v", err)
+			}
+			hits = append(hits, fmt.Sprintf(`{"_id":%q,"_source":%s}`, doc.Term, source))
+		}
+		total := len(hits)
+		if size, ok := body["size"].(float64); ok && int(size) < len(hits) {
+			hits = hits[:int(size)]
+		}
+		return 200, fmt.Sprintf(`{"hits":{"total":{"value":%d,"relation":"eq"},"hits":[%s]}}`, total, strings.Join(hits, ","))
+	}
+}
+
+func newTestHotTermsRepo(t *testing.T, respond func(req recordedESRequest) (int, string)) (HotSearchTermRepository, *fakeESTransport) {
+	t.Helper()
+	client, transport := newFakeESClient(t, respond)
+	return NewESHotSearchTermRepository(client, newTestLogger(t), testHotTermsIndex, config.HotTermsConfig{DefaultLimit: 2, MaxLimit: 3}), transport
+}
+
+func TestGetHotSearchTermsExcludesNonPositiveCounts(t *testing.T) {
+	now := time.Now().UTC()
+	seeded := []models.HotSearchTermES{
+		{Term: "kafka", Count: 12, LastSearchedAt: now},
+		{Term: "golang", Count: 3, LastSearchedAt: now},
+		{Term: "decayed", Count: 0, LastSearchedAt: now},
+		{Term: "negative", Count: -2, LastSearchedAt: now},
+	}
+	repo, transport := newTestHotTermsRepo(t, hotTermsSearchResponder(t, seeded))
+
+	terms, err := repo.GetHotSearchTerms(context.Background(), 3, "")
+	if err != nil {
+		t.Fatalf("GetHotSearchTerms 返回错误: %v", err)
+	}
+	want := []models.HotSearchTerm{{Term: "kafka", Count: 12}, {Term: "golang", Count: 3}}
+	if len(terms) != len(want) {
+		t.Fatalf("GetHotSearchTerms = %+v, want %+v", terms, want)
+	}
+	for i := range want {
+		if terms[i] != want[i] {
+			t.Errorf("terms[%d] = %+v, want %+v", i, terms[i], want[i])
+		}
+	}
+
+	requests := transport.recorded()
+	if len(requests) != 1 {
+		t.Fatalf("发送了 %d 个请求, want 1", len(requests))
+	}
+	if want := "/" + testHotTermsIndex + "/_search"; requests[0].Path != want {
+		t.Errorf("请求路径 = %s, want %s", requests[0].Path, want)
+	}
+	if gt, ok := countLowerBound(decodeJSONMap(t, requests[0].Body)["query"]); !ok || gt != 0 {
+		t.Errorf("查询缺少 count > 0 过滤条件: %s", requests[0].Body)
+	}
+}
+
+func TestGetHotSearchTermsLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    int
+		wantSize float64
+	}{
+		{name: "未指定时使用默认数量", limit: 0, wantSize: 2},
+		{name: "负数使用默认数量", limit: -1, wantSize: 2},
+		{name: "上限以内", limit: 3, wantSize: 3},
+		{name: "超过上限时截断", limit: 100, wantSize: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, transport := newTestHotTermsRepo(t, hotTermsSearchResponder(t, nil))
+			if _, err := repo.GetHotSearchTerms(context.Background(), tt.limit, ""); err != nil {
+				t.Fatalf("GetHotSearchTerms 返回错误: %v", err)
+			}
+			body := decodeJSONMap(t, transport.recorded()[0].Body)
+			if body["size"] != tt.wantSize {
+				t.Errorf("size = %v, want %v", body["size"], tt.wantSize)
+			}
+		})
+	}
+}
+
+func TestGetHotSearchTermsESError(t *testing.T) {
+	repo, _ := newTestHotTermsRepo(t, func(recordedESRequest) (int, string) {
+		return 404, `{"error":{"type":"index_not_found_exception"},"status":404}`
+	})
+	if _, err := repo.GetHotSearchTerms(context.Background(), 0, ""); err == nil || !strings.Contains(err.Error(), "index_not_found_exception") {
+		t.Errorf("GetHotSearchTerms error = %v, want 包含 ES 错误响应", err)
+	}
+}
+
+func TestSuggestHotTermsExcludesNonPositiveCounts(t *testing.T) {
+	repo, transport := newTestHotTermsRepo(t, hotTermsSearchResponder(t, []models.HotSearchTermES{
+		{Term: "kafka", Count: 4},
+		{Term: "kafka connect", Count: 0},
+	}))
+
+	terms, err := repo.SuggestHotTerms(context.Background(), "kaf", 5)
+	if err != nil {
+		t.Fatalf("SuggestHotTerms 返回错误: %v", err)
+	}
+	if len(terms) != 1 || terms[0].Term != "kafka" {
+		t.Errorf("SuggestHotTerms = %+v, want 只有 kafka", terms)
+	}
+	assertJSONEqual(t, transport.recorded()[0].Body, `{
+		"size": 5,
+		"_source": ["term", "count"],
+		"query": {"bool": {"filter": [
+			{"prefix": {"term": {"value": "kaf"}}},
+			{"range": {"count": {"gt": 0}}}
+		]}},
+		"sort": [{"count": {"order": "desc"}}]
+	}`)
+}
+
+func TestGetSearchVolume(t *testing.T) {
+	since := time.Date(2025, 6, 1, 8, 0, 0, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name     string
+		since    time.Time
+		wantBody string
+	}{
+		{
+			name:  "不限时间",
+			since: time.Time{},
+			wantBody: `{
+				"size": 0,
+				"track_total_hits": true,
+				"query": {"bool": {"filter": [{"range": {"count": {"gt": 0}}}]}},
+				"aggs": {"total_searches": {"sum": {"field": "count"}}}
+			}`,
+		},
+		{
+			name:  "时间窗口按 UTC 格式化",
+			since: since,
+			wantBody: `{
+				"size": 0,
+				"track_total_hits": true,
+				"query": {"bool": {"filter": [
+					{"range": {"count": {"gt": 0}}},
+					{"range": {"last_searched_at": {"gte": "2025-06-01T00:00:00Z"}}}
+				]}},
+				"aggs": {"total_searches": {"sum": {"field": "count"}}}
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, transport := newTestHotTermsRepo(t, func(recordedESRequest) (int, string) {
+				return 200, `{"hits":{"total":{"value":42,"relation":"eq"},"hits":[]},"aggregations":{"total_searches":{"value":1280.0}}}`
+			})
+
+			volume, err := repo.GetSearchVolume(context.Background(), tt.since)
+			if err != nil {
+				t.Fatalf("GetSearchVolume 返回错误: %v", err)
+			}
+			if volume.TotalSearches != 1280 || volume.DistinctTerms != 42 {
+				t.Errorf("GetSearchVolume = %+v, want TotalSearches=1280 DistinctTerms=42", volume)
+			}
+			assertJSONEqual(t, transport.recorded()[0].Body, tt.wantBody)
+		})
+	}
+}
+
+func TestGetHotSearchTermsSort(t *testing.T) {
+	tests := []struct {
+		name     string
+		sort     string
+		wantBody string
+	}{
+		{
+			name: "未指定时按计数排序",
+			sort: "",
+			wantBody: `{
+				"from": 0, "size": 2,
+				"query": {"range": {"count": {"gt": 0}}},
+				"sort": [{"count": {"order": "desc"}}]
+			}`,
+		},
+		{
+			name: "count",
+			sort: models.HotTermsSortCount,
+			wantBody: `{
+				"from": 0, "size": 2,
+				"query": {"range": {"count": {"gt": 0}}},
+				"sort": [{"count": {"order": "desc"}}]
+			}`,
+		},
+		{
+			name: "recent 按最后搜索时间排序，相同时按计数",
+			sort: models.HotTermsSortRecent,
+			wantBody: `{
+				"from": 0, "size": 2,
+				"query": {"range": {"count": {"gt": 0}}},
+				"sort": [{"last_searched_at": {"order": "desc"}}, {"count": {"order": "desc"}}]
+			}`,
+		},
+		{
+			name: "blended 使用 function_score 综合计数与时间衰减",
+			sort: models.HotTermsSortBlended,
+			wantBody: `{
+				"from": 0, "size": 2,
+				"query": {"function_score": {
+					"query": {"range": {"count": {"gt": 0}}},
+					"functions": [
+						{"field_value_factor": {"field": "count", "modifier": "ln1p", "missing": 0}},
+						{"gauss": {"last_searched_at": {"origin": "now", "scale": "7d", "decay": 0.5}}}
+					],
+					"score_mode": "multiply",
+					"boost_mode": "replace"
+				}},
+				"sort": [{"_score": {"order": "desc"}}, {"count": {"order": "desc"}}]
+			}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, transport := newTestHotTermsRepo(t, hotTermsSearchResponder(t, []models.HotSearchTermES{{Term: "kafka", Count: 5}}))
+
+			terms, err := repo.GetHotSearchTerms(context.Background(), 0, tt.sort)
+			if err != nil {
+				t.Fatalf("GetHotSearchTerms 返回错误: %v", err)
+			}
+			if len(terms) != 1 || terms[0] != (models.HotSearchTerm{Term: "kafka", Count: 5}) {
+				t.Errorf("GetHotSearchTerms = %+v, want 只有 kafka", terms)
+			}
+			assertJSONEqual(t, transport.recorded()[0].Body, tt.wantBody)
+		})
+	}
+}
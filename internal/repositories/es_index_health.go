@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// 索引健康状态中除 green/yellow/red 之外的取值。
+const (
+	IndexHealthMissing = "missing" // 索引不存在
+	IndexHealthUnknown = "unknown" // 无法获取健康状态 (例如 ES 不可达)
+)
+
+// indexHealthTimeout 是 _cluster/health 请求中 ES 端的等待超时，避免就绪检查被长时间挂起。
+const indexHealthTimeout = 2 * time.Second
+
+// fetchIndexHealth 返回指定索引的健康状态 (green/yellow/red)，索引不存在时返回 IndexHealthMissing。
+// 帖子仓库与热门搜索词仓库共用此函数，各自传入自己的索引名称。
+// 为什么先检查索引是否存在?
+// 对不存在的索引调用 _cluster/health 时，ES 会一直等待到超时再返回 red，
+// 无法与 "索引存在但分片未分配" 区分，也会拖慢就绪检查。
+func fetchIndexHealth(ctx context.Context, client *elasticsearch.Client, indexName string) (string, error) {
+	existsRes, err := esapi.IndicesExistsRequest{Index: []string{indexName}}.Do(ctx, client)
+	if err != nil {
+		return IndexHealthUnknown, fmt.Errorf("检查索引 '%s' 是否存在失败: %w", indexName, err)
+	}
+	existsRes.Body.Close()
+	if existsRes.StatusCode == 404 {
+		return IndexHealthMissing, nil
+	}
+	if existsRes.IsError() {
+		return IndexHealthUnknown, fmt.Errorf("检查索引 '%s' 是否存在时出错，状态码: %s", indexName, existsRes.Status())
+	}
+
+	healthRes, err := esapi.ClusterHealthRequest{
+		Index:   []string{indexName},
+		Timeout: indexHealthTimeout,
+	}.Do(ctx, client)
+	if err != nil {
+		return IndexHealthUnknown, fmt.Errorf("请求索引 '%s' 的 _cluster/health 失败: %w", indexName, err)
+	}
+	defer healthRes.Body.Close()
+	// 等待超时时 ES 返回 408，但响应体中仍包含当前的健康状态，因此这里不把 408 视为错误。
+	if healthRes.IsError() && healthRes.StatusCode != 408 {
+		body, _ := io.ReadAll(healthRes.Body)
+		return IndexHealthUnknown, fmt.Errorf("索引 '%s' 的 _cluster/health 请求失败，状态码: %s，响应: %s", indexName, healthRes.Status(), string(body))
+	}
+	var healthBody struct {
+		Status string `json:"status"`
+	}
+	if err := json.NewDecoder(healthRes.Body).Decode(&healthBody); err != nil {
+		return IndexHealthUnknown, fmt.Errorf("解码索引 '%s' 的 _cluster/health 响应失败: %w", indexName, err)
+	}
+	return healthBody.Status, nil
+}
+
+// checkIndexReadiness 将 fetchIndexHealth 的结果转换为就绪检查中的一项依赖状态。
+// 索引存在且健康状态为 green 或 yellow (副本未分配不影响读写) 时视为健康。
+func checkIndexReadiness(ctx context.Context, client *elasticsearch.Client, indexName string) models.DependencyStatus {
+	dep := models.DependencyStatus{Index: indexName}
+	status, err := fetchIndexHealth(ctx, client, indexName)
+	dep.Status = status
+	switch {
+	case err != nil:
+		dep.Error = err.Error()
+	case status == IndexHealthMissing:
+		dep.Error = fmt.Sprintf("索引 '%s' 不存在", indexName)
+	case status == "green" || status == "yellow":
+		dep.Healthy = true
+	default:
+		dep.Error = fmt.Sprintf("索引 '%s' 的健康状态为 %s", indexName, status)
+	}
+	return dep
+}
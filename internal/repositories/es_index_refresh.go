@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// refreshIndex 调用 _refresh API，使指定索引中此前的写入立即可被搜索。
+// 写入使用 Refresh:"false" 以保证吞吐，端到端测试等需要 "写后立即可读" 的场景通过它显式刷新，
+// 而不必把全局刷新策略改成代价很高的 "true"。
+func refreshIndex(ctx context.Context, client *elasticsearch.Client, indexName string) (*models.IndexRefreshResult, error) {
+	res, err := esapi.IndicesRefreshRequest{Index: []string{indexName}}.Do(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("请求索引 '%s' 的 _refresh 失败: %w", indexName, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		body, _ := io.ReadAll(res.Body)
+		return nil, fmt.Errorf("索引 '%s' 的 _refresh 请求失败，状态码: %s，响应: %s", indexName, res.Status(), string(body))
+	}
+
+	var refreshBody struct {
+		Shards struct {
+			Total      int `json:"total"`
+			Successful int `json:"successful"`
+			Failed     int `json:"failed"`
+		} `json:"_shards"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&refreshBody); err != nil {
+		return nil, fmt.Errorf("解码索引 '%s' 的 _refresh 响应失败: %w", indexName, err)
+	}
+	return &models.IndexRefreshResult{
+		Index:            indexName,
+		ShardsTotal:      refreshBody.Shards.Total,
+		ShardsSuccessful: refreshBody.Shards.Successful,
+		ShardsFailed:     refreshBody.Shards.Failed,
+	}, nil
+}
+
+// updateRefreshInterval 调用 _settings API 动态修改指定索引的 index.refresh_interval。
+// interval 为空时写入 null，让索引恢复为 ES 默认值。
+func updateRefreshInterval(ctx context.Context, client *elasticsearch.Client, indexName, interval string) error {
+	var value interface{}
+	if interval != "" {
+		value = interval
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"index": map[string]interface{}{"refresh_interval": value},
+	})
+	if err != nil {
+		return fmt.Errorf("序列化索引 '%s' 的 _settings 请求体失败: %w", indexName, err)
+	}
+
+	res, err := esapi.IndicesPutSettingsRequest{
+		Index: []string{indexName},
+		Body:  strings.NewReader(string(body)),
+	}.Do(ctx, client)
+	if err != nil {
+		return fmt.Errorf("请求更新索引 '%s' 的 refresh_interval 失败: %w", indexName, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		respBody, _ := io.ReadAll(res.Body)
+		return fmt.Errorf("更新索引 '%s' 的 refresh_interval 失败，状态码: %s，响应: %s", indexName, res.Status(), string(respBody))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// fetchIndexStats 使用 _count 和 _stats API 获取指定索引的文档数量、存储大小和分片信息。
+// 帖子仓库与热门搜索词仓库共用此函数，各自传入自己的索引名称。
+// 为什么文档数量使用 _count 而不是 _stats 中的 docs.count?
+// _stats 的 docs.count 是 Lucene 层面的计数，会包含嵌套文档且可能受未合并段影响，
+// _count 返回的是用户可见的文档数量，更符合运维的直观预期。
+func fetchIndexStats(ctx context.Context, client *elasticsearch.Client, indexName string) (*models.IndexStatsEntry, error) {
+	entry := &models.IndexStatsEntry{Index: indexName}
+
+	// --- 文档数量 ---
+	countRes, err := esapi.CountRequest{Index: []string{indexName}}.Do(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("请求索引 '%s' 的 _count 失败: %w", indexName, err)
+	}
+	defer countRes.Body.Close()
+	if countRes.IsError() {
+		body, _ := io.ReadAll(countRes.Body)
+		return nil, fmt.Errorf("索引 '%s' 的 _count 请求失败，状态码: %s，响应: %s", indexName, countRes.Status(), string(body))
+	}
+	var countBody struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(countRes.Body).Decode(&countBody); err != nil {
+		return nil, fmt.Errorf("解码索引 '%s' 的 _count 响应失败: %w", indexName, err)
+	}
+	entry.DocCount = countBody.Count
+
+	// --- 存储大小与分片 ---
+	statsRes, err := esapi.IndicesStatsRequest{
+		Index:  []string{indexName},
+		Metric: []string{"store"},
+	}.Do(ctx, client)
+	if err != nil {
+		return nil, fmt.Errorf("请求索引 '%s' 的 _stats 失败: %w", indexName, err)
+	}
+	defer statsRes.Body.Close()
+	if statsRes.IsError() {
+		body, _ := io.ReadAll(statsRes.Body)
+		return nil, fmt.Errorf("索引 '%s' 的 _stats 请求失败，状态码: %s，响应: %s", indexName, statsRes.Status(), string(body))
+	}
+	var statsBody struct {
+		Shards struct {
+			Total      int `json:"total"`
+			Successful int `json:"successful"`
+		} `json:"_shards"`
+		All struct {
+			Primaries struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"primaries"`
+			Total struct {
+				Store struct {
+					SizeInBytes int64 `json:"size_in_bytes"`
+				} `json:"store"`
+			} `json:"total"`
+		} `json:"_all"`
+	}
+	if err := json.NewDecoder(statsRes.Body).Decode(&statsBody); err != nil {
+		return nil, fmt.Errorf("解码索引 '%s' 的 _stats 响应失败: %w", indexName, err)
+	}
+	// 使用 _all 汇总值：当 indexName 是指向多个具体索引的别名时，结果仍然是整体的统计。
+	entry.StoreSizeBytes = statsBody.All.Total.Store.SizeInBytes
+	entry.PrimarySizeBytes = statsBody.All.Primaries.Store.SizeInBytes
+	entry.ShardsTotal = statsBody.Shards.Total
+	entry.ShardsSuccessful = statsBody.Shards.Successful
+
+	return entry, nil
+}
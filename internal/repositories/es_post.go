@@ -9,9 +9,12 @@ import (
 	"io"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/config"
 	"github.com/Xushengqwer/post_search/internal/models" // 确保 EsPostDocument, SearchResult 等模型定义在此
 
 	"github.com/elastic/go-elasticsearch/v8"
@@ -26,25 +29,97 @@ type PostRepository interface {
 	// 如果具有相同 ID 的文档已存在，则会更新它；否则，创建新文档。
 	IndexPost(ctx context.Context, doc models.EsPostDocument) error
 
+	// BulkIndexPosts 使用 _bulk API 批量索引多个帖子文档，返回逐条检查得到的失败条目。
+	// 只有整个请求失败时才返回 error；部分失败通过 []BulkItemFailure 返回。
+	BulkIndexPosts(ctx context.Context, docs []models.EsPostDocument) ([]BulkItemFailure, error)
+
+	// PatchPost 使用 _update API 只更新帖子的部分字段 (字段名必须在 models.PatchableFields 白名单中)。
+	// 文档不存在时返回 ErrPostNotFound；包含不允许的字段时返回 ErrUnpatchableField，值与映射类型不符时返回 ErrInvalidPatchValue。
+	PatchPost(ctx context.Context, postID uint64, fields map[string]interface{}) error
+
+	// PatchPostIfUnchanged 与 PatchPost 相同，但只在文档仍是 current 读取时的版本时更新 (if_seq_no/if_primary_term)；
+	// 文档在读取之后被其他写入修改时返回 ErrVersionConflict。
+	PatchPostIfUnchanged(ctx context.Context, current *StoredPost, fields map[string]interface{}) error
+
 	// DeletePost 根据帖子 ID 从 Elasticsearch 中删除一个帖子文档。
 	// 如果文档不存在，此操作应被视为幂等成功。
 	DeletePost(ctx context.Context, postID uint64) error
 
 	// SearchPosts 根据提供的搜索请求在 Elasticsearch 中执行搜索查询。
+	// 查询无效时返回的错误包装 ErrBadQuery，ES 不可用时包装 ErrESUnavailable；
+	// 同时进行的搜索达到 searchConfig.maxConcurrentSearches 时返回 ErrSearchBusy。
 	SearchPosts(ctx context.Context, req models.SearchRequest) (*models.SearchResult, error)
+
+	// MultiSearchPosts 使用 _msearch API 在一次请求中执行多个搜索，结果顺序与 reqs 一致。
+	// 任一子搜索失败时返回 *MultiSearchItemError (包装 ErrBadQuery 或 ErrESUnavailable)，不返回部分结果。
+	MultiSearchPosts(ctx context.Context, reqs []models.SearchRequest) ([]*models.SearchResult, error)
+
+	// GetPostsByIDs 使用 _mget API 一次性获取多个帖子文档。
+	// 返回结果保持请求中 ID 的顺序，索引中不存在的 ID 会被省略；
+	// 某个文档读取失败 (例如所在分片不可用) 时返回包装 ErrESUnavailable 的错误，而不是省略该 ID。
+	GetPostsByIDs(ctx context.Context, ids []uint64) ([]models.EsPostDocument, error)
+
+	// GetPost 读取单个帖子的完整文档 (包括只存储的原文字段 title_raw/content_raw) 及其版本 (_seq_no/_primary_term)。
+	// 文档不存在时返回 (nil, nil)；读取失败时返回错误，不会当作不存在。
+	GetPost(ctx context.Context, postID uint64) (*StoredPost, error)
+
+	// ParkPost 将帖子的完整文档写入暂存索引，供 searchableOnly 状态策略恢复帖子时完整重新索引 (见 es_post_parked.go)。
+	ParkPost(ctx context.Context, doc models.EsPostDocument) error
+	// GetParkedPost 读取帖子的暂存副本，不存在时返回 (nil, nil)。
+	GetParkedPost(ctx context.Context, postID uint64) (*models.EsPostDocument, error)
+	// DeleteParkedPost 删除帖子的暂存副本，不存在时视为成功。
+	DeleteParkedPost(ctx context.Context, postID uint64) error
+
+	// IndexStats 返回帖子索引的文档数量、存储大小和分片信息。
+	IndexStats(ctx context.Context) (*models.IndexStatsEntry, error)
+	// RefreshIndex 刷新帖子索引，使此前的写入立即可被搜索 (供测试与运维使用)。
+	RefreshIndex(ctx context.Context) (*models.IndexRefreshResult, error)
+	// UpdateRefreshInterval 动态修改帖子索引的 refresh_interval；interval 为空时恢复为配置的 RefreshInterval。
+	UpdateRefreshInterval(ctx context.Context, interval string) (*models.IndexRefreshIntervalResult, error)
+
+	// IndexHealth 检查帖子索引是否存在且健康状态至少为 yellow，用于就绪检查。
+	IndexHealth(ctx context.Context) models.DependencyStatus
+
+	// FindPostsByContact 按联系方式精确匹配 (term 查询) 帖子，供管理员排查欺诈等场景使用。
+	FindPostsByContact(ctx context.Context, contact string, limit int) ([]models.EsPostDocument, error)
+
+	// GetPopularPosts 返回指定状态下浏览量 (view_count) 最高的帖子，不带关键词条件，用于零结果兜底推荐。
+	GetPopularPosts(ctx context.Context, statuses []enums.Status, limit int) ([]models.EsPostDocument, error)
+
+	// GetRecentPosts 返回最近写入 (updated_at 最新) 的帖子，不带任何查询条件，用于排查索引流程。
+	GetRecentPosts(ctx context.Context, limit int) ([]models.EsPostDocument, error)
+
+	// DeleteExpiredPosts 使用 _delete_by_query 删除 expires_at 早于当前时间的帖子，返回删除的文档数量。
+	DeleteExpiredPosts(ctx context.Context) (int64, error)
 }
 
 // esPostRepository 是 PostRepository 接口针对 Elasticsearch 的具体实现。
 type esPostRepository struct {
-	client    *elasticsearch.Client // 注入的 Elasticsearch Go 客户端实例。
-	indexName string                // 此仓库操作的目标 Elasticsearch 索引名称。
-	logger    *core.ZapLogger       // 注入的 Logger 实例，用于结构化日志记录。
+	client         *elasticsearch.Client // 注入的 Elasticsearch Go 客户端实例。
+	indexName      string                // 此仓库操作的目标 Elasticsearch 索引名称。
+	archiveIndices []string              // include_archived 时额外搜索的归档索引 (见 es_post_archive.go)。
+	queryBuilder   *SearchQueryBuilder   // 按服务端选项 (来自 SearchConfig) 构建搜索 DSL。
+	slowMs         int64                 // 慢查询阈值 (毫秒)，0 表示不记录慢查询日志。
+	searchSlots    chan struct{}         // 限制同时进行的 ES 搜索数量的信号量，nil 表示不限制 (见 es_search_limit.go)。
+	redactor       payloadRedactor       // 记录请求体日志前对敏感字段脱敏。
+	logger         *core.ZapLogger       // 注入的 Logger 实例，用于结构化日志记录。
+
+	routeByAuthor bool // 是否以 author_id 作为 routing 值 (见 es_post_routing.go)。
+
+	refreshInterval string // 配置的 refresh_interval，动态调整后恢复时使用 (为空表示 ES 默认值)。
+
+	ensureIndex    IndexEnsurer    // 确保租户索引存在，为 nil 时不自动创建 (见 tenant.go)。
+	tenantMu       sync.Mutex      // 保护 ensuredIndices，并串行化租户索引的创建。
+	ensuredIndices map[string]bool // 已确认存在的租户索引。
 }
 
 // NewESPostRepository 创建一个新的 esPostRepository 实例。
 // 参数:
 //   - client: 一个初始化完成且可用的 *elasticsearch.Client 实例。
-//   - indexName: 将要操作的 Elasticsearch 索引的名称。不能为空。
+//   - indexCfg: 将要操作的 Elasticsearch 索引的配置。名称不能为空；RouteByAuthor 决定是否按作者路由。
+//   - searchCfg: 搜索业务配置，用于构建搜索 DSL (例如新帖加权参数)。
+//   - redactionCfg: 记录请求体日志时需要脱敏的字段。
+//   - ensureIndex: 首次写入租户索引时确保其存在，可为 nil (未启用多租户或不自动创建)。
 //   - logger: 一个 *core.ZapLogger 实例，用于日志记录。
 //
 // 返回值:
@@ -52,7 +127,8 @@ type esPostRepository struct {
 //
 // 注意：此构造函数在关键依赖缺失时会 panic，因为仓库无法在缺少这些依赖的情况下正常工作。
 // 这是一种快速失败的策略，确保服务不会以不完整状态启动。
-func NewESPostRepository(client *elasticsearch.Client, indexName string, logger *core.ZapLogger) PostRepository {
+func NewESPostRepository(client *elasticsearch.Client, indexCfg config.IndexSpecificConfig, searchCfg config.SearchConfig, redactionCfg config.PayloadLogRedactionConfig, ensureIndex IndexEnsurer, logger *core.ZapLogger) PostRepository {
+	indexName := indexCfg.Name
 	if logger == nil {
 		// Logger 是最基础的依赖，如果它缺失，后续的任何操作和错误都无法被有效记录。
 		panic("创建 esPostRepository 失败：Logger 实例不能为 nil")
@@ -69,11 +145,23 @@ func NewESPostRepository(client *elasticsearch.Client, indexName string, logger
 
 	logger.Info("Elasticsearch PostRepository 初始化成功",
 		zap.String("index_name", indexName),
+		zap.Bool("route_by_author", indexCfg.RouteByAuthor),
 	)
 	return &esPostRepository{
-		client:    client,
-		indexName: indexName,
-		logger:    logger,
+		client:         client,
+		indexName:      indexName,
+		archiveIndices: normalizeArchiveIndices(searchCfg.ArchiveIndices, indexName),
+		queryBuilder:   NewSearchQueryBuilder(searchCfg, indexCfg, logger),
+		slowMs:         searchCfg.SlowSearchThresholdMs,
+		searchSlots:    newSearchSlots(searchCfg.MaxConcurrentSearches),
+		redactor:       newPayloadRedactor(redactionCfg),
+		logger:         logger,
+		routeByAuthor:  indexCfg.RouteByAuthor,
+
+		refreshInterval: indexCfg.RefreshInterval,
+
+		ensureIndex:    ensureIndex,
+		ensuredIndices: make(map[string]bool),
 	}
 }
 
@@ -119,10 +207,26 @@ func (repo *esPostRepository) logAndWrapESError(res *esapi.Response, operationDe
 	return fmt.Errorf("Elasticsearch 操作 '%s' 失败，状态码: %s", operationDesc, res.Status())
 }
 
+// esPostSource 是帖子文档写入 ES 时的 _source。
+// EsPostDocument 不序列化只存储的原文字段 (models.StoredOnlyFields)，以免经 API 返回未清理的 HTML，写入时在这里补上。
+type esPostSource struct {
+	models.EsPostDocument
+	TitleRaw   string `json:"title_raw,omitempty"`
+	ContentRaw string `json:"content_raw,omitempty"`
+}
+
+func newESPostSource(doc models.EsPostDocument) esPostSource {
+	return esPostSource{EsPostDocument: doc, TitleRaw: doc.TitleRaw, ContentRaw: doc.ContentRaw}
+}
+
 // IndexPost 在 Elasticsearch 中索引（创建或更新）一个帖子文档。
 // 它使用文档的 ID 作为 Elasticsearch 文档的 _id，从而实现幂等性：
 // 如果具有相同 ID 的文档已存在，则会更新它；否则，会创建新文档。
 func (repo *esPostRepository) IndexPost(ctx context.Context, doc models.EsPostDocument) error {
+	indexName, err := repo.writeIndexFor(ctx)
+	if err != nil {
+		return err
+	}
 	// 为什么在这里设置 UpdatedAt?
 	// 确保每次索引操作（无论是创建还是更新）都会刷新文档的最后更新时间戳。
 	// 这有助于追踪文档的最新状态，并可用于排序或过滤。使用 UTC 时间是最佳实践，以避免时区问题。
@@ -130,7 +234,7 @@ func (repo *esPostRepository) IndexPost(ctx context.Context, doc models.EsPostDo
 	docID := strconv.FormatUint(doc.ID, 10) // Elasticsearch 的 DocumentID 通常是字符串类型。
 
 	// 将 Go 结构体（文档）序列化为 JSON 字节流，以便作为请求体发送给 Elasticsearch。
-	payload, err := json.Marshal(doc)
+	payload, err := json.Marshal(newESPostSource(doc))
 	if err != nil {
 		repo.logger.Error("序列化 EsPostDocument 为 JSON 失败，无法发送给 Elasticsearch",
 			zap.Uint64("post_id", doc.ID),
@@ -139,11 +243,11 @@ func (repo *esPostRepository) IndexPost(ctx context.Context, doc models.EsPostDo
 		// 这是一个应用程序内部的错误，通常表明模型定义或数据有问题。
 		return fmt.Errorf("序列化帖子文档 (ID: %d) 失败: %w", doc.ID, err)
 	}
-	repo.logger.Debug("准备索引的文档JSON体", zap.String("document_id", docID), zap.ByteString("payload", payload))
+	repo.logger.Debug("准备索引的文档JSON体", zap.String("document_id", docID), repo.redactor.field("payload", payload))
 
 	// 构建 Elasticsearch 的 IndexRequest。
 	req := esapi.IndexRequest{
-		Index:      repo.indexName,           // 指定目标索引。
+		Index:      indexName,                // 指定目标索引。
 		DocumentID: docID,                    // 指定文档 ID，实现创建或更新 (upsert) 行为。
 		Body:       bytes.NewReader(payload), // 请求体包含序列化后的文档数据。
 		Refresh:    "false",                  // "false" (默认): 异步刷新。写入操作会先写入内存缓冲区和事务日志，然后才刷新到磁盘段，使其可搜索。
@@ -153,6 +257,9 @@ func (repo *esPostRepository) IndexPost(ctx context.Context, doc models.EsPostDo
 		// 对于高吞吐量的索引场景（如 Kafka 消费），"false" 通常是首选。
 	}
 
+	// 开启按作者路由时以 author_id 作为 routing 值，否则为空 (按 _id 路由)。
+	req.Routing = repo.routingFor(doc.AuthorID)
+
 	// 执行 Elasticsearch 索引请求。
 	res, err := req.Do(ctx, repo.client)
 	if err != nil {
@@ -207,11 +314,17 @@ func (repo *esPostRepository) IndexPost(ctx context.Context, doc models.EsPostDo
 // 此操作是幂等的：如果目标文档本就不存在 (Elasticsearch 返回 404 Not Found)，
 // 则视为操作成功，因为“文档不存在”这个目标状态已经达成。
 func (repo *esPostRepository) DeletePost(ctx context.Context, postID uint64) error {
+	indexName := repo.indexFor(ctx)
 	docID := strconv.FormatUint(postID, 10)
 	repo.logger.Info("准备从 Elasticsearch 删除文档", zap.String("document_id", docID))
 
+	if repo.routeByAuthor {
+		// 删除事件不携带作者 ID，无法得到 routing 值，改为在所有分片上按 id 删除。
+		return repo.deletePostByQuery(ctx, postID)
+	}
+
 	req := esapi.DeleteRequest{
-		Index:      repo.indexName,
+		Index:      indexName,
 		DocumentID: docID,
 		Refresh:    "false", // 与 IndexPost 的 Refresh 参数含义类似。
 	}
@@ -279,6 +392,13 @@ func (repo *esPostRepository) DeletePost(ctx context.Context, postID uint64) err
 // SearchPosts 根据提供的搜索请求在 Elasticsearch 索引中执行查询。
 // 此方法现在会尝试解析高亮结果。
 func (repo *esPostRepository) SearchPosts(ctx context.Context, req models.SearchRequest) (*models.SearchResult, error) {
+	indices, archived := repo.searchIndices(ctx, req.IncludeArchived)
+	release, err := repo.acquireSearchSlot("search")
+	if err != nil {
+		repo.logger.Warn("同时进行的 ES 搜索数已达上限，拒绝本次搜索", zap.String("query_keywords", req.Query))
+		return nil, err
+	}
+	defer release()
 	repo.logger.Info("开始执行 Elasticsearch 搜索 (包含高亮请求)", // 日志更新
 		zap.String("query_keywords", req.Query),
 		zap.Int("page", req.Page),
@@ -287,55 +407,101 @@ func (repo *esPostRepository) SearchPosts(ctx context.Context, req models.Search
 		zap.String("sort_order", req.SortOrder),
 		zap.String("filter_author_id", req.AuthorID),
 		zap.Any("filter_status", req.Status),
+		zap.Strings("indices", indices),
 	)
 
-	queryJSON, err := buildSearchQuery(req) // buildSearchQuery 现在会加入 highlight 部分
+	queryJSON, err := repo.queryBuilder.SearchBody(req) // buildSearchQuery 现在会加入 highlight 部分
 	if err != nil {
 		repo.logger.Error("构建 Elasticsearch 搜索查询 DSL 失败", zap.Any("search_request_params", req), zap.Error(err))
-		return nil, fmt.Errorf("构建搜索查询失败: %w", err)
+		return nil, fmt.Errorf("%w: 构建搜索查询失败: %w", ErrBadQuery, err)
 	}
-	repo.logger.Debug("构建的 Elasticsearch 查询 DSL (含高亮)", zap.String("dsl_query", string(queryJSON))) // 日志更新
+	repo.logger.Debug("构建的 Elasticsearch 查询 DSL (含高亮)", repo.redactor.field("dsl_query", queryJSON))
 
 	searchReq := esapi.SearchRequest{
-		Index:          []string{repo.indexName},
-		Body:           bytes.NewReader(queryJSON),
-		TrackTotalHits: true,
+		Index:             indices,
+		Body:              bytes.NewReader(queryJSON),
+		TrackTotalHits:    true,
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}
+	if archived {
+		// 归档索引可能尚未创建 (或已被删除)，跳过它而不是让整个搜索失败。
+		ignoreUnavailable := true
+		searchReq.IgnoreUnavailable = &ignoreUnavailable
+	} else if routing := repo.routingFor(req.AuthorID); routing != "" {
+		// 开启按作者路由且按作者筛选时，只查询该作者所在的分片。
+		// 归档索引不一定按作者路由写入，包含归档索引时不使用 routing，以免漏掉文档。
+		searchReq.Routing = []string{routing}
 	}
 
 	res, err := searchReq.Do(ctx, repo.client)
 	if err != nil {
 		repo.logger.Error("执行 Elasticsearch 搜索请求时发生连接或客户端错误", zap.String("query_keywords", req.Query), zap.Error(err))
-		return nil, fmt.Errorf("Elasticsearch 搜索请求失败: %w", err)
+		return nil, classifyTransportError(ctx, fmt.Errorf("Elasticsearch 搜索请求失败: %w", err))
 	}
 	defer res.Body.Close()
 
 	if res.IsError() {
-		return nil, repo.logAndWrapESError(res, "搜索文档", req.Query)
+		return nil, classifyResponseError(res, repo.logAndWrapESError(res, "搜索文档", req.Query))
 	}
 
 	// 3. 解析成功的响应
-	// 更新临时的匿名结构体以包含 highlight 字段
-	var esResponse struct {
-		Took int `json:"took"`
-		Hits struct {
-			Total struct {
-				Value    int64  `json:"value"`
-				Relation string `json:"relation"`
-			} `json:"total"`
-			Hits []struct {
-				Source    models.EsPostDocument `json:"_source"`             // 文档的实际内容
-				Score     float64               `json:"_score,omitempty"`    // 文档的相关性评分 (可选)
-				Highlight map[string][]string   `json:"highlight,omitempty"` // 新增：用于接收高亮结果
-			} `json:"hits"`
-		} `json:"hits"`
+	var esResponse esSearchResponse
+	if err := repo.decodeESResponse(res, &esResponse, "搜索", req.Query); err != nil {
+		return nil, err
 	}
 
-	if err := json.NewDecoder(res.Body).Decode(&esResponse); err != nil {
-		repo.logger.Error("解码 Elasticsearch 搜索响应体失败", zap.String("query_keywords", req.Query), zap.Error(err))
-		return nil, fmt.Errorf("解码 Elasticsearch 搜索响应失败: %w", err)
+	// 4. 映射到应用程序的结果模型 (models.SearchResult)
+	searchResult := repo.toSearchResult(req, queryJSON, &esResponse)
+	repo.logSearchCompletion(req, queryJSON, searchResult, esResponse.Hits.Total.Relation)
+	return searchResult, nil
+}
+
+// esSearchResponse 是帖子搜索响应 (_search，以及 _msearch 中的每个子响应) 中需要解析的部分。
+type esSearchResponse struct {
+	Took int `json:"took"`
+	Hits struct {
+		Total struct {
+			Value    int64  `json:"value"`
+			Relation string `json:"relation"`
+		} `json:"total"`
+		Hits []struct {
+			Source    esHitSource         `json:"_source"`             // 文档的实际内容
+			Score     float64             `json:"_score,omitempty"`    // 文档的相关性评分 (可选)
+			Highlight map[string][]string `json:"highlight,omitempty"` // 新增：用于接收高亮结果
+		} `json:"hits"`
+	} `json:"hits"`
+	Aggregations struct {
+		PriceRanges struct {
+			Buckets []models.RangeFacetBucket `json:"buckets"`
+		} `json:"price_ranges"`
+	} `json:"aggregations"`
+}
+
+// esHitSource 是搜索命中的 _source：解码为 EsPostDocument 的同时保留原始 JSON，
+// 请求携带 source_fields 时据此生成投影，区分 "未返回的字段" 与 "值为零的字段"。
+type esHitSource struct {
+	Doc models.EsPostDocument
+	Raw json.RawMessage
+}
+
+func (s *esHitSource) UnmarshalJSON(data []byte) error {
+	s.Raw = append(s.Raw[:0], data...)
+	return json.Unmarshal(data, &s.Doc)
+}
+
+// projection 返回 _source 中实际存在的字段。数字保留为 json.Number，避免大 ID 损失精度。
+func (s esHitSource) projection() (map[string]interface{}, error) {
+	decoder := json.NewDecoder(bytes.NewReader(s.Raw))
+	decoder.UseNumber()
+	fields := make(map[string]interface{})
+	if err := decoder.Decode(&fields); err != nil {
+		return nil, err
 	}
+	return fields, nil
+}
 
-	// 4. 映射到应用程序的结果模型 (models.SearchResult)
+// toSearchResult 将 ES 搜索响应映射为 models.SearchResult：附加高亮片段，按请求附加分面统计和调试 DSL。
+func (repo *esPostRepository) toSearchResult(req models.SearchRequest, queryJSON []byte, esResponse *esSearchResponse) *models.SearchResult {
 	searchResult := &models.SearchResult{
 		Hits:  make([]models.EsPostDocument, 0, len(esResponse.Hits.Hits)),
 		Total: esResponse.Hits.Total.Value,
@@ -344,25 +510,363 @@ func (repo *esPostRepository) SearchPosts(ctx context.Context, req models.Search
 		Took:  int64(esResponse.Took),
 	}
 
+	if req.IncludeFacets {
+		searchResult.Facets = &models.SearchFacets{PriceRanges: esResponse.Aggregations.PriceRanges.Buckets}
+		if searchResult.Facets.PriceRanges == nil {
+			searchResult.Facets.PriceRanges = []models.RangeFacetBucket{}
+		}
+	}
+
+	if req.Debug {
+		searchResult.Debug = &models.SearchDebugInfo{DSL: debugDSL(queryJSON, req.Pretty)}
+	}
+
+	projected := len(req.SourceFields) > 0
+	if projected {
+		searchResult.ProjectedHits = make([]map[string]interface{}, 0, len(esResponse.Hits.Hits))
+	}
 	for _, hit := range esResponse.Hits.Hits {
-		doc := hit.Source // 从 _source 获取文档主体
+		doc := hit.Source.Doc // 从 _source 获取文档主体
 		// 新增：如果存在高亮结果，则将其赋值给文档的 Highlights 字段
 		if hit.Highlight != nil && len(hit.Highlight) > 0 {
 			doc.Highlights = hit.Highlight
 			repo.logger.Debug("为文档附加了高亮片段", zap.Uint64("doc_id", doc.ID), zap.Any("highlights", doc.Highlights))
 		}
 		searchResult.Hits = append(searchResult.Hits, doc)
+
+		if projected {
+			fields, err := hit.Source.projection()
+			if err != nil || fields == nil {
+				// _source 已成功解码为文档，这里只会在它不是 JSON 对象 (例如 null) 时发生。
+				repo.logger.Warn("生成 source_fields 投影失败，只返回 id", zap.Uint64("doc_id", doc.ID), zap.Error(err))
+				fields = map[string]interface{}{"id": doc.ID}
+			}
+			if len(doc.Highlights) > 0 {
+				fields["highlights"] = doc.Highlights
+			}
+			searchResult.ProjectedHits = append(searchResult.ProjectedHits, fields)
+		}
+	}
+	return searchResult
+}
+
+// logSearchCompletion 记录搜索完成日志；耗时超过慢查询阈值时以 Warn 级别记录完整的 DSL 和请求参数。
+func (repo *esPostRepository) logSearchCompletion(req models.SearchRequest, queryJSON []byte, searchResult *models.SearchResult, totalRelation string) {
+	if repo.slowMs > 0 && searchResult.Took > repo.slowMs {
+		// 慢查询：记录完整的 DSL 和请求参数，便于复现和优化。
+		repo.logger.Warn("Elasticsearch 搜索耗时超过慢查询阈值",
+			zap.Int64("query_took_ms", searchResult.Took),
+			zap.Int64("slow_threshold_ms", repo.slowMs),
+			zap.Int64("total_hits_found", searchResult.Total),
+			repo.redactor.field("dsl_query", queryJSON),
+			zap.Any("search_request_params", req),
+		)
+		return
 	}
 
 	repo.logger.Info("Elasticsearch 搜索成功完成 (含高亮处理)", // 日志更新
 		zap.Int64("query_took_ms", searchResult.Took),
 		zap.Int64("total_hits_found", searchResult.Total),
 		zap.Int("returned_hits_count", len(searchResult.Hits)),
-		zap.String("total_hits_relation", esResponse.Hits.Total.Relation),
+		zap.String("total_hits_relation", totalRelation),
 		zap.Int("requested_page", req.Page),
 		zap.Int("requested_size", req.Size),
 		zap.String("query_keywords", req.Query),
 	)
+}
 
-	return searchResult, nil
+// debugDSL 返回回显给调用方的查询 DSL，pretty 为 true 时使用两个空格缩进。
+func debugDSL(queryJSON []byte, pretty bool) string {
+	if !pretty {
+		return string(queryJSON)
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, queryJSON, "", "  "); err != nil {
+		return string(queryJSON)
+	}
+	return indented.String()
+}
+
+// GetPostsByIDs 使用 Elasticsearch 的 _mget API 批量获取帖子文档，避免调用方发起 N 次独立请求。
+// 返回的文档顺序与 ids 参数中的顺序一致；在索引中未找到的 ID 会被直接跳过，不视为错误，
+// 但 ES 对单个文档返回的错误会作为整个请求的错误返回。
+func (repo *esPostRepository) GetPostsByIDs(ctx context.Context, ids []uint64) ([]models.EsPostDocument, error) {
+	indexName := repo.indexFor(ctx)
+	if len(ids) == 0 {
+		return []models.EsPostDocument{}, nil
+	}
+	repo.logger.Info("开始通过 _mget 批量获取帖子文档", zap.Int("requested_ids_count", len(ids)))
+
+	docIDs := make([]string, 0, len(ids))
+	for _, id := range ids {
+		docIDs = append(docIDs, strconv.FormatUint(id, 10))
+	}
+	if repo.routeByAuthor {
+		// _mget 需要 routing 值才能定位文档，按作者路由时改用 ids 查询。
+		return repo.getPostsByIDsViaSearch(ctx, ids, docIDs)
+	}
+	payload, err := json.Marshal(map[string]interface{}{"ids": docIDs})
+	if err != nil {
+		repo.logger.Error("序列化 _mget 请求体失败", zap.Int("requested_ids_count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("序列化 _mget 请求体失败: %w", err)
+	}
+
+	mgetReq := esapi.MgetRequest{
+		Index:          indexName,
+		Body:           bytes.NewReader(payload),
+		SourceExcludes: models.StoredOnlyFields,
+	}
+	res, err := mgetReq.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch _mget 请求时发生连接或客户端错误", zap.Int("requested_ids_count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch _mget 请求失败: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "批量获取文档", docIDs)
+	}
+
+	var esResponse struct {
+		Docs []struct {
+			ID     string                `json:"_id"`
+			Found  bool                  `json:"found"`
+			Source models.EsPostDocument `json:"_source"`
+			Error  *esErrorCause         `json:"error"`
+		} `json:"docs"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "_mget ", len(ids)); err != nil {
+		return nil, err
+	}
+
+	// _mget 的响应顺序与请求顺序一致，这里只需过滤掉未找到的文档即可保持原有顺序。
+	// 单个文档读取失败 (例如所在分片不可用) 时 found 同样为 false，但不能当作不存在：
+	// 调用方 (例如状态策略) 会据此决定跳过或删除，因此返回包装 ErrESUnavailable 的错误。
+	docs := make([]models.EsPostDocument, 0, len(esResponse.Docs))
+	for _, d := range esResponse.Docs {
+		if d.Error != nil {
+			repo.logger.Error("_mget 中的文档读取失败",
+				zap.String("document_id", d.ID),
+				zap.String("es_error_type", d.Error.Type),
+				zap.String("es_error_reason", d.Error.Reason))
+			return nil, fmt.Errorf("%w: _mget 读取文档 %s 失败: %s", ErrESUnavailable, d.ID, d.Error)
+		}
+		if !d.Found {
+			repo.logger.Debug("_mget 中的文档在索引中未找到，已跳过", zap.String("document_id", d.ID))
+			continue
+		}
+		docs = append(docs, d.Source)
+	}
+
+	repo.logger.Info("通过 _mget 批量获取帖子文档完成",
+		zap.Int("requested_ids_count", len(ids)),
+		zap.Int("found_docs_count", len(docs)),
+	)
+	return docs, nil
+}
+
+// IndexStats 返回帖子索引的文档数量、存储大小和分片信息。
+func (repo *esPostRepository) IndexStats(ctx context.Context) (*models.IndexStatsEntry, error) {
+	stats, err := fetchIndexStats(ctx, repo.client, repo.indexName)
+	if err != nil {
+		repo.logger.Error("获取帖子索引统计信息失败", zap.String("index_name", repo.indexName), zap.Error(err))
+		return nil, err
+	}
+	return stats, nil
+}
+
+// RefreshIndex 刷新帖子索引，使此前的写入立即可被搜索。
+func (repo *esPostRepository) RefreshIndex(ctx context.Context) (*models.IndexRefreshResult, error) {
+	result, err := refreshIndex(ctx, repo.client, repo.indexName)
+	if err != nil {
+		repo.logger.Error("刷新帖子索引失败", zap.String("index_name", repo.indexName), zap.Error(err))
+		return nil, err
+	}
+	repo.logger.Info("帖子索引已刷新",
+		zap.String("index_name", repo.indexName),
+		zap.Int("shards_successful", result.ShardsSuccessful),
+		zap.Int("shards_failed", result.ShardsFailed),
+	)
+	return result, nil
+}
+
+// UpdateRefreshInterval 动态修改帖子索引的 refresh_interval。
+// 批量写入前可设置为 "-1" 关闭自动刷新以提高吞吐；interval 为空时恢复为配置的 RefreshInterval。
+func (repo *esPostRepository) UpdateRefreshInterval(ctx context.Context, interval string) (*models.IndexRefreshIntervalResult, error) {
+	if interval == "" {
+		interval = repo.refreshInterval
+	}
+	if err := updateRefreshInterval(ctx, repo.client, repo.indexName, interval); err != nil {
+		repo.logger.Error("更新帖子索引 refresh_interval 失败",
+			zap.String("index_name", repo.indexName),
+			zap.String("refresh_interval", interval),
+			zap.Error(err),
+		)
+		return nil, err
+	}
+	repo.logger.Info("帖子索引 refresh_interval 已更新",
+		zap.String("index_name", repo.indexName),
+		zap.String("refresh_interval", interval),
+	)
+	return &models.IndexRefreshIntervalResult{Index: repo.indexName, RefreshInterval: interval}, nil
+}
+
+// IndexHealth 检查帖子索引是否存在且健康状态至少为 yellow。
+func (repo *esPostRepository) IndexHealth(ctx context.Context) models.DependencyStatus {
+	dep := checkIndexReadiness(ctx, repo.client, repo.indexName)
+	if !dep.Healthy {
+		repo.logger.Warn("帖子索引未就绪", zap.String("index_name", repo.indexName), zap.String("status", dep.Status), zap.String("reason", dep.Error))
+	}
+	return dep
+}
+
+// GetPopularPosts 使用 match_all 查询 (仅按状态过滤) 并按 view_count 倒序返回浏览量最高的帖子。
+func (repo *esPostRepository) GetPopularPosts(ctx context.Context, statuses []enums.Status, limit int) ([]models.EsPostDocument, error) {
+	indexName := repo.indexFor(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"size": limit,
+		"query": map[string]interface{}{
+			"bool": map[string]interface{}{
+				"must":   map[string]interface{}{"match_all": map[string]interface{}{}},
+				"filter": []map[string]interface{}{{"terms": map[string]interface{}{"status": statuses}}},
+			},
+		},
+		"sort": []map[string]interface{}{
+			{"view_count": map[string]interface{}{"order": "desc", "missing": "_last"}},
+			{"id": map[string]interface{}{"order": "desc"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化热门帖子查询失败: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index:             []string{indexName},
+		Body:              bytes.NewReader(payload),
+		SourceExcludes:    models.StoredOnlyFields,
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行热门帖子查询时发生连接或客户端错误", zap.Int("limit", limit), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 热门帖子查询失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "查询热门帖子", limit)
+	}
+
+	var esResponse struct {
+		Hits struct {
+			Hits []struct {
+				Source models.EsPostDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "热门帖子查询", limit); err != nil {
+		return nil, err
+	}
+
+	docs := make([]models.EsPostDocument, 0, len(esResponse.Hits.Hits))
+	for _, hit := range esResponse.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
+}
+
+// GetRecentPosts 使用 match_all 查询并按 updated_at 倒序返回最近写入的帖子。
+// updated_at 在每次索引时刷新，因此结果反映的是 "最近被索引" 而非 "最近创建" 的帖子。
+func (repo *esPostRepository) GetRecentPosts(ctx context.Context, limit int) ([]models.EsPostDocument, error) {
+	indexName := repo.indexFor(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"size":  limit,
+		"query": map[string]interface{}{"match_all": map[string]interface{}{}},
+		"sort": []map[string]interface{}{
+			{"updated_at": map[string]interface{}{"order": "desc"}},
+			{"id": map[string]interface{}{"order": "desc"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化最近索引帖子查询失败: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index:             []string{indexName},
+		Body:              bytes.NewReader(payload),
+		SourceExcludes:    models.StoredOnlyFields,
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行最近索引帖子查询时发生连接或客户端错误", zap.Int("limit", limit), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 最近索引帖子查询失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "查询最近索引的帖子", limit)
+	}
+
+	var esResponse struct {
+		Hits struct {
+			Hits []struct {
+				Source models.EsPostDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "最近索引帖子查询", limit); err != nil {
+		return nil, err
+	}
+
+	docs := make([]models.EsPostDocument, 0, len(esResponse.Hits.Hits))
+	for _, hit := range esResponse.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
+}
+
+// FindPostsByContact 使用 term 查询在 contact_info (keyword) 上精确匹配帖子，按 updated_at 倒序返回。
+// 精确匹配意味着联系方式必须与索引中存储的值完全一致 (包括大小写和空白)。
+func (repo *esPostRepository) FindPostsByContact(ctx context.Context, contact string, limit int) ([]models.EsPostDocument, error) {
+	indexName := repo.indexFor(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"size":  limit,
+		"query": map[string]interface{}{"term": map[string]interface{}{"contact_info": contact}},
+		"sort": []map[string]interface{}{
+			{"updated_at": map[string]interface{}{"order": "desc"}},
+			{"id": map[string]interface{}{"order": "desc"}},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化按联系方式查询的请求体失败: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index:             []string{indexName},
+		Body:              bytes.NewReader(payload),
+		SourceExcludes:    models.StoredOnlyFields,
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行按联系方式查询时发生连接或客户端错误", zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 按联系方式查询失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "按联系方式查询帖子", limit)
+	}
+
+	var esResponse struct {
+		Hits struct {
+			Hits []struct {
+				Source models.EsPostDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "按联系方式查询", nil); err != nil {
+		return nil, err
+	}
+
+	docs := make([]models.EsPostDocument, 0, len(esResponse.Hits.Hits))
+	for _, hit := range esResponse.Hits.Hits {
+		docs = append(docs, hit.Source)
+	}
+	return docs, nil
 }
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// normalizeArchiveIndices 去除归档索引列表中的空白项、重复项以及与主帖子索引同名的项。
+func normalizeArchiveIndices(indices []string, primary string) []string {
+	seen := map[string]bool{primary: true}
+	var result []string
+	for _, name := range indices {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// searchIndices 返回一次搜索的目标索引：当前请求的帖子索引 (见 indexFor)，
+// 以及 includeArchived 为 true 时配置的归档索引。archived 表示是否包含了归档索引。
+// 租户索引没有对应的归档索引，多租户请求始终只搜索租户索引。
+func (repo *esPostRepository) searchIndices(ctx context.Context, includeArchived bool) (indices []string, archived bool) {
+	indexName := repo.indexFor(ctx)
+	if !includeArchived || len(repo.archiveIndices) == 0 {
+		return []string{indexName}, false
+	}
+	if indexName != repo.indexName {
+		repo.logger.Debug("租户索引没有对应的归档索引，忽略 include_archived", zap.String("index_name", indexName))
+		return []string{indexName}, false
+	}
+	return append([]string{indexName}, repo.archiveIndices...), true
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// BulkItemFailure 描述批量索引中单个文档的失败详情。
+type BulkItemFailure struct {
+	Index     int    // 失败文档在 BulkIndexPosts 参数 docs 中的位置，同一批次包含重复的帖子 ID 时也能准确对应
+	PostID    uint64 // 失败文档的帖子 ID
+	Status    int    // 该条目的 HTTP 状态码，例如 400、429、503
+	ErrorType string // ES 返回的错误类型，例如 "mapper_parsing_exception"
+	Reason    string // ES 返回的错误原因
+	Permanent bool   // 是否为永久性错误 (重试不会成功)，例如映射冲突
+}
+
+// Error 实现 error 接口，便于把单条失败作为 SendToDLQ 的 processingError 使用。
+func (f BulkItemFailure) Error() string {
+	return fmt.Sprintf("批量索引帖子 ID %d 失败 (状态码 %d, %s): %s", f.PostID, f.Status, f.ErrorType, f.Reason)
+}
+
+// isPermanentBulkStatus 判断批量条目的状态码是否代表永久性错误。
+// 4xx (429 除外) 通常是文档本身的问题 (映射冲突、字段类型错误)，重试同一文档不会成功；
+// 429 (队列已满) 和 5xx 属于暂时性错误，可以重试。
+func isPermanentBulkStatus(status int) bool {
+	return status >= 400 && status < 500 && status != 429
+}
+
+// BulkIndexPosts 使用 _bulk API 一次性索引多个帖子文档，并逐条检查响应结果。
+// docs 不会被修改：写入的 updated_at 只设置在请求体中的副本上。
+//
+// 返回值:
+//   - []BulkItemFailure: 失败的条目 (按请求顺序)，成功的条目不会出现在其中。
+//     _bulk 响应的 items 与请求中的操作一一按位置对应，因此失败条目按位置 (Index) 关联到 docs，
+//     而不是解析响应中的 _id。
+//   - error: 仅当整个请求失败 (网络错误、ES 返回非 2xx、响应无法解析或条目数量不一致) 时返回；
+//     此时调用方应视为整批失败。
+//
+// 消费路径如何使用返回结果 (偏移量提交语义) 见 kafka 包的 bulk_consumer.go。
+func (repo *esPostRepository) BulkIndexPosts(ctx context.Context, docs []models.EsPostDocument) ([]BulkItemFailure, error) {
+	indexName, err := repo.writeIndexFor(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) == 0 {
+		return nil, nil
+	}
+
+	now := time.Now().UTC()
+	var body bytes.Buffer
+	for _, doc := range docs {
+		doc.UpdatedAt = now // 与 IndexPost 保持一致：每次写入都刷新更新时间 (doc 是副本，不影响调用方)
+		action := map[string]interface{}{
+			"_index": indexName,
+			"_id":    strconv.FormatUint(doc.ID, 10),
+		}
+		if routing := repo.routingFor(doc.AuthorID); routing != "" {
+			action["routing"] = routing
+		}
+		meta := map[string]interface{}{"index": action}
+		metaLine, err := json.Marshal(meta)
+		if err != nil {
+			return nil, fmt.Errorf("序列化批量索引元数据 (ID: %d) 失败: %w", doc.ID, err)
+		}
+		docLine, err := json.Marshal(newESPostSource(doc))
+		if err != nil {
+			return nil, fmt.Errorf("序列化帖子文档 (ID: %d) 失败: %w", doc.ID, err)
+		}
+		body.Write(metaLine)
+		body.WriteByte('\n')
+		body.Write(docLine)
+		body.WriteByte('\n')
+	}
+
+	res, err := esapi.BulkRequest{Body: &body}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 批量索引请求时发生连接或客户端错误", zap.Int("doc_count", len(docs)), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 批量索引请求失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "批量索引文档", len(docs))
+	}
+
+	var bulkResponse struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID     string `json:"_id"`
+			Status int    `json:"status"`
+			Error  *struct {
+				Type   string `json:"type"`
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&bulkResponse); err != nil {
+		repo.logger.Error("解码 Elasticsearch 批量索引响应体失败", zap.Error(err))
+		return nil, fmt.Errorf("解码 Elasticsearch 批量索引响应失败: %w", err)
+	}
+	if !bulkResponse.Errors {
+		repo.logger.Info("批量索引帖子文档成功", zap.Int("doc_count", len(docs)))
+		return nil, nil
+	}
+	if len(bulkResponse.Items) != len(docs) {
+		// 条目无法按位置对应到文档时，无法判断哪些文档已写入，只能整批按失败处理。
+		repo.logger.Error("Elasticsearch 批量索引响应的条目数量与请求不一致",
+			zap.Int("doc_count", len(docs)),
+			zap.Int("item_count", len(bulkResponse.Items)),
+		)
+		return nil, fmt.Errorf("Elasticsearch 批量索引响应包含 %d 个条目，请求包含 %d 个文档", len(bulkResponse.Items), len(docs))
+	}
+
+	var failures []BulkItemFailure
+	for i, item := range bulkResponse.Items {
+		for _, result := range item { // 每个条目只有一个键，即操作类型 ("index")
+			if result.Error == nil {
+				continue
+			}
+			if result.ID != "" && result.ID != strconv.FormatUint(docs[i].ID, 10) {
+				repo.logger.Warn("批量索引响应条目的 _id 与请求中同一位置的文档不一致，按位置处理",
+					zap.Int("item_index", i),
+					zap.Uint64("post_id", docs[i].ID),
+					zap.String("item_id", result.ID),
+				)
+			}
+			failures = append(failures, BulkItemFailure{
+				Index:     i,
+				PostID:    docs[i].ID,
+				Status:    result.Status,
+				ErrorType: result.Error.Type,
+				Reason:    result.Error.Reason,
+				Permanent: isPermanentBulkStatus(result.Status),
+			})
+		}
+	}
+
+	repo.logger.Warn("批量索引帖子文档部分失败",
+		zap.Int("doc_count", len(docs)),
+		zap.Int("failed_count", len(failures)),
+	)
+	return failures, nil
+}
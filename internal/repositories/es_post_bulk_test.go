@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+const testPostsIndex = "posts_test"
+
+func newTestPostRepo(t *testing.T, indexCfg config.IndexSpecificConfig, respond func(req recordedESRequest) (int, string)) (PostRepository, *fakeESTransport) {
+	t.Helper()
+	client, transport := newFakeESClient(t, respond)
+	if indexCfg.Name == "" {
+		indexCfg.Name = testPostsIndex
+	}
+	return NewESPostRepository(client, indexCfg, config.SearchConfig{}, config.PayloadLogRedactionConfig{}, nil, newTestLogger(t)), transport
+}
+
+// mixedBulkResponse 依次对应 ID 为 1、2、3、4 的文档：1 成功，2 映射冲突 (永久性)，
+// 3 队列已满 (暂时性)，4 分片不可用 (暂时性)。条目 3 缺少 _id，失败仍应按位置对应到文档。
+const mixedBulkResponse = `{
+	"took": 7,
+	"errors": true,
+	"items": [
+		{"index": {"_index": "posts_test", "_id": "1", "status": 201, "result": "created"}},
+		{"index": {"_index": "posts_test", "_id": "2", "status": 400,
+			"error": {"type": "mapper_parsing_exception", "reason": "failed to parse field [price_per_unit]"}}},
+		{"index": {"_index": "posts_test", "status": 429,
+			"error": {"type": "es_rejected_execution_exception", "reason": "rejected execution"}}},
+		{"index": {"_index": "posts_test", "_id": "4", "status": 503,
+			"error": {"type": "unavailable_shards_exception", "reason": "primary shard is not active"}}}
+	]
+}`
+
+func TestBulkIndexPostsMixedResults(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, mixedBulkResponse
+	})
+	docs := []models.EsPostDocument{
+		{ID: 1, Title: "one", AuthorID: "a1"},
+		{ID: 2, Title: "two", AuthorID: "a2"},
+		{ID: 3, Title: "three", AuthorID: "a3"},
+		{ID: 4, Title: "four", AuthorID: "a4"},
+	}
+
+	failures, err := repo.BulkIndexPosts(context.Background(), docs)
+	if err != nil {
+		t.Fatalf("BulkIndexPosts 返回错误: %v", err)
+	}
+
+	want := []BulkItemFailure{
+		{Index: 1, PostID: 2, Status: 400, ErrorType: "mapper_parsing_exception", Reason: "failed to parse field [price_per_unit]", Permanent: true},
+		{Index: 2, PostID: 3, Status: 429, ErrorType: "es_rejected_execution_exception", Reason: "rejected execution"},
+		{Index: 3, PostID: 4, Status: 503, ErrorType: "unavailable_shards_exception", Reason: "primary shard is not active"},
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("failures = %+v, want %+v", failures, want)
+	}
+	for i := range want {
+		if failures[i] != want[i] {
+			t.Errorf("failures[%d] = %+v, want %+v", i, failures[i], want[i])
+		}
+	}
+
+	for i, doc := range docs {
+		if !doc.UpdatedAt.IsZero() {
+			t.Errorf("docs[%d].UpdatedAt = %v, BulkIndexPosts 不应修改调用方的文档", i, doc.UpdatedAt)
+		}
+	}
+
+	requests := transport.recorded()
+	if len(requests) != 1 || requests[0].Path != "/_bulk" {
+		t.Fatalf("requests = %+v, want 一个 /_bulk 请求", requests)
+	}
+	lines := bytes.Split(bytes.TrimSuffix(requests[0].Body, []byte("\n")), []byte("\n"))
+	if len(lines) != 2*len(docs) {
+		t.Fatalf("请求体有 %d 行, want %d:\n%s", len(lines), 2*len(docs), requests[0].Body)
+	}
+	assertJSONEqual(t, lines[2], `{"index": {"_index": "posts_test", "_id": "2"}}`)
+	var written models.EsPostDocument
+	if err := json.Unmarshal(lines[3], &written); err != nil {
+		t.Fatalf("解析请求体中的文档失败: %v", err)
+	}
+	if written.ID != 2 || written.UpdatedAt.IsZero() || time.Since(written.UpdatedAt) > time.Minute {
+		t.Errorf("写入的文档 = %+v, want ID 2 且 updated_at 为当前时间", written)
+	}
+}
+
+func TestBulkIndexPostsRouting(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{RouteByAuthor: true}, func(recordedESRequest) (int, string) {
+		return 200, `{"errors": false, "items": [{"index": {"_id": "7", "status": 200}}]}`
+	})
+
+	failures, err := repo.BulkIndexPosts(context.Background(), []models.EsPostDocument{{ID: 7, Title: "seven", AuthorID: "author-7"}})
+	if err != nil || len(failures) != 0 {
+		t.Fatalf("BulkIndexPosts = %+v, %v, want 无失败", failures, err)
+	}
+	meta := bytes.SplitN(transport.recorded()[0].Body, []byte("\n"), 2)[0]
+	assertJSONEqual(t, meta, `{"index": {"_index": "posts_test", "_id": "7", "routing": "author-7"}}`)
+}
+
+func TestBulkIndexPostsRequestFailures(t *testing.T) {
+	docs := []models.EsPostDocument{{ID: 1, Title: "one"}, {ID: 2, Title: "two"}}
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "ES 返回非 2xx", status: 413, body: `{"error": "request entity too large"}`},
+		{name: "响应无法解析", status: 200, body: `{"errors": true, "items": [`},
+		{name: "条目数量与请求不一致", status: 200, body: `{"errors": true, "items": [
+			{"index": {"_id": "1", "status": 400, "error": {"type": "mapper_parsing_exception", "reason": "bad"}}}
+		]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+				return tt.status, tt.body
+			})
+			failures, err := repo.BulkIndexPosts(context.Background(), docs)
+			if err == nil {
+				t.Fatalf("BulkIndexPosts = %+v, want 整批失败的错误", failures)
+			}
+		})
+	}
+}
+
+func TestBulkIndexPostsEmpty(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		t.Error("空批次不应发送请求")
+		return 500, ""
+	})
+	if failures, err := repo.BulkIndexPosts(context.Background(), nil); err != nil || failures != nil {
+		t.Errorf("BulkIndexPosts(nil) = %+v, %v, want nil, nil", failures, err)
+	}
+	if n := len(transport.recorded()); n != 0 {
+		t.Errorf("发送了 %d 个请求, want 0", n)
+	}
+}
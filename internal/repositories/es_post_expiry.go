@@ -0,0 +1,56 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// DeleteExpiredPosts 删除 expires_at 早于当前时间的帖子。
+// 时间比较使用 ES 服务端的 "now"，避免本服务与集群时钟不一致；没有 expires_at 的文档不会命中 range 查询，永不过期。
+// conflicts=proceed: 清理期间帖子可能被重新索引 (版本冲突)，跳过这些文档即可，下一轮清理会重新判断。
+func (repo *esPostRepository) DeleteExpiredPosts(ctx context.Context) (int64, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"range": map[string]interface{}{
+				"expires_at": map[string]interface{}{"lt": "now"},
+			},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("序列化过期帖子清理的 _delete_by_query 请求体失败: %w", err)
+	}
+
+	res, err := esapi.DeleteByQueryRequest{
+		Index:     []string{repo.indexName},
+		Body:      bytes.NewReader(payload),
+		Conflicts: "proceed",
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行过期帖子清理的 _delete_by_query 请求时发生连接或客户端错误", zap.String("index_name", repo.indexName), zap.Error(err))
+		return 0, fmt.Errorf("Elasticsearch 过期帖子清理请求失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return 0, repo.logAndWrapESError(res, "删除过期帖子", repo.indexName)
+	}
+
+	var result struct {
+		Deleted          int64 `json:"deleted"`
+		VersionConflicts int64 `json:"version_conflicts"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		return 0, fmt.Errorf("解码过期帖子清理的 _delete_by_query 响应失败: %w", err)
+	}
+	if result.VersionConflicts > 0 {
+		repo.logger.Warn("过期帖子清理时部分文档发生版本冲突，已跳过，将在下一轮清理中重新判断",
+			zap.String("index_name", repo.indexName),
+			zap.Int64("version_conflicts", result.VersionConflicts),
+		)
+	}
+	return result.Deleted, nil
+}
@@ -0,0 +1,130 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// MultiSearchItemError 表示批量搜索中第 Index 个子搜索失败 (构建查询失败或 ES 返回了错误子响应)。
+// Err 与单个搜索一样包装 ErrBadQuery 或 ErrESUnavailable，调用方可以用 errors.Is 区分。
+type MultiSearchItemError struct {
+	Index int
+	Err   error
+}
+
+func (e *MultiSearchItemError) Error() string {
+	return fmt.Sprintf("第 %d 个子搜索失败: %v", e.Index, e.Err)
+}
+
+func (e *MultiSearchItemError) Unwrap() error {
+	return e.Err
+}
+
+// MultiSearchPosts 使用 _msearch API 在一次往返中执行多个帖子搜索 (例如首页的热门、最新、推荐)。
+// 每个子搜索的 DSL 与 SearchPosts 完全相同 (包括按作者路由)，结果按 reqs 的顺序返回。
+func (repo *esPostRepository) MultiSearchPosts(ctx context.Context, reqs []models.SearchRequest) ([]*models.SearchResult, error) {
+	if len(reqs) == 0 {
+		return []*models.SearchResult{}, nil
+	}
+	// 一次 _msearch 只占用一个 ES 连接，与单个搜索一样占用一个并发名额。
+	release, err := repo.acquireSearchSlot("msearch")
+	if err != nil {
+		repo.logger.Warn("同时进行的 ES 搜索数已达上限，拒绝本次批量搜索", zap.Int("sub_search_count", len(reqs)))
+		return nil, err
+	}
+	defer release()
+	repo.logger.Info("开始执行 Elasticsearch 批量搜索 (_msearch)", zap.Int("sub_search_count", len(reqs)))
+
+	// _msearch 的请求体为 NDJSON：每个子搜索一行头部 (索引、路由) 加一行查询体。
+	queries := make([][]byte, len(reqs))
+	var body bytes.Buffer
+	for i, req := range reqs {
+		queryJSON, err := repo.queryBuilder.SearchBody(req)
+		if err != nil {
+			repo.logger.Error("构建批量搜索子查询 DSL 失败", zap.Int("sub_search_index", i), zap.Any("search_request_params", req), zap.Error(err))
+			return nil, &MultiSearchItemError{Index: i, Err: fmt.Errorf("%w: 构建搜索查询失败: %w", ErrBadQuery, err)}
+		}
+		queries[i] = queryJSON
+
+		// SearchPosts 通过 URL 参数要求精确统计总数，_msearch 不支持该参数，需要写入每个子查询体。
+		bodyJSON, err := withTrackTotalHits(queryJSON)
+		if err != nil {
+			return nil, &MultiSearchItemError{Index: i, Err: fmt.Errorf("%w: 构建搜索查询失败: %w", ErrBadQuery, err)}
+		}
+		indices, archived := repo.searchIndices(ctx, req.IncludeArchived)
+		header := map[string]interface{}{"index": indices}
+		if archived || repo.ignoreUnavailable(ctx) != nil {
+			header["ignore_unavailable"] = true
+		} else if routing := repo.routingFor(req.AuthorID); routing != "" {
+			header["routing"] = routing
+		}
+		headerJSON, err := json.Marshal(header)
+		if err != nil {
+			return nil, fmt.Errorf("序列化批量搜索子查询头部失败: %w", err)
+		}
+		body.Write(headerJSON)
+		body.WriteByte('\n')
+		body.Write(bodyJSON)
+		body.WriteByte('\n')
+		repo.logger.Debug("构建的批量搜索子查询 DSL", zap.Int("sub_search_index", i), repo.redactor.field("dsl_query", queryJSON))
+	}
+
+	res, err := esapi.MsearchRequest{Body: &body}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 批量搜索请求时发生连接或客户端错误", zap.Int("sub_search_count", len(reqs)), zap.Error(err))
+		return nil, classifyTransportError(ctx, fmt.Errorf("Elasticsearch 批量搜索请求失败: %w", err))
+	}
+	defer res.Body.Close()
+
+	if res.IsError() {
+		return nil, classifyResponseError(res, repo.logAndWrapESError(res, "批量搜索文档", len(reqs)))
+	}
+
+	var esResponse struct {
+		Responses []struct {
+			esSearchResponse
+			Status int             `json:"status"`
+			Error  json.RawMessage `json:"error"`
+		} `json:"responses"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "批量搜索", len(reqs)); err != nil {
+		return nil, err
+	}
+	if len(esResponse.Responses) != len(reqs) {
+		return nil, fmt.Errorf("Elasticsearch 批量搜索返回了 %d 个子响应，预期 %d 个", len(esResponse.Responses), len(reqs))
+	}
+
+	results := make([]*models.SearchResult, len(reqs))
+	for i := range esResponse.Responses {
+		item := &esResponse.Responses[i]
+		if len(item.Error) > 0 {
+			repo.logger.Error("Elasticsearch 批量搜索的子搜索失败",
+				zap.Int("sub_search_index", i),
+				zap.Int("es_status", item.Status),
+				zap.ByteString("es_error", item.Error),
+				zap.String("query_keywords", reqs[i].Query),
+			)
+			subErr := fmt.Errorf("Elasticsearch 子搜索失败，状态码: %d，响应: %s", item.Status, item.Error)
+			return nil, &MultiSearchItemError{Index: i, Err: classifyStatusError(item.Status, subErr)}
+		}
+		results[i] = repo.toSearchResult(reqs[i], queries[i], &item.esSearchResponse)
+		repo.logSearchCompletion(reqs[i], queries[i], results[i], item.Hits.Total.Relation)
+	}
+	return results, nil
+}
+
+// withTrackTotalHits 在查询体中加入 "track_total_hits": true。
+func withTrackTotalHits(queryJSON []byte) ([]byte, error) {
+	var query map[string]json.RawMessage
+	if err := json.Unmarshal(queryJSON, &query); err != nil {
+		return nil, err
+	}
+	query["track_total_hits"] = json.RawMessage("true")
+	return json.Marshal(query)
+}
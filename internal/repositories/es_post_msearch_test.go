@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+// msearchHit 返回只包含一条 ID 为 id 的命中的 _msearch 子响应。
+func msearchHit(id int) string {
+	doc, _ := json.Marshal(map[string]interface{}{"id": id, "title": "标题"})
+	return `{"status": 200, "took": 2, "hits": {"total": {"value": 1, "relation": "eq"}, "hits": [{"_score": 1.0, "_source": ` + string(doc) + `}]}}`
+}
+
+func TestMultiSearchPosts(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, `{"took": 3, "responses": [` + msearchHit(1) + `, ` + msearchHit(2) + `]}`
+	})
+	reqs := []models.SearchRequest{
+		{Query: "kafka", Page: 1, Size: 10},
+		{Query: "go", Page: 2, Size: 5},
+	}
+
+	results, err := repo.MultiSearchPosts(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("MultiSearchPosts 返回错误: %v", err)
+	}
+	if len(results) != 2 || results[0].Hits[0].ID != 1 || results[1].Hits[0].ID != 2 {
+		t.Fatalf("results = %+v, want 按请求顺序返回 ID 1、2", results)
+	}
+	if results[1].Page != 2 || results[1].Size != 5 {
+		t.Errorf("第 2 个结果的分页 = (%d, %d), want (2, 5)", results[1].Page, results[1].Size)
+	}
+
+	requests := transport.recorded()
+	if len(requests) != 1 || requests[0].Path != "/_msearch" {
+		t.Fatalf("requests = %+v, want 一个 _msearch 请求", requests)
+	}
+	// 请求体为 NDJSON：每个子搜索一行头部加一行查询体，查询体中要求精确统计总数。
+	lines := bytes.Split(bytes.TrimSuffix(requests[0].Body, []byte("\n")), []byte("\n"))
+	if len(lines) != 4 {
+		t.Fatalf("请求体有 %d 行, want 4 行:\n%s", len(lines), requests[0].Body)
+	}
+	for i := 0; i < len(lines); i += 2 {
+		assertJSONEqual(t, lines[i], `{"index": ["`+testPostsIndex+`"]}`)
+		if body := decodeJSONMap(t, lines[i+1]); body["track_total_hits"] != true {
+			t.Errorf("第 %d 个子查询体 track_total_hits = %v, want true", i/2, body["track_total_hits"])
+		}
+	}
+}
+
+func TestMultiSearchPostsSubRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		failed  string  // 第 2 个子搜索的子响应
+		wantErr error   // 子搜索错误应包装的哨兵错误
+		notWant []error // 子搜索错误不应包装的哨兵错误
+	}{
+		{
+			name:    "400 映射为 ErrBadQuery",
+			failed:  `{"status": 400, "error": {"type": "search_phase_execution_exception", "reason": "failed to create query"}}`,
+			wantErr: ErrBadQuery,
+		},
+		{
+			name:    "429 映射为 ErrESUnavailable",
+			failed:  `{"status": 429, "error": {"type": "es_rejected_execution_exception", "reason": "rejected execution"}}`,
+			wantErr: ErrESUnavailable,
+		},
+		{
+			name:    "503 映射为 ErrESUnavailable",
+			failed:  `{"status": 503, "error": {"type": "no_shard_available_action_exception", "reason": "no shard available"}}`,
+			wantErr: ErrESUnavailable,
+		},
+		{
+			name:    "404 不归类",
+			failed:  `{"status": 404, "error": {"type": "index_not_found_exception", "reason": "no such index"}}`,
+			notWant: []error{ErrBadQuery, ErrESUnavailable},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+				return 200, `{"took": 3, "responses": [` + msearchHit(1) + `, ` + tt.failed + `, ` + msearchHit(3) + `]}`
+			})
+			reqs := []models.SearchRequest{{Query: "a", Page: 1, Size: 10}, {Query: "b", Page: 1, Size: 10}, {Query: "c", Page: 1, Size: 10}}
+
+			results, err := repo.MultiSearchPosts(context.Background(), reqs)
+			if results != nil {
+				t.Errorf("results = %+v, want nil", results)
+			}
+			var itemErr *MultiSearchItemError
+			if !errors.As(err, &itemErr) {
+				t.Fatalf("err = %v, want *MultiSearchItemError", err)
+			}
+			if itemErr.Index != 1 {
+				t.Errorf("Index = %d, want 1", itemErr.Index)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want 包装 %v", err, tt.wantErr)
+			}
+			for _, notWant := range tt.notWant {
+				if errors.Is(err, notWant) {
+					t.Errorf("err = %v, 不应包装 %v", err, notWant)
+				}
+			}
+		})
+	}
+}
+
+func TestMultiSearchPostsRequestErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{name: "整个请求 503", status: 503, body: `{"error": {"type": "cluster_block_exception", "reason": "blocked"}, "status": 503}`, wantErr: ErrESUnavailable},
+		{name: "整个请求 400", status: 400, body: `{"error": {"type": "illegal_argument_exception", "reason": "bad ndjson"}, "status": 400}`, wantErr: ErrBadQuery},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+				return tt.status, tt.body
+			})
+			_, err := repo.MultiSearchPosts(context.Background(), []models.SearchRequest{{Query: "a", Page: 1, Size: 10}})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want 包装 %v", err, tt.wantErr)
+			}
+			var itemErr *MultiSearchItemError
+			if errors.As(err, &itemErr) {
+				t.Errorf("整个请求失败时不应返回子搜索错误: %v", err)
+			}
+		})
+	}
+}
+
+func TestMultiSearchPostsResponseCountMismatch(t *testing.T) {
+	repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, `{"took": 3, "responses": [` + msearchHit(1) + `]}`
+	})
+	reqs := []models.SearchRequest{{Query: "a", Page: 1, Size: 10}, {Query: "b", Page: 1, Size: 10}}
+
+	if _, err := repo.MultiSearchPosts(context.Background(), reqs); err == nil {
+		t.Error("子响应数量与请求不一致时应返回错误")
+	}
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// 暂存索引说明:
+// searchableOnly 状态策略 (indexingConfig.statusPolicy) 会把不可搜索状态的帖子移出帖子索引。
+// 之后的部分更新事件 (PostPatchedEvent) 可能把帖子恢复为可搜索状态，但它只携带变化的字段，
+// 无法据此重建完整文档。因此移出 (或跳过) 时把完整文档保存到暂存索引 "<主索引名>-parked"
+// (租户为 "<主索引名>-parked_<租户>")，恢复时读取暂存副本、合并变化的字段后完整重新索引。
+// 暂存索引只按 ID 读写，不参与搜索；名称匹配主索引模板的 "<主索引名>-*" 模式，首次写入时由 ES 按模板自动创建。
+
+// parkedIndexSuffix 是暂存索引名相对于主索引名的后缀。
+const parkedIndexSuffix = "-parked"
+
+// parkedIndexFor 返回本次操作的暂存索引名称。
+func (repo *esPostRepository) parkedIndexFor(ctx context.Context) string {
+	return TenantIndexName(repo.indexName+parkedIndexSuffix, TenantFromContext(ctx))
+}
+
+// document 返回包含只存储的原文字段 (title_raw/content_raw) 的帖子文档。
+func (s esPostSource) document() models.EsPostDocument {
+	doc := s.EsPostDocument
+	doc.TitleRaw, doc.ContentRaw = s.TitleRaw, s.ContentRaw
+	return doc
+}
+
+// GetPost 读取帖子在索引中的完整文档 (包括只存储的原文字段) 及其版本。
+// 文档不存在时返回 (nil, nil)；读取失败 (包括分片不可用) 时返回错误，不会当作不存在。
+func (repo *esPostRepository) GetPost(ctx context.Context, postID uint64) (*StoredPost, error) {
+	if repo.routeByAuthor {
+		// Get 请求需要 routing 值才能定位分片，按作者路由时改用 ids 查询。
+		return repo.getPostViaSearch(ctx, postID)
+	}
+	// 租户索引尚未创建时与搜索类请求一致，视为文档不存在。
+	return repo.getDocument(ctx, repo.indexFor(ctx), postID, TenantFromContext(ctx) != "")
+}
+
+// ParkPost 将帖子的完整文档写入暂存索引 (覆盖已有的暂存副本)。
+func (repo *esPostRepository) ParkPost(ctx context.Context, doc models.EsPostDocument) error {
+	indexName := repo.parkedIndexFor(ctx)
+	docID := strconv.FormatUint(doc.ID, 10)
+	docBytes, err := json.Marshal(newESPostSource(doc))
+	if err != nil {
+		return fmt.Errorf("序列化暂存文档 (ID: %d) 失败: %w", doc.ID, err)
+	}
+
+	res, err := esapi.IndexRequest{
+		Index:      indexName,
+		DocumentID: docID,
+		Body:       bytes.NewReader(docBytes),
+		Refresh:    "false",
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 暂存请求时发生连接或客户端错误", zap.Uint64("post_id", doc.ID), zap.Error(err))
+		return fmt.Errorf("Elasticsearch 暂存请求 (ID: %d) 失败: %w", doc.ID, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return repo.logAndWrapESError(res, "暂存文档", docID)
+	}
+	repo.logger.Info("已将帖子完整文档写入暂存索引", zap.Uint64("post_id", doc.ID), zap.String("index_name", indexName))
+	return nil
+}
+
+// GetParkedPost 读取帖子在暂存索引中的副本；副本或暂存索引不存在时返回 (nil, nil)。
+func (repo *esPostRepository) GetParkedPost(ctx context.Context, postID uint64) (*models.EsPostDocument, error) {
+	stored, err := repo.getDocument(ctx, repo.parkedIndexFor(ctx), postID, true)
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	return &stored.EsPostDocument, nil
+}
+
+// DeleteParkedPost 删除帖子在暂存索引中的副本；副本或暂存索引不存在时视为成功 (幂等)。
+func (repo *esPostRepository) DeleteParkedPost(ctx context.Context, postID uint64) error {
+	docID := strconv.FormatUint(postID, 10)
+	res, err := esapi.DeleteRequest{
+		Index:      repo.parkedIndexFor(ctx),
+		DocumentID: docID,
+		Refresh:    "false",
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 删除暂存副本请求时发生连接或客户端错误", zap.Uint64("post_id", postID), zap.Error(err))
+		return fmt.Errorf("Elasticsearch 删除暂存副本请求 (ID: %d) 失败: %w", postID, err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil
+	}
+	if res.IsError() {
+		return repo.logAndWrapESError(res, "删除暂存副本", docID)
+	}
+	repo.logger.Info("已删除帖子的暂存副本", zap.Uint64("post_id", postID))
+	return nil
+}
+
+// getDocument 使用 Get API 读取 indexName 中的帖子文档 (包括原文字段) 及其版本。
+// 文档不存在时返回 (nil, nil)；索引不存在时，missingIndexOK 为 true 则同样返回 (nil, nil)，否则返回错误。
+func (repo *esPostRepository) getDocument(ctx context.Context, indexName string, postID uint64, missingIndexOK bool) (*StoredPost, error) {
+	docID := strconv.FormatUint(postID, 10)
+	res, err := esapi.GetRequest{Index: indexName, DocumentID: docID}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch Get 请求时发生连接或客户端错误",
+			zap.String("index_name", indexName),
+			zap.Uint64("post_id", postID),
+			zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch 获取文档请求 (ID: %d) 失败: %w", postID, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		// 文档不存在时响应体为 {"found": false}；索引不存在时为 error 信封。
+		var notFound esErrorEnvelope
+		if json.NewDecoder(res.Body).Decode(&notFound) != nil || notFound.Error.Type == "" {
+			return nil, nil
+		}
+		if notFound.Error.Type == "index_not_found_exception" && missingIndexOK {
+			return nil, nil
+		}
+		repo.logger.Error("Elasticsearch 获取文档失败",
+			zap.String("index_name", indexName),
+			zap.Uint64("post_id", postID),
+			zap.String("es_error_type", notFound.Error.Type),
+			zap.String("es_error_reason", notFound.Error.Reason))
+		return nil, fmt.Errorf("Elasticsearch 获取文档 (ID: %d) 失败: %s", postID, &notFound.Error)
+	}
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "获取文档", docID)
+	}
+
+	var result struct {
+		SeqNo       int64        `json:"_seq_no"`
+		PrimaryTerm int64        `json:"_primary_term"`
+		Source      esPostSource `json:"_source"`
+	}
+	if err := repo.decodeESResponse(res, &result, "获取文档", docID); err != nil {
+		return nil, err
+	}
+	return &StoredPost{EsPostDocument: result.Source.document(), SeqNo: result.SeqNo, PrimaryTerm: result.PrimaryTerm}, nil
+}
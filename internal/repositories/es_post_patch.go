@@ -0,0 +1,165 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// ErrUnpatchableField 表示部分更新请求包含不在 models.PatchableFields 白名单中的字段。
+var ErrUnpatchableField = errors.New("字段不允许部分更新")
+
+// ErrInvalidPatchValue 表示部分更新字段的值与该字段在索引映射中的类型不符 (见 models.CheckPatchValue)。
+var ErrInvalidPatchValue = errors.New("部分更新字段的值类型不正确")
+
+// ErrPostNotFound 表示部分更新的目标帖子在索引中不存在。
+// _update 无法对不存在的文档做部分更新，调用方通常应等待完整的索引事件或将消息转入 DLQ。
+var ErrPostNotFound = errors.New("帖子文档不存在")
+
+// ErrVersionConflict 表示条件部分更新 (PatchPostIfUnchanged) 的目标文档在读取之后已被其他写入修改。
+var ErrVersionConflict = errors.New("帖子文档版本已变化")
+
+// patchRetryOnConflict 是 _update 遇到版本冲突 (并发写入同一文档) 时由 ES 内部重试的次数。
+const patchRetryOnConflict = 3
+
+// StoredPost 是从索引中读取的帖子文档及其版本 (_seq_no/_primary_term)，用于条件更新。
+type StoredPost struct {
+	models.EsPostDocument
+	SeqNo       int64
+	PrimaryTerm int64
+}
+
+// PatchPost 使用 _update API 的 partial doc 只更新帖子的指定字段，并刷新 updated_at。
+// 字段名必须在 models.PatchableFields 白名单中，否则返回 ErrUnpatchableField 且不发送请求；
+// 字段值与索引映射的类型不符时返回 ErrInvalidPatchValue；文档不存在时返回 ErrPostNotFound。
+func (repo *esPostRepository) PatchPost(ctx context.Context, postID uint64, fields map[string]interface{}) error {
+	if err := repo.checkPatchFields(postID, fields); err != nil {
+		return err
+	}
+
+	docID := strconv.FormatUint(postID, 10)
+	req := esapi.UpdateRequest{
+		Index:      repo.indexFor(ctx),
+		DocumentID: docID,
+		Refresh:    "false", // 与 IndexPost 一致，依赖索引的 refresh_interval。
+	}
+	retryOnConflict := patchRetryOnConflict
+	req.RetryOnConflict = &retryOnConflict
+
+	if repo.routeByAuthor {
+		// 部分更新事件不携带作者 ID，先在所有分片上按 ID 查出文档，得到 routing 值。
+		docs, err := repo.getPostsByIDsViaSearch(ctx, []uint64{postID}, []string{docID})
+		if err != nil {
+			return fmt.Errorf("查询帖子 ID %d 的 routing 值失败: %w", postID, err)
+		}
+		if len(docs) == 0 {
+			return fmt.Errorf("%w: ID %d", ErrPostNotFound, postID)
+		}
+		req.Routing = repo.routingFor(docs[0].AuthorID)
+	}
+	return repo.doPatch(ctx, req, postID, fields)
+}
+
+// PatchPostIfUnchanged 与 PatchPost 相同，但通过 if_seq_no/if_primary_term 只在文档仍是 current 读取时的版本时更新。
+// 版本不一致时 ES 返回 409，这里返回 ErrVersionConflict，由调用方重新读取后重试或改为完整写入；
+// 条件更新不能与 retry_on_conflict 同时使用 (ES 内部重试会基于新版本覆盖)。
+func (repo *esPostRepository) PatchPostIfUnchanged(ctx context.Context, current *StoredPost, fields map[string]interface{}) error {
+	postID := current.ID
+	if err := repo.checkPatchFields(postID, fields); err != nil {
+		return err
+	}
+	seqNo, primaryTerm := int(current.SeqNo), int(current.PrimaryTerm)
+	req := esapi.UpdateRequest{
+		Index:         repo.indexFor(ctx),
+		DocumentID:    strconv.FormatUint(postID, 10),
+		Refresh:       "false",
+		IfSeqNo:       &seqNo,
+		IfPrimaryTerm: &primaryTerm,
+		Routing:       repo.routingFor(current.AuthorID), // 读取到的文档已带有作者 ID，无需再查询 routing 值。
+	}
+	return repo.doPatch(ctx, req, postID, fields)
+}
+
+// checkPatchFields 检查部分更新的字段非空、都在 models.PatchableFields 白名单中，且值符合字段的类型。
+func (repo *esPostRepository) checkPatchFields(postID uint64, fields map[string]interface{}) error {
+	if len(fields) == 0 {
+		return fmt.Errorf("%w: 帖子 ID %d 的部分更新没有包含任何字段", ErrUnpatchableField, postID)
+	}
+	var invalid []string
+	for name := range fields {
+		if !models.PatchableFields[name] {
+			invalid = append(invalid, name)
+		}
+	}
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		repo.logger.Warn("部分更新包含不允许修改的字段，已拒绝", zap.Uint64("post_id", postID), zap.Strings("invalid_fields", invalid))
+		return fmt.Errorf("%w: %v", ErrUnpatchableField, invalid)
+	}
+	for name, value := range fields {
+		if err := models.CheckPatchValue(name, value); err != nil {
+			repo.logger.Warn("部分更新字段的值类型不正确，已拒绝", zap.Uint64("post_id", postID), zap.Error(err))
+			return fmt.Errorf("%w: %w", ErrInvalidPatchValue, err)
+		}
+	}
+	return nil
+}
+
+// doPatch 以 fields 和刷新后的 updated_at 作为 partial doc 执行 _update 请求。
+func (repo *esPostRepository) doPatch(ctx context.Context, req esapi.UpdateRequest, postID uint64, fields map[string]interface{}) error {
+	doc := make(map[string]interface{}, len(fields)+1)
+	for name, value := range fields {
+		doc[name] = value
+	}
+	doc["updated_at"] = time.Now().UTC()
+	payload, err := json.Marshal(map[string]interface{}{"doc": doc})
+	if err != nil {
+		return fmt.Errorf("序列化部分更新请求体 (ID: %d) 失败: %w", postID, err)
+	}
+	req.Body = bytes.NewReader(payload)
+
+	res, err := req.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch 部分更新请求时发生连接或客户端错误", zap.Uint64("post_id", postID), zap.Error(err))
+		return fmt.Errorf("Elasticsearch 部分更新请求 (ID: %d) 失败: %w", postID, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNotFound {
+		repo.logger.Warn("部分更新的目标文档在 Elasticsearch 中不存在", zap.Uint64("post_id", postID))
+		return fmt.Errorf("%w: ID %d", ErrPostNotFound, postID)
+	}
+	if res.StatusCode == http.StatusConflict && req.IfSeqNo != nil {
+		repo.logger.Info("条件部分更新的目标文档版本已变化", zap.Uint64("post_id", postID))
+		return fmt.Errorf("%w: ID %d", ErrVersionConflict, postID)
+	}
+	if res.IsError() {
+		return repo.logAndWrapESError(res, "部分更新文档", req.DocumentID)
+	}
+
+	repo.logger.Info("成功部分更新 Elasticsearch 文档",
+		zap.Uint64("post_id", postID),
+		zap.Strings("fields", patchFieldNames(fields)),
+	)
+	return nil
+}
+
+// patchFieldNames 返回排序后的字段名列表，仅用于日志 (不记录字段值，避免输出联系方式等敏感内容)。
+func patchFieldNames(fields map[string]interface{}) []string {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,191 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// 按作者路由 (routeByAuthor) 的说明:
+// 开启后写入时以 author_id 作为 routing 值，同一作者的帖子落在同一个分片上，
+// 按作者筛选的搜索只需查询该分片。代价是:
+//   - 已有数据是按 _id 路由写入的，开启前必须重建索引 (reindex)，否则旧文档无法按 ID 定位；
+//   - 按 ID 的读取和删除无法得知文档所在分片，需要改为查询所有分片 (见下方的函数)；
+//   - 帖子更换作者后重新索引会在另一个分片上生成副本，需要先删除旧文档。
+
+// routingFor 在开启按作者路由时返回作者 ID 作为 routing 值，否则返回空字符串 (使用默认的 _id 路由)。
+func (repo *esPostRepository) routingFor(authorID string) string {
+	if repo.routeByAuthor {
+		return authorID
+	}
+	return ""
+}
+
+// deletePostByQuery 在开启按作者路由时删除帖子。
+// 帖子删除事件只携带帖子 ID，无法得到 routing 值；按 ID 的 Delete 请求会被发送到错误的分片，
+// 因此改用 _delete_by_query 在所有分片上按 id 删除。文档不存在时同样视为成功 (幂等)。
+func (repo *esPostRepository) deletePostByQuery(ctx context.Context, postID uint64) error {
+	indexName := repo.indexFor(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"query": map[string]interface{}{
+			"term": map[string]interface{}{"id": postID},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("序列化 _delete_by_query 请求体 (ID: %d) 失败: %w", postID, err)
+	}
+
+	res, err := esapi.DeleteByQueryRequest{
+		Index:             []string{indexName},
+		Body:              bytes.NewReader(payload),
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx), // 租户索引尚未创建时视为文档不存在
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch _delete_by_query 请求时发生连接或客户端错误", zap.Uint64("post_id", postID), zap.Error(err))
+		return fmt.Errorf("Elasticsearch 删除请求 (ID: %d) 失败: %w", postID, err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return repo.logAndWrapESError(res, "按查询删除文档", postID)
+	}
+
+	var result struct {
+		Deleted int64 `json:"deleted"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&result); err != nil {
+		repo.logger.Debug("_delete_by_query 请求成功，但解码响应体失败", zap.Uint64("post_id", postID), zap.Error(err))
+		return nil
+	}
+	if result.Deleted == 0 {
+		repo.logger.Warn("尝试删除的文档在 Elasticsearch 中未找到，视为操作成功 (幂等性)", zap.Uint64("post_id", postID))
+		return nil
+	}
+	repo.logger.Info("成功通过 _delete_by_query 删除文档", zap.Uint64("post_id", postID), zap.Int64("deleted", result.Deleted))
+	return nil
+}
+
+// getPostsByIDsViaSearch 在开启按作者路由时按 ID 批量获取帖子。
+// _mget 需要每个文档的 routing 值才能定位分片，这里改用 ids 查询在所有分片上检索，
+// 然后按请求中的 ID 顺序重新排列结果；未找到的 ID 会被跳过，有分片执行失败时返回错误。
+func (repo *esPostRepository) getPostsByIDsViaSearch(ctx context.Context, ids []uint64, docIDs []string) ([]models.EsPostDocument, error) {
+	indexName := repo.indexFor(ctx)
+	payload, err := json.Marshal(map[string]interface{}{
+		"size":  len(docIDs),
+		"query": map[string]interface{}{"ids": map[string]interface{}{"values": docIDs}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化 ids 查询请求体失败: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index:             []string{indexName},
+		Body:              bytes.NewReader(payload),
+		SourceExcludes:    models.StoredOnlyFields,
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch ids 查询时发生连接或客户端错误", zap.Int("requested_ids_count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch ids 查询失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "按 ID 查询文档", docIDs)
+	}
+
+	var esResponse struct {
+		Shards struct {
+			Failed int `json:"failed"`
+		} `json:"_shards"`
+		Hits struct {
+			Hits []struct {
+				ID     string                `json:"_id"`
+				Source models.EsPostDocument `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&esResponse); err != nil {
+		repo.logger.Error("解码 Elasticsearch ids 查询响应体失败", zap.Error(err))
+		return nil, fmt.Errorf("解码 Elasticsearch ids 查询响应失败: %w", err)
+	}
+	// 与 _mget 的单文档错误相同：有分片失败时未命中的 ID 可能只是读取失败，不能当作不存在。
+	if esResponse.Shards.Failed > 0 {
+		repo.logger.Error("ids 查询有分片执行失败", zap.Int("failed_shards", esResponse.Shards.Failed), zap.Int("requested_ids_count", len(ids)))
+		return nil, fmt.Errorf("%w: ids 查询有 %d 个分片执行失败", ErrESUnavailable, esResponse.Shards.Failed)
+	}
+
+	byID := make(map[string]models.EsPostDocument, len(esResponse.Hits.Hits))
+	for _, hit := range esResponse.Hits.Hits {
+		byID[hit.ID] = hit.Source
+	}
+	docs := make([]models.EsPostDocument, 0, len(byID))
+	for _, id := range ids {
+		if doc, ok := byID[strconv.FormatUint(id, 10)]; ok {
+			docs = append(docs, doc)
+		}
+	}
+
+	repo.logger.Info("通过 ids 查询批量获取帖子文档完成 (按作者路由)",
+		zap.Int("requested_ids_count", len(ids)),
+		zap.Int("found_docs_count", len(docs)),
+	)
+	return docs, nil
+}
+
+// getPostViaSearch 在开启按作者路由时读取单个帖子的完整文档 (包括原文字段) 及其版本。
+// 与 getPostsByIDsViaSearch 相同，改用 ids 查询在所有分片上检索；有分片执行失败时返回错误。
+func (repo *esPostRepository) getPostViaSearch(ctx context.Context, postID uint64) (*StoredPost, error) {
+	docID := strconv.FormatUint(postID, 10)
+	payload, err := json.Marshal(map[string]interface{}{
+		"size":                1,
+		"query":               map[string]interface{}{"ids": map[string]interface{}{"values": []string{docID}}},
+		"seq_no_primary_term": true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("序列化 ids 查询请求体失败: %w", err)
+	}
+
+	res, err := esapi.SearchRequest{
+		Index:             []string{repo.indexFor(ctx)},
+		Body:              bytes.NewReader(payload),
+		IgnoreUnavailable: repo.ignoreUnavailable(ctx),
+	}.Do(ctx, repo.client)
+	if err != nil {
+		repo.logger.Error("执行 Elasticsearch ids 查询时发生连接或客户端错误", zap.Uint64("post_id", postID), zap.Error(err))
+		return nil, fmt.Errorf("Elasticsearch ids 查询失败: %w", err)
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		return nil, repo.logAndWrapESError(res, "按 ID 查询文档", docID)
+	}
+
+	var esResponse struct {
+		Shards struct {
+			Failed int `json:"failed"`
+		} `json:"_shards"`
+		Hits struct {
+			Hits []struct {
+				SeqNo       int64        `json:"_seq_no"`
+				PrimaryTerm int64        `json:"_primary_term"`
+				Source      esPostSource `json:"_source"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	if err := repo.decodeESResponse(res, &esResponse, "ids 查询", docID); err != nil {
+		return nil, err
+	}
+	if esResponse.Shards.Failed > 0 {
+		repo.logger.Error("ids 查询有分片执行失败", zap.Int("failed_shards", esResponse.Shards.Failed), zap.Uint64("post_id", postID))
+		return nil, fmt.Errorf("%w: ids 查询有 %d 个分片执行失败", ErrESUnavailable, esResponse.Shards.Failed)
+	}
+	if len(esResponse.Hits.Hits) == 0 {
+		return nil, nil
+	}
+	hit := esResponse.Hits.Hits[0]
+	return &StoredPost{EsPostDocument: hit.Source.document(), SeqNo: hit.SeqNo, PrimaryTerm: hit.PrimaryTerm}, nil
+}
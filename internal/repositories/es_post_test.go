@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+func TestIndexPostStoresRawFields(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 201, `{"_index": "posts_test", "_id": "1", "result": "created"}`
+	})
+	doc := models.EsPostDocument{ID: 1, Title: "标题", Content: "正文", TitleRaw: "<b>标题</b>", ContentRaw: "<p>正文</p>"}
+
+	if err := repo.IndexPost(context.Background(), doc); err != nil {
+		t.Fatalf("IndexPost 返回错误: %v", err)
+	}
+
+	requests := transport.recorded()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %+v, want 一个索引请求", requests)
+	}
+	body := decodeJSONMap(t, requests[0].Body)
+	if body["title_raw"] != "<b>标题</b>" || body["content_raw"] != "<p>正文</p>" {
+		t.Errorf("写入的 _source = %s, 应包含 title_raw 与 content_raw", requests[0].Body)
+	}
+}
+
+func TestSearchPostsOmitsStoredOnlyFields(t *testing.T) {
+	// 即使 ES 返回了只存储的原文字段 (例如旧版本写入、未应用 _source 过滤)，搜索结果中也不应出现。
+	const response = `{
+		"took": 3,
+		"hits": {
+			"total": {"value": 1, "relation": "eq"},
+			"hits": [{
+				"_score": 1.2,
+				"_source": {
+					"id": 1, "title": "标题", "content": "正文",
+					"title_raw": "<b>标题</b>", "content_raw": "<script>alert(1)</script>正文"
+				}
+			}]
+		}
+	}`
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, response
+	})
+
+	result, err := repo.SearchPosts(context.Background(), models.SearchRequest{Query: "正文", Page: 1, Size: 10})
+	if err != nil {
+		t.Fatalf("SearchPosts 返回错误: %v", err)
+	}
+	if len(result.Hits) != 1 {
+		t.Fatalf("Hits = %+v, want 1 条", result.Hits)
+	}
+
+	requests := transport.recorded()
+	if len(requests) != 1 {
+		t.Fatalf("requests = %+v, want 一个搜索请求", requests)
+	}
+	assertJSONEqual(t, decodeJSONMap(t, requests[0].Body)["_source"], `{"excludes": ["title_raw", "content_raw"]}`)
+
+	hitJSON, err := json.Marshal(result.Hits[0])
+	if err != nil {
+		t.Fatalf("序列化搜索结果失败: %v", err)
+	}
+	for _, field := range models.StoredOnlyFields {
+		if strings.Contains(string(hitJSON), field) {
+			t.Errorf("搜索结果 %s 不应包含 %s", hitJSON, field)
+		}
+	}
+}
+
+func TestSearchPostsHighlightEncoding(t *testing.T) {
+	const content = `<script>alert(1)</script>kafka 入门`
+	tests := []struct {
+		name      string
+		requested string
+		want      string
+	}{
+		{name: "默认 html 编码转义字段内容", want: `&lt;script&gt;alert(1)&lt;&#x2F;script&gt;<em>kafka</em> 入门`},
+		{name: "请求 default 原样返回", requested: models.HighlightEncoderDefault, want: `<script>alert(1)</script><em>kafka</em> 入门`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 模拟 ES 的高亮行为：encoder 为 html 时先转义字段内容 (与 ES 的 HTML 编码一致，包括 '/')，再插入高亮标签。
+			repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(req recordedESRequest) (int, string) {
+				highlight, _ := decodeJSONMap(t, req.Body)["highlight"].(map[string]any)
+				fragment := content
+				if highlight["encoder"] == models.HighlightEncoderHTML {
+					fragment = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", `"`, "&quot;", "'", "&#x27;", "/", "&#x2F;").Replace(fragment)
+				}
+				fragment = strings.Replace(fragment, "kafka", "<em>kafka</em>", 1)
+				contentJSON, _ := json.Marshal(content)
+				fragmentJSON, _ := json.Marshal(fragment)
+				return 200, `{"took": 1, "hits": {"total": {"value": 1, "relation": "eq"}, "hits": [{
+					"_score": 1.0,
+					"_source": {"id": 1, "title": "标题", "content": ` + string(contentJSON) + `},
+					"highlight": {"content": [` + string(fragmentJSON) + `]}
+				}]}}`
+			})
+
+			req := models.SearchRequest{Query: "kafka", Page: 1, Size: 10, HighlightEncoder: tt.requested}
+			result, err := repo.SearchPosts(context.Background(), req)
+			if err != nil {
+				t.Fatalf("SearchPosts 返回错误: %v", err)
+			}
+			if len(result.Hits) != 1 {
+				t.Fatalf("Hits = %+v, want 1 条", result.Hits)
+			}
+			got := result.Hits[0].Highlights["content"]
+			if len(got) != 1 || got[0] != tt.want {
+				t.Errorf("content 高亮 = %q, want [%q]", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchPostsSourceFieldsProjection(t *testing.T) {
+	const response = `{
+		"took": 2,
+		"hits": {
+			"total": {"value": 1, "relation": "eq"},
+			"hits": [{
+				"_score": 1.0,
+				"_source": {"id": 18446744073709551615, "title": "标题"},
+				"highlight": {"title": ["<strong>标题</strong>"]}
+			}]
+		}
+	}`
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, response
+	})
+	req := models.SearchRequest{Query: "标题", Page: 1, Size: 10, SourceFields: []string{"id", "title"}}
+
+	result, err := repo.SearchPosts(context.Background(), req)
+	if err != nil {
+		t.Fatalf("SearchPosts 返回错误: %v", err)
+	}
+	assertJSONEqual(t, decodeJSONMap(t, transport.recorded()[0].Body)["_source"], `{"includes": ["id", "title"]}`)
+
+	// 未请求的字段 (例如 view_count、status) 不应以零值出现在响应中；大 ID 不应损失精度。
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("序列化搜索结果失败: %v", err)
+	}
+	var decoded struct {
+		Hits []json.RawMessage `json:"hits"`
+	}
+	if err := json.Unmarshal(resultJSON, &decoded); err != nil {
+		t.Fatalf("解析搜索结果失败: %v", err)
+	}
+	if len(decoded.Hits) != 1 {
+		t.Fatalf("hits = %s, want 1 条", resultJSON)
+	}
+	want := `{"id":18446744073709551615,"title":"标题","highlights":{"title":["<strong>标题</strong>"]}}`
+	var got, wantValue interface{}
+	gotDecoder := json.NewDecoder(strings.NewReader(string(decoded.Hits[0])))
+	gotDecoder.UseNumber()
+	wantDecoder := json.NewDecoder(strings.NewReader(want))
+	wantDecoder.UseNumber()
+	if err := gotDecoder.Decode(&got); err != nil {
+		t.Fatalf("解析命中失败: %v", err)
+	}
+	if err := wantDecoder.Decode(&wantValue); err != nil {
+		t.Fatalf("解析期望值失败: %v", err)
+	}
+	if !reflect.DeepEqual(got, wantValue) {
+		t.Errorf("hits[0] = %s, want %s", decoded.Hits[0], want)
+	}
+
+	// 服务内部使用的 Hits 仍然是完整解码的文档。
+	if result.Hits[0].ID != 18446744073709551615 || result.Hits[0].Title != "标题" {
+		t.Errorf("Hits[0] = %+v", result.Hits[0])
+	}
+}
+
+func TestSearchPostsWithoutSourceFieldsReturnsDocuments(t *testing.T) {
+	repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, `{"took": 1, "hits": {"total": {"value": 1, "relation": "eq"}, "hits": [{"_source": {"id": 7, "title": "标题"}}]}}`
+	})
+
+	result, err := repo.SearchPosts(context.Background(), models.SearchRequest{Page: 1, Size: 10})
+	if err != nil {
+		t.Fatalf("SearchPosts 返回错误: %v", err)
+	}
+	if result.ProjectedHits != nil {
+		t.Errorf("ProjectedHits = %v, 未指定 source_fields 时应为 nil", result.ProjectedHits)
+	}
+	resultJSON, err := json.Marshal(result)
+	if err != nil {
+		t.Fatalf("序列化搜索结果失败: %v", err)
+	}
+	// 未指定 source_fields 时返回完整的文档结构 (包括零值字段)。
+	if !strings.Contains(string(resultJSON), `"view_count":0`) {
+		t.Errorf("搜索结果 %s 应包含完整的文档字段", resultJSON)
+	}
+}
+
+func TestSearchPostsDecodeErrorOmitsResponseBody(t *testing.T) {
+	// id 类型不匹配导致解码失败；错误信息中不应出现响应体 (可能含联系方式等敏感字段)。
+	repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, `{"took": 1, "hits": {"hits": [{"_source": {"id": "not-a-number", "contact_info": "13800000000"}}]}}`
+	})
+
+	_, err := repo.SearchPosts(context.Background(), models.SearchRequest{Query: "kafka", Page: 1, Size: 10})
+	if err == nil {
+		t.Fatal("SearchPosts 应返回解码错误")
+	}
+	if strings.Contains(err.Error(), "13800000000") {
+		t.Errorf("错误信息 %q 不应包含响应体内容", err)
+	}
+}
+
+func TestGetPostsByIDsSurfacesPerDocumentErrors(t *testing.T) {
+	// 文档 2 所在分片不可用：不能当作不存在而省略，否则调用方 (状态策略) 会据此跳过应有的删除。
+	repo, _ := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+		return 200, `{"docs": [
+			{"_id": "1", "found": true, "_source": {"id": 1, "title": "标题"}},
+			{"_id": "2", "error": {"type": "no_shard_available_action_exception", "reason": "No shard available"}}
+		]}`
+	})
+
+	_, err := repo.GetPostsByIDs(context.Background(), []uint64{1, 2})
+	if !errors.Is(err, ErrESUnavailable) {
+		t.Errorf("err = %v, want ErrESUnavailable", err)
+	}
+}
+
+func TestParkedPostRoundTrip(t *testing.T) {
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(req recordedESRequest) (int, string) {
+		switch {
+		case req.Method == "PUT":
+			return 201, `{"result": "created"}`
+		case req.Method == "GET" && strings.HasSuffix(req.Path, "/_doc/1"):
+			return 200, `{"found": true, "_source": {"id": 1, "title": "标题", "status": 0, "content_raw": "<p>正文</p>"}}`
+		default:
+			// 暂存索引尚未创建。
+			return 404, `{"error": {"type": "index_not_found_exception", "reason": "no such index"}, "status": 404}`
+		}
+	})
+	ctx := context.Background()
+	if err := repo.ParkPost(ctx, models.EsPostDocument{ID: 1, Title: "标题", ContentRaw: "<p>正文</p>"}); err != nil {
+		t.Fatalf("ParkPost 返回错误: %v", err)
+	}
+	written := transport.recorded()[0]
+	if written.Path != "/"+testPostsIndex+"-parked/_doc/1" {
+		t.Errorf("暂存请求路径 = %s", written.Path)
+	}
+	if body := decodeJSONMap(t, written.Body); body["content_raw"] != "<p>正文</p>" {
+		t.Errorf("暂存的 _source = %s, 应包含原文字段", written.Body)
+	}
+
+	doc, err := repo.GetParkedPost(ctx, 1)
+	if err != nil || doc == nil {
+		t.Fatalf("GetParkedPost(1) = %v, %v", doc, err)
+	}
+	if doc.Title != "标题" || doc.ContentRaw != "<p>正文</p>" {
+		t.Errorf("暂存副本 = %+v", *doc)
+	}
+
+	missing, err := repo.GetParkedPost(ctx, 2)
+	if err != nil || missing != nil {
+		t.Errorf("暂存索引不存在时 GetParkedPost = %v, %v, want nil, nil", missing, err)
+	}
+}
+
+func TestPatchPostIfUnchanged(t *testing.T) {
+	status := 200
+	repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(req recordedESRequest) (int, string) {
+		if req.Method == "GET" {
+			return 200, `{"_seq_no": 17, "_primary_term": 2, "found": true, "_source": {"id": 1, "title": "标题", "view_count": 3}}`
+		}
+		if status == 409 {
+			return 409, `{"error": {"type": "version_conflict_engine_exception", "reason": "version conflict"}, "status": 409}`
+		}
+		return 200, `{"result": "updated"}`
+	})
+	ctx := context.Background()
+
+	current, err := repo.GetPost(ctx, 1)
+	if err != nil || current == nil {
+		t.Fatalf("GetPost(1) = %v, %v", current, err)
+	}
+	if current.SeqNo != 17 || current.PrimaryTerm != 2 || current.ViewCount != 3 {
+		t.Fatalf("GetPost(1) = %+v, want seq_no 17, primary_term 2", *current)
+	}
+
+	if err := repo.PatchPostIfUnchanged(ctx, current, map[string]interface{}{"view_count": 4}); err != nil {
+		t.Fatalf("PatchPostIfUnchanged 返回错误: %v", err)
+	}
+	update := transport.recorded()[1]
+	// 条件更新使用读取时的版本，且不能与 retry_on_conflict 同时使用。
+	for _, want := range []string{"if_seq_no=17", "if_primary_term=2"} {
+		if !strings.Contains(update.Query, want) {
+			t.Errorf("查询参数 %q 应包含 %s", update.Query, want)
+		}
+	}
+	if strings.Contains(update.Query, "retry_on_conflict") {
+		t.Errorf("查询参数 %q 不应包含 retry_on_conflict", update.Query)
+	}
+
+	status = 409
+	err = repo.PatchPostIfUnchanged(ctx, current, map[string]interface{}{"view_count": 4})
+	if !errors.Is(err, ErrVersionConflict) {
+		t.Errorf("409 时 err = %v, want ErrVersionConflict", err)
+	}
+}
+
+func TestPatchPostRejectsInvalidFields(t *testing.T) {
+	tests := []struct {
+		name    string
+		fields  map[string]interface{}
+		wantErr error
+	}{
+		{name: "不在白名单中的字段", fields: map[string]interface{}{"author_id": "a1"}, wantErr: ErrUnpatchableField},
+		{name: "字符串浏览量", fields: map[string]interface{}{"view_count": "12"}, wantErr: ErrInvalidPatchValue},
+		{name: "小数状态", fields: map[string]interface{}{"status": 1.5}, wantErr: ErrInvalidPatchValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo, transport := newTestPostRepo(t, config.IndexSpecificConfig{}, func(recordedESRequest) (int, string) {
+				return 200, `{"result": "updated"}`
+			})
+
+			err := repo.PatchPost(context.Background(), 1, tt.fields)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want %v", err, tt.wantErr)
+			}
+			if requests := transport.recorded(); len(requests) != 0 {
+				t.Errorf("不应发送请求: %+v", requests)
+			}
+		})
+	}
+}
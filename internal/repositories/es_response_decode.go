@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+	"go.uber.org/zap"
+)
+
+// esErrorCause 是 ES 错误对象中用于排查的部分 (错误信封的 error，以及 _mget 中单个文档的 error)。
+type esErrorCause struct {
+	Type   string `json:"type"`
+	Reason string `json:"reason"`
+}
+
+func (c *esErrorCause) String() string {
+	return c.Type + ": " + c.Reason
+}
+
+// esErrorEnvelope 是 ES 错误响应的 error 信封。
+// 代理或 ES 偶尔会以 2xx 状态码返回错误信封，解码失败时据此记录原因。
+type esErrorEnvelope struct {
+	Error esErrorCause `json:"error"`
+}
+
+// decodeESResponse 读取成功响应 (2xx) 的响应体并解码到 v。
+// 解码失败时 (例如代理或 ES 返回了与预期结构不一致的内容)，记录状态码、响应体长度和 ES 的 error 信封 (如果有)。
+// 响应体本身只在 Debug 级别经过 payloadRedactor 脱敏后记录，也不包含在返回的错误中：
+// 搜索和 _mget 的响应含有整页文档 (包括联系方式等敏感字段)。
+// 参数:
+//   - operationDesc: 操作描述，例如 "搜索"、"_mget"，用于日志和错误信息。
+//   - contextIdentifier: 用于日志记录的上下文标识符，例如查询关键词。
+func (repo *esPostRepository) decodeESResponse(res *esapi.Response, v interface{}, operationDesc string, contextIdentifier interface{}) error {
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		repo.logger.Error(fmt.Sprintf("读取 Elasticsearch %s响应体失败", operationDesc),
+			zap.Any("context_identifier", contextIdentifier),
+			zap.String("es_status", res.Status()),
+			zap.Error(err))
+		return fmt.Errorf("读取 Elasticsearch %s响应失败: %w", operationDesc, err)
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		logFields := []zap.Field{
+			zap.Any("context_identifier", contextIdentifier),
+			zap.String("es_status", res.Status()),
+			zap.Int("response_body_bytes", len(body)),
+			zap.Error(err),
+		}
+		var envelope esErrorEnvelope
+		if json.Unmarshal(body, &envelope) == nil && envelope.Error.Type != "" {
+			logFields = append(logFields,
+				zap.String("es_error_type", envelope.Error.Type),
+				zap.String("es_error_reason", envelope.Error.Reason))
+		}
+		repo.logger.Error(fmt.Sprintf("解码 Elasticsearch %s响应体失败", operationDesc), logFields...)
+		repo.logger.Debug(fmt.Sprintf("解码失败的 Elasticsearch %s响应体", operationDesc), repo.redactor.field("es_response_body", body))
+		return fmt.Errorf("解码 Elasticsearch %s响应失败 (状态码: %s，响应体 %d 字节): %w", operationDesc, res.Status(), len(body), err)
+	}
+	return nil
+}
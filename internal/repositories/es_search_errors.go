@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/elastic/go-elasticsearch/v8/esapi"
+)
+
+// ErrBadQuery 表示搜索请求生成的查询无法构建或被 Elasticsearch 以 400 拒绝 (例如字段类型不匹配、查询语法错误)。
+// 这类错误由客户端参数导致，重试不会成功，API 层应返回 400 而不是 500。
+var ErrBadQuery = errors.New("搜索查询无效")
+
+// ErrESUnavailable 表示 Elasticsearch 暂时不可用：连接失败，或返回 429/5xx (限流、分片不可用、网关超时等)。
+// API 层应返回 503，客户端可稍后重试。
+var ErrESUnavailable = errors.New("Elasticsearch 暂时不可用")
+
+// classifyTransportError 为执行请求时的连接或客户端错误附加 ErrESUnavailable。
+// 请求上下文已取消或超时 (客户端断开、调用方设置的截止时间) 时保持原错误，不视为 ES 不可用。
+func classifyTransportError(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return err
+	}
+	return fmt.Errorf("%w: %w", ErrESUnavailable, err)
+}
+
+// classifyResponseError 按 ES 响应状态码为错误附加 ErrBadQuery 或 ErrESUnavailable，其余状态码保持原错误。
+func classifyResponseError(res *esapi.Response, err error) error {
+	return classifyStatusError(res.StatusCode, err)
+}
+
+// classifyStatusError 与 classifyResponseError 相同，但直接使用状态码 (例如 _msearch 中每个子响应的 status)。
+func classifyStatusError(statusCode int, err error) error {
+	switch {
+	case statusCode == http.StatusBadRequest:
+		return fmt.Errorf("%w: %w", ErrBadQuery, err)
+	case statusCode == http.StatusTooManyRequests || statusCode >= http.StatusInternalServerError:
+		return fmt.Errorf("%w: %w", ErrESUnavailable, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"errors"
+
+	"github.com/Xushengqwer/post_search/internal/metrics"
+)
+
+// ErrSearchBusy 表示同时进行中的 ES 搜索请求已达到配置的上限 (searchConfig.maxConcurrentSearches)。
+// 请求不会排队等待，API 层应返回 503 并携带 Retry-After，提示客户端稍后重试。
+var ErrSearchBusy = errors.New("搜索服务繁忙")
+
+// searchRejections 统计因并发搜索数达到上限而被拒绝的搜索请求。
+var searchRejections = metrics.NewCounterVec(
+	"post_search_es_search_rejected_total",
+	"ES search requests rejected because the concurrent search limit was reached.",
+	"operation",
+)
+
+// newSearchSlots 创建限制并发搜索数的信号量，limit <= 0 时返回 nil (不限制)。
+func newSearchSlots(limit int) chan struct{} {
+	if limit <= 0 {
+		return nil
+	}
+	return make(chan struct{}, limit)
+}
+
+// acquireSearchSlot 尝试占用一个并发搜索名额，成功时返回释放名额的函数。
+// 名额已满时立即返回 ErrSearchBusy 而不是等待：突发流量下排队只会让请求在超时前堆积，
+// 并继续占用 ES 连接池。operation 用于区分指标中的调用方 (search、msearch)。
+func (repo *esPostRepository) acquireSearchSlot(operation string) (func(), error) {
+	if repo.searchSlots == nil {
+		return func() {}, nil
+	}
+	select {
+	case repo.searchSlots <- struct{}{}:
+		return func() { <-repo.searchSlots }, nil
+	default:
+		searchRejections.Inc(operation)
+		return nil, ErrSearchBusy
+	}
+}
@@ -0,0 +1,82 @@
+package repositories
+
+import (
+	"encoding/json"
+
+	"github.com/Xushengqwer/post_search/config"
+	"go.uber.org/zap"
+)
+
+// 未配置 PayloadLogRedactionConfig 时使用的脱敏字段与掩码。
+var defaultRedactedFields = []string{"contact_qr_code", "contact_info"}
+
+const defaultRedactionMask = "***"
+
+// payloadRedactor 在记录请求体日志之前把敏感字段的值替换为掩码。
+type payloadRedactor struct {
+	fields map[string]bool
+	mask   string
+}
+
+func newPayloadRedactor(cfg config.PayloadLogRedactionConfig) payloadRedactor {
+	fieldNames := cfg.Fields
+	if len(fieldNames) == 0 {
+		fieldNames = defaultRedactedFields
+	}
+	fields := make(map[string]bool, len(fieldNames))
+	for _, f := range fieldNames {
+		fields[f] = true
+	}
+	mask := cfg.Mask
+	if mask == "" {
+		mask = defaultRedactionMask
+	}
+	return payloadRedactor{fields: fields, mask: mask}
+}
+
+// field 返回一个延迟脱敏的日志字段：只有日志级别允许输出时才会解析并脱敏请求体，
+// 避免在 Info 级别下为每次写入付出额外的 JSON 解析开销。
+func (r payloadRedactor) field(key string, payload []byte) zap.Field {
+	return zap.Stringer(key, redactedPayload{redactor: r, payload: payload})
+}
+
+// redactedPayload 实现 fmt.Stringer，在被日志编码时才执行脱敏。
+type redactedPayload struct {
+	redactor payloadRedactor
+	payload  []byte
+}
+
+func (p redactedPayload) String() string {
+	var body interface{}
+	if err := json.Unmarshal(p.payload, &body); err != nil {
+		// 无法解析时不输出原文，避免敏感信息经由格式异常的请求体泄露。
+		return "<请求体无法解析为 JSON，已省略>"
+	}
+	redacted, err := json.Marshal(p.redactor.redact(body))
+	if err != nil {
+		return "<请求体脱敏后序列化失败，已省略>"
+	}
+	return string(redacted)
+}
+
+// redact 递归遍历 JSON 值，把键名命中脱敏字段且值非空的项替换为掩码 (空值保留，便于区分 "未填写")。
+func (r payloadRedactor) redact(v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if r.fields[k] && child != nil && child != "" {
+				val[k] = r.mask
+				continue
+			}
+			val[k] = r.redact(child)
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = r.redact(child)
+		}
+		return val
+	default:
+		return v
+	}
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+// SearchQueryBuilder 按服务端的搜索配置生成帖子搜索的查询 DSL。
+// PostRepository 执行搜索时使用它，服务之外的工具 (例如 cmd/explain) 也通过它得到与线上完全一致的查询。
+type SearchQueryBuilder struct {
+	opts searchQueryOptions
+}
+
+// NewSearchQueryBuilder 根据搜索配置和帖子索引配置 (是否启用 .en 子字段) 创建查询构建器，无效的配置项会记录警告并使用默认值。
+func NewSearchQueryBuilder(searchCfg config.SearchConfig, indexCfg config.IndexSpecificConfig, logger *core.ZapLogger) *SearchQueryBuilder {
+	return &SearchQueryBuilder{opts: newSearchQueryOptions(searchCfg, indexCfg.EnglishSubfields, logger)}
+}
+
+// SearchBody 返回 _search 请求体 (查询、分页、排序、高亮、分面等)。
+// req 应已由服务层规范化 (页码、页大小、排序字段等已填充默认值)。
+func (b *SearchQueryBuilder) SearchBody(req models.SearchRequest) ([]byte, error) {
+	return buildSearchQuery(req, b.opts)
+}
+
+// Query 返回请求体中的 query 部分，可直接用于 _explain 等只接受查询的 API。
+func (b *SearchQueryBuilder) Query(req models.SearchRequest) map[string]interface{} {
+	query, _ := buildQueryClause(req, b.opts)
+	return query
+}
@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// 多租户说明:
+// 开启 TenancyConfig 后，API 中间件从请求头读取租户标识并通过 WithTenant 写入请求上下文，
+// 帖子仓库的文档级操作 (索引、删除、搜索、按 ID 获取等) 改为作用于 "<主索引名>_<租户>"，
+// 租户索引在首次写入时按主索引的配置与映射自动创建；读取尚未创建的租户索引时返回空结果。
+// 未携带租户的请求仍使用主索引，与单租户部署的行为完全一致。
+// 索引级的运维操作 (统计、刷新、refresh_interval、就绪检查、过期清理) 始终作用于主索引。
+
+// ErrInvalidTenant 表示租户标识格式无效或不在允许的租户列表中。
+var ErrInvalidTenant = errors.New("租户标识无效")
+
+// tenantIDPattern 限制租户标识的格式：拼接到索引名后必须仍是合法的 ES 索引名 (小写、不含特殊字符)。
+var tenantIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+// reservedTenantPrefix 是租户标识不允许使用的前缀：租户索引名为 "<主索引名>_<租户>"，
+// 以 archive 开头的租户会与按 "<主索引名>_archive..." 命名的归档索引 (searchConfig.archiveIndices) 重名，
+// 导致租户读写归档数据。
+const reservedTenantPrefix = "archive"
+
+// IndexEnsurer 确保指定名称的帖子索引存在 (不存在时按主索引的配置与映射创建)。
+type IndexEnsurer func(ctx context.Context, indexName string) error
+
+// tenantKey 是租户标识在 context 中的键。
+type tenantKey struct{}
+
+// WithTenant 返回一个携带租户标识的上下文；tenant 应已经过 ParseTenant 校验，空字符串表示不使用租户。
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext 返回上下文中的租户标识，未设置时返回空字符串。
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// TenantIndexName 返回租户对应的帖子索引名称；tenant 为空时返回 baseIndex 本身。
+func TenantIndexName(baseIndex, tenant string) string {
+	if tenant == "" {
+		return baseIndex
+	}
+	return baseIndex + "_" + tenant
+}
+
+// ParseTenant 规范化 (去除两端空白并转为小写) 并校验租户标识。
+// raw 为空时返回空字符串 (不使用租户)；allowed 非空时只接受列表中的租户。
+// 格式无效、使用了保留前缀 (reservedTenantPrefix) 或不被允许时返回包装 ErrInvalidTenant 的错误。
+func ParseTenant(raw string, allowed []string) (string, error) {
+	tenant := strings.ToLower(strings.TrimSpace(raw))
+	if tenant == "" {
+		return "", nil
+	}
+	if !tenantIDPattern.MatchString(tenant) {
+		return "", fmt.Errorf("%w: '%s' 只能包含小写字母、数字、'_' 和 '-'，且不超过 63 个字符", ErrInvalidTenant, raw)
+	}
+	if strings.HasPrefix(tenant, reservedTenantPrefix) {
+		return "", fmt.Errorf("%w: '%s' 使用了保留前缀 '%s' (与归档索引的命名冲突)", ErrInvalidTenant, raw, reservedTenantPrefix)
+	}
+	if len(allowed) > 0 {
+		for _, a := range allowed {
+			if strings.ToLower(strings.TrimSpace(a)) == tenant {
+				return tenant, nil
+			}
+		}
+		return "", fmt.Errorf("%w: '%s' 不在允许的租户列表中", ErrInvalidTenant, raw)
+	}
+	return tenant, nil
+}
+
+// indexFor 返回本次操作的目标索引：上下文中有租户时为租户索引，否则为主索引。
+// 只计算索引名，不会创建索引：读取和删除操作不应因为请求携带了任意租户标识就创建新索引，
+// 租户索引不存在时，搜索类请求通过 ignoreUnavailable 返回空结果。
+func (repo *esPostRepository) indexFor(ctx context.Context) string {
+	return TenantIndexName(repo.indexName, TenantFromContext(ctx))
+}
+
+// writeIndexFor 返回写入操作 (IndexPost、BulkIndexPosts) 的目标索引，租户索引不存在时先创建。
+// 只有写入路径会创建租户索引，写入来自受信任的上游事件 (Kafka 消息头中的租户同样经过 ParseTenant 校验)。
+// 已确认存在的租户索引会被缓存，之后的写入不再检查；确保过程持有互斥锁，避免并发写入重复创建同一个索引。
+func (repo *esPostRepository) writeIndexFor(ctx context.Context) (string, error) {
+	tenant := TenantFromContext(ctx)
+	indexName := repo.indexFor(ctx)
+	if tenant == "" || repo.ensureIndex == nil {
+		return indexName, nil
+	}
+
+	repo.tenantMu.Lock()
+	defer repo.tenantMu.Unlock()
+	if repo.ensuredIndices[indexName] {
+		return indexName, nil
+	}
+	if err := repo.ensureIndex(ctx, indexName); err != nil {
+		return "", fmt.Errorf("准备租户 '%s' 的帖子索引 '%s' 失败: %w", tenant, indexName, err)
+	}
+	repo.ensuredIndices[indexName] = true
+	return indexName, nil
+}
+
+// ignoreUnavailable 返回搜索类请求的 ignore_unavailable 参数：租户请求为 true，
+// 使尚未写入过数据 (索引尚未创建) 的租户得到空结果而不是 404；主索引缺失仍应作为错误暴露，返回 nil。
+func (repo *esPostRepository) ignoreUnavailable(ctx context.Context) *bool {
+	if TenantFromContext(ctx) == "" {
+		return nil
+	}
+	ignore := true
+	return &ignore
+}
@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseTenant(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		allowed []string
+		want    string
+		wantErr bool
+	}{
+		{name: "未携带租户", raw: "  ", want: ""},
+		{name: "规范化为小写", raw: " BrandA ", want: "branda"},
+		{name: "格式无效", raw: "brand.a", wantErr: true},
+		{name: "不在允许列表中", raw: "brandb", allowed: []string{"brandA"}, wantErr: true},
+		{name: "在允许列表中", raw: "BRANDA", allowed: []string{" branda "}, want: "branda"},
+		{name: "与归档索引重名", raw: "archive", wantErr: true},
+		{name: "归档索引前缀", raw: "Archive_2023", wantErr: true},
+		{name: "保留前缀即使在允许列表中也拒绝", raw: "archive", allowed: []string{"archive"}, wantErr: true},
+		{name: "只是包含 archive", raw: "my-archive", want: "my-archive"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTenant(tt.raw, tt.allowed)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidTenant) {
+					t.Errorf("ParseTenant(%q) error = %v, want ErrInvalidTenant", tt.raw, err)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("ParseTenant(%q) = %q, %v; want %q", tt.raw, got, err, tt.want)
+			}
+		})
+	}
+}
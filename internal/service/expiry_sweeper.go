@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// defaultExpirySweepInterval 是过期帖子清理的默认间隔，在配置缺失或无效时使用。
+const defaultExpirySweepInterval = 10 * time.Minute
+
+// ExpirySweeper 在后台周期性删除已过期 (expires_at 早于当前时间) 的帖子。
+type ExpirySweeper struct {
+	postRepo repositories.PostRepository
+	interval time.Duration
+	logger   *core.ZapLogger
+}
+
+// NewExpirySweeper 创建 ExpirySweeper；SweepInterval <= 0 时使用默认值 10m。
+func NewExpirySweeper(postRepo repositories.PostRepository, cfg config.ExpiryConfig, logger *core.ZapLogger) *ExpirySweeper {
+	interval := cfg.SweepInterval
+	if interval <= 0 {
+		interval = defaultExpirySweepInterval
+	}
+	return &ExpirySweeper{postRepo: postRepo, interval: interval, logger: logger}
+}
+
+// Run 立即执行一次清理，之后按配置的间隔重复执行，直到 ctx 被取消。
+// 单次清理失败只记录错误，不影响后续的清理。
+func (s *ExpirySweeper) Run(ctx context.Context) {
+	s.logger.Info("过期帖子清理任务已启动", zap.Duration("sweep_interval", s.interval))
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.sweep(ctx)
+		select {
+		case <-ctx.Done():
+			s.logger.Info("过期帖子清理任务已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sweep 执行一次清理并记录删除的文档数量。
+func (s *ExpirySweeper) sweep(ctx context.Context) {
+	start := time.Now()
+	deleted, err := s.postRepo.DeleteExpiredPosts(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return
+		}
+		s.logger.Error("清理过期帖子失败", zap.Error(err))
+		return
+	}
+	s.logger.Info("过期帖子清理完成",
+		zap.Int64("deleted", deleted),
+		zap.Duration("took", time.Since(start)),
+	)
+}
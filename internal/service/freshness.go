@@ -0,0 +1,30 @@
+package service
+
+import (
+	"time"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+// freshnessWindows 是 SearchRequest.Freshness 各取值对应的时间窗口 ("本月" 按 30 天计算)。
+var freshnessWindows = map[string]time.Duration{
+	models.FreshnessDay:   24 * time.Hour,
+	models.FreshnessWeek:  7 * 24 * time.Hour,
+	models.FreshnessMonth: 30 * 24 * time.Hour,
+}
+
+// applyFreshness 把 Freshness 快捷选项转换为 updated_at 的下限 (UpdatedFrom)。
+// 与客户端显式传入的 updated_from 组合时取更窄的窗口 (较晚的下限)。
+// 下限按分钟截断：相对 "现在" 的窗口每毫秒都在变化，截断后同一分钟内的相同请求可以命中结果缓存。
+func applyFreshness(req *models.SearchRequest, now time.Time) {
+	window, ok := freshnessWindows[req.Freshness]
+	if !ok {
+		return
+	}
+	from := now.Add(-window).Truncate(time.Minute).UnixMilli()
+	if req.UpdatedFrom == nil || *req.UpdatedFrom < from {
+		req.UpdatedFrom = &from
+	}
+	// 已经转换为 UpdatedFrom，清空后缓存键只取决于实际生效的时间窗口。
+	req.Freshness = ""
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"github.com/Xushengqwer/go-common/core"
+	"go.uber.org/zap"
+)
+
+// hotTermSampler 决定一次搜索是否计入热门搜索词统计，以及计入时的增量。
+// 采样率为 rate 时只有约 rate 比例的搜索会写入 ES，被采样时的增量期望为 1/rate，使各词的计数期望值与不采样时相同。
+// 1/rate 通常不是整数 (例如 rate 为 0.3 时为 3.33)：直接四舍五入会使计数系统性偏低或偏高，
+// 因此增量取 floor(1/rate)，再以小数部分为概率额外加 1。
+type hotTermSampler struct {
+	rate      float64        // 采样率，取值 (0, 1]；为 0 时不记录任何搜索词。
+	increment int64          // 每次被采样时的基础计数增量，即 floor(1/rate)。
+	fraction  float64        // 1/rate 的小数部分，被采样时以此概率在 increment 上额外加 1。
+	random    func() float64 // 返回 [0, 1) 的随机数，为 nil 时使用 rand.Float64 (测试中替换为固定种子)。
+}
+
+// newHotTermSampler 根据配置的采样率创建 hotTermSampler。
+// 未配置 (nil) 时采样率为 1，即每次搜索都计数；超出 [0, 1] 的值会被截断到边界并记录警告。
+func newHotTermSampler(configured *float64, logger *core.ZapLogger) hotTermSampler {
+	if configured == nil {
+		return hotTermSampler{rate: 1, increment: 1}
+	}
+	rate := *configured
+	if rate < 0 || rate > 1 || math.IsNaN(rate) {
+		logger.Warn("配置的热门搜索词采样率 (searchConfig.hotTermSampleRate) 超出 [0, 1]，已截断", zap.Float64("configured_rate", rate))
+		if rate > 1 {
+			rate = 1
+		} else {
+			rate = 0
+		}
+	}
+	if rate == 0 {
+		logger.Info("热门搜索词采样率为 0，搜索词将不会被计数")
+		return hotTermSampler{}
+	}
+	whole, fraction := math.Modf(1 / rate)
+	if rate < 1 {
+		logger.Info("热门搜索词计数已启用采样",
+			zap.Float64("sample_rate", rate),
+			zap.Float64("expected_increment_per_sample", 1/rate),
+		)
+	}
+	return hotTermSampler{rate: rate, increment: int64(whole), fraction: fraction}
+}
+
+// sample 返回本次搜索的计数增量；返回 0 表示本次不计数。
+func (s hotTermSampler) sample() int64 {
+	if s.rate >= 1 {
+		return s.increment
+	}
+	if s.rate <= 0 || s.float64() >= s.rate {
+		return 0
+	}
+	if s.fraction > 0 && s.float64() < s.fraction {
+		return s.increment + 1
+	}
+	return s.increment
+}
+
+func (s hotTermSampler) float64() float64 {
+	if s.random != nil {
+		return s.random()
+	}
+	return rand.Float64()
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+)
+
+func TestHotTermSamplerIsUnbiased(t *testing.T) {
+	// 1/rate 不是整数的采样率：四舍五入的增量会使计数期望偏离 1 (例如 0.3 时为 0.9，0.4 时为 1.2)。
+	for _, rate := range []float64{1, 0.5, 0.4, 0.3, 0.07} {
+		configured := rate
+		sampler := newHotTermSampler(&configured, newTestLogger(t))
+		sampler.random = rand.New(rand.NewPCG(1, 2)).Float64
+
+		const searches = 400000
+		var total int64
+		for i := 0; i < searches; i++ {
+			total += sampler.sample()
+		}
+		// 每次搜索的平均计数应接近 1 (不采样时的计数)。
+		if mean := float64(total) / searches; math.Abs(mean-1) > 0.02 {
+			t.Errorf("rate = %v: 每次搜索的平均计数 = %.4f, want ≈ 1", rate, mean)
+		}
+	}
+}
+
+func TestHotTermSamplerIncrements(t *testing.T) {
+	rate := 0.3
+	sampler := newHotTermSampler(&rate, newTestLogger(t))
+	sampler.random = rand.New(rand.NewPCG(3, 4)).Float64
+
+	// 被采样时的增量只能是 floor(1/rate) 或再加 1。
+	for i := 0; i < 10000; i++ {
+		if got := sampler.sample(); got != 0 && got != 3 && got != 4 {
+			t.Fatalf("sample() = %d, want 0、3 或 4", got)
+		}
+	}
+}
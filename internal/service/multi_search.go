@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+	"go.uber.org/zap"
+)
+
+// ErrTooManySearchRequests 表示批量搜索请求中的子搜索数量超过了配置的上限。
+var ErrTooManySearchRequests = errors.New("批量搜索的子搜索数量超过上限")
+
+// MultiSearchItemError 表示批量搜索中第 Index 个子搜索失败 (Index 为客户端请求中的下标)。
+// Err 保留与单个搜索相同的错误链 (ErrTooManyExcludeIDs、ErrQueryTooLong、ErrBadQuery、ErrESUnavailable 等)。
+type MultiSearchItemError = repositories.MultiSearchItemError
+
+// MultiSearch 在一次 ES 往返 (_msearch) 中执行多个相互独立的搜索，结果顺序与 reqs 一致。
+// 每个子搜索与 Search 一样经过校验、规范化、可搜索状态限制和结果缓存；
+// 命中缓存或状态不可见的子搜索不会发送给 ES。任一子搜索失败时整体返回 *MultiSearchItemError。
+// 零结果兜底推荐同样生效，使写入结果缓存的内容与 Search 一致。
+func (s *SearchService) MultiSearch(ctx context.Context, reqs []models.SearchRequest) ([]*models.SearchResult, error) {
+	if len(reqs) > s.cfg.MaxMultiSearchRequests {
+		return nil, fmt.Errorf("%w: 请求 %d 个，上限 %d 个", ErrTooManySearchRequests, len(reqs), s.cfg.MaxMultiSearchRequests)
+	}
+
+	results := make([]*models.SearchResult, len(reqs))
+	cacheKeys := make([]string, len(reqs))
+	cacheable := make([]bool, len(reqs))
+	var pending []models.SearchRequest
+	var pendingIndexes []int
+	for i := range reqs {
+		req := reqs[i]
+		visible, err := s.prepareSearch(ctx, &req)
+		if err != nil {
+			return nil, &MultiSearchItemError{Index: i, Err: err}
+		}
+		if !visible {
+			results[i] = emptySearchResult(req)
+			continue
+		}
+
+		cacheKeys[i], cacheable[i] = s.resultCache.keyFor(ctx, req)
+		if cacheable[i] {
+			if cached, ok := s.resultCache.get(cacheKeys[i]); ok {
+				resultCacheRequests.Inc("hit")
+				results[i] = cached
+				continue
+			}
+			resultCacheRequests.Inc("miss")
+		} else if s.resultCache != nil {
+			resultCacheRequests.Inc("bypass")
+		}
+		pending = append(pending, req)
+		pendingIndexes = append(pendingIndexes, i)
+	}
+
+	s.logger.Info("正在处理批量搜索请求",
+		zap.Int("子搜索数量", len(reqs)),
+		zap.Int("需要查询ES的数量", len(pending)),
+	)
+	if len(pending) == 0 {
+		return results, nil
+	}
+
+	searched, err := s.postRepo.MultiSearchPosts(ctx, pending)
+	if err != nil {
+		// 仓库层的下标对应 pending，转换为客户端请求中的下标。
+		var itemErr *MultiSearchItemError
+		if errors.As(err, &itemErr) {
+			err = &MultiSearchItemError{Index: pendingIndexes[itemErr.Index], Err: itemErr.Err}
+		}
+		s.logger.Error("调用 PostRepository 执行批量搜索时发生错误", zap.Int("子搜索数量", len(pending)), zap.Error(err))
+		return nil, fmt.Errorf("执行批量搜索失败: %w", err)
+	}
+
+	for j, result := range searched {
+		i := pendingIndexes[j]
+		s.attachZeroResultFallback(ctx, pending[j], result)
+		results[i] = result
+		if cacheable[i] {
+			s.resultCache.put(cacheKeys[i], result)
+		}
+	}
+	return results, nil
+}
+
+// MaxMultiSearchRequests 返回批量搜索单次允许的最大子搜索数量 (供 API 层生成校验错误信息)。
+func (s *SearchService) MaxMultiSearchRequests() int {
+	return s.cfg.MaxMultiSearchRequests
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+// fakeMultiSearchRepo 是只实现 MultiSearchPosts 的 PostRepository，记录每次发送给 ES 的子搜索。
+// failIndex >= 0 时第 failIndex 个子搜索 (仓库层下标) 返回 ErrBadQuery。
+type fakeMultiSearchRepo struct {
+	repositories.PostRepository
+
+	calls     [][]models.SearchRequest
+	failIndex int
+}
+
+func (r *fakeMultiSearchRepo) MultiSearchPosts(_ context.Context, reqs []models.SearchRequest) ([]*models.SearchResult, error) {
+	r.calls = append(r.calls, reqs)
+	if r.failIndex >= 0 && r.failIndex < len(reqs) {
+		return nil, &repositories.MultiSearchItemError{Index: r.failIndex, Err: fmt.Errorf("%w: 子搜索 %q 失败", repositories.ErrBadQuery, reqs[r.failIndex].Query)}
+	}
+	results := make([]*models.SearchResult, len(reqs))
+	for i, req := range reqs {
+		results[i] = &models.SearchResult{Hits: []models.EsPostDocument{{ID: uint64(i + 1), Title: req.Query}}, Total: 1, Page: req.Page, Size: req.Size}
+	}
+	return results, nil
+}
+
+func newTestMultiSearchService(t *testing.T, cfg config.SearchConfig, failIndex int) (*SearchService, *fakeMultiSearchRepo) {
+	t.Helper()
+	repo := &fakeMultiSearchRepo{failIndex: failIndex}
+	return NewSearchService(repo, &fakeHotTermsRepo{}, cfg, config.IndexingConfig{}, newTestLogger(t)), repo
+}
+
+func statusPtr(s enums.Status) *enums.Status { return &s }
+
+func TestMultiSearchBatchSizeLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured int
+		count      int
+		wantErr    bool
+	}{
+		{name: "等于配置的上限", configured: 3, count: 3},
+		{name: "超过配置的上限", configured: 3, count: 4, wantErr: true},
+		{name: "未配置时使用默认上限", count: defaultMaxMultiSearchRequests},
+		{name: "超过默认上限", count: defaultMaxMultiSearchRequests + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestMultiSearchService(t, config.SearchConfig{MaxMultiSearchRequests: tt.configured}, -1)
+			reqs := make([]models.SearchRequest, tt.count)
+			for i := range reqs {
+				reqs[i] = models.SearchRequest{Query: fmt.Sprintf("q%d", i), Page: 1, Size: 10}
+			}
+
+			results, err := svc.MultiSearch(context.Background(), reqs)
+			if tt.wantErr {
+				if !errors.Is(err, ErrTooManySearchRequests) {
+					t.Fatalf("err = %v, want ErrTooManySearchRequests", err)
+				}
+				if len(repo.calls) != 0 {
+					t.Errorf("超过上限时不应查询 ES: %d 次调用", len(repo.calls))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("MultiSearch 返回错误: %v", err)
+			}
+			if len(results) != tt.count {
+				t.Errorf("返回 %d 个结果, want %d", len(results), tt.count)
+			}
+		})
+	}
+}
+
+func TestMultiSearchItemErrors(t *testing.T) {
+	// 第 0 个子搜索请求了不可搜索的状态，不发送给 ES；因此仓库层下标比客户端下标小 1。
+	hidden := models.SearchRequest{Query: "草稿", Page: 1, Size: 10, Status: statusPtr(enums.Pending)}
+	tests := []struct {
+		name      string
+		reqs      []models.SearchRequest
+		failIndex int
+		wantIndex int
+		wantErr   error
+		wantCalls int
+	}{
+		{
+			name:      "ES 子搜索错误的下标转换为客户端请求中的下标",
+			reqs:      []models.SearchRequest{hidden, {Query: "kafka", Page: 1, Size: 10}, {Query: "go", Page: 1, Size: 10}},
+			failIndex: 1,
+			wantIndex: 2,
+			wantErr:   repositories.ErrBadQuery,
+			wantCalls: 1,
+		},
+		{
+			name:      "校验失败的子搜索不发送请求",
+			reqs:      []models.SearchRequest{{Query: "kafka", Page: 1, Size: 10}, {Query: "go", Page: 1, Size: 10, ExcludeIDs: make([]uint64, defaultMaxExcludeIDs+1)}},
+			failIndex: -1,
+			wantIndex: 1,
+			wantErr:   ErrTooManyExcludeIDs,
+			wantCalls: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestMultiSearchService(t, config.SearchConfig{}, tt.failIndex)
+
+			_, err := svc.MultiSearch(context.Background(), tt.reqs)
+			var itemErr *MultiSearchItemError
+			if !errors.As(err, &itemErr) {
+				t.Fatalf("err = %v, want *MultiSearchItemError", err)
+			}
+			if itemErr.Index != tt.wantIndex {
+				t.Errorf("Index = %d, want %d", itemErr.Index, tt.wantIndex)
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("err = %v, want 包装 %v", err, tt.wantErr)
+			}
+			if len(repo.calls) != tt.wantCalls {
+				t.Errorf("MultiSearchPosts 调用了 %d 次, want %d", len(repo.calls), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestMultiSearchKeepsRequestOrder(t *testing.T) {
+	svc, repo := newTestMultiSearchService(t, config.SearchConfig{}, -1)
+	reqs := []models.SearchRequest{
+		{Query: "kafka", Page: 1, Size: 10},
+		{Query: "草稿", Page: 1, Size: 10, Status: statusPtr(enums.Pending)},
+		{Query: "go", Page: 2, Size: 5},
+	}
+
+	results, err := svc.MultiSearch(context.Background(), reqs)
+	if err != nil {
+		t.Fatalf("MultiSearch 返回错误: %v", err)
+	}
+	if len(repo.calls) != 1 || len(repo.calls[0]) != 2 {
+		t.Fatalf("发送给 ES 的子搜索 = %+v, want 1 次调用、2 个子搜索", repo.calls)
+	}
+	if len(results) != 3 {
+		t.Fatalf("返回 %d 个结果, want 3", len(results))
+	}
+	if results[0].Hits[0].Title != "kafka" || results[2].Hits[0].Title != "go" {
+		t.Errorf("结果顺序与请求不一致: %+v", results)
+	}
+	if len(results[1].Hits) != 0 || results[1].Total != 0 {
+		t.Errorf("不可搜索状态的子搜索应返回空结果: %+v", results[1])
+	}
+	if results[2].Page != 2 || results[2].Size != 5 {
+		t.Errorf("第 3 个结果的分页 = (%d, %d), want (2, 5)", results[2].Page, results[2].Size)
+	}
+}
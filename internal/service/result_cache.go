@@ -0,0 +1,150 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/metrics"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+// 结果缓存的默认参数，在配置缺失或无效时使用。
+const (
+	defaultResultCacheTTL        = 5 * time.Second
+	defaultResultCacheMaxEntries = 1000
+)
+
+// resultCacheRequests 统计搜索结果缓存的使用情况。
+// result 标签取值: hit、miss、bypass (请求不满足缓存条件或调用方要求绕过)。
+var resultCacheRequests = metrics.NewCounterVec(
+	"post_search_result_cache_requests_total",
+	"Search requests by result cache outcome.",
+	"result",
+)
+
+// bypassResultCacheKey 是标记 "本次搜索不使用结果缓存" 的 context 键。
+type bypassResultCacheKey struct{}
+
+// WithoutResultCache 返回一个要求 Search 绕过结果缓存的上下文。
+// API 层对已认证用户或 admin 请求调用它：这类请求可能依赖实时数据，不应看到其他请求缓存的结果。
+func WithoutResultCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassResultCacheKey{}, true)
+}
+
+func resultCacheBypassed(ctx context.Context) bool {
+	bypass, _ := ctx.Value(bypassResultCacheKey{}).(bool)
+	return bypass
+}
+
+// resultCache 是带 TTL 的 LRU 缓存，键为规范化后的 SearchRequest 的 JSON 表示。
+// 缓存的 *SearchResult 在多个请求之间共享，调用方不得修改返回的结果。
+type resultCache struct {
+	cfg config.ResultCacheConfig
+
+	mu      sync.Mutex
+	order   *list.List               // 最近使用的条目在前
+	entries map[string]*list.Element // 键 -> order 中的元素 (值为 *resultCacheEntry)
+}
+
+type resultCacheEntry struct {
+	key       string
+	result    *models.SearchResult
+	expiresAt time.Time
+}
+
+// newResultCache 根据配置创建结果缓存；未启用时返回 nil (nil 缓存的所有方法都是安全的空操作)。
+func newResultCache(cfg config.ResultCacheConfig) *resultCache {
+	if !cfg.Enabled {
+		return nil
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultResultCacheTTL
+	}
+	if cfg.MaxEntries <= 0 {
+		cfg.MaxEntries = defaultResultCacheMaxEntries
+	}
+	return &resultCache{
+		cfg:     cfg,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// keyFor 判断请求是否可以缓存，可以时返回缓存键。
+// req 必须已经过 SearchService 的规范化 (分页截断、默认排序、author_id 规范化)，保证等价请求得到相同的键。
+func (c *resultCache) keyFor(ctx context.Context, req models.SearchRequest) (string, bool) {
+	if c == nil || resultCacheBypassed(ctx) {
+		return "", false
+	}
+	// 浏览模式 (关键词为空) 的结果随新帖写入变化最快，且不属于 "热门搜索"，不缓存。
+	if strings.TrimSpace(req.Query) == "" {
+		return "", false
+	}
+	if !c.cfg.IncludeAllPages && req.Page > 1 {
+		return "", false
+	}
+	if !c.cfg.IncludeFiltered && hasSearchFilters(req) {
+		return "", false
+	}
+	key, err := json.Marshal(req)
+	if err != nil {
+		return "", false
+	}
+	// 不同租户的相同请求查询的是不同的索引，缓存键必须区分租户。
+	if tenant := repositories.TenantFromContext(ctx); tenant != "" {
+		return tenant + "|" + string(key), true
+	}
+	return string(key), true
+}
+
+// hasSearchFilters 判断请求是否带有关键词以外的筛选条件。
+func hasSearchFilters(req models.SearchRequest) bool {
+	return req.AuthorID != "" || req.Status != nil || req.CreatedFrom != nil || req.CreatedTo != nil ||
+		req.UpdatedFrom != nil || req.UpdatedTo != nil || req.Freshness != "" ||
+		len(req.ExcludeIDs) > 0 || len(req.Tags) > 0 || len(req.FilterGroups) > 0 ||
+		req.HasContactQRCode != nil || req.MinScore != nil || req.IncludeArchived
+}
+
+// get 返回未过期的缓存结果，并把条目移到最近使用的位置。
+func (c *resultCache) get(key string) (*models.SearchResult, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := elem.Value.(*resultCacheEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.order.Remove(elem)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return entry.result, true
+}
+
+// put 写入缓存，超出 MaxEntries 时淘汰最久未使用的条目。
+func (c *resultCache) put(key string, result *models.SearchResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiresAt := time.Now().Add(c.cfg.TTL)
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*resultCacheEntry)
+		entry.result = result
+		entry.expiresAt = expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&resultCacheEntry{key: key, result: result, expiresAt: expiresAt})
+	for c.order.Len() > c.cfg.MaxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*resultCacheEntry).key)
+	}
+}
@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+)
+
+func TestResultCacheKeyForFilteredRequests(t *testing.T) {
+	hasQRCode := false
+	minScore := 1.5
+	tests := []struct {
+		name      string
+		req       models.SearchRequest
+		wantCache bool
+	}{
+		{name: "只有关键词", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10}, wantCache: true},
+		{name: "按作者筛选", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10, AuthorID: "u1"}},
+		{name: "按标签筛选", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10, Tags: []string{"go"}}},
+		{name: "按是否有联系方式二维码筛选", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10, HasContactQRCode: &hasQRCode}},
+		{name: "相关性得分下限", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10, MinScore: &minScore}},
+		{name: "同时搜索归档索引", req: models.SearchRequest{Query: "kafka", Page: 1, Size: 10, IncludeArchived: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := newResultCache(config.ResultCacheConfig{Enabled: true})
+			if _, ok := cache.keyFor(context.Background(), tt.req); ok != tt.wantCache {
+				t.Errorf("未开启 IncludeFiltered 时 keyFor 可缓存 = %v, want %v", ok, tt.wantCache)
+			}
+
+			// 开启 IncludeFiltered 后带筛选条件的请求同样缓存。
+			cache = newResultCache(config.ResultCacheConfig{Enabled: true, IncludeFiltered: true})
+			if _, ok := cache.keyFor(context.Background(), tt.req); !ok {
+				t.Error("开启 IncludeFiltered 时 keyFor 应可缓存")
+			}
+		})
+	}
+}
@@ -2,30 +2,122 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings" // 导入 strings 包用于规范化查询
+	"time"
+	"unicode/utf8"
 
 	"github.com/Xushengqwer/go-common/core" // 确保这是你项目中 core 包的正确路径
+	"github.com/Xushengqwer/go-common/models/enums"
 
+	"github.com/Xushengqwer/post_search/config"
 	"github.com/Xushengqwer/post_search/internal/models"       // 确保 models 包路径正确
 	"github.com/Xushengqwer/post_search/internal/repositories" // 确保 repositories 包路径正确
 
 	"go.uber.org/zap"
 )
 
+// hardMaxPageSize 与 models.SearchRequest.Size 的 binding 标签 (max=100) 保持一致，
+// 是配置项 SearchConfig.MaxPageSize 允许设置的最大值。
+const hardMaxPageSize = 100
+
+// defaultMaxMgetIDs 是未配置 SearchConfig.MaxMgetIDs 时批量获取接口的默认 ID 数量上限。
+const defaultMaxMgetIDs = 100
+
+// 未配置默认排序时使用的排序字段与顺序，与引入可配置默认排序之前的行为一致。
+const (
+	defaultSortBy    = "updated_at"
+	defaultSortOrder = "desc"
+)
+
+// maxContactLookupResults 是按联系方式查询 (admin) 单次返回的最大帖子数量。
+const maxContactLookupResults = 100
+
+// 诊断接口 (GET /_recent) 的默认返回数量与默认上限。
+const (
+	defaultRecentLimit    = 20
+	defaultMaxRecentLimit = 50
+)
+
+// 未配置 SearchConfig.MaxQueryLength / MaxQueryTokens 时搜索关键词的默认上限。
+const (
+	defaultMaxQueryLength = 200
+	defaultMaxQueryTokens = 32
+)
+
+// 分页浏览热门搜索词 (admin) 的默认页大小，以及 from + size 的上限 (ES 默认的 index.max_result_window)。
+const (
+	defaultHotTermsPageSize = 20
+	maxHotTermsResultWindow = 10000
+)
+
+// 未配置 SearchConfig.HotSuggest 时热门搜索词联想的默认最小前缀长度与最大返回数量。
+const (
+	defaultMinSuggestPrefixLength = 2
+	defaultMaxSuggestResults      = 10
+)
+
+// defaultMaxMultiSearchRequests 是未配置 SearchConfig.MaxMultiSearchRequests 时批量搜索的默认子搜索数量上限。
+const defaultMaxMultiSearchRequests = 10
+
+// defaultMaxExcludeIDs 是未配置 SearchConfig.MaxExcludeIDs 时搜索请求排除列表的默认数量上限。
+const defaultMaxExcludeIDs = 100
+
+// ErrTooManyIDs 表示批量获取请求中的 ID 数量超过了配置的上限。
+// API 层可以使用 errors.Is 识别此错误并返回 400。
+var ErrTooManyIDs = errors.New("请求的帖子 ID 数量超过上限")
+
+// ErrTooManyExcludeIDs 表示搜索请求中需要排除的帖子 ID 数量超过了配置的上限。
+var ErrTooManyExcludeIDs = errors.New("需要排除的帖子 ID 数量超过上限")
+
+// ErrQueryTooLong 表示搜索关键词的字符数超过了配置的上限。
+var ErrQueryTooLong = errors.New("搜索关键词长度超过上限")
+
+// ErrHotTermsPageOutOfRange 表示分页浏览热门搜索词时 from + size 超出了 ES 允许的深度分页窗口。
+var ErrHotTermsPageOutOfRange = errors.New("热门搜索词分页超出可浏览范围")
+
+// ErrBadQuery、ErrESUnavailable 与 ErrSearchBusy 由仓库层返回，Search 包装错误时保留它们，
+// API 层无需依赖仓库包即可用 errors.Is 区分客户端导致的无效查询 (400)、ES 不可用 (503) 与并发搜索数达到上限 (503)。
+var (
+	ErrBadQuery      = repositories.ErrBadQuery
+	ErrESUnavailable = repositories.ErrESUnavailable
+	ErrSearchBusy    = repositories.ErrSearchBusy
+)
+
+// ErrSuggestPrefixTooShort 表示热门搜索词联想的前缀 (规范化后) 短于配置的最小长度。
+var ErrSuggestPrefixTooShort = errors.New("联想前缀长度不足")
+
+// ErrTooManyQueryTokens 表示搜索关键词切分后的词数超过了配置的上限。
+var ErrTooManyQueryTokens = errors.New("搜索关键词包含的词数超过上限")
+
+// ErrInvalidRefreshInterval 表示 refresh_interval 不是合法的时间值 (例如 "30s") 或 "-1"。
+var ErrInvalidRefreshInterval = errors.New("refresh_interval 格式无效")
+
+// refreshIntervalPattern 匹配 ES 接受的 refresh_interval 取值：带单位的时间值，或 "-1" (关闭自动刷新)。
+var refreshIntervalPattern = regexp.MustCompile(`^(-1|[0-9]+(ms|s|m|h|d))$`)
+
 // SearchService 封装了与帖子搜索相关的业务逻辑。
 // 它作为 API 处理层（例如 HTTP Handler）和数据仓库层 (Repository) 之间的中介，
 // 负责协调搜索请求的处理、调用数据访问操作，并可能执行一些业务规则或数据转换。
 type SearchService struct {
-	postRepo          repositories.PostRepository          // PostRepository 接口的实例，用于与 Elasticsearch 交互帖子数据。
-	hotSearchTermRepo repositories.HotSearchTermRepository // 新增：HotSearchTermRepository 接口的实例，用于热门搜索词统计。
-	logger            *core.ZapLogger                      // ZapLogger 实例，用于结构化日志记录。
+	postRepo           repositories.PostRepository          // PostRepository 接口的实例，用于与 Elasticsearch 交互帖子数据。
+	hotSearchTermRepo  repositories.HotSearchTermRepository // 新增：HotSearchTermRepository 接口的实例，用于热门搜索词统计。
+	cfg                config.SearchConfig                  // 搜索业务配置，例如服务端生效的最大分页大小。
+	lowercaseAuthorID  bool                                 // 是否将 author_id 筛选值转为小写，与索引侧 (IndexingConfig) 保持一致。
+	resultCache        *resultCache                         // 热门搜索结果缓存，未启用时为 nil。
+	searchableStatuses []enums.Status                       // 非 admin 请求可见的帖子状态。
+	hotTermSampler     hotTermSampler                       // 热门搜索词计数采样。
+	logger             *core.ZapLogger                      // ZapLogger 实例，用于结构化日志记录。
 }
 
 // NewSearchService 创建 SearchService 的一个新实例。
 // 参数:
 //   - postRepo: 一个已经初始化并准备好的 PostRepository 实例。
 //   - hotSearchTermRepo: 一个已经初始化并准备好的 HotSearchTermRepository 实例。
+//   - cfg: 搜索业务配置。无效值会被替换为安全的默认值。
+//   - indexingCfg: 索引写入配置，用于让 author_id 筛选值的规范化方式与索引侧一致。
 //   - logger: 一个注入的 Logger 实例，用于服务内部的日志记录。
 //
 // 返回值:
@@ -33,6 +125,8 @@ type SearchService struct {
 func NewSearchService(
 	postRepo repositories.PostRepository,
 	hotSearchTermRepo repositories.HotSearchTermRepository, // 新增参数
+	cfg config.SearchConfig,
+	indexingCfg config.IndexingConfig,
 	logger *core.ZapLogger,
 ) *SearchService {
 	if logger == nil {
@@ -45,17 +139,88 @@ func NewSearchService(
 		logger.Fatal("创建 SearchService 失败：HotSearchTermRepository 实例不能为 nil。服务将无法处理热门搜索词功能。")
 	}
 
-	logger.Info("SearchService 初始化成功 (包含热门搜索词支持)。")
+	if cfg.MaxPageSize <= 0 || cfg.MaxPageSize > hardMaxPageSize {
+		logger.Warn("配置中的最大分页大小 (searchConfig.maxPageSize) 无效或超出硬上限，将使用硬上限。",
+			zap.Int("configured_max_page_size", cfg.MaxPageSize),
+			zap.Int("hard_max_page_size", hardMaxPageSize),
+		)
+		cfg.MaxPageSize = hardMaxPageSize
+	}
+	if cfg.MaxMgetIDs <= 0 {
+		cfg.MaxMgetIDs = defaultMaxMgetIDs
+	}
+	if cfg.MaxMultiSearchRequests <= 0 {
+		cfg.MaxMultiSearchRequests = defaultMaxMultiSearchRequests
+	}
+	if cfg.MaxExcludeIDs <= 0 {
+		cfg.MaxExcludeIDs = defaultMaxExcludeIDs
+	}
+	if cfg.MaxQueryLength <= 0 {
+		cfg.MaxQueryLength = defaultMaxQueryLength
+	}
+	if cfg.MaxQueryTokens <= 0 {
+		cfg.MaxQueryTokens = defaultMaxQueryTokens
+	}
+	if cfg.HotSuggest.MinPrefixLength <= 0 {
+		cfg.HotSuggest.MinPrefixLength = defaultMinSuggestPrefixLength
+	}
+	if cfg.HotSuggest.MaxResults <= 0 {
+		cfg.HotSuggest.MaxResults = defaultMaxSuggestResults
+	}
+	if cfg.ZeroResultFallback.Size <= 0 || cfg.ZeroResultFallback.Size > cfg.MaxPageSize {
+		cfg.ZeroResultFallback.Size = min(defaultZeroResultFallbackSize, cfg.MaxPageSize)
+	}
+	if cfg.UnifiedSearch.TermSuggestionLimit <= 0 {
+		cfg.UnifiedSearch.TermSuggestionLimit = defaultTermSuggestionLimit
+	}
+	if cfg.UnifiedSearch.TermSuggestionTimeout <= 0 {
+		cfg.UnifiedSearch.TermSuggestionTimeout = defaultTermSuggestionTimeout
+	}
+	cfg.DefaultSort = normalizeSortConfig(cfg.DefaultSort, "searchConfig.defaultSort", logger)
+	cfg.BrowseSort = normalizeSortConfig(cfg.BrowseSort, "searchConfig.browseSort", logger)
+
+	logger.Info("SearchService 初始化成功 (包含热门搜索词支持)。",
+		zap.Int("max_page_size", cfg.MaxPageSize),
+		zap.Bool("result_cache_enabled", cfg.ResultCache.Enabled),
+	)
 	return &SearchService{
-		postRepo:          postRepo,
-		hotSearchTermRepo: hotSearchTermRepo, // 初始化新字段
-		logger:            logger,
+		postRepo:           postRepo,
+		hotSearchTermRepo:  hotSearchTermRepo, // 初始化新字段
+		cfg:                cfg,
+		lowercaseAuthorID:  indexingCfg.LowercaseAuthorID,
+		resultCache:        newResultCache(cfg.ResultCache),
+		searchableStatuses: normalizeSearchableStatuses(cfg.SearchableStatuses, logger),
+		hotTermSampler:     newHotTermSampler(cfg.HotTermSampleRate, logger),
+		logger:             logger,
 	}
 }
 
 // Search 根据提供的请求条件执行帖子搜索操作。
 // ... (您现有的 Search 方法保持不变，它只负责帖子搜索的核心逻辑) ...
 func (s *SearchService) Search(ctx context.Context, req models.SearchRequest) (*models.SearchResult, error) { // [cite: post_search/internal/service/search_service.go]
+	visible, err := s.prepareSearch(ctx, &req)
+	if err != nil {
+		return nil, err
+	}
+	// 可搜索状态限制：客户端请求了不可见的状态时，结果必然为空，无需查询 ES。
+	if !visible {
+		s.logger.Info("请求的帖子状态不在公开搜索可见范围内，返回空结果", zap.Any("请求状态", *req.Status))
+		return emptySearchResult(req), nil
+	}
+
+	// 结果缓存：键基于规范化后的请求，因此放在分页截断和默认排序之后。
+	cacheKey, cacheable := s.resultCache.keyFor(ctx, req)
+	if cacheable {
+		if cached, ok := s.resultCache.get(cacheKey); ok {
+			resultCacheRequests.Inc("hit")
+			s.logger.Debug("搜索结果缓存命中", zap.String("搜索关键词", req.Query), zap.Int("请求页码", req.Page))
+			return cached, nil
+		}
+		resultCacheRequests.Inc("miss")
+	} else if s.resultCache != nil {
+		resultCacheRequests.Inc("bypass")
+	}
+
 	logFields := []zap.Field{
 		zap.String("搜索关键词", req.Query),
 		zap.Int("请求页码", req.Page),
@@ -68,6 +233,11 @@ func (s *SearchService) Search(ctx context.Context, req models.SearchRequest) (*
 	}
 	if req.Status != nil {
 		logFields = append(logFields, zap.Any("筛选_状态", *req.Status))
+	} else if len(req.Statuses) > 0 {
+		logFields = append(logFields, zap.Any("可搜索状态", req.Statuses))
+	}
+	if len(req.ExcludeIDs) > 0 {
+		logFields = append(logFields, zap.Int("排除_ID数量", len(req.ExcludeIDs)))
 	}
 	s.logger.Info("正在处理帖子搜索请求", logFields...)
 
@@ -89,13 +259,154 @@ func (s *SearchService) Search(ctx context.Context, req models.SearchRequest) (*
 		zap.Int64("查询耗时_ms", searchResult.Took),
 	)
 
+	s.attachZeroResultFallback(ctx, req, searchResult)
+
+	if cacheable {
+		s.resultCache.put(cacheKey, searchResult)
+	}
 	return searchResult, nil
 }
 
+// prepareSearch 校验并规范化搜索请求：检查排除 ID 数量与关键词上限，规范化作者 ID，截断分页大小，
+// 填充默认排序与时间窗口，并施加可搜索状态限制。
+// 返回 false 表示请求的状态对当前调用方不可见，结果必然为空，调用方无需查询 ES。
+func (s *SearchService) prepareSearch(ctx context.Context, req *models.SearchRequest) (bool, error) {
+	if len(req.ExcludeIDs) > s.cfg.MaxExcludeIDs {
+		s.logger.Warn("搜索请求中需要排除的 ID 数量超过上限",
+			zap.Int("exclude_ids_count", len(req.ExcludeIDs)),
+			zap.Int("max_exclude_ids", s.cfg.MaxExcludeIDs),
+		)
+		return false, fmt.Errorf("%w: 请求 %d 个，上限 %d 个", ErrTooManyExcludeIDs, len(req.ExcludeIDs), s.cfg.MaxExcludeIDs)
+	}
+	if err := s.CheckQueryLimits(req.Query); err != nil {
+		return false, err
+	}
+
+	// 规范化作者 ID，与索引侧写入 author_id 时的处理方式一致 (去除空白，按配置转为小写)。
+	req.AuthorID = models.NormalizeAuthorID(req.AuthorID, s.lowercaseAuthorID)
+	req.FilterGroups = models.NormalizeFilterGroupAuthorIDs(req.FilterGroups, s.lowercaseAuthorID)
+
+	// 服务端分页上限：binding 标签只做硬性校验，这里按配置把超大的 size 截断到生效上限。
+	if req.Size > s.cfg.MaxPageSize {
+		s.logger.Info("请求的每页数量超过服务端上限，已截断",
+			zap.Int("requested_size", req.Size),
+			zap.Int("clamped_size", s.cfg.MaxPageSize),
+		)
+		req.Size = s.cfg.MaxPageSize
+	}
+
+	s.applyDefaultSort(req)
+	applyFreshness(req, time.Now())
+	return s.applySearchableStatuses(ctx, req), nil
+}
+
+// emptySearchResult 返回不查询 ES 时使用的空结果 (保留请求的分页信息)。
+func emptySearchResult(req models.SearchRequest) *models.SearchResult {
+	return &models.SearchResult{Hits: []models.EsPostDocument{}, Page: req.Page, Size: req.Size}
+}
+
+// applyDefaultSort 在客户端未指定 sort_by 时填充默认排序。
+// 没有正向关键词时视为浏览模式，使用 BrowseSort；否则使用 DefaultSort。客户端显式指定的 sort_by 始终优先。
+// 判断依据与 buildSearchQuery 一致：只包含排除词 (例如 "-kafka") 或仅有过滤条件 (例如只传 author_id)
+// 的请求会执行 match_all，所有文档得分相同，此时按相关性排序没有意义。
+func (s *SearchService) applyDefaultSort(req *models.SearchRequest) {
+	if req.SortBy == "" {
+		def := s.cfg.DefaultSort
+		if positiveQuery, _ := models.SplitExclusionTerms(req.Query); positiveQuery == "" {
+			def = s.cfg.BrowseSort
+		}
+		req.SortBy = def.SortBy
+		if req.SortOrder == "" {
+			req.SortOrder = def.SortOrder
+		}
+	}
+	if req.SortOrder == "" {
+		req.SortOrder = defaultSortOrder
+	}
+}
+
+// normalizeSortConfig 校验默认排序配置，字段不在可排序白名单中或顺序无效时回退为 updated_at desc。
+func normalizeSortConfig(sc config.SortConfig, configKey string, logger *core.ZapLogger) config.SortConfig {
+	if sc.SortBy == "" {
+		sc.SortBy = defaultSortBy
+	} else if !models.IsSortableField(sc.SortBy) {
+		logger.Warn("配置的默认排序字段不可排序，将使用默认值",
+			zap.String("config_key", configKey),
+			zap.String("configured_sort_by", sc.SortBy),
+			zap.String("fallback_sort_by", defaultSortBy),
+		)
+		sc.SortBy = defaultSortBy
+	}
+	if sc.SortOrder != "asc" && sc.SortOrder != "desc" {
+		sc.SortOrder = defaultSortOrder
+	}
+	return sc
+}
+
+// GetPostsByIDs 批量获取指定 ID 的帖子文档，返回顺序与请求顺序一致，不存在的 ID 会被省略。
+// 与 Search 一致，非 admin 请求只返回可搜索状态 (searchConfig.searchableStatuses) 的帖子，其他状态的帖子与不存在的 ID 一样被省略。
+// 如果 ID 数量超过配置的上限，返回包装了 ErrTooManyIDs 的错误。
+func (s *SearchService) GetPostsByIDs(ctx context.Context, ids []uint64) ([]models.EsPostDocument, error) {
+	if len(ids) > s.cfg.MaxMgetIDs {
+		s.logger.Warn("批量获取帖子的 ID 数量超过上限",
+			zap.Int("requested_ids_count", len(ids)),
+			zap.Int("max_mget_ids", s.cfg.MaxMgetIDs),
+		)
+		return nil, fmt.Errorf("%w: 请求 %d 个，上限 %d 个", ErrTooManyIDs, len(ids), s.cfg.MaxMgetIDs)
+	}
+
+	docs, err := s.postRepo.GetPostsByIDs(ctx, ids)
+	if err != nil {
+		s.logger.Error("调用 PostRepository 批量获取帖子失败", zap.Int("requested_ids_count", len(ids)), zap.Error(err))
+		return nil, fmt.Errorf("批量获取帖子失败: %w", err)
+	}
+	return s.filterSearchableDocs(ctx, docs), nil
+}
+
+// MaxMgetIDs 返回批量获取接口生效的 ID 数量上限，供 API 层生成校验错误信息。
+func (s *SearchService) MaxMgetIDs() int {
+	return s.cfg.MaxMgetIDs
+}
+
+// MaxExcludeIDs 返回搜索请求排除列表生效的数量上限，供 API 层生成校验错误信息。
+func (s *SearchService) MaxExcludeIDs() int {
+	return s.cfg.MaxExcludeIDs
+}
+
+// CheckQueryLimits 检查搜索关键词的长度和词数是否超过配置的上限。
+// 只有空白的关键词视为浏览模式 (match_all)，不受限制。
+// API 层在异步记录热门搜索词之前调用它，避免把异常的超长关键词计入统计；Search 内部也会再次检查。
+func (s *SearchService) CheckQueryLimits(query string) error {
+	trimmed := strings.TrimSpace(query)
+	if trimmed == "" {
+		return nil
+	}
+	if length := utf8.RuneCountInString(trimmed); length > s.cfg.MaxQueryLength {
+		s.logger.Warn("搜索关键词长度超过上限", zap.Int("query_length", length), zap.Int("max_query_length", s.cfg.MaxQueryLength))
+		return fmt.Errorf("%w: %d 个字符，上限 %d 个", ErrQueryTooLong, length, s.cfg.MaxQueryLength)
+	}
+	if tokens := len(strings.Fields(trimmed)); tokens > s.cfg.MaxQueryTokens {
+		s.logger.Warn("搜索关键词词数超过上限", zap.Int("query_tokens", tokens), zap.Int("max_query_tokens", s.cfg.MaxQueryTokens))
+		return fmt.Errorf("%w: %d 个词，上限 %d 个", ErrTooManyQueryTokens, tokens, s.cfg.MaxQueryTokens)
+	}
+	return nil
+}
+
+// MaxQueryLength 返回搜索关键词允许的最大字符数 (已填充默认值)。
+func (s *SearchService) MaxQueryLength() int {
+	return s.cfg.MaxQueryLength
+}
+
+// MaxQueryTokens 返回搜索关键词允许的最大词数 (已填充默认值)。
+func (s *SearchService) MaxQueryTokens() int {
+	return s.cfg.MaxQueryTokens
+}
+
 // --- 新增服务方法 ---
 
 // LogSearchQuery 记录一个搜索查询，用于热门搜索词分析。
 // 它会规范化查询字符串，然后调用 HotSearchTermRepository 来递增该词的计数。
+// 配置了采样率 (SearchConfig.HotTermSampleRate) 时，只有被采样的搜索会计数，增量按采样率放大。
 func (s *SearchService) LogSearchQuery(ctx context.Context, query string) error {
 	// 1. 规范化查询字符串
 	//    - 转换为小写，以确保 "Go" 和 "go" 被视为同一个词。
@@ -108,14 +419,21 @@ func (s *SearchService) LogSearchQuery(ctx context.Context, query string) error
 		return nil // 对于空查询，不执行任何操作，也不报错
 	}
 
-	// 3. 记录将要递增计数的词
+	// 3. 采样：未被采样的搜索不写入 ES
+	increment := s.hotTermSampler.sample()
+	if increment == 0 {
+		return nil
+	}
+
+	// 4. 记录将要递增计数的词
 	s.logger.Debug("准备记录并递增搜索词计数",
 		zap.String("original_query", query),
 		zap.String("normalized_query_to_log", normalizedQuery),
+		zap.Int64("increment", increment),
 	)
 
-	// 4. 调用 HotSearchTermRepository 的方法
-	err := s.hotSearchTermRepo.IncrementSearchTermCount(ctx, normalizedQuery)
+	// 5. 调用 HotSearchTermRepository 的方法
+	err := s.hotSearchTermRepo.IncrementSearchTermCount(ctx, normalizedQuery, increment)
 	if err != nil {
 		s.logger.Error("调用 HotSearchTermRepository 递增搜索词计数失败",
 			zap.String("normalized_query", normalizedQuery),
@@ -131,17 +449,18 @@ func (s *SearchService) LogSearchQuery(ctx context.Context, query string) error
 	return nil
 }
 
-// GetHotSearchTerms 从 HotSearchTermRepository 检索热门搜索词列表。
-func (s *SearchService) GetHotSearchTerms(ctx context.Context, limit int) ([]models.HotSearchTerm, error) {
-	s.logger.Info("服务层：正在请求获取热门搜索词列表", zap.Int("limit", limit))
+// GetHotSearchTerms 从 HotSearchTermRepository 检索热门搜索词列表，sort 为 models.HotTermsSort* 之一 (为空时按计数排序)。
+func (s *SearchService) GetHotSearchTerms(ctx context.Context, limit int, sort string) ([]models.HotSearchTerm, error) {
+	s.logger.Info("服务层：正在请求获取热门搜索词列表", zap.Int("limit", limit), zap.String("sort", sort))
 
-	terms, err := s.hotSearchTermRepo.GetHotSearchTerms(ctx, limit)
+	terms, err := s.hotSearchTermRepo.GetHotSearchTerms(ctx, limit, sort)
 	if err != nil {
 		s.logger.Error("调用 HotSearchTermRepository 获取热门搜索词列表失败",
 			zap.Int("limit", limit),
+			zap.String("sort", sort),
 			zap.Error(err),
 		)
-		return nil, fmt.Errorf("获取热门搜索词列表失败 (limit: %d): %w", limit, err)
+		return nil, fmt.Errorf("获取热门搜索词列表失败 (limit: %d, sort: %s): %w", limit, sort, err)
 	}
 
 	s.logger.Info("服务层：成功获取热门搜索词列表",
@@ -150,3 +469,182 @@ func (s *SearchService) GetHotSearchTerms(ctx context.Context, limit int) ([]mod
 	)
 	return terms, nil
 }
+
+// SearchVolume 统计最近 window 内的搜索量 (近似值，见 models.SearchVolume)；window <= 0 时统计全部搜索词的累计计数。
+func (s *SearchService) SearchVolume(ctx context.Context, window time.Duration) (*models.SearchVolume, error) {
+	var since time.Time
+	windowDesc := "all"
+	if window > 0 {
+		since = time.Now().UTC().Add(-window)
+		windowDesc = formatWindow(window)
+	}
+
+	volume, err := s.hotSearchTermRepo.GetSearchVolume(ctx, since)
+	if err != nil {
+		s.logger.Error("调用 HotSearchTermRepository 统计搜索量失败", zap.String("window", windowDesc), zap.Error(err))
+		return nil, fmt.Errorf("统计搜索量失败 (window: %s): %w", windowDesc, err)
+	}
+	volume.Window = windowDesc
+	if !since.IsZero() {
+		volume.Since = &since
+	}
+	return volume, nil
+}
+
+// formatWindow 返回时间窗口的简短表示，去掉 Duration.String 末尾为零的分钟和秒 (24h0m0s -> 24h)。
+func formatWindow(window time.Duration) string {
+	text := window.String()
+	if strings.HasSuffix(text, "m0s") {
+		text = strings.TrimSuffix(text, "0s")
+	}
+	if strings.HasSuffix(text, "h0m") {
+		text = strings.TrimSuffix(text, "0m")
+	}
+	return text
+}
+
+// ListHotSearchTerms 分页返回全部热门搜索词及其总数，供 admin 界面浏览。
+// size <= 0 时使用默认页大小，超过 hardMaxPageSize 时截断；from + size 超过 ES 深度分页窗口时返回 ErrHotTermsPageOutOfRange。
+func (s *SearchService) ListHotSearchTerms(ctx context.Context, from, size int) (*models.HotSearchTermPage, error) {
+	if from < 0 {
+		from = 0
+	}
+	if size <= 0 {
+		size = defaultHotTermsPageSize
+	} else if size > hardMaxPageSize {
+		size = hardMaxPageSize
+	}
+	if from+size > maxHotTermsResultWindow {
+		return nil, fmt.Errorf("%w: from + size 不能超过 %d", ErrHotTermsPageOutOfRange, maxHotTermsResultWindow)
+	}
+
+	page, err := s.hotSearchTermRepo.ListHotSearchTerms(ctx, from, size)
+	if err != nil {
+		s.logger.Error("调用 HotSearchTermRepository 分页获取热门搜索词失败",
+			zap.Int("from", from),
+			zap.Int("size", size),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("分页获取热门搜索词失败 (from: %d, size: %d): %w", from, size, err)
+	}
+	return page, nil
+}
+
+// SuggestHotTerms 返回以 prefix 开头的热门搜索词，按搜索次数倒序。
+// prefix 按与 LogSearchQuery 相同的方式规范化，短于配置的最小长度时返回 ErrSuggestPrefixTooShort；
+// limit <= 0 或超过配置的上限时使用上限。
+func (s *SearchService) SuggestHotTerms(ctx context.Context, prefix string, limit int) ([]models.HotSearchTerm, error) {
+	normalizedPrefix := strings.TrimSpace(strings.ToLower(prefix))
+	if utf8.RuneCountInString(normalizedPrefix) < s.cfg.HotSuggest.MinPrefixLength {
+		return nil, fmt.Errorf("%w: 至少需要 %d 个字符", ErrSuggestPrefixTooShort, s.cfg.HotSuggest.MinPrefixLength)
+	}
+	if limit <= 0 || limit > s.cfg.HotSuggest.MaxResults {
+		limit = s.cfg.HotSuggest.MaxResults
+	}
+
+	terms, err := s.hotSearchTermRepo.SuggestHotTerms(ctx, normalizedPrefix, limit)
+	if err != nil {
+		s.logger.Error("调用 HotSearchTermRepository 联想热门搜索词失败",
+			zap.String("prefix", normalizedPrefix),
+			zap.Error(err),
+		)
+		return nil, fmt.Errorf("联想热门搜索词失败 (prefix: %s): %w", normalizedPrefix, err)
+	}
+	return terms, nil
+}
+
+// MinSuggestPrefixLength 返回热门搜索词联想生效的最小前缀长度，供 API 层生成错误信息。
+func (s *SearchService) MinSuggestPrefixLength() int {
+	return s.cfg.HotSuggest.MinPrefixLength
+}
+
+// IndexStats 汇总帖子索引和热门搜索词索引的统计信息 (文档数量、存储大小、分片数)。
+func (s *SearchService) IndexStats(ctx context.Context) (*models.IndexStats, error) {
+	postsStats, err := s.postRepo.IndexStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取帖子索引统计信息失败: %w", err)
+	}
+	hotTermsStats, err := s.hotSearchTermRepo.IndexStats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("获取热门搜索词索引统计信息失败: %w", err)
+	}
+
+	s.logger.Debug("服务层：成功获取索引统计信息",
+		zap.Int64("posts_doc_count", postsStats.DocCount),
+		zap.Int64("hot_terms_doc_count", hotTermsStats.DocCount),
+	)
+	return &models.IndexStats{
+		Posts:    *postsStats,
+		HotTerms: *hotTermsStats,
+	}, nil
+}
+
+// RefreshIndex 刷新帖子索引，使此前写入的帖子立即可被搜索。
+func (s *SearchService) RefreshIndex(ctx context.Context) (*models.IndexRefreshResult, error) {
+	result, err := s.postRepo.RefreshIndex(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("刷新帖子索引失败: %w", err)
+	}
+	return result, nil
+}
+
+// SetRefreshInterval 动态调整帖子索引的 refresh_interval，interval 为空时恢复为配置值。
+// 格式不合法时返回 ErrInvalidRefreshInterval。
+func (s *SearchService) SetRefreshInterval(ctx context.Context, interval string) (*models.IndexRefreshIntervalResult, error) {
+	interval = strings.TrimSpace(interval)
+	if interval != "" && !refreshIntervalPattern.MatchString(interval) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidRefreshInterval, interval)
+	}
+	result, err := s.postRepo.UpdateRefreshInterval(ctx, interval)
+	if err != nil {
+		return nil, fmt.Errorf("更新帖子索引 refresh_interval 失败: %w", err)
+	}
+	return result, nil
+}
+
+// Readiness 检查服务依赖的各个索引是否就绪，并逐项返回结果。
+// 帖子索引与热门搜索词索引分别列出，便于区分是哪一个索引出现问题；任意一项不健康时整体为未就绪。
+func (s *SearchService) Readiness(ctx context.Context) *models.ReadinessReport {
+	postsDep := s.postRepo.IndexHealth(ctx)
+	postsDep.Name = "posts_index"
+	hotTermsDep := s.hotSearchTermRepo.IndexHealth(ctx)
+	hotTermsDep.Name = "hot_terms_index"
+
+	return &models.ReadinessReport{
+		Ready:        postsDep.Healthy && hotTermsDep.Healthy,
+		Dependencies: []models.DependencyStatus{postsDep, hotTermsDep},
+	}
+}
+
+// RecentPosts 返回最近被索引的帖子，用于排查 Kafka -> ES 的索引流程。
+// limit <= 0 时使用默认值 20，超过 SearchConfig.MaxRecentLimit 时截断到上限。
+func (s *SearchService) RecentPosts(ctx context.Context, limit int) ([]models.EsPostDocument, error) {
+	maxLimit := s.cfg.MaxRecentLimit
+	if maxLimit <= 0 {
+		maxLimit = defaultMaxRecentLimit
+	}
+	if limit <= 0 {
+		limit = defaultRecentLimit
+	}
+	if limit > maxLimit {
+		limit = maxLimit
+	}
+
+	docs, err := s.postRepo.GetRecentPosts(ctx, limit)
+	if err != nil {
+		s.logger.Error("调用 PostRepository 获取最近索引的帖子失败", zap.Int("limit", limit), zap.Error(err))
+		return nil, fmt.Errorf("获取最近索引的帖子失败 (limit: %d): %w", limit, err)
+	}
+	return docs, nil
+}
+
+// FindPostsByContact 按联系方式精确查找帖子 (admin)。联系方式两端的空白会被去除；
+// 结果最多返回 maxContactLookupResults 条，调用方 (审计日志) 负责记录访问。
+func (s *SearchService) FindPostsByContact(ctx context.Context, contact string) ([]models.EsPostDocument, error) {
+	contact = strings.TrimSpace(contact)
+	docs, err := s.postRepo.FindPostsByContact(ctx, contact, maxContactLookupResults)
+	if err != nil {
+		return nil, fmt.Errorf("按联系方式查询帖子失败: %w", err)
+	}
+	return docs, nil
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	commonconfig "github.com/Xushengqwer/go-common/config"
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/post_search/config"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"github.com/Xushengqwer/post_search/internal/repositories"
+)
+
+func newTestLogger(t *testing.T) *core.ZapLogger {
+	t.Helper()
+	logger, err := core.NewZapLogger(commonconfig.ZapConfig{Level: "error", Encoding: "console"})
+	if err != nil {
+		t.Fatalf("创建测试 logger 失败: %v", err)
+	}
+	return logger
+}
+
+// fakeSearchRepo 是只实现 SearchPosts 的 PostRepository：用与线上相同的 SearchQueryBuilder 生成请求体并记录下来，
+// 未覆盖的方法会因嵌入的 nil 接口而 panic。
+type fakeSearchRepo struct {
+	repositories.PostRepository
+
+	builder  *repositories.SearchQueryBuilder
+	requests []models.SearchRequest
+	bodies   [][]byte
+}
+
+func (r *fakeSearchRepo) SearchPosts(_ context.Context, req models.SearchRequest) (*models.SearchResult, error) {
+	body, err := r.builder.SearchBody(req)
+	if err != nil {
+		return nil, err
+	}
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	return &models.SearchResult{Hits: []models.EsPostDocument{}, Page: req.Page, Size: req.Size}, nil
+}
+
+// fakeHotTermsRepo 是不会被调用的 HotSearchTermRepository，仅满足 NewSearchService 的非 nil 检查。
+type fakeHotTermsRepo struct {
+	repositories.HotSearchTermRepository
+}
+
+// newTestSearchService 返回使用 cfg 与 indexingCfg 的 SearchService 及其记录请求的假仓库。
+func newTestSearchService(t *testing.T, cfg config.SearchConfig, indexingCfg config.IndexingConfig) (*SearchService, *fakeSearchRepo) {
+	t.Helper()
+	logger := newTestLogger(t)
+	repo := &fakeSearchRepo{builder: repositories.NewSearchQueryBuilder(cfg, config.IndexSpecificConfig{}, logger)}
+	return NewSearchService(repo, &fakeHotTermsRepo{}, cfg, indexingCfg, logger), repo
+}
+
+// assertJSONEqual 比较 got 与 want 两段 JSON 是否语义相同 (忽略键顺序与空白)。
+func assertJSONEqual(t *testing.T, got []byte, want string) {
+	t.Helper()
+	var gotValue, wantValue interface{}
+	if err := json.Unmarshal(got, &gotValue); err != nil {
+		t.Fatalf("解析实际 JSON 失败: %v\n%s", err, got)
+	}
+	if err := json.Unmarshal([]byte(want), &wantValue); err != nil {
+		t.Fatalf("解析期望 JSON 失败: %v\n%s", err, want)
+	}
+	gotNormalized, _ := json.Marshal(gotValue)
+	wantNormalized, _ := json.Marshal(wantValue)
+	if !bytes.Equal(gotNormalized, wantNormalized) {
+		t.Errorf("JSON 不一致:\n got: %s\nwant: %s", gotNormalized, wantNormalized)
+	}
+}
+
+func TestSearchAuthorFilterSort(t *testing.T) {
+	cfg := config.SearchConfig{
+		DefaultSort: config.SortConfig{SortBy: "_score", SortOrder: "desc"},
+		BrowseSort:  config.SortConfig{SortBy: "updated_at", SortOrder: "desc"},
+	}
+	tests := []struct {
+		name      string
+		req       models.SearchRequest
+		wantQuery string
+		wantSort  string
+	}{
+		{
+			name: "关键词加作者筛选使用默认排序 (相关性)",
+			req:  models.SearchRequest{Query: "kafka", AuthorID: "author-1", Page: 1, Size: 10},
+			wantQuery: `{"bool": {
+				"must": {"multi_match": {"query": "kafka", "fields": ["author_username^1", "content^1", "title^3"], "type": "best_fields"}},
+				"filter": [{"term": {"author_id": "author-1"}}]
+			}}`,
+			wantSort: `[
+				{"_score": {"order": "desc"}},
+				{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+			]`,
+		},
+		{
+			name: "只按作者筛选时使用浏览模式默认排序",
+			req:  models.SearchRequest{AuthorID: "author-1", Page: 1, Size: 10},
+			wantQuery: `{"bool": {
+				"must": {"match_all": {}},
+				"filter": [{"term": {"author_id": "author-1"}}]
+			}}`,
+			wantSort: `[
+				{"updated_at": {"order": "desc", "missing": "_last", "unmapped_type": "date"}},
+				{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+			]`,
+		},
+		{
+			name: "只有排除词时视为浏览模式",
+			req:  models.SearchRequest{Query: "-kafka", AuthorID: "author-1", Page: 1, Size: 10},
+			wantQuery: `{"bool": {
+				"must": {"match_all": {}},
+				"filter": [{"term": {"author_id": "author-1"}}],
+				"must_not": [{"multi_match": {"query": "kafka", "fields": ["author_username^1", "content^1", "title^3"], "type": "best_fields"}}]
+			}}`,
+			wantSort: `[
+				{"updated_at": {"order": "desc", "missing": "_last", "unmapped_type": "date"}},
+				{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+			]`,
+		},
+		{
+			name: "客户端显式按相关性排序时仍追加 id 次级排序",
+			req:  models.SearchRequest{AuthorID: "author-1", Page: 1, Size: 10, SortBy: "_score"},
+			wantQuery: `{"bool": {
+				"must": {"match_all": {}},
+				"filter": [{"term": {"author_id": "author-1"}}]
+			}}`,
+			wantSort: `[
+				{"_score": {"order": "desc"}},
+				{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}
+			]`,
+		},
+		{
+			name: "按 id 排序时没有次级排序",
+			req:  models.SearchRequest{AuthorID: "author-1", Page: 1, Size: 10, SortBy: "id", SortOrder: "asc"},
+			wantQuery: `{"bool": {
+				"must": {"match_all": {}},
+				"filter": [{"term": {"author_id": "author-1"}}]
+			}}`,
+			wantSort: `[{"id": {"order": "asc", "missing": "_last", "unmapped_type": "unsigned_long"}}]`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestSearchService(t, cfg, config.IndexingConfig{})
+
+			// admin 请求不附加可搜索状态过滤，便于只检查作者筛选与排序。
+			if _, err := svc.Search(WithAdminAccess(context.Background()), tt.req); err != nil {
+				t.Fatalf("Search 返回错误: %v", err)
+			}
+			if len(repo.bodies) != 1 {
+				t.Fatalf("SearchPosts 调用了 %d 次, want 1", len(repo.bodies))
+			}
+			var body map[string]json.RawMessage
+			if err := json.Unmarshal(repo.bodies[0], &body); err != nil {
+				t.Fatalf("解析请求体失败: %v", err)
+			}
+			assertJSONEqual(t, body["query"], tt.wantQuery)
+			assertJSONEqual(t, body["sort"], tt.wantSort)
+		})
+	}
+}
+
+func TestSearchNormalizesAuthorID(t *testing.T) {
+	tests := []struct {
+		name      string
+		req       models.SearchRequest
+		lowercase bool
+		want      string
+		wantGroup interface{}
+	}{
+		{
+			name: "去除两端空白",
+			req:  models.SearchRequest{AuthorID: "  Author1 \t"},
+			want: "Author1",
+		},
+		{
+			name:      "开启小写",
+			req:       models.SearchRequest{AuthorID: " Author1 "},
+			lowercase: true,
+			want:      "author1",
+		},
+		{
+			name: "过滤条件组中的 author_id 同样规范化",
+			req: models.SearchRequest{FilterGroups: []models.FilterGroup{{Should: []models.FilterCondition{
+				{Field: "author_id", Op: models.FilterOpIn, Value: []interface{}{" Author1", "AUTHOR2 "}},
+			}}}},
+			lowercase: true,
+			wantGroup: []interface{}{"author1", "author2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, repo := newTestSearchService(t, config.SearchConfig{}, config.IndexingConfig{LowercaseAuthorID: tt.lowercase})
+			tt.req.Page, tt.req.Size = 1, 10
+			if _, err := svc.Search(context.Background(), tt.req); err != nil {
+				t.Fatalf("Search 返回错误: %v", err)
+			}
+			got := repo.requests[0]
+			if got.AuthorID != tt.want {
+				t.Errorf("AuthorID = %q, want %q", got.AuthorID, tt.want)
+			}
+			if tt.wantGroup != nil {
+				if value := got.FilterGroups[0].Should[0].Value; !reflect.DeepEqual(value, tt.wantGroup) {
+					t.Errorf("过滤条件组的 author_id = %#v, want %#v", value, tt.wantGroup)
+				}
+			}
+		})
+	}
+}
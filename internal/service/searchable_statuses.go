@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Xushengqwer/go-common/core"
+	"github.com/Xushengqwer/go-common/models/enums"
+	"github.com/Xushengqwer/post_search/internal/models"
+	"go.uber.org/zap"
+)
+
+// defaultSearchableStatuses 是未配置 SearchConfig.SearchableStatuses 时公开搜索可见的帖子状态：只有审核通过的帖子。
+var defaultSearchableStatuses = []enums.Status{enums.Approved}
+
+// adminAccessKey 是标记 "本次搜索来自 admin，不受可搜索状态限制" 的 context 键。
+type adminAccessKey struct{}
+
+// WithAdminAccess 返回一个允许 Search 返回任意状态帖子的上下文。
+// API 层只应在请求携带了有效的 admin 凭据时调用它。
+func WithAdminAccess(ctx context.Context) context.Context {
+	return context.WithValue(ctx, adminAccessKey{}, true)
+}
+
+func hasAdminAccess(ctx context.Context) bool {
+	admin, _ := ctx.Value(adminAccessKey{}).(bool)
+	return admin
+}
+
+// normalizeSearchableStatuses 将配置中的状态值转换为 enums.Status，丢弃无法识别的值并去重。
+// 配置为空或全部无效时使用 defaultSearchableStatuses，保证公开搜索默认不会返回未发布的帖子。
+func normalizeSearchableStatuses(configured []int, logger *core.ZapLogger) []enums.Status {
+	statuses := make([]enums.Status, 0, len(configured))
+	seen := make(map[enums.Status]bool, len(configured))
+	for _, v := range configured {
+		status := enums.Status(v)
+		if status != enums.Pending && status != enums.Approved && status != enums.Rejected {
+			logger.Warn("配置中的可搜索状态 (searchConfig.searchableStatuses) 包含无法识别的值，已忽略", zap.Int("status", v))
+			continue
+		}
+		if !seen[status] {
+			seen[status] = true
+			statuses = append(statuses, status)
+		}
+	}
+	if len(statuses) == 0 {
+		return defaultSearchableStatuses
+	}
+	return statuses
+}
+
+// applySearchableStatuses 对非 admin 请求施加可搜索状态限制。
+// 客户端只能在允许的状态内进一步缩小范围，不能扩大：
+//   - 未指定 status：按全部允许的状态过滤。
+//   - 指定了允许的 status：保持不变 (已经是更窄的条件)。
+//   - 指定了不允许的 status：返回 false，调用方应直接返回空结果。
+func (s *SearchService) applySearchableStatuses(ctx context.Context, req *models.SearchRequest) bool {
+	if hasAdminAccess(ctx) {
+		return true
+	}
+	if req.Status == nil {
+		req.Statuses = s.searchableStatuses
+		return true
+	}
+	for _, allowed := range s.searchableStatuses {
+		if *req.Status == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// filterSearchableDocs 对非 admin 请求去掉状态不在可搜索状态中的文档 (原地过滤，保持顺序)。
+// 用于按 ID 直接获取文档的接口：这类接口不经过搜索查询的状态过滤，知道 ID 就能读到草稿或被拒绝的帖子。
+func (s *SearchService) filterSearchableDocs(ctx context.Context, docs []models.EsPostDocument) []models.EsPostDocument {
+	if hasAdminAccess(ctx) {
+		return docs
+	}
+	visible := docs[:0]
+	for _, doc := range docs {
+		if s.isSearchableStatus(doc.Status) {
+			visible = append(visible, doc)
+		}
+	}
+	if dropped := len(docs) - len(visible); dropped > 0 {
+		s.logger.Debug("已省略不可搜索状态的帖子", zap.Int("dropped_count", dropped))
+	}
+	return visible
+}
+
+// isSearchableStatus 判断状态是否在公开可见的状态中。
+func (s *SearchService) isSearchableStatus(status enums.Status) bool {
+	for _, allowed := range s.searchableStatuses {
+		if status == allowed {
+			return true
+		}
+	}
+	return false
+}
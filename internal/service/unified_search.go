@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"go.uber.org/zap"
+	"golang.org/x/sync/errgroup"
+)
+
+// 未配置 SearchConfig.UnifiedSearch 时热门搜索词分支的默认返回数量与超时。
+const (
+	defaultTermSuggestionLimit   = 5
+	defaultTermSuggestionTimeout = 300 * time.Millisecond
+)
+
+// UnifiedSearch 并发执行帖子搜索与热门搜索词前缀联想，合并为一个响应。
+// 帖子搜索失败时整体返回错误；热门搜索词分支失败或超时只会降级 (TermSuggestions 为空并标记 TermSuggestionsDegraded)，
+// 不影响帖子结果。关键词为空或短于联想的最小前缀长度时不查询热门搜索词。
+func (s *SearchService) UnifiedSearch(ctx context.Context, req models.SearchRequest) (*models.UnifiedSearchResult, error) {
+	result := &models.UnifiedSearchResult{TermSuggestions: []models.HotSearchTerm{}}
+
+	g, gctx := errgroup.WithContext(ctx)
+	g.Go(func() error {
+		posts, err := s.Search(gctx, req)
+		if err != nil {
+			return err
+		}
+		result.Posts = posts
+		return nil
+	})
+
+	if utf8.RuneCountInString(strings.TrimSpace(req.Query)) >= s.cfg.HotSuggest.MinPrefixLength {
+		g.Go(func() error {
+			termCtx, cancel := context.WithTimeout(gctx, s.cfg.UnifiedSearch.TermSuggestionTimeout)
+			defer cancel()
+
+			terms, err := s.SuggestHotTerms(termCtx, req.Query, s.cfg.UnifiedSearch.TermSuggestionLimit)
+			if err != nil {
+				// 返回 nil 而不是 err：热门搜索词索引不可用时不应取消帖子搜索分支。
+				s.logger.Warn("统一搜索中的热门搜索词联想失败，已降级", zap.String("query", req.Query), zap.Error(err))
+				result.TermSuggestionsDegraded = true
+				return nil
+			}
+			result.TermSuggestions = terms
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
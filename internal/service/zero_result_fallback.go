@@ -0,0 +1,36 @@
+package service
+
+import (
+	"context"
+
+	"github.com/Xushengqwer/post_search/internal/models"
+	"go.uber.org/zap"
+)
+
+// defaultZeroResultFallbackSize 是未配置 SearchConfig.ZeroResultFallback.Size 时兜底推荐的帖子数量。
+const defaultZeroResultFallbackSize = 5
+
+// attachZeroResultFallback 在关键词搜索零命中且启用了兜底推荐时，查询浏览量最高的帖子并附加到 result.Fallbacks。
+// 只对带关键词的搜索生效：浏览模式或纯筛选条件零命中说明确实没有数据，推荐无关帖子反而误导。
+// 兜底帖子始终只从公开可见的状态 (searchConfig.searchableStatuses) 中选取，即使请求来自 admin。
+// 兜底查询失败时只记录警告，不影响原搜索结果。
+func (s *SearchService) attachZeroResultFallback(ctx context.Context, req models.SearchRequest, result *models.SearchResult) {
+	if !s.cfg.ZeroResultFallback.Enabled || result.Total > 0 {
+		return
+	}
+	if positiveQuery, _ := models.SplitExclusionTerms(req.Query); positiveQuery == "" {
+		return
+	}
+
+	docs, err := s.postRepo.GetPopularPosts(ctx, s.searchableStatuses, s.cfg.ZeroResultFallback.Size)
+	if err != nil {
+		s.logger.Warn("零结果兜底推荐查询失败，返回不带兜底结果的响应", zap.String("搜索关键词", req.Query), zap.Error(err))
+		return
+	}
+	if len(docs) == 0 {
+		return
+	}
+	result.Fallbacks = docs
+	result.FallbackUsed = true
+	s.logger.Info("关键词搜索零命中，已附带兜底推荐", zap.String("搜索关键词", req.Query), zap.Int("兜底数量", len(docs)))
+}
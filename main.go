@@ -3,7 +3,6 @@ package main
 
 import (
 	"context"
-	"encoding/json" // 导入 encoding/json 包
 	"errors"
 	"flag"
 	_ "github.com/Xushengqwer/post_search/docs" // 确保路径正确
@@ -40,6 +39,11 @@ import (
 
 // @host localhost:8083 // 请根据您的实际配置修改
 // @schemes http https  // 根据您的服务支持情况调整
+
+// @securityDefinitions.apikey AdminKey
+// @in header
+// @name X-Admin-Key
+// @description 管理/诊断接口所需的密钥 (adminConfig.apiKey)
 func main() {
 	// --- 0. 配置和基础设置 ---
 	var configFile string
@@ -51,12 +55,8 @@ func main() {
 		log.Fatalf("致命错误: 加载配置文件 '%s' 失败: %v", configFile, err)
 	}
 
-	// --- 打印最终配置以供调试 ---
-	configBytes, err := json.MarshalIndent(cfg, "", "  ")
-	if err != nil {
-		log.Fatalf("无法序列化最终配置以进行打印: %v", err)
-	}
-	log.Printf("应用最终将使用以下配置:\n%s\n", string(configBytes))
+	// 不打印完整配置：其中包含 ES/Kafka 密码和管理接口密钥等敏感信息。
+	log.Printf("已加载配置文件 '%s'", configFile)
 
 	// 注册zapLogger实例
 	logger, loggerErr := core.NewZapLogger(cfg.ZapConfig)
@@ -72,6 +72,15 @@ func main() {
 	logger.Info("Logger 初始化成功。")
 
 	// --- HTTP Transport 和 Tracer 初始化 ---
+	// ES 客户端的连接池大小来自配置，未配置时使用与之前一致的默认值。
+	esMaxIdleConns := cfg.ElasticsearchConfig.MaxIdleConns
+	if esMaxIdleConns <= 0 {
+		esMaxIdleConns = 100
+	}
+	esMaxIdleConnsPerHost := cfg.ElasticsearchConfig.MaxIdleConnsPerHost
+	if esMaxIdleConnsPerHost <= 0 {
+		esMaxIdleConnsPerHost = 10
+	}
 	baseHttpTransport := &http.Transport{
 		Proxy: http.ProxyFromEnvironment,
 		DialContext: (&net.Dialer{
@@ -79,7 +88,9 @@ func main() {
 			KeepAlive: 30 * time.Second,
 		}).DialContext,
 		ForceAttemptHTTP2:     true,
-		MaxIdleConns:          100,
+		MaxIdleConns:          esMaxIdleConns,
+		MaxIdleConnsPerHost:   esMaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.ElasticsearchConfig.MaxConnsPerHost,
 		IdleConnTimeout:       90 * time.Second,
 		TLSHandshakeTimeout:   10 * time.Second,
 		ExpectContinueTimeout: 1 * time.Second,
@@ -128,22 +139,28 @@ func main() {
 	if primaryIndexName == "" {
 		logger.Fatal("主帖子索引名称 (elasticsearchConfig.primaryIndex.name) 未在配置中指定。")
 	}
-	postRepo := repoES.NewESPostRepository(esClientCore.Client, primaryIndexName, logger)
+	// 启用多租户时，租户索引在首次写入时按主索引的配置创建。
+	var ensureTenantIndex repoES.IndexEnsurer
+	if cfg.TenancyConfig.Enabled {
+		ensureTenantIndex = esClientCore.EnsurePostsIndex
+	}
+	postRepo := repoES.NewESPostRepository(esClientCore.Client, cfg.ElasticsearchConfig.PrimaryIndex, cfg.SearchConfig, cfg.ElasticsearchConfig.PayloadLogRedaction, ensureTenantIndex, logger)
 	logger.Info("主帖子 Elasticsearch Repository (PostRepository) 初始化成功。", zap.String("index_name", primaryIndexName))
 
 	hotTermsIndexName := cfg.ElasticsearchConfig.HotTermsIndex.Name
 	if hotTermsIndexName == "" {
 		logger.Fatal("热门搜索词索引名称 (elasticsearchConfig.hotTermsIndex.name) 未在配置中指定。")
 	}
-	hotSearchTermRepo := repoES.NewESHotSearchTermRepository(esClientCore.Client, logger, hotTermsIndexName)
+	hotSearchTermRepo := repoES.NewESHotSearchTermRepository(esClientCore.Client, logger, hotTermsIndexName, cfg.SearchConfig.HotTerms)
 	logger.Info("热门搜索词 Elasticsearch Repository (HotSearchTermRepository) 初始化成功。", zap.String("index_name", hotTermsIndexName))
 
 	// 6. 初始化业务服务层 - SearchService
-	searchSvc := service.NewSearchService(postRepo, hotSearchTermRepo, logger)
+	searchSvc := service.NewSearchService(postRepo, hotSearchTermRepo, cfg.SearchConfig, cfg.IndexingConfig, logger)
 	logger.Info("SearchService 初始化成功。")
 
 	// 7. 初始化业务服务层 - EventService (用于处理 Kafka 事件)
-	eventSvc := coreKafka.NewEventService(postRepo, logger)
+	eventSvc := coreKafka.NewEventService(postRepo, cfg.IndexingConfig, logger)
+	eventSvc.SetSearchableStatuses(cfg.SearchConfig.SearchableStatuses) // 状态索引策略与公开搜索使用相同的可搜索状态
 	logger.Info("EventService 初始化成功。")
 
 	// 8. 初始化 Kafka Sarama 配置
@@ -158,41 +175,34 @@ func main() {
 	if err != nil {
 		logger.Fatal("创建 Kafka DLQ 同步生产者失败", zap.Error(err))
 	}
-	defer func() {
-		logger.Info("正在关闭 Kafka DLQ 生产者...")
-		if err := dlqProducer.Close(); err != nil {
-			logger.Error("关闭 Kafka DLQ 生产者时发生错误", zap.Error(err))
-		} else {
-			logger.Info("Kafka DLQ 生产者已成功关闭。")
-		}
-	}()
 	logger.Info("Kafka DLQ 同步生产者初始化成功。")
 
 	// 10. 初始化 Kafka 消息处理器 (Handler)
-	var auditTopic, deleteTopic string
-	if len(cfg.KafkaConfig.SubscribedTopics) >= 1 {
-		auditTopic = cfg.KafkaConfig.SubscribedTopics[0]
-	} else {
-		logger.Fatal("Kafka 配置错误：未找到用于审计事件的主题 (SubscribedTopics[0])")
-	}
-	if len(cfg.KafkaConfig.SubscribedTopics) >= 2 {
-		deleteTopic = cfg.KafkaConfig.SubscribedTopics[1]
-	} else {
-		logger.Warn("Kafka 配置警告：未明确找到用于删除事件的主题 (期望在 SubscribedTopics[1])。如果服务不处理删除事件，此警告可忽略。")
-	}
-	if auditTopic == "" || (deleteTopic == "" && len(cfg.KafkaConfig.SubscribedTopics) > 1) {
-		logger.Fatal("Kafka 主题配置不完整：auditTopic 或 deleteTopic 未能正确从 SubscribedTopics 中提取。")
+	topics, err := coreKafka.ResolveTopics(&cfg.KafkaConfig, logger)
+	if err != nil {
+		logger.Fatal("Kafka 主题配置无效", zap.Error(err))
 	}
 
 	kafkaHandler := coreKafka.NewHandler(
 		eventSvc,
 		dlqProducer,
 		cfg.KafkaConfig.DLQTopic,
-		auditTopic,
-		deleteTopic,
+		topics.Audit,
+		topics.Delete,
 		logger,
 		cfg.KafkaConfig.MaxRetryAttempts,
+		cfg.KafkaConfig.DLQSend,
 	)
+	kafkaHandler.SetMessageTimeout(cfg.KafkaConfig.MessageTimeout)
+	kafkaHandler.SetMaxMessageBytes(cfg.KafkaConfig.MaxMessageBytes)
+	kafkaHandler.SetSchemaCompatibility(cfg.KafkaConfig.Schema)
+	kafkaHandler.SetTenancy(cfg.TenancyConfig)
+	kafkaHandler.SetBulkIndexing(cfg.KafkaConfig.BulkIndexing)
+	kafkaHandler.SetDLQFailureHook(coreKafka.NewDLQFailureWebhook(cfg.KafkaConfig.DLQSend.FailureWebhook, logger))
+	// 可选的部分更新主题承载帖子部分更新事件 (PostPatchedEvent)，只更新变更的字段。
+	if topics.Patch != "" {
+		kafkaHandler.RegisterTopicHandler(topics.Patch, kafkaHandler.PostPatchedHandler())
+	}
 	logger.Info("Kafka 消息处理器 (Handler) 初始化成功。")
 
 	// 11. 初始化 Kafka 消费者组
@@ -205,18 +215,12 @@ func main() {
 	if err != nil {
 		logger.Fatal("创建 Kafka 消费者组失败", zap.Error(err))
 	}
-	defer func() {
-		logger.Info("正在关闭 Kafka 消费者组...")
-		if err := consumerGroup.Close(); err != nil {
-			logger.Error("关闭 Kafka 消费者组时发生错误", zap.Error(err))
-		} else {
-			logger.Info("Kafka 消费者组已成功关闭。")
-		}
-	}()
 	logger.Info("Kafka 消费者组初始化成功。")
 
 	// 12. 初始化 API Handler (控制器)
 	searchApiHandler := api.NewSearchHandler(searchSvc, logger)
+	searchApiHandler.SetKafkaStatusProvider(consumerGroup)  // 供 admin 接口 /_kafka-status 与 /_consumer/pause、/_consumer/resume 使用
+	searchApiHandler.SetHotTermsIndexResetter(esClientCore) // 供 admin 接口 /_hot-terms/reset 使用
 	logger.Info("API Handler (SearchHandler) 初始化成功。")
 
 	// 13. 初始化并配置 Gin Web 引擎及路由
@@ -230,6 +234,11 @@ func main() {
 	consumerGroup.Start(ctx)
 	logger.Info("Kafka 消费者组已启动，开始在后台消费消息。")
 
+	// 可选的过期帖子清理任务，随全局上下文取消而停止。
+	if cfg.IndexingConfig.Expiry.Enabled {
+		go service.NewExpirySweeper(postRepo, cfg.IndexingConfig.Expiry, logger).Run(ctx)
+	}
+
 	serverAddr := cfg.Server.ListenAddr
 	if serverAddr == "" {
 		serverAddr = ":" + cfg.Server.Port
@@ -254,21 +263,41 @@ func main() {
 	signal.Notify(quitSignal, syscall.SIGINT, syscall.SIGTERM)
 	logger.Info("服务已成功启动。正在监听中断或终止信号以进行优雅关闭...")
 
-	receivedSignal := <-quitSignal
-	logger.Info("接收到关闭信号，开始进行服务的优雅关闭...", zap.String("signal", receivedSignal.String()))
+	exitCode := 0
+	select {
+	case receivedSignal := <-quitSignal:
+		logger.Info("接收到关闭信号，开始进行服务的优雅关闭...", zap.String("signal", receivedSignal.String()))
+	case err := <-consumerGroup.Fatal():
+		// 消费者组持续失败 (kafkaConfig.consumeRetry.exitAfter)：优雅关闭后以非零状态码退出，由 Kubernetes 重启进程。
+		logger.Error("Kafka 消费者组持续失败，开始进行服务的优雅关闭并退出进程...", zap.Error(err))
+		exitCode = 1
+	case <-ctx.Done():
+		logger.Warn("全局上下文已被取消 (HTTP 服务器异常退出)，开始进行服务的优雅关闭...")
+	}
 
 	cancel()
 	logger.Info("已发出全局上下文取消信号，通知所有组件开始关闭。")
 
-	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 15*time.Second)
+	shutdownTimeout := cfg.ShutdownConfig.Timeout
+	if shutdownTimeout <= 0 {
+		shutdownTimeout = defaultShutdownTimeout
+	}
+	shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), shutdownTimeout)
 	defer shutdownCancel()
 
-	logger.Info("正在优雅地关闭 HTTP API 服务器...")
-	if err := httpServer.Shutdown(shutdownCtx); err != nil {
-		logger.Error("关闭 HTTP API 服务器时发生错误", zap.Error(err))
-	} else {
-		logger.Info("HTTP API 服务器已成功关闭。")
+	// 关闭顺序：先停止接收新的请求和消息，再等待进行中的工作写完 ES，最后释放 ES 客户端和 DLQ 生产者。
+	// DLQ 生产者必须在消费者组之后关闭，因为排空进行中的消息时仍可能需要发送死信。
+	runShutdown(shutdownCtx, logger, []shutdownStage{
+		{name: "HTTP API 服务器", fn: httpServer.Shutdown},
+		{name: "Kafka 消费者组 (排空进行中的消息)", fn: func(context.Context) error { return consumerGroup.Close() }},
+		{name: "异步热门搜索词记录", fn: searchApiHandler.WaitForAsyncTasks},
+		{name: "Elasticsearch 客户端", fn: func(context.Context) error { esClientCore.Close(); return nil }},
+		{name: "Kafka DLQ 生产者", fn: func(context.Context) error { return dlqProducer.Close() }},
+	})
+
+	logger.Info("服务所有组件已完成关闭流程，程序即将退出。", zap.Duration("shutdown_budget", shutdownTimeout), zap.Int("exit_code", exitCode))
+	if exitCode != 0 {
+		_ = logger.Logger().Sync()
+		os.Exit(exitCode) // os.Exit 不执行 defer，因此先手动刷新日志。
 	}
-
-	logger.Info("服务所有组件已完成关闭流程，程序即将退出。")
 }
@@ -9,6 +9,7 @@ import (
 	"github.com/Xushengqwer/post_search/constants"                 // 假设常量包定义了 ServiceName
 	_ "github.com/Xushengqwer/post_search/docs"                    // 确保路径正确
 	"github.com/Xushengqwer/post_search/internal/api"              // 项目的 API Handler 包
+	"github.com/Xushengqwer/post_search/internal/metrics"          // 进程内计数器 (Prometheus 文本格式)
 
 	"github.com/gin-gonic/gin"
 	swaggerFiles "github.com/swaggo/files"
@@ -39,8 +40,31 @@ func SetupRouter(
 	logger.Info("开始为 PostSearch 服务设置 Gin 路由...")
 
 	// 1. 创建 Gin 引擎实例
-	// gin.Default() 会默认使用 Logger (Gin自带的日志中间件) 和 Recovery (Panic恢复) 中间件。
-	router := gin.Default()
+	// 运行模式必须在创建引擎之前设置，否则 debug 模式的路由表和警告仍会打印。
+	ginMode := cfg.GinConfig.Mode
+	switch ginMode {
+	case gin.DebugMode, gin.ReleaseMode, gin.TestMode:
+	case "":
+		ginMode = gin.ReleaseMode
+	default:
+		logger.Warn("Gin 运行模式配置 (ginConfig.mode) 无效，将使用 release", zap.String("configured_mode", ginMode))
+		ginMode = gin.ReleaseMode
+	}
+	gin.SetMode(ginMode)
+
+	// 使用 gin.New() 而非 gin.Default()：访问日志由下面的请求日志中间件统一记录，
+	// Gin 自带的 Logger 只在 debug 模式下启用，便于本地开发。
+	router := gin.New()
+	router.Use(gin.Recovery())
+	if ginMode == gin.DebugMode {
+		router.Use(gin.Logger())
+	}
+
+	// 只信任配置中的代理：默认 (列表为空) 不信任任何代理，防止客户端伪造 X-Forwarded-For 绕过按 IP 的限流。
+	if err := router.SetTrustedProxies(cfg.GinConfig.TrustedProxies); err != nil {
+		logger.Fatal("受信任代理配置 (ginConfig.trustedProxies) 无效", zap.Strings("trusted_proxies", cfg.GinConfig.TrustedProxies), zap.Error(err))
+	}
+	logger.Info("Gin 引擎已创建。", zap.String("mode", ginMode), zap.Strings("trusted_proxies", cfg.GinConfig.TrustedProxies))
 
 	// 2. 应用通用中间件 (中间件的注册顺序通常很重要)
 
@@ -49,6 +73,18 @@ func SetupRouter(
 	router.Use(otelgin.Middleware(constants.ServiceName)) // 使用 constants.ServiceName
 	logger.Info("OpenTelemetry (OTel) 中间件已注册。", zap.String("service_name", constants.ServiceName))
 
+	// 2.1.1 Trace ID 响应头 (依赖 otelgin 创建的 span，必须在其之后注册)
+	if !cfg.TraceIDConfig.Disabled {
+		router.Use(api.TraceIDMiddleware(cfg.TraceIDConfig))
+		logger.Info("Trace ID 响应头中间件已注册。", zap.String("header_name", cfg.TraceIDConfig.HeaderName))
+	}
+
+	// 2.1.2 多租户 (可选)：根据请求头选择租户的帖子索引
+	if cfg.TenancyConfig.Enabled {
+		router.Use(api.TenantMiddleware(cfg.TenancyConfig, logger))
+		logger.Info("租户中间件已注册。", zap.String("header_name", cfg.TenancyConfig.HeaderName))
+	}
+
 	// 2.2 全局错误处理中间件 (Panic Recovery)
 	router.Use(commonMiddleware.ErrorHandlingMiddleware(logger))
 	logger.Info("全局错误处理 (Panic Recovery) 中间件已注册。")
@@ -61,7 +97,18 @@ func SetupRouter(
 		logger.Warn("无法获取底层的 *zap.Logger 实例，跳过请求日志中间件的注册。")
 	}
 
-	// 2.4 请求超时中间件
+	// 2.4 响应压缩中间件 (可选)：带高亮和正文的大页搜索结果体积较大，压缩可显著减少移动端的传输量。
+	// 必须在请求超时中间件之前注册：超时中间件在另一个 goroutine 中执行后续中间件，注册在它之后时
+	// 压缩流的结束会与超时响应 (504) 并发写入同一个连接。
+	if cfg.CompressionConfig.Enabled {
+		router.Use(api.CompressionMiddleware(cfg.CompressionConfig, logger))
+		logger.Info("响应压缩中间件已注册。",
+			zap.Int("min_size", cfg.CompressionConfig.MinSize),
+			zap.Int("level", cfg.CompressionConfig.Level),
+		)
+	}
+
+	// 2.5 请求超时中间件
 	var requestTimeout time.Duration
 	if cfg.Server.RequestTimeout > 0 {
 		requestTimeout = cfg.Server.RequestTimeout
@@ -80,7 +127,8 @@ func SetupRouter(
 
 	// 3. 创建 API 版本路由组
 	// API 前缀可以考虑从配置中读取，以增加灵活性。
-	apiV1Group := router.Group("/api/v1/search")
+	// AdminIdentityMiddleware 只识别携带有效 admin 凭据的请求 (例如不受可搜索状态限制的搜索)，不拒绝任何请求。
+	apiV1Group := router.Group("/api/v1/search", api.AdminIdentityMiddleware(cfg.AdminConfig))
 	logger.Info("API 路由将统一注册到基础路径 /api/v1/search 分组下。")
 
 	// 4. 注册 SearchHandler 的路由
@@ -90,6 +138,12 @@ func SetupRouter(
 		// 将其负责的路由（例如 /search, /_health）注册到我们创建的 apiV1Group下。
 		searchHandler.RegisterRoutes(apiV1Group)
 		logger.Info("SearchHandler 的相关路由已成功注册到 /api/v1 分组。")
+
+		// 管理/诊断类接口使用独立的路由组 (路径前缀相同)，只有该组应用 admin 认证中间件，
+		// 公开的搜索与热门搜索词接口不受影响。审计中间件在认证之前注册，认证失败的变更尝试同样会被记录。
+		adminGroup := apiV1Group.Group("", api.AuditMiddleware(logger), api.AdminAuthMiddleware(cfg.AdminConfig, logger))
+		searchHandler.RegisterAdminRoutes(adminGroup)
+		logger.Info("SearchHandler 的 admin 路由已注册 (需要 admin 密钥)。")
 	} else {
 		// 如果 SearchHandler 未初始化，这是一个严重的配置问题。
 		logger.Error("SearchHandler 实例为 nil，其 API 路由无法注册！")
@@ -103,7 +157,11 @@ func SetupRouter(
 	router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	logger.Info("Swagger UI 路由已注册。可以通过 /swagger/index.html 访问 API 文档。")
 
+	// 6. 配置 Prometheus 指标抓取路由 (不在 API 分组内，与 Swagger 一样挂在根路径下)
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+	logger.Info("Prometheus 指标路由已注册: /metrics")
+
 	logger.Info("PostSearch 服务的 Gin 路由设置已全部完成。")
-	// 6. 返回配置好的 Gin 引擎实例
+	// 7. 返回配置好的 Gin 引擎实例
 	return router
 }
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/Xushengqwer/go-common/core"
+	"go.uber.org/zap"
+)
+
+// defaultShutdownTimeout 是未配置 shutdownConfig.timeout 时整个关闭流程的总时间预算。
+const defaultShutdownTimeout = 30 * time.Second
+
+// shutdownStage 是关闭流程中的一个阶段。
+type shutdownStage struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// runShutdown 按顺序执行各关闭阶段，所有阶段共享 ctx 的时间预算。
+// 为什么不使用 defer?
+// defer 按 LIFO 顺序执行，关闭顺序隐含在初始化顺序中，调整初始化代码就可能让 ES 客户端
+// 在消费者组处理完进行中的消息之前被关闭。这里把顺序显式地写出来。
+// 某个阶段超出剩余预算时放弃等待它 (该阶段在后台继续运行)，继续执行后续阶段，保证进程能按时退出。
+func runShutdown(ctx context.Context, logger *core.ZapLogger, stages []shutdownStage) {
+	for i, stage := range stages {
+		logger.Info("关闭阶段开始", zap.Int("stage", i+1), zap.String("name", stage.name))
+		start := time.Now()
+
+		done := make(chan error, 1)
+		go func() { done <- stage.fn(ctx) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				logger.Error("关闭阶段执行出错", zap.String("name", stage.name), zap.Duration("elapsed", time.Since(start)), zap.Error(err))
+			} else {
+				logger.Info("关闭阶段完成", zap.String("name", stage.name), zap.Duration("elapsed", time.Since(start)))
+			}
+		case <-ctx.Done():
+			logger.Warn("关闭阶段超出总时间预算，放弃等待",
+				zap.String("name", stage.name),
+				zap.Duration("elapsed", time.Since(start)),
+			)
+		}
+	}
+}